@@ -15,6 +15,7 @@ import (
 	"github.com/Alturino/ecommerce/cart/internal/controller"
 	"github.com/Alturino/ecommerce/cart/internal/otel"
 	"github.com/Alturino/ecommerce/cart/internal/service"
+	inCache "github.com/Alturino/ecommerce/internal/cache"
 	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
 	"github.com/Alturino/ecommerce/internal/infra"
@@ -98,12 +99,12 @@ func RunCartService(c context.Context) {
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing cart service").Logger()
 	logger.Info().Msg("initializing cart service")
 	queries := repository.New(db)
-	cartService := service.NewCartService(db, queries, cache)
+	cartService := service.NewCartService(db, queries, inCache.New(cache, cfg.Cache), cfg.Cart, cfg.Currency)
 	logger.Info().Msg("initialized cart service")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing cart controller").Logger()
 	logger.Info().Msg("initializing cart controller")
-	controller.AttachCartController(mux, cartService)
+	controller.AttachCartController(mux, cartService, cfg.Pagination)
 	logger.Info().Msg("initialized cart controller")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing server").Logger()
@@ -139,6 +140,12 @@ func RunCartService(c context.Context) {
 		logger.Info().Msg("shutdown server")
 	}()
 
+	if cfg.Cart.Reservation.Enabled {
+		logger = logger.With().Str(constants.KEY_PROCESS, "start reservation releaser").Logger()
+		logger.Info().Msg("start reservation releaser")
+		go NewReservationReleaser(cartService).StartReleaser(logger.WithContext(c))
+	}
+
 	<-c.Done()
 	logger = logger.With().Str(constants.KEY_PROCESS, "shutdown server").Logger()
 	logger.Info().Msg("received interuption signal shutting down")
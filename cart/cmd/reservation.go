@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/cart/internal/service"
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/log"
+)
+
+const reservationReleaseInterval = time.Minute
+
+type ReservationReleaser struct {
+	svc *service.CartService
+}
+
+func NewReservationReleaser(svc *service.CartService) *ReservationReleaser {
+	return &ReservationReleaser{svc: svc}
+}
+
+// StartReleaser periodically deletes the stock reservations whose TTL expired.
+func (r ReservationReleaser) StartReleaser(c context.Context) {
+	logger := zerolog.Ctx(c).
+		With().
+		Str(constants.KEY_TAG, "ReservationReleaser StartReleaser").
+		Str(constants.KEY_PROCESS, "releasing expired reservations").
+		Logger()
+
+	tick := time.Tick(reservationReleaseInterval)
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-tick:
+			reqId := uuid.NewString()
+			lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+			ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+			released, err := r.svc.ReleaseExpiredReservations(ctx)
+			if err != nil {
+				err = fmt.Errorf("failed releasing expired reservations with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+				continue
+			}
+			lg.Debug().Int64(constants.KEY_RESERVATIONS_RELEASED, released).Msg("released expired reservations")
+		}
+	}
+}
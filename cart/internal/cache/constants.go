@@ -1,7 +1,7 @@
 package cache
 
+// families of the cached entries of the cart service, see internal/cache.
 const (
-	KEY_CARTS             = "carts:%s"
-	KEY_CARTS_AND_USER_ID = "carts:%s-%s"
-	KEY_CARTS_BY_USER_ID  = "carts:user_id:%s"
+	KEY_CARTS            = "carts"
+	KEY_CARTS_BY_USER_ID = "carts_by_user_id"
 )
@@ -2,12 +2,15 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 	"go.opentelemetry.io/otel/attribute"
@@ -17,6 +20,7 @@ import (
 	"github.com/Alturino/ecommerce/cart/internal/service"
 	"github.com/Alturino/ecommerce/cart/pkg/request"
 	"github.com/Alturino/ecommerce/internal"
+	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
 	inHttp "github.com/Alturino/ecommerce/internal/http"
 	"github.com/Alturino/ecommerce/internal/log"
@@ -25,11 +29,16 @@ import (
 )
 
 type CartController struct {
-	service *service.CartService
+	service    *service.CartService
+	pagination config.Pagination
 }
 
-func AttachCartController(mux *mux.Router, service *service.CartService) {
-	controller := CartController{service: service}
+func AttachCartController(
+	mux *mux.Router,
+	service *service.CartService,
+	pagination config.Pagination,
+) {
+	controller := CartController{service: service, pagination: pagination}
 
 	router := mux.PathPrefix("/carts").Subrouter()
 	router.Use(
@@ -39,6 +48,13 @@ func AttachCartController(mux *mux.Router, service *service.CartService) {
 		middleware.Auth,
 	)
 	router.HandleFunc("", controller.InsertCart).Methods(http.MethodPost)
+	router.HandleFunc("", controller.FindCarts).Methods(http.MethodGet)
+	router.HandleFunc("/active", controller.FindActiveCart).Methods(http.MethodGet)
+	router.HandleFunc("/items", controller.AddCartItem).Methods(http.MethodPost)
+	router.HandleFunc("/items", controller.ClearCart).Methods(http.MethodDelete)
+	router.HandleFunc("/items/{cartItemId}", controller.UpdateCartItem).Methods(http.MethodPatch)
+	router.HandleFunc("/items/{cartItemId}", controller.RemoveActiveCartItem).
+		Methods(http.MethodDelete)
 	router.HandleFunc("/{cartId}/checkout", controller.CheckoutCart).Methods(http.MethodPost)
 	router.HandleFunc("/{cartId}", controller.FindCartById).Methods(http.MethodGet)
 	router.HandleFunc("/{cartId}/{cartItemId}", controller.RemoveCartItem).
@@ -144,6 +160,83 @@ func (t CartController) InsertCart(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (t CartController) FindCarts(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CartController FindCarts")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartController FindCarts").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating page").Logger()
+	logger.Trace().Msg("validating page")
+	span.AddEvent("validating page")
+	page, err := inHttp.ParsePage(r, t.pagination)
+	if err != nil {
+		err = fmt.Errorf("failed validating page with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated page")
+	logger = logger.With().Any(constants.KEY_PAGE, page).Logger()
+	logger.Debug().Msg("validated page")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got user id from jwt token")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Debug().Msg("got user id from jwt token")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding carts").Logger()
+	logger.Trace().Msg("finding carts")
+	span.AddEvent("finding carts")
+	c = logger.WithContext(c)
+	carts, nextCursor, err := t.service.FindCarts(c, request.FindCarts{UserId: userId, Page: page})
+	if err != nil {
+		err = fmt.Errorf("failed finding carts with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found carts")
+	logger.Info().Int(constants.KEY_CARTS, len(carts)).Msg("found carts")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":      "success",
+		"statusCode":  http.StatusOK,
+		"message":     "found carts",
+		"next_cursor": nextCursor,
+		"data": map[string]interface{}{
+			"carts": carts,
+		},
+	})
+}
+
 func (t CartController) FindCartById(w http.ResponseWriter, r *http.Request) {
 	c, span := otel.Tracer.Start(r.Context(), "CartController FindCartById")
 	defer span.End()
@@ -177,11 +270,11 @@ func (t CartController) FindCartById(w http.ResponseWriter, r *http.Request) {
 		Logger()
 	logger.Debug().Msg("validated uuid")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
-	logger.Trace().Msg("getting userId from jwtToken")
-	userId, err := internal.UserIdFromJwtToken(c)
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting owner from jwtToken").Logger()
+	logger.Trace().Msg("getting owner from jwtToken")
+	owner, err := internal.OwnerFromJwtToken(c)
 	if err != nil {
-		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		err = fmt.Errorf("failed getting owner from jwtToken with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
@@ -192,21 +285,28 @@ func (t CartController) FindCartById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	span.AddEvent("got user id from jwt token")
-	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger = logger.With().
+		Str(constants.KEY_USER_ID, owner.UserId.String()).
+		Bool(constants.KEY_ADMIN, owner.Admin).
+		Logger()
 	logger.Debug().Msg("got user id from jwt token")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "find cart").Logger()
 	logger.Trace().Msg("find cart id")
 	span.AddEvent("find cart id")
 	c = logger.WithContext(c)
-	cart, err := t.service.FindCartById(c, request.FindCartById{ID: cartId, UserId: userId})
+	cart, err := t.service.FindCartById(c, request.FindCartById{ID: cartId, UserId: owner.Scope()})
 	if err != nil {
 		err = fmt.Errorf("failed finding cartId=%s with error=%w", cartId.String(), err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
 			"status":     "failed",
-			"statusCode": http.StatusBadRequest,
+			"statusCode": statusCode,
 			"message":    err.Error(),
 		})
 		return
@@ -215,7 +315,26 @@ func (t CartController) FindCartById(w http.ResponseWriter, r *http.Request) {
 	logger = logger.With().Any(constants.KEY_CART, cart).Logger()
 	logger.Info().Msg("found cart id")
 
-	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+	etag, err := inHttp.ContentETag(cart)
+	if err != nil {
+		err = fmt.Errorf("failed computing etag of cartId=%s with error=%w", cartId.String(), err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	if inHttp.NotModified(r, etag) {
+		logger.Info().Str(constants.KEY_ETAG, etag).Msg("cart not modified")
+		w.Header().Set(inHttp.KEY_HEADER_ETAG, etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{inHttp.KEY_HEADER_ETAG: etag}, map[string]interface{}{
 		"status":     "success",
 		"statusCode": http.StatusOK,
 		"message":    fmt.Sprintf("cartId=%s found", cartId.String()),
@@ -237,7 +356,7 @@ func (t CartController) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
 		Logger()
 
 	logger.Trace().Msg("validating cartId is a valid uuid")
-	cartId, err := uuid.Parse(r.PathValue("cartId"))
+	cartId, err := uuid.Parse(mux.Vars(r)["cartId"])
 	if err != nil {
 		err = fmt.Errorf("failed validating cartId=%s with error=%w", cartId.String(), err)
 		inOtel.RecordError(err, span)
@@ -254,7 +373,7 @@ func (t CartController) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "validating cartItemId").Logger()
 	logger.Trace().Msg("validating cartItemId is valid uuid")
-	cartItemId, err := uuid.Parse(r.PathValue("cartItemId"))
+	cartItemId, err := uuid.Parse(mux.Vars(r)["cartItemId"])
 	if err != nil {
 		err = fmt.Errorf("failed validating cartItemId=%s with error=%w", cartId.String(), err)
 		inOtel.RecordError(err, span)
@@ -269,21 +388,57 @@ func (t CartController) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
 	logger = logger.With().Str(constants.KEY_CART_ITEM_ID, cartItemId.String()).Logger()
 	logger.Debug().Msg("cart item id is a valid uuid")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting owner from jwtToken").Logger()
+	logger.Trace().Msg("getting owner from jwtToken")
+	span.AddEvent("getting owner from jwtToken")
+	owner, err := internal.OwnerFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting owner from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got owner from jwtToken")
+	logger = logger.With().
+		Str(constants.KEY_USER_ID, owner.UserId.String()).
+		Bool(constants.KEY_ADMIN, owner.Admin).
+		Logger()
+	logger.Debug().Msg("got owner from jwtToken")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "removing cart item").Logger()
 	logger.Trace().Msg("removing cart item")
 	c = logger.WithContext(c)
-	err = t.service.RemoveCartItem(c, request.RemoveCartItem{ID: cartItemId, CartId: cartId})
+	err = t.service.RemoveCartItem(
+		c,
+		request.RemoveCartItem{ID: cartItemId, CartId: cartId, UserId: owner.Scope()},
+	)
 	if err != nil {
 		err = fmt.Errorf("failed removing cart item with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
 			"status":     "failed",
-			"statusCode": http.StatusInternalServerError,
+			"statusCode": statusCode,
 			"message":    err.Error(),
 		})
 		return
 	}
+	logger.Info().Msg("removed cart item")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    fmt.Sprintf("cartItemId=%s removed", cartItemId.String()),
+	})
 }
 
 func (t CartController) CheckoutCart(w http.ResponseWriter, r *http.Request) {
@@ -348,16 +503,44 @@ func (t CartController) CheckoutCart(w http.ResponseWriter, r *http.Request) {
 	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
 	logger.Debug().Msg("got user id")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	param := request.CheckoutCart{}
+	err = json.NewDecoder(r.Body).Decode(&param)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = fmt.Errorf("failed decoding request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    "request body is invalid",
+		})
+		return
+	}
+	param.UserId, param.CartId = userId, cartId
+	err = validator.New(validator.WithRequiredStructEnabled()).StructCtx(c, param)
+	if err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    "request body is invalid",
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger.Debug().Msg("decoded request body")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "checkout cart").Logger()
 	logger.Trace().Msg("checking out cart cart")
 	span.AddEvent("checking out cart cart")
 	jwt := internal.JwtTokenFromContext(c)
 	c = logger.WithContext(c)
-	cart, err := t.service.CheckoutCart(
-		c,
-		jwt,
-		request.CheckoutCart{UserId: userId, CartId: cartId},
-	)
+	cart, err := t.service.CheckoutCart(c, jwt, param)
 	if err != nil {
 		err = fmt.Errorf("failed checkout cart id=%s with error=%w", cartId.String(), err)
 		inOtel.RecordError(err, span)
@@ -0,0 +1,427 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/cart/internal/otel"
+	"github.com/Alturino/ecommerce/cart/pkg/request"
+	"github.com/Alturino/ecommerce/internal"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+)
+
+func (t CartController) FindActiveCart(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CartController FindActiveCart")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartController FindActiveCart").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got user id")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Debug().Msg("got user id")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding active cart").Logger()
+	logger.Trace().Msg("finding active cart")
+	span.AddEvent("finding active cart")
+	c = logger.WithContext(c)
+	cart, err := t.service.FindActiveCart(c, userId)
+	if err != nil {
+		err = fmt.Errorf("failed finding active cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": cartItemStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found active cart")
+	logger.Info().Msg("found active cart")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "found active cart",
+		"data": map[string]interface{}{
+			"cart": cart,
+		},
+	})
+}
+
+func (t CartController) AddCartItem(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CartController AddCartItem")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartController AddCartItem").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.InsertCartItem{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		err = fmt.Errorf("failed decoding request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, reqBody); err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got user id")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Debug().Msg("got user id")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "adding cart item").Logger()
+	logger.Trace().Msg("adding cart item")
+	span.AddEvent("adding cart item")
+	c = logger.WithContext(c)
+	cart, err := t.service.AddCartItems(c, userId, []request.InsertCartItem{reqBody})
+	if err != nil {
+		err = fmt.Errorf("failed adding cart item with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": cartItemStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("added cart item")
+	logger.Info().Msg("added cart item")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully added cart item",
+		"data": map[string]interface{}{
+			"cart": cart,
+		},
+	})
+}
+
+func (t CartController) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CartController UpdateCartItem")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartController UpdateCartItem").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating cartItemId").Logger()
+	logger.Trace().Msg("validating cartItemId")
+	span.AddEvent("validating cartItemId")
+	cartItemId, err := uuid.Parse(mux.Vars(r)["cartItemId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating cartItemId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated cartItemId")
+	logger = logger.With().Str(constants.KEY_CART_ITEM_ID, cartItemId.String()).Logger()
+	logger.Debug().Msg("validated cartItemId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.UpdateCartItem{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		err = fmt.Errorf("failed decoding request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, reqBody); err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got user id")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Debug().Msg("got user id")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating cart item").Logger()
+	logger.Trace().Msg("updating cart item")
+	span.AddEvent("updating cart item")
+	c = logger.WithContext(c)
+	cart, err := t.service.UpdateCartItem(c, userId, cartItemId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed updating cart item with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": cartItemStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("updated cart item")
+	logger.Info().Msg("updated cart item")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully updated cart item",
+		"data": map[string]interface{}{
+			"cart": cart,
+		},
+	})
+}
+
+func (t CartController) RemoveActiveCartItem(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CartController RemoveActiveCartItem")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartController RemoveActiveCartItem").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating cartItemId").Logger()
+	logger.Trace().Msg("validating cartItemId")
+	span.AddEvent("validating cartItemId")
+	cartItemId, err := uuid.Parse(mux.Vars(r)["cartItemId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating cartItemId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated cartItemId")
+	logger = logger.With().Str(constants.KEY_CART_ITEM_ID, cartItemId.String()).Logger()
+	logger.Debug().Msg("validated cartItemId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got user id")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Debug().Msg("got user id")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing cart item").Logger()
+	logger.Trace().Msg("removing cart item")
+	span.AddEvent("removing cart item")
+	c = logger.WithContext(c)
+	cart, err := t.service.RemoveActiveCartItem(c, userId, cartItemId)
+	if err != nil {
+		err = fmt.Errorf("failed removing cart item with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": cartItemStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("removed cart item")
+	logger.Info().Msg("removed cart item")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    fmt.Sprintf("cartItemId=%s removed", cartItemId.String()),
+		"data": map[string]interface{}{
+			"cart": cart,
+		},
+	})
+}
+
+func (t CartController) ClearCart(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CartController ClearCart")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartController ClearCart").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got user id")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Debug().Msg("got user id")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "clearing cart").Logger()
+	logger.Trace().Msg("clearing cart")
+	span.AddEvent("clearing cart")
+	c = logger.WithContext(c)
+	cart, err := t.service.ClearCart(c, userId)
+	if err != nil {
+		err = fmt.Errorf("failed clearing cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": cartItemStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("cleared cart")
+	logger.Info().Msg("cleared cart")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully cleared cart",
+		"data": map[string]interface{}{
+			"cart": cart,
+		},
+	})
+}
+
+// cartItemStatusCode maps a missing active cart or item to not found, a
+// product without enough stock to conflict and a product that can not be put
+// in a cart to unprocessable entity.
+func cartItemStatusCode(err error) int {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, inErrors.ErrOutOfStock):
+		return http.StatusConflict
+	case errors.Is(err, inErrors.ErrUnknownProduct),
+		errors.Is(err, inErrors.ErrUnknownVariant),
+		errors.Is(err, inErrors.ErrProductArchived):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -7,13 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/attribute"
@@ -23,7 +23,10 @@ import (
 	"github.com/Alturino/ecommerce/cart/internal/otel"
 	"github.com/Alturino/ecommerce/cart/pkg/request"
 	"github.com/Alturino/ecommerce/cart/pkg/response"
+	inCache "github.com/Alturino/ecommerce/internal/cache"
+	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
 	inHttp "github.com/Alturino/ecommerce/internal/http"
 	"github.com/Alturino/ecommerce/internal/log"
 	inOtel "github.com/Alturino/ecommerce/internal/otel"
@@ -31,17 +34,27 @@ import (
 )
 
 type CartService struct {
-	pool    *pgxpool.Pool
-	queries *repository.Queries
-	cache   *redis.Client
+	pool     *pgxpool.Pool
+	queries  *repository.Queries
+	cache    *inCache.Cache
+	config   config.Cart
+	currency config.Currency
 }
 
 func NewCartService(
 	pool *pgxpool.Pool,
 	queries *repository.Queries,
-	cache *redis.Client,
+	cache *inCache.Cache,
+	config config.Cart,
+	currency config.Currency,
 ) *CartService {
-	return &CartService{pool: pool, queries: queries, cache: cache}
+	return &CartService{
+		pool:     pool,
+		queries:  queries,
+		cache:    cache,
+		config:   config,
+		currency: currency,
+	}
 }
 
 func (svc CartService) InsertCart(
@@ -93,271 +106,358 @@ func (svc CartService) InsertCart(
 	span.AddEvent("found user")
 	logger.Info().Msg("found user")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
-	logger.Trace().Msg("initializing transaction")
-	span.AddEvent("initializing transaction")
-	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	logger = logger.With().Str(constants.KEY_PROCESS, "adding cart items").Logger()
+	logger.Trace().Msg("adding cart items")
+	span.AddEvent("adding cart items")
+	cart, err := svc.AddCartItems(logger.WithContext(c), userID, param.CartItems)
 	if err != nil {
-		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		err = fmt.Errorf("failed adding cart items with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		return response.Cart{}, err
 	}
-	defer func() {
-		lg := logger.With().Str(constants.KEY_PROCESS, "rolling back transaction").Logger()
-		lg.Trace().Msg("rolling back transaction")
-		err = tx.Rollback(c)
-		if err != nil {
-			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
-			if errors.Is(err, pgx.ErrTxClosed) {
-				lg.Debug().Err(err).Msg(err.Error())
-				return
-			}
-			lg.Error().Err(err).Msg(err.Error())
-			inOtel.RecordError(err, span)
-			return
+	span.AddEvent("added cart items")
+	logger.Info().Msg("added cart items")
+
+	return cart, nil
+}
+
+// reserveStock holds the quantity of every cart item until the reservation TTL
+// expires, the item is removed or the cart is checked out. It fails with
+// ErrOutOfStock when the product or variant quantity minus the active holds of
+// the other carts can not cover a cart item. Items with a variant are held
+// against the stock of the variant and only lock the variant row.
+func (svc CartService) reserveStock(
+	c context.Context,
+	tx pgx.Tx,
+	cartId uuid.UUID,
+	cartItems []repository.InsertCartItemsParams,
+) error {
+	c, span := otel.Tracer.Start(c, "CartService reserveStock")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService reserveStock").
+		Str(constants.KEY_CART_ID, cartId.String()).
+		Logger()
+
+	productIds := make([]uuid.UUID, 0, len(cartItems))
+	variantIds := make([]uuid.UUID, 0, len(cartItems))
+	for _, item := range cartItems {
+		if item.VariantID.Valid {
+			variantIds = append(variantIds, item.VariantID.Bytes)
+			continue
 		}
-		lg.Info().Msg("rolled back transaction")
-	}()
-	span.AddEvent("initialized transaction")
-	logger.Trace().Msg("initialized transaction")
-
-	logger = logger.With().Str(constants.KEY_PROCESS, "inserting cart to database").Logger()
-	logger.Trace().Msg("inserting cart to database")
-	span.AddEvent("inserting cart to database")
-	cart, err := svc.queries.WithTx(tx).InsertCart(c, userID)
+		productIds = append(productIds, item.ProductID)
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "checking archived products").Logger()
+	logger.Trace().Msg("checking archived products")
+	span.AddEvent("checking archived products")
+	err := rejectArchived(c, svc.queries.WithTx(tx), cartItemProductIds(cartItems))
 	if err != nil {
-		err = fmt.Errorf("failed inserting cart with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		return response.Cart{}, err
+		return err
 	}
-	span.AddEvent("inserted cart to database")
-	logger = logger.With().Any(constants.KEY_CART, cart).Logger()
-	logger.Trace().Msg("inserted cart to database")
-
-	span.AddEvent("merging cart items")
-	logger.Trace().Msg("merging cart items")
-	mp := map[string]request.CartItem{}
-	merged := []request.CartItem{}
-	for _, item := range param.CartItems {
-		lg := logger.With().
-			Str(constants.KEY_PRODUCT_ID, item.ProductId.String()).
-			Int32(constants.KEY_CART_ITEM_QUANTITY, item.Quantity).
-			Logger()
-		existing, ok := mp[item.ProductId.String()]
-		if ok {
-			lg.Info().Msg("merging cart item")
-			mp[item.ProductId.String()] = request.CartItem{
-				ProductId: item.ProductId,
-				Price:     item.Price,
-				Quantity:  existing.Quantity + item.Quantity,
-			}
-			lg.Info().
-				Int32(constants.KEY_CART_ITEM_MERGED_QUANTITY, existing.Quantity+item.Quantity).
-				Msg("merged cart item")
-			continue
+	logger.Info().Msg("checked archived products")
+	span.AddEvent("checked archived products")
+
+	available := make(map[uuid.UUID]int32, len(cartItems))
+	if len(productIds) > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "locking products").Logger()
+		logger.Trace().Msg("locking products")
+		span.AddEvent("locking products")
+		products, err := svc.queries.WithTx(tx).FindProductsByIdsForUpdate(c, productIds)
+		if err != nil {
+			err = fmt.Errorf("failed locking products with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return err
 		}
-		mp[item.ProductId.String()] = item
-	}
-	for _, item := range mp {
-		merged = append(merged, item)
-	}
-	param.CartItems = merged
-	span.AddEvent("merged cart items")
-	logger = logger.With().Any(constants.KEY_CART_ITEMS_MERGED, merged).Logger()
-	logger.Trace().Msg("merged cart items")
-
-	logger = logger.With().Str(constants.KEY_PROCESS, "inserting cart items to database").Logger()
-	logger.Trace().Msg("inserting cart items to database")
-	args := make([]repository.InsertCartItemsParams, len(param.CartItems))
-	for i, item := range param.CartItems {
-		args[i] = repository.InsertCartItemsParams{
-			ID:        uuid.New(),
-			CartID:    cart.ID,
-			ProductID: item.ProductId,
-			Quantity:  item.Quantity,
-			Price: pgtype.Numeric{
-				Exp:              item.Price.Exponent(),
-				InfinityModifier: pgtype.Finite,
-				Int:              item.Price.Coefficient(),
-				NaN:              false,
-				Valid:            true,
+		logger.Info().Msg("locked products")
+		span.AddEvent("locked products")
+
+		logger = logger.With().Str(constants.KEY_PROCESS, "finding reserved quantity").Logger()
+		logger.Trace().Msg("finding reserved quantity")
+		span.AddEvent("finding reserved quantity")
+		reserved, err := svc.queries.WithTx(tx).FindReservedQuantities(
+			c,
+			repository.FindReservedQuantitiesParams{
+				ProductIds:      productIds,
+				ExcludedCartIds: []uuid.UUID{cartId},
 			},
+		)
+		if err != nil {
+			err = fmt.Errorf("failed finding reserved quantity with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return err
+		}
+		logger.Info().Any(constants.KEY_RESERVED_QUANTITIES, reserved).Msg("found reserved quantity")
+		span.AddEvent("found reserved quantity")
+
+		for _, product := range products {
+			available[product.ID] = product.Quantity
+		}
+		for _, row := range reserved {
+			available[row.ProductID] -= row.Quantity
 		}
 	}
-	logger = logger.With().Any(constants.KEY_CART_ITEMS, args).Logger()
-	logger.Trace().Msg("inserting cart items to database")
-	insertedCount, err := svc.queries.WithTx(tx).InsertCartItems(c, args)
-	if err != nil {
-		err = fmt.Errorf("failed inserting cartItems to database with error=%w", err)
-		inOtel.RecordError(err, span)
-		logger.Error().Err(err).Msg(err.Error())
-		return response.Cart{}, err
+
+	if len(variantIds) > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "locking variants").Logger()
+		logger.Trace().Msg("locking variants")
+		span.AddEvent("locking variants")
+		variants, err := svc.queries.WithTx(tx).FindProductVariantsByIdsForUpdate(c, variantIds)
+		if err != nil {
+			err = fmt.Errorf("failed locking variants with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return err
+		}
+		logger.Info().Msg("locked variants")
+		span.AddEvent("locked variants")
+
+		logger = logger.With().Str(constants.KEY_PROCESS, "validating variants").Logger()
+		logger.Trace().Msg("validating variants")
+		span.AddEvent("validating variants")
+		err = validateVariants(cartItems, variants)
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return err
+		}
+		logger.Info().Msg("validated variants")
+		span.AddEvent("validated variants")
+
+		logger = logger.With().Str(constants.KEY_PROCESS, "finding reserved variant quantity").Logger()
+		logger.Trace().Msg("finding reserved variant quantity")
+		span.AddEvent("finding reserved variant quantity")
+		reserved, err := svc.queries.WithTx(tx).FindReservedVariantQuantities(
+			c,
+			repository.FindReservedVariantQuantitiesParams{
+				VariantIds:      variantIds,
+				ExcludedCartIds: []uuid.UUID{cartId},
+			},
+		)
+		if err != nil {
+			err = fmt.Errorf("failed finding reserved variant quantity with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return err
+		}
+		logger.Info().
+			Any(constants.KEY_RESERVED_QUANTITIES, reserved).
+			Msg("found reserved variant quantity")
+		span.AddEvent("found reserved variant quantity")
+
+		for _, variant := range variants {
+			available[variant.ID] = variant.Quantity
+		}
+		for _, row := range reserved {
+			available[row.VariantID] -= row.Quantity
+		}
 	}
-	span.AddEvent("inserted cart items to database")
-	logger = logger.With().Int64(constants.KEY_CART_ITEMS_COUNT, insertedCount).Logger()
-	logger.Info().Msg("inserted cart items to database")
 
-	logger = logger.With().
-		Str(constants.KEY_PROCESS, "finding cart by id").
-		Str(constants.KEY_CART_ID, cart.ID.String()).
-		Logger()
-	logger.Trace().Msg("finding cart by id")
-	span.AddEvent("finding cart by id")
-	cartDb, err := svc.queries.WithTx(tx).
-		FindCartById(c, repository.FindCartByIdParams{ID: userID, ID_2: cart.ID})
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting stock reservations").Logger()
+	expiresAt := pgtype.Timestamptz{
+		Time:             time.Now().Add(svc.config.Reservation.TTL),
+		InfinityModifier: pgtype.Finite,
+		Valid:            true,
+	}
+	args := make([]repository.InsertStockReservationsParams, 0, len(cartItems))
+	for _, item := range cartItems {
+		key := stockKey(item.ProductID, item.VariantID)
+		if available[key] < item.Quantity {
+			stock := "productId=" + item.ProductID.String()
+			if item.VariantID.Valid {
+				stock = "variantId=" + key.String()
+			}
+			err := fmt.Errorf(
+				"%s has %d available for quantity=%d with error=%w",
+				stock,
+				max(available[key], 0),
+				item.Quantity,
+				inErrors.ErrOutOfStock,
+			)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return err
+		}
+		args = append(args, repository.InsertStockReservationsParams{
+			CartID:     cartId,
+			CartItemID: item.ID,
+			ProductID:  item.ProductID,
+			VariantID:  item.VariantID,
+			Quantity:   item.Quantity,
+			ExpiresAt:  expiresAt,
+		})
+	}
+	logger.Trace().Msg("inserting stock reservations")
+	span.AddEvent("inserting stock reservations")
+	_, err = svc.queries.WithTx(tx).InsertStockReservations(c, args)
 	if err != nil {
-		err = fmt.Errorf("failed finding cart by id with error=%w", err)
+		err = fmt.Errorf("failed inserting stock reservations with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		return response.Cart{}, err
+		return err
 	}
-	span.AddEvent("found cart by id")
-	logger = logger.With().RawJSON(constants.KEY_CART_ITEMS, cartDb.CartItems).Logger()
-	logger.Trace().Msg("found cart by id")
+	logger.Info().Time(constants.KEY_EXPIRES_AT, expiresAt.Time).Msg("inserted stock reservations")
+	span.AddEvent("inserted stock reservations")
+
+	return nil
+}
+
+// ReleaseExpiredReservations deletes the stock reservations whose TTL expired.
+// Expired reservations are already ignored when the available quantity is
+// computed, this only keeps the table small.
+func (s CartService) ReleaseExpiredReservations(c context.Context) (int64, error) {
+	c, span := otel.Tracer.Start(c, "CartService ReleaseExpiredReservations")
+	defer span.End()
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "mapping cart").Logger()
-	logger.Trace().Msg("mapping cart to cart response")
-	span.AddEvent("mapping cart to cart response")
-	cartResponse, err := cartDb.Response()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService ReleaseExpiredReservations").
+		Str(constants.KEY_PROCESS, "deleting expired stock reservations").
+		Logger()
+
+	logger.Trace().Msg("deleting expired stock reservations")
+	span.AddEvent("deleting expired stock reservations")
+	released, err := s.queries.DeleteExpiredStockReservations(c)
 	if err != nil {
-		err = fmt.Errorf("failed mapping cart with error=%w", err)
+		err = fmt.Errorf("failed deleting expired stock reservations with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		return response.Cart{}, err
+		return 0, err
 	}
-	span.AddEvent("mapped cart db to cart response")
-	logger = logger.With().Any(constants.KEY_CART_RESPONSE, cartResponse).Logger()
-	logger.Trace().Msg("mapped cart db to cart response")
+	span.AddEvent("deleted expired stock reservations")
+	logger.Info().Int64(constants.KEY_RESERVATIONS_RELEASED, released).Msg("deleted expired stock reservations")
 
-	cacheKey := fmt.Sprintf(cache.KEY_CARTS, cart.ID.String())
-	logger = logger.With().
-		Str(constants.KEY_PROCESS, "inserting cart to cache").
+	return released, nil
+}
+
+func (s CartService) FindCartById(
+	c context.Context,
+	param request.FindCartById,
+) (cart response.Cart, err error) {
+	c, span := otel.Tracer.Start(c, "CartService FindCartById")
+	defer span.End()
+
+	cacheKey := param.ID.String()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService FindCartById").
 		Str(constants.KEY_CACHE_KEY, cacheKey).
 		Logger()
-	logger.Trace().Msg("inserting cart to cache")
-	err = svc.cache.JSONSet(c, cacheKey, "$", cartResponse).Err()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding cart").Logger()
+	logger.Trace().Msg("finding cart")
+	span.AddEvent("finding cart")
+	c = logger.WithContext(c)
+	cart, err = inCache.GetOrLoad(
+		c,
+		s.cache,
+		cache.KEY_CARTS,
+		cacheKey,
+		func(c context.Context) (response.Cart, error) {
+			cart, err := s.queries.FindCartById(c, repository.FindCartByIdParams{ID: param.ID})
+			if err != nil {
+				return response.Cart{}, err
+			}
+			return cart.Response()
+		},
+	)
 	if err != nil {
-		err = fmt.Errorf("failed inserting cart to cache with error=%w", err)
+		err = fmt.Errorf("failed finding cart with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		return response.Cart{}, err
 	}
-	logger.Trace().Msg("inserted cart to cache")
+	span.AddEvent("found cart")
+	logger = logger.With().Any(constants.KEY_CART, cart).Logger()
+	logger.Debug().Msg("found cart")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
-	logger.Trace().Msg("committing transaction")
-	span.AddEvent("committing transaction")
-	err = tx.Commit(c)
-	if err != nil {
-		newErr := svc.cache.JSONDel(c, cacheKey, "$").Err()
-		if newErr != nil {
-			err = fmt.Errorf(
-				"failed committing transaction with error=%w",
-				errors.Join(err, newErr),
-			)
-		}
+	logger = logger.With().Str(constants.KEY_PROCESS, "checking cart owner").Logger()
+	logger.Trace().Msg("checking cart owner")
+	span.AddEvent("checking cart owner")
+	if param.UserId != uuid.Nil && cart.UserID != param.UserId {
+		err = fmt.Errorf("failed checking cart owner with error=%w", pgx.ErrNoRows)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		return response.Cart{}, err
 	}
-	span.AddEvent("committed transaction")
-	logger.Trace().Msg("committed transaction")
+	span.AddEvent("checked cart owner")
+	logger.Debug().Msg("checked cart owner")
 
-	return cartResponse, nil
+	logger.Info().Msg("found cart")
+	return cart, nil
 }
 
-func (s CartService) FindCartById(
+// FindCarts returns a page of the carts of a user, newest first.
+func (s CartService) FindCarts(
 	c context.Context,
-	param request.FindCartById,
-) (cart response.Cart, err error) {
-	c, span := otel.Tracer.Start(c, "CartService FindCartById")
+	param request.FindCarts,
+) ([]response.Cart, string, error) {
+	c, span := otel.Tracer.Start(c, "CartService FindCarts")
 	defer span.End()
 
-	cacheKey := fmt.Sprintf(cache.KEY_CARTS, param.ID.String())
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
-		Str(constants.KEY_TAG, "CartService FindCartById").
-		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Str(constants.KEY_TAG, "CartService FindCarts").
+		Str(constants.KEY_USER_ID, param.UserId.String()).
+		Any(constants.KEY_PAGE, param.Page).
 		Logger()
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "finding cart in cache").Logger()
-	logger.Trace().Msg("finding cart in cache")
-	span.AddEvent("finding cart in cache")
-	jsonCache, err := s.cache.JSONGet(c, cacheKey).Result()
-	if err != nil || err == redis.Nil || errors.Is(err, redis.Nil) || jsonCache == "" {
-		err = fmt.Errorf("failed finding cart in cache with error=%w", err)
+	cursorId, cursorCreatedAt, err := param.Page.TimeCursorParams()
+	if err != nil {
 		inOtel.RecordError(err, span)
-		logger.Debug().Err(err).Msg(err.Error())
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "finding cart in db").Logger()
-		logger.Trace().Msg("finding cart in db")
-		span.AddEvent("finding cart in db")
-		cart, err := s.queries.FindCartById(
-			c,
-			repository.FindCartByIdParams{ID: param.ID, ID_2: param.UserId},
-		)
-		if err != nil {
-			err = fmt.Errorf("failed finding cart in db with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return response.Cart{}, err
-		}
-		span.AddEvent("found cart in db")
-		logger = logger.With().Any(constants.KEY_CART, cart).Logger()
-		logger.Debug().Msg("found cart in db")
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "inserting cart in cache").Logger()
-		logger.Trace().Msg("inserting cart in cache")
-		span.AddEvent("inserting cart in cache")
-		err = s.cache.JSONSet(c, cacheKey, "$", cart).Err()
-		if err != nil {
-			err = fmt.Errorf("failed inserting cart in cache with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return response.Cart{}, err
-		}
-		span.AddEvent("inserted cart in cache")
-		logger.Debug().Msg("inserted cart in cache")
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "mapping cart").Logger()
-		logger.Trace().Msg("mapping cart")
-		span.AddEvent("mapping cart")
-		cartResponse, err := cart.Response()
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding carts in db").Logger()
+	logger.Trace().Msg("finding carts in db")
+	span.AddEvent("finding carts in db")
+	rows, err := s.queries.FindCartsByUserId(c, repository.FindCartsByUserIdParams{
+		UserID:          param.UserId,
+		CursorID:        cursorId,
+		CursorCreatedAt: cursorCreatedAt,
+		ResultLimit:     param.Page.Limit + 1,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed finding carts in db with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	rows, nextCursor := inHttp.NextPage(
+		rows,
+		param.Page.Limit,
+		func(row repository.FindCartsByUserIdRow) inHttp.Cursor {
+			return inHttp.TimeCursor(row.CreatedAt.Time, row.ID)
+		},
+	)
+	carts := make([]response.Cart, 0, len(rows))
+	for _, row := range rows {
+		cart, err := row.Response()
 		if err != nil {
 			err = fmt.Errorf("failed mapping cart with error=%w", err)
 			inOtel.RecordError(err, span)
 			logger.Error().Err(err).Msg(err.Error())
-			return response.Cart{}, err
+			return nil, "", err
 		}
-		span.AddEvent("mapped cart")
-		logger = logger.With().Any(constants.KEY_CART_RESPONSE, cartResponse).Logger()
-		logger.Debug().Msg("mapped cart")
-
-		logger.Info().Msg("found cart in db and inserted in cache")
-		return cart.Response()
-	}
-	span.AddEvent("found cart in cache")
-	logger = logger.With().RawJSON(constants.KEY_JSON_CACHE, []byte(jsonCache)).Logger()
-	logger.Debug().Msg("found cart in cache")
-
-	logger = logger.With().Str(constants.KEY_PROCESS, "unmarshaling cache").Logger()
-	logger.Trace().Msg("unmarshaling cache")
-	span.AddEvent("unmarshaling cache")
-	err = json.Unmarshal([]byte(jsonCache), &cart)
-	if err != nil {
-		err = fmt.Errorf("failed unmarshaling cache with error=%w", err)
-		inOtel.RecordError(err, span)
-		logger.Error().Err(err).Msg(err.Error())
-		return response.Cart{}, err
+		carts = append(carts, cart)
 	}
-	span.AddEvent("unmarshaling cache")
-	logger.Debug().Msg("unmarshaled cache")
+	span.AddEvent("found carts in db")
+	logger.Info().Int(constants.KEY_CARTS, len(carts)).Msg("found carts in db")
 
-	logger.Info().Msg("found cart in cache")
-	return cart, nil
+	return carts, nextCursor, nil
 }
 
 func (s CartService) FindCartByUserId(
@@ -367,7 +467,7 @@ func (s CartService) FindCartByUserId(
 	c, span := otel.Tracer.Start(c, "CartService FindCartByUserId")
 	defer span.End()
 
-	cacheKey := fmt.Sprintf(cache.KEY_CARTS_BY_USER_ID, userId.String())
+	cacheKey := userId.String()
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
@@ -375,46 +475,28 @@ func (s CartService) FindCartByUserId(
 		Str(constants.KEY_TAG, "CartService FindCartByUserId").
 		Logger()
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "finding carts in cache").Logger()
-	logger.Trace().Msg("finding carts in cache")
-	jsonString, err := s.cache.JSONGet(c, cacheKey).Result()
-	if err != nil || err == redis.Nil || errors.Is(err, redis.Nil) || jsonString == "" {
-		err = fmt.Errorf("failed finding carts in cache with error=%w", err)
-		logger.Info().Err(err).Msg(err.Error())
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "finding carts in db").Logger()
-		logger.Trace().Msg("finding carts in db")
-		span.AddEvent("finding carts in db")
-		carts, err := s.queries.FindCartByUserId(c, userId)
-		if err != nil {
-			err = fmt.Errorf("failed finding carts in db with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return nil, err
-		}
-		span.AddEvent("found carts in db")
-		logger.Debug().Msg("found carts in db")
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "inserting cache").Logger()
-		logger.Trace().Msg("inserting carts to cache")
-		span.AddEvent("inserting carts to cache")
-		err = s.cache.JSONSet(c, cacheKey, "$", carts).Err()
-		if err != nil {
-			err = fmt.Errorf("failed inserting cache with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return nil, err
-		}
-		span.AddEvent("inserted carts to cache")
-		logger.Debug().Msg("inserted carts to cache")
-
-		logger.Info().Msg("found carts in database and inserted to cache")
-		return carts, err
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding carts").Logger()
+	logger.Trace().Msg("finding carts")
+	span.AddEvent("finding carts")
+	c = logger.WithContext(c)
+	carts, err = inCache.GetOrLoad(
+		c,
+		s.cache,
+		cache.KEY_CARTS_BY_USER_ID,
+		cacheKey,
+		func(c context.Context) ([]repository.FindCartByUserIdRow, error) {
+			return s.queries.FindCartByUserId(c, userId)
+		},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed finding carts with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
 	}
-	span.AddEvent("found carts in cache")
-	logger = logger.With().Str(constants.KEY_JSON_CACHE, jsonString).Logger()
+	span.AddEvent("found carts")
+	logger.Info().Int(constants.KEY_CARTS, len(carts)).Msg("found carts")
 
-	logger.Info().Msg("found carts in cache")
 	return carts, nil
 }
 
@@ -433,9 +515,9 @@ func (s CartService) RemoveCartItem(c context.Context, param request.RemoveCartI
 	logger = logger.With().Str(constants.KEY_PROCESS, "find cart").Logger()
 	logger.Trace().Msg("finding cartId")
 	span.AddEvent("finding cartId")
-	_, err := s.queries.FindCartById(
+	cart, err := s.queries.FindCartById(
 		c,
-		repository.FindCartByIdParams{ID: param.CartId, ID_2: param.UserId},
+		repository.FindCartByIdParams{ID: param.CartId, UserID: toScope(param.UserId)},
 	)
 	if err != nil {
 		err = fmt.Errorf("failed finding cartId=%s with error=%w", param.ID.String(), err)
@@ -459,18 +541,6 @@ func (s CartService) RemoveCartItem(c context.Context, param request.RemoveCartI
 	span.AddEvent("found cartItemId")
 	logger.Debug().Msg("found cartItemId")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "deleting cart item from cache").Logger()
-	logger.Trace().Msg("deleting cart item from cache")
-	err = s.cache.Del(c, cache.KEY_CARTS+param.CartId.String()).Err()
-	if err != nil {
-		err = fmt.Errorf("failed deleting cart from cache with error=%w", err)
-		inOtel.RecordError(err, span)
-		logger.Error().Err(err).Msg(err.Error())
-		return err
-	}
-	span.AddEvent("deleted cart item from cache")
-	logger.Info().Msg("deleted cart item from cache")
-
 	logger = logger.With().Str(constants.KEY_PROCESS, "deleting cart item from database").Logger()
 	logger.Trace().Msg("deleting cart item from database")
 	_, err = s.queries.DeleteCartItemFromCartsById(
@@ -486,6 +556,22 @@ func (s CartService) RemoveCartItem(c context.Context, param request.RemoveCartI
 	span.AddEvent("deleted cart item from database")
 	logger.Info().Msg("deleted cart item from database")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "deleting cart from cache").Logger()
+	logger.Trace().Msg("deleting cart from cache")
+	span.AddEvent("deleting cart from cache")
+	err = s.cache.Delete(c, cache.KEY_CARTS, param.CartId.String())
+	if err == nil {
+		err = s.cache.Delete(c, cache.KEY_CARTS_BY_USER_ID, cart.UserID.String())
+	}
+	if err != nil {
+		err = fmt.Errorf("failed deleting cart from cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	span.AddEvent("deleted cart from cache")
+	logger.Info().Msg("deleted cart from cache")
+
 	logger.Info().Msg("deleted cart item")
 	return nil
 }
@@ -494,7 +580,7 @@ func (s CartService) RemoveCart(c context.Context, param request.RemoveCart) err
 	c, span := otel.Tracer.Start(c, "CartService RemoveCart")
 	defer span.End()
 
-	cacheKey := fmt.Sprintf(cache.KEY_CARTS, param.ID.String())
+	cacheKey := param.ID.String()
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
@@ -534,7 +620,10 @@ func (s CartService) RemoveCart(c context.Context, param request.RemoveCart) err
 	logger = logger.With().Str(constants.KEY_PROCESS, "delete cart from cache").Logger()
 	logger.Trace().Msg("deleting cart from cache")
 	span.AddEvent("deleting cart from cache")
-	err = s.cache.JSONDel(c, cacheKey, "$").Err()
+	err = s.cache.Delete(c, cache.KEY_CARTS, cacheKey)
+	if err == nil {
+		err = s.cache.Delete(c, cache.KEY_CARTS_BY_USER_ID, param.UserId.String())
+	}
 	if err != nil {
 		err = fmt.Errorf("failed deleting cart from cache with error=%w", err)
 		inOtel.RecordError(err, span)
@@ -619,10 +708,27 @@ func (s CartService) CheckoutCart(
 	span.AddEvent("found cart by id")
 	logger.Info().Msg("found cart by id")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "checking archived products").Logger()
+	logger.Trace().Msg("checking archived products")
+	span.AddEvent("checking archived products")
+	productIds := make([]uuid.UUID, 0, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		productIds = append(productIds, item.ProductID)
+	}
+	err = rejectArchived(c, s.queries, productIds)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("checked archived products")
+	logger.Info().Msg("checked archived products")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "mapping-cart").Logger()
 	logger.Trace().Msg("mapping cart to order")
 	span.AddEvent("mapping cart to order")
 	order := cart.Order()
+	order.ShippingLocation = param.ShippingLocation
 	span.AddEvent("mapped cart to order")
 	logger.Debug().Msg("mapped cart to order")
 
@@ -714,3 +820,85 @@ func (s CartService) CheckoutCart(
 
 	return cart, nil
 }
+
+// cartItemKey returns the key cart items are merged by, items of different
+// variants of the same product are kept apart.
+func cartItemKey(productId uuid.UUID, variantId pgtype.UUID) string {
+	if !variantId.Valid {
+		return productId.String()
+	}
+	return productId.String() + ":" + uuid.UUID(variantId.Bytes).String()
+}
+
+// stockKey returns the id the stock of a cart item is held against, which is
+// the variant when the item has one and the product otherwise.
+func stockKey(productId uuid.UUID, variantId pgtype.UUID) uuid.UUID {
+	if variantId.Valid {
+		return variantId.Bytes
+	}
+	return productId
+}
+
+// validateVariants fails with ErrUnknownVariant when a cart item references a
+// variant that is missing or belongs to another product.
+func validateVariants(
+	cartItems []repository.InsertCartItemsParams,
+	variants []repository.ProductVariant,
+) error {
+	owners := make(map[uuid.UUID]uuid.UUID, len(variants))
+	for _, variant := range variants {
+		owners[variant.ID] = variant.ProductID
+	}
+	for _, item := range cartItems {
+		if !item.VariantID.Valid {
+			continue
+		}
+		owner, ok := owners[item.VariantID.Bytes]
+		if !ok || owner != item.ProductID {
+			return fmt.Errorf(
+				"failed validating variantId=%s of productId=%s with error=%w",
+				uuid.UUID(item.VariantID.Bytes),
+				item.ProductID,
+				inErrors.ErrUnknownVariant,
+			)
+		}
+	}
+	return nil
+}
+
+// rejectArchived fails with ErrProductArchived when any of productIds is
+// archived, archived products can not be added to a cart or checked out.
+func rejectArchived(
+	c context.Context,
+	queries *repository.Queries,
+	productIds []uuid.UUID,
+) error {
+	archived, err := queries.FindArchivedProductIds(c, productIds)
+	if err != nil {
+		return fmt.Errorf("failed finding archived products with error=%w", err)
+	}
+	if len(archived) > 0 {
+		return fmt.Errorf("productIds=%v with error=%w", archived, inErrors.ErrProductArchived)
+	}
+	return nil
+}
+
+func cartItemProductIds(cartItems []repository.InsertCartItemsParams) []uuid.UUID {
+	productIds := make([]uuid.UUID, 0, len(cartItems))
+	for _, item := range cartItems {
+		productIds = append(productIds, item.ProductID)
+	}
+	return productIds
+}
+
+// toScope returns the owner a query is scoped to, none for uuid.Nil.
+func toScope(userId uuid.UUID) pgtype.UUID {
+	return pgtype.UUID{Bytes: userId, Valid: userId != uuid.Nil}
+}
+
+func toUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *id, Valid: true}
+}
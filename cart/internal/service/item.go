@@ -0,0 +1,768 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/Alturino/ecommerce/cart/internal/cache"
+	"github.com/Alturino/ecommerce/cart/internal/otel"
+	"github.com/Alturino/ecommerce/cart/pkg/request"
+	"github.com/Alturino/ecommerce/cart/pkg/response"
+	"github.com/Alturino/ecommerce/internal"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	"github.com/Alturino/ecommerce/internal/log"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	productResponse "github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+// FindActiveCart returns the active cart of a user, pgx.ErrNoRows when the user
+// has not added anything yet.
+func (s CartService) FindActiveCart(c context.Context, userId uuid.UUID) (response.Cart, error) {
+	c, span := otel.Tracer.Start(c, "CartService FindActiveCart")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService FindActiveCart").
+		Str(constants.KEY_USER_ID, userId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding active cart").Logger()
+	logger.Trace().Msg("finding active cart")
+	span.AddEvent("finding active cart")
+	row, err := s.queries.FindActiveCartByUserId(c, userId)
+	if err != nil {
+		err = fmt.Errorf("failed finding active cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	cart, err := row.Response()
+	if err != nil {
+		err = fmt.Errorf("failed mapping cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("found active cart")
+	logger.Info().Any(constants.KEY_CART, cart).Msg("found active cart")
+
+	return cart, nil
+}
+
+// AddCartItems adds items to the active cart of a user and creates the cart
+// when the user has none. An item of a product, or variant, that is already in
+// the cart is merged into it by adding up the quantities. Every product is
+// checked in the product service, which also gives the price of the item.
+func (s CartService) AddCartItems(
+	c context.Context,
+	userId uuid.UUID,
+	items []request.InsertCartItem,
+) (response.Cart, error) {
+	c, span := otel.Tracer.Start(c, "CartService AddCartItems")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService AddCartItems").
+		Str(constants.KEY_USER_ID, userId.String()).
+		Int(constants.KEY_CART_ITEMS, len(items)).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "merging cart items").Logger()
+	logger.Trace().Msg("merging cart items")
+	span.AddEvent("merging cart items")
+	items = mergeCartItems(items)
+	span.AddEvent("merged cart items")
+	logger = logger.With().Any(constants.KEY_CART_ITEMS_MERGED, items).Logger()
+	logger.Debug().Msg("merged cart items")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding products").Logger()
+	logger.Trace().Msg("finding products")
+	span.AddEvent("finding products")
+	products := make(map[uuid.UUID]productResponse.Product, len(items))
+	for _, item := range items {
+		if _, ok := products[item.ProductId]; ok {
+			continue
+		}
+		product, err := s.findProduct(c, item.ProductId)
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Cart{}, err
+		}
+		products[item.ProductId] = product
+	}
+	span.AddEvent("found products")
+	logger.Info().Int(constants.KEY_PRODUCTS, len(products)).Msg("found products")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := s.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	span.AddEvent("initialized transaction")
+	logger.Trace().Msg("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking active cart").Logger()
+	logger.Trace().Msg("locking active cart")
+	span.AddEvent("locking active cart")
+	cartId, err := s.activeCartId(c, tx, userId)
+	if err != nil {
+		err = fmt.Errorf("failed locking active cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("locked active cart")
+	logger = logger.With().Str(constants.KEY_CART_ID, cartId.String()).Logger()
+	logger.Debug().Msg("locked active cart")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding cart items").Logger()
+	logger.Trace().Msg("finding cart items")
+	span.AddEvent("finding cart items")
+	cartItems, err := s.queries.WithTx(tx).FindCartItemByCartId(c, cartId)
+	if err != nil {
+		err = fmt.Errorf("failed finding cart items with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	existing := make(map[string]repository.CartItem, len(cartItems))
+	for _, item := range cartItems {
+		existing[cartItemKey(item.ProductID, item.VariantID)] = item
+	}
+	span.AddEvent("found cart items")
+	logger.Debug().Int(constants.KEY_CART_ITEMS_COUNT, len(cartItems)).Msg("found cart items")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "adding cart items").Logger()
+	logger.Trace().Msg("adding cart items")
+	span.AddEvent("adding cart items")
+	for _, item := range items {
+		lg := logger.With().
+			Str(constants.KEY_PRODUCT_ID, item.ProductId.String()).
+			Int32(constants.KEY_CART_ITEM_QUANTITY, item.Quantity).
+			Logger()
+
+		current, merged := existing[cartItemKey(item.ProductId, toUUID(item.VariantId))]
+		quantity := item.Quantity
+		if merged {
+			quantity += current.Quantity
+		}
+		price, err := validateCartItem(products[item.ProductId], item.VariantId, quantity)
+		if err != nil {
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			return response.Cart{}, err
+		}
+
+		if merged {
+			_, err = s.queries.WithTx(tx).UpdateCartItemQuantity(c, repository.UpdateCartItemQuantityParams{
+				Quantity: quantity,
+				ID:       current.ID,
+				CartID:   cartId,
+			})
+			if err != nil {
+				err = fmt.Errorf("failed merging cart item with error=%w", err)
+				inOtel.RecordError(err, span)
+				lg.Error().Err(err).Msg(err.Error())
+				return response.Cart{}, err
+			}
+			lg.Info().Int32(constants.KEY_CART_ITEM_MERGED_QUANTITY, quantity).Msg("merged cart item")
+			continue
+		}
+		_, err = s.queries.WithTx(tx).InsertCartItem(c, repository.InsertCartItemParams{
+			ID:        uuid.New(),
+			CartID:    cartId,
+			ProductID: item.ProductId,
+			Quantity:  quantity,
+			Price: pgtype.Numeric{
+				Exp:              price.Exponent(),
+				InfinityModifier: pgtype.Finite,
+				Int:              price.Coefficient(),
+				NaN:              false,
+				Valid:            true,
+			},
+			VariantID: toUUID(item.VariantId),
+			Currency:  s.currency.BaseCurrency(),
+		})
+		if err != nil {
+			err = fmt.Errorf("failed inserting cart item with error=%w", err)
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			return response.Cart{}, err
+		}
+		lg.Info().Msg("inserted cart item")
+	}
+	span.AddEvent("added cart items")
+	logger.Info().Msg("added cart items")
+
+	return s.commitCart(logger.WithContext(c), tx, userId, cartId)
+}
+
+// UpdateCartItem sets the quantity of an item of the active cart of a user, it
+// fails with pgx.ErrNoRows when the item is not in that cart.
+func (s CartService) UpdateCartItem(
+	c context.Context,
+	userId uuid.UUID,
+	cartItemId uuid.UUID,
+	param request.UpdateCartItem,
+) (response.Cart, error) {
+	c, span := otel.Tracer.Start(c, "CartService UpdateCartItem")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService UpdateCartItem").
+		Str(constants.KEY_USER_ID, userId.String()).
+		Str(constants.KEY_CART_ITEM_ID, cartItemId.String()).
+		Int32(constants.KEY_CART_ITEM_QUANTITY, param.Quantity).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding cart item").Logger()
+	logger.Trace().Msg("finding cart item")
+	span.AddEvent("finding cart item")
+	item, err := s.queries.FindCartItemById(c, cartItemId)
+	if err != nil {
+		err = fmt.Errorf("failed finding cart item with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("found cart item")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, item.ProductID.String()).Logger()
+	logger.Debug().Msg("found cart item")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating cart item").Logger()
+	logger.Trace().Msg("validating cart item")
+	span.AddEvent("validating cart item")
+	product, err := s.findProduct(c, item.ProductID)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	_, err = validateCartItem(product, fromUUID(item.VariantID), param.Quantity)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("validated cart item")
+	logger.Debug().Msg("validated cart item")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := s.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	span.AddEvent("initialized transaction")
+	logger.Trace().Msg("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking active cart").Logger()
+	logger.Trace().Msg("locking active cart")
+	span.AddEvent("locking active cart")
+	cartId, err := s.queries.WithTx(tx).FindActiveCartIdByUserIdForUpdate(c, userId)
+	if err != nil {
+		err = fmt.Errorf("failed locking active cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("locked active cart")
+	logger = logger.With().Str(constants.KEY_CART_ID, cartId.String()).Logger()
+	logger.Debug().Msg("locked active cart")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating cart item").Logger()
+	logger.Trace().Msg("updating cart item")
+	span.AddEvent("updating cart item")
+	_, err = s.queries.WithTx(tx).UpdateCartItemQuantity(c, repository.UpdateCartItemQuantityParams{
+		Quantity: param.Quantity,
+		ID:       cartItemId,
+		CartID:   cartId,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed updating cart item with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("updated cart item")
+	logger.Info().Msg("updated cart item")
+
+	return s.commitCart(logger.WithContext(c), tx, userId, cartId)
+}
+
+// RemoveActiveCartItem removes an item from the active cart of a user, it
+// fails with pgx.ErrNoRows when the item is not in that cart.
+func (s CartService) RemoveActiveCartItem(
+	c context.Context,
+	userId uuid.UUID,
+	cartItemId uuid.UUID,
+) (response.Cart, error) {
+	c, span := otel.Tracer.Start(c, "CartService RemoveActiveCartItem")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService RemoveActiveCartItem").
+		Str(constants.KEY_USER_ID, userId.String()).
+		Str(constants.KEY_CART_ITEM_ID, cartItemId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := s.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	span.AddEvent("initialized transaction")
+	logger.Trace().Msg("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking active cart").Logger()
+	logger.Trace().Msg("locking active cart")
+	span.AddEvent("locking active cart")
+	cartId, err := s.queries.WithTx(tx).FindActiveCartIdByUserIdForUpdate(c, userId)
+	if err != nil {
+		err = fmt.Errorf("failed locking active cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("locked active cart")
+	logger = logger.With().Str(constants.KEY_CART_ID, cartId.String()).Logger()
+	logger.Debug().Msg("locked active cart")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "deleting cart item").Logger()
+	logger.Trace().Msg("deleting cart item")
+	span.AddEvent("deleting cart item")
+	_, err = s.queries.WithTx(tx).DeleteCartItemFromCartsById(
+		c,
+		repository.DeleteCartItemFromCartsByIdParams{ID: cartItemId, CartID: cartId},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed deleting cart item with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("deleted cart item")
+	logger.Info().Msg("deleted cart item")
+
+	return s.commitCart(logger.WithContext(c), tx, userId, cartId)
+}
+
+// ClearCart removes every item from the active cart of a user, the cart itself
+// is kept.
+func (s CartService) ClearCart(c context.Context, userId uuid.UUID) (response.Cart, error) {
+	c, span := otel.Tracer.Start(c, "CartService ClearCart")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService ClearCart").
+		Str(constants.KEY_USER_ID, userId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := s.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	span.AddEvent("initialized transaction")
+	logger.Trace().Msg("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking active cart").Logger()
+	logger.Trace().Msg("locking active cart")
+	span.AddEvent("locking active cart")
+	cartId, err := s.queries.WithTx(tx).FindActiveCartIdByUserIdForUpdate(c, userId)
+	if err != nil {
+		err = fmt.Errorf("failed locking active cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("locked active cart")
+	logger = logger.With().Str(constants.KEY_CART_ID, cartId.String()).Logger()
+	logger.Debug().Msg("locked active cart")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "deleting cart items").Logger()
+	logger.Trace().Msg("deleting cart items")
+	span.AddEvent("deleting cart items")
+	err = s.queries.WithTx(tx).DeleteCartItemsByCartId(c, cartId)
+	if err != nil {
+		err = fmt.Errorf("failed deleting cart items with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("deleted cart items")
+	logger.Info().Msg("deleted cart items")
+
+	return s.commitCart(logger.WithContext(c), tx, userId, cartId)
+}
+
+// activeCartId locks the active cart of a user and creates it when the user has
+// none. When another request creates it first, the cart created here is
+// dropped and the other one is used.
+func (s CartService) activeCartId(c context.Context, tx pgx.Tx, userId uuid.UUID) (uuid.UUID, error) {
+	queries := s.queries.WithTx(tx)
+	cartId, err := queries.FindActiveCartIdByUserIdForUpdate(c, userId)
+	if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return cartId, err
+	}
+	cart, err := queries.InsertCart(c, userId)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed inserting cart with error=%w", err)
+	}
+	cartId, err = queries.InsertActiveCart(
+		c,
+		repository.InsertActiveCartParams{UserID: userId, CartID: cart.ID},
+	)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return cartId, err
+	}
+	_, err = queries.DeleteCartByIdAndUserId(
+		c,
+		repository.DeleteCartByIdAndUserIdParams{ID: cart.ID, UserID: userId},
+	)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed deleting duplicate cart with error=%w", err)
+	}
+	return queries.FindActiveCartIdByUserIdForUpdate(c, userId)
+}
+
+// commitCart finishes a change of the active cart of a user. The stock of the
+// cart is held again for its new items, and the cart is cached before the
+// transaction is committed so a failing cache never leaves a stale cart behind.
+func (s CartService) commitCart(
+	c context.Context,
+	tx pgx.Tx,
+	userId uuid.UUID,
+	cartId uuid.UUID,
+) (response.Cart, error) {
+	c, span := otel.Tracer.Start(c, "CartService commitCart")
+	defer span.End()
+
+	cacheKey := cartId.String()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CartService commitCart").
+		Str(constants.KEY_USER_ID, userId.String()).
+		Str(constants.KEY_CART_ID, cartId.String()).
+		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Logger()
+
+	if s.config.Reservation.Enabled {
+		logger = logger.With().Str(constants.KEY_PROCESS, "reserving stock").Logger()
+		logger.Trace().Msg("reserving stock")
+		span.AddEvent("reserving stock")
+		err := s.rereserveStock(logger.WithContext(c), tx, cartId)
+		if err != nil {
+			err = fmt.Errorf("failed reserving stock with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Cart{}, err
+		}
+		span.AddEvent("reserved stock")
+		logger.Info().Msg("reserved stock")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "touching cart").Logger()
+	logger.Trace().Msg("touching cart")
+	span.AddEvent("touching cart")
+	err := s.queries.WithTx(tx).TouchCart(c, cartId)
+	if err != nil {
+		err = fmt.Errorf("failed touching cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("touched cart")
+	logger.Trace().Msg("touched cart")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding active cart").Logger()
+	logger.Trace().Msg("finding active cart")
+	span.AddEvent("finding active cart")
+	row, err := s.queries.WithTx(tx).FindActiveCartByUserId(c, userId)
+	if err != nil {
+		err = fmt.Errorf("failed finding active cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	cart, err := row.Response()
+	if err != nil {
+		err = fmt.Errorf("failed mapping cart with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("found active cart")
+	logger = logger.With().Any(constants.KEY_CART_RESPONSE, cart).Logger()
+	logger.Trace().Msg("found active cart")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating cart in cache").Logger()
+	logger.Trace().Msg("updating cart in cache")
+	span.AddEvent("updating cart in cache")
+	err = s.cache.Set(c, cache.KEY_CARTS, cacheKey, cart)
+	if err == nil {
+		err = s.cache.Delete(c, cache.KEY_CARTS_BY_USER_ID, userId.String())
+	}
+	if err != nil {
+		err = fmt.Errorf("failed updating cart in cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("updated cart in cache")
+	logger.Trace().Msg("updated cart in cache")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		newErr := s.cache.Delete(c, cache.KEY_CARTS, cacheKey)
+		err = fmt.Errorf("failed committing transaction with error=%w", errors.Join(err, newErr))
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Cart{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	return cart, nil
+}
+
+// rereserveStock replaces the stock reservations of a cart with holds for the
+// current quantity of its items.
+func (s CartService) rereserveStock(c context.Context, tx pgx.Tx, cartId uuid.UUID) error {
+	queries := s.queries.WithTx(tx)
+	err := queries.DeleteStockReservationsByCartIds(c, []uuid.UUID{cartId})
+	if err != nil {
+		return fmt.Errorf("failed deleting stock reservations with error=%w", err)
+	}
+	cartItems, err := queries.FindCartItemByCartId(c, cartId)
+	if err != nil {
+		return fmt.Errorf("failed finding cart items with error=%w", err)
+	}
+	if len(cartItems) == 0 {
+		return nil
+	}
+	args := make([]repository.InsertCartItemsParams, 0, len(cartItems))
+	for _, item := range cartItems {
+		args = append(args, repository.InsertCartItemsParams{
+			ID:        item.ID,
+			CartID:    item.CartID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+			VariantID: item.VariantID,
+			Currency:  item.Currency,
+		})
+	}
+	return s.reserveStock(c, tx, cartId, args)
+}
+
+// findProduct finds a product in the product service with the token of the
+// request. It fails with ErrUnknownProduct when the product does not exist.
+func (s CartService) findProduct(c context.Context, productId uuid.UUID) (productResponse.Product, error) {
+	req, err := http.NewRequestWithContext(
+		c,
+		http.MethodGet,
+		constants.URL_PRODUCT_SERVICE+"/"+productId.String(),
+		nil,
+	)
+	if err != nil {
+		return productResponse.Product{}, fmt.Errorf(
+			"failed creating request to product service with error=%w",
+			err,
+		)
+	}
+	req.Header.Add(inHttp.KEY_HEADER_REQUEST_ID, log.RequestIDFromContext(c))
+	if token := internal.JwtTokenFromContext(c); token != nil {
+		req.Header.Add("Authorization", "Bearer "+token.Raw)
+	}
+	resp, err := otelhttp.DefaultClient.Do(req)
+	if err != nil {
+		return productResponse.Product{}, fmt.Errorf(
+			"failed sending request to product service with error=%w",
+			err,
+		)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return productResponse.Product{}, fmt.Errorf(
+			"productId=%s with error=%w",
+			productId,
+			inErrors.ErrUnknownProduct,
+		)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return productResponse.Product{}, fmt.Errorf(
+			"product service returned status code=%d for productId=%s",
+			resp.StatusCode,
+			productId,
+		)
+	}
+	body := struct {
+		Data struct {
+			Product productResponse.Product `json:"product"`
+		} `json:"data"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return productResponse.Product{}, fmt.Errorf(
+			"failed decoding product of productId=%s with error=%w",
+			productId,
+			err,
+		)
+	}
+	return body.Data.Product, nil
+}
+
+// validateCartItem checks that quantity of product, or of its variant, can be
+// put in a cart and returns the price of the item. Backorderable and
+// preorderable products may be added beyond their stock.
+func validateCartItem(
+	product productResponse.Product,
+	variantId *uuid.UUID,
+	quantity int32,
+) (decimal.Decimal, error) {
+	if product.ArchivedAt != nil {
+		return decimal.Decimal{}, fmt.Errorf(
+			"productId=%s with error=%w",
+			product.ID,
+			inErrors.ErrProductArchived,
+		)
+	}
+	stock, price := product.Quantity, product.Price
+	if variantId != nil {
+		found := false
+		for _, variant := range product.Variants {
+			if variant.ID != *variantId {
+				continue
+			}
+			found = true
+			stock = variant.Quantity
+			if variant.Price != nil {
+				price = *variant.Price
+			}
+			break
+		}
+		if !found {
+			return decimal.Decimal{}, fmt.Errorf(
+				"failed validating variantId=%s of productId=%s with error=%w",
+				*variantId,
+				product.ID,
+				inErrors.ErrUnknownVariant,
+			)
+		}
+	}
+	if quantity > stock && !product.Backorderable && !product.Preorderable {
+		return decimal.Decimal{}, fmt.Errorf(
+			"productId=%s has %d in stock for quantity=%d with error=%w",
+			product.ID,
+			max(stock, 0),
+			quantity,
+			inErrors.ErrOutOfStock,
+		)
+	}
+	return price, nil
+}
+
+// mergeCartItems adds up the quantities of items of the same product and
+// variant, keeping the order in which they were first requested.
+func mergeCartItems(items []request.InsertCartItem) []request.InsertCartItem {
+	merged := make([]request.InsertCartItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		key := cartItemKey(item.ProductId, toUUID(item.VariantId))
+		if i, ok := index[key]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func fromUUID(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	value := uuid.UUID(id.Bytes)
+	return &value
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/cart/pkg/request"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	productResponse "github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+func TestValidateCartItem(t *testing.T) {
+	variantPrice := decimal.NewFromInt(15)
+	variant := productResponse.Variant{ID: uuid.New(), Price: &variantPrice, Quantity: 2}
+	product := productResponse.Product{
+		ID:       uuid.New(),
+		Price:    decimal.NewFromInt(10),
+		Quantity: 5,
+		Variants: []productResponse.Variant{variant},
+	}
+	unknownVariant := uuid.New()
+	archivedAt := time.Now()
+	archived := product
+	archived.ArchivedAt = &archivedAt
+	backorderable := product
+	backorderable.Backorderable = true
+
+	testCases := []struct {
+		name      string
+		product   productResponse.Product
+		variantId *uuid.UUID
+		quantity  int32
+		price     decimal.Decimal
+		err       error
+	}{
+		{"product in stock", product, nil, 5, product.Price, nil},
+		{"product out of stock", product, nil, 6, decimal.Decimal{}, inErrors.ErrOutOfStock},
+		{"variant in stock", product, &variant.ID, 2, variantPrice, nil},
+		{"variant out of stock", product, &variant.ID, 3, decimal.Decimal{}, inErrors.ErrOutOfStock},
+		{"unknown variant", product, &unknownVariant, 1, decimal.Decimal{}, inErrors.ErrUnknownVariant},
+		{"archived product", archived, nil, 1, decimal.Decimal{}, inErrors.ErrProductArchived},
+		{"backorderable product", backorderable, nil, 6, product.Price, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			price, err := validateCartItem(tc.product, tc.variantId, tc.quantity)
+			assert.ErrorIs(t, err, tc.err)
+			assert.True(t, tc.price.Equal(price))
+		})
+	}
+}
+
+func TestMergeCartItems(t *testing.T) {
+	productId, variantId := uuid.New(), uuid.New()
+	merged := mergeCartItems([]request.InsertCartItem{
+		{ProductId: productId, Quantity: 1},
+		{ProductId: productId, VariantId: &variantId, Quantity: 2},
+		{ProductId: productId, Quantity: 3},
+	})
+	assert.Equal(t, []request.InsertCartItem{
+		{ProductId: productId, Quantity: 4},
+		{ProductId: productId, VariantId: &variantId, Quantity: 2},
+	}, merged)
+}
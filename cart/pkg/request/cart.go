@@ -2,17 +2,13 @@ package request
 
 import (
 	"github.com/google/uuid"
-	"github.com/shopspring/decimal"
+
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	orderRequest "github.com/Alturino/ecommerce/order/pkg/request"
 )
 
 type Cart struct {
-	CartItems []CartItem `validate:"required" json:"cart_items"`
-}
-
-type CartItem struct {
-	ProductId uuid.UUID       `validate:"required,uuid"  json:"product_id"`
-	Price     decimal.Decimal `validate:"required"       json:"price"`
-	Quantity  int32           `validate:"required,gte=1" json:"quantity"`
+	CartItems []InsertCartItem `validate:"required,dive" json:"cart_items"`
 }
 
 type RemoveCart struct {
@@ -20,25 +16,40 @@ type RemoveCart struct {
 	UserId uuid.UUID `validate:"required, uuid" json:"user_id"`
 }
 
+// RemoveCartItem removes an item of a cart of UserId, uuid.Nil lets admins
+// remove items of every cart.
 type RemoveCartItem struct {
 	ID     uuid.UUID `validate:"required, uuid"`
 	CartId uuid.UUID `validate:"required, uuid"`
-	UserId uuid.UUID `validate:"required, uuid"`
+	UserId uuid.UUID
 }
 
+// InsertCartItem adds Quantity of a product, or of one of its variants, to the
+// active cart. The price is taken from the product service.
 type InsertCartItem struct {
-	CartId    uuid.UUID       `validate:"required,uuid"  json:"cartId"`
-	ProductId uuid.UUID       `validate:"required,uuid"  json:"productId"`
-	Price     decimal.Decimal `validate:"required"       json:"price"`
-	Quantity  int             `validate:"required,gte=1" json:"quantity"`
+	ProductId uuid.UUID  `validate:"required,uuid"  json:"product_id"`
+	VariantId *uuid.UUID `                          json:"variant_id"`
+	Quantity  int32      `validate:"required,gte=1" json:"quantity"`
+}
+
+// UpdateCartItem sets the quantity of an item of the active cart.
+type UpdateCartItem struct {
+	Quantity int32 `validate:"required,gte=1" json:"quantity"`
 }
 
 type CheckoutCart struct {
-	UserId uuid.UUID `validate:"required,uuid" json:"userId"`
-	CartId uuid.UUID `validate:"required,uuid" json:"cartId"`
+	ShippingLocation *orderRequest.Location `validate:"omitempty"     json:"shipping_location"`
+	UserId           uuid.UUID              `validate:"required,uuid" json:"userId"`
+	CartId           uuid.UUID              `validate:"required,uuid" json:"cartId"`
+}
+
+type FindCarts struct {
+	UserId uuid.UUID `validate:"required,uuid"`
+	Page   inHttp.Page
 }
 
+// FindCartById finds a cart of UserId, uuid.Nil lets admins find every cart.
 type FindCartById struct {
 	ID     uuid.UUID `validate:"required, uuid" json:"id"`
-	UserId uuid.UUID `validate:"required, uuid" json:"userId"`
+	UserId uuid.UUID `                          json:"userId"`
 }
@@ -15,12 +15,16 @@ type Cart struct {
 	UpdatedAt time.Time  `json:"updated_at"`
 }
 
+// CartItem is a product in a cart, Price is in Currency, the base currency of
+// the catalog when the item was added.
 type CartItem struct {
 	ID        uuid.UUID       `json:"id"`
 	CartID    uuid.UUID       `json:"cart_id"`
 	ProductID uuid.UUID       `json:"product_id"`
+	VariantID *uuid.UUID      `json:"variant_id"`
 	Quantity  int32           `json:"quantity"`
 	Price     decimal.Decimal `json:"price"`
+	Currency  string          `json:"currency"`
 	CreatedAt time.Time       `json:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at"`
 }
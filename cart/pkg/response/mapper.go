@@ -9,6 +9,7 @@ func (c Cart) Order() request.CreateOrder {
 			ID:        item.ID,
 			Price:     item.Price,
 			ProductID: item.ProductID,
+			VariantID: item.VariantID,
 			OrderID:   c.ID,
 			Quantity:  item.Quantity,
 			CreatedAt: item.CreatedAt,
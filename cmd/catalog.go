@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/Alturino/ecommerce/product/cmd"
+)
+
+func catalogCommand() *cobra.Command {
+	importParam := cmd.CatalogImport{}
+	importCmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import products from a csv or jsonl catalog",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				importParam.File = args[0]
+			}
+			return cmd.RunCatalogImport(command.Context(), importParam)
+		},
+	}
+	importCmd.Flags().StringVar(&importParam.Format, "format", "", "csv or jsonl, detected from the file extension when empty")
+	importCmd.Flags().StringVar(&importParam.Match, "match", "name", "match existing products by name or sku")
+	importCmd.Flags().BoolVar(&importParam.DryRun, "dry-run", false, "report the changes without applying them")
+	importCmd.Flags().IntVar(&importParam.ChunkSize, "chunk-size", 500, "rows applied per transaction")
+
+	exportParam := cmd.CatalogExport{}
+	exportCmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export products to a csv or jsonl catalog",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(command *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				exportParam.File = args[0]
+			}
+			return cmd.RunCatalogExport(command.Context(), exportParam)
+		},
+	}
+	exportCmd.Flags().StringVar(&exportParam.Format, "format", "", "csv or jsonl, detected from the file extension when empty")
+
+	catalogCmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Import and export the product catalog",
+	}
+	catalogCmd.AddCommand(importCmd, exportCmd)
+	return catalogCmd
+}
@@ -26,6 +26,7 @@ func Start() {
 
 	rootCmd := &cobra.Command{}
 	commands := []*cobra.Command{
+		catalogCommand(),
 		{
 			Use:   "cart",
 			Short: "Run cart service",
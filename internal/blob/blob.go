@@ -0,0 +1,34 @@
+// Package blob stores binary objects such as product images behind a
+// BlobStore, so the product service does not depend on where they are kept.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+type BlobStore interface {
+	// Put stores size bytes of r under key, replacing the blob that is already
+	// stored under it.
+	Put(c context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns the blob stored under key and its content type, it fails with
+	// ErrNotFound when there is none. The caller closes the returned reader.
+	Get(c context.Context, key string) (io.ReadCloser, string, error)
+	// Delete removes the blob stored under key, deleting a missing blob is not
+	// an error.
+	Delete(c context.Context, key string) error
+}
+
+// ValidKey reports whether key is a relative slash separated path that stays
+// inside the store, e.g. "products/<id>/<id>.jpg".
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	return path.Clean(key) == key && key != "." && !strings.HasPrefix(key, "../") && key != ".."
+}
@@ -0,0 +1,113 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileSystem stores blobs as files under a root directory, the content type of
+// a blob is kept next to it in a file with the .type suffix.
+type FileSystem struct {
+	root string
+}
+
+func NewFileSystem(root string) (FileSystem, error) {
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		return FileSystem{}, fmt.Errorf("failed creating root=%s with error=%w", root, err)
+	}
+	return FileSystem{root: root}, nil
+}
+
+func (f FileSystem) Put(
+	c context.Context,
+	key string,
+	r io.Reader,
+	size int64,
+	contentType string,
+) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed creating directory of key=%s with error=%w", key, err)
+	}
+
+	// the blob is written to a temporary file first so a reader never sees a
+	// partially written blob.
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed creating file of key=%s with error=%w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed writing key=%s with error=%w", key, err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("failed writing key=%s wrote %d of %d bytes", key, written, size)
+	}
+	err = os.WriteFile(name+".type", []byte(contentType), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed writing content type of key=%s with error=%w", key, err)
+	}
+	err = os.Rename(tmp.Name(), name)
+	if err != nil {
+		return fmt.Errorf("failed moving key=%s with error=%w", key, err)
+	}
+	return nil
+}
+
+func (f FileSystem) Get(c context.Context, key string) (io.ReadCloser, string, error) {
+	name, err := f.path(key)
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", fmt.Errorf("failed opening key=%s with error=%w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed opening key=%s with error=%w", key, err)
+	}
+	contentType, err := os.ReadFile(name + ".type")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		file.Close()
+		return nil, "", fmt.Errorf("failed reading content type of key=%s with error=%w", key, err)
+	}
+	if len(contentType) == 0 {
+		contentType = []byte("application/octet-stream")
+	}
+	return file, string(contentType), nil
+}
+
+func (f FileSystem) Delete(c context.Context, key string) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{name, name + ".type"} {
+		err = os.Remove(name)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed removing key=%s with error=%w", key, err)
+		}
+	}
+	return nil
+}
+
+func (f FileSystem) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid key=%s", key)
+	}
+	return filepath.Join(f.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,42 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSystem(t *testing.T) {
+	c := context.Background()
+	store, err := NewFileSystem(t.TempDir())
+	require.NoError(t, err)
+
+	err = store.Put(c, "products/a/b.png", strings.NewReader("image"), 5, "image/png")
+	require.NoError(t, err)
+
+	body, contentType, err := store.Get(c, "products/a/b.png")
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "image", string(data))
+	assert.Equal(t, "image/png", contentType)
+
+	require.NoError(t, store.Delete(c, "products/a/b.png"))
+	require.NoError(t, store.Delete(c, "products/a/b.png"), "deleting a missing blob should not fail")
+	_, _, err = store.Get(c, "products/a/b.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestValidKey(t *testing.T) {
+	assert.True(t, ValidKey("products/a/b.png"))
+	assert.False(t, ValidKey(""))
+	assert.False(t, ValidKey("/etc/passwd"))
+	assert.False(t, ValidKey("../etc/passwd"))
+	assert.False(t, ValidKey("products/../../etc/passwd"))
+	assert.False(t, ValidKey("products//b.png"))
+}
@@ -0,0 +1,186 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Alturino/ecommerce/internal/config"
+)
+
+// unsignedPayload skips hashing the request body, which S3 and MinIO accept
+// when the body is sent over a connection they trust.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3 stores blobs in a bucket of an S3 compatible object storage such as MinIO,
+// requests are signed with AWS signature version 4.
+type S3 struct {
+	client   *http.Client
+	endpoint *url.URL
+	config   config.S3
+	now      func() time.Time
+}
+
+func NewS3(cfg config.S3, client *http.Client) (S3, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return S3{}, fmt.Errorf("failed parsing endpoint=%s with error=%w", cfg.Endpoint, err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return S3{}, fmt.Errorf("invalid endpoint=%s", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return S3{}, fmt.Errorf("bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return S3{client: client, endpoint: endpoint, config: cfg, now: time.Now}, nil
+}
+
+func (s S3) Put(
+	c context.Context,
+	key string,
+	r io.Reader,
+	size int64,
+	contentType string,
+) error {
+	req, err := s.request(c, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	res, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed putting key=%s with error=%w", key, err)
+	}
+	defer res.Body.Close()
+	return nil
+}
+
+func (s S3) Get(c context.Context, key string) (io.ReadCloser, string, error) {
+	req, err := s.request(c, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed getting key=%s with error=%w", key, err)
+	}
+	return res.Body, res.Header.Get("Content-Type"), nil
+}
+
+func (s S3) Delete(c context.Context, key string) error {
+	req, err := s.request(c, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.do(req)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed deleting key=%s with error=%w", key, err)
+	}
+	if res != nil {
+		res.Body.Close()
+	}
+	return nil
+}
+
+func (s S3) request(c context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("invalid key=%s", key)
+	}
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.config.Bucket + "/" + key
+	req, err := http.NewRequestWithContext(c, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating request of key=%s with error=%w", key, err)
+	}
+	return req, nil
+}
+
+// do signs and sends req, a response without a 2xx status is returned as an
+// error and a 404 as ErrNotFound.
+func (s S3) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, ErrNotFound
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected status=%d body=%s", res.StatusCode, message)
+	}
+	return res, nil
+}
+
+// sign adds the AWS signature version 4 headers to req.
+func (s S3) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + unsignedPayload,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
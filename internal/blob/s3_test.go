@@ -0,0 +1,70 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Alturino/ecommerce/internal/config"
+)
+
+func TestS3(t *testing.T) {
+	c := context.Background()
+	var mu sync.Mutex
+	objects := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(
+			t,
+			strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/"),
+			"request should be signed",
+		)
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = string(data)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewS3(config.S3{
+		Endpoint:  server.URL,
+		Bucket:    "media",
+		AccessKey: "access",
+		SecretKey: "secret",
+	}, server.Client())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(c, "products/a.png", strings.NewReader("image"), 5, "image/png"))
+	assert.Equal(t, "image", objects["/media/products/a.png"], "objects should be addressed path style")
+
+	body, contentType, err := store.Get(c, "products/a.png")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "image", string(data))
+	assert.Equal(t, "image/png", contentType)
+
+	require.NoError(t, store.Delete(c, "products/a.png"))
+	_, _, err = store.Get(c, "products/a.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
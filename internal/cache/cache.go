@@ -0,0 +1,246 @@
+// Package cache is a typed cache-aside layer over redis. Entries belong to a
+// family, e.g. carts, whose entries share a TTL and a version. Bumping the
+// version of a family with Invalidate drops all of its entries at once.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/constants"
+)
+
+var ErrMiss = errors.New("cache miss")
+
+// tombstone is cached for a row that does not exist, the JSON of a value is
+// never empty.
+const tombstone = ""
+
+// Cache embeds the redis client so services keep publishing and subscribing
+// through it, Get, GetOrLoad, Set, Delete and Invalidate are the cache-aside
+// operations.
+type Cache struct {
+	*redis.Client
+	config config.Cache
+	group  *singleflight.Group
+	hits   metric.Int64Counter
+	misses metric.Int64Counter
+}
+
+func New(client *redis.Client, config config.Cache) *Cache {
+	meter := otel.Meter("github.com/Alturino/ecommerce/internal/cache")
+	hits, _ := meter.Int64Counter(
+		"cache.hits",
+		metric.WithDescription("Cache lookups answered by redis, including cached missing rows"),
+	)
+	misses, _ := meter.Int64Counter(
+		"cache.misses",
+		metric.WithDescription("Cache lookups that had to be loaded from the database"),
+	)
+	return &Cache{
+		Client: client,
+		config: config,
+		group:  &singleflight.Group{},
+		hits:   hits,
+		misses: misses,
+	}
+}
+
+// TTL returns how long the entries of family live, zero keeps them until they
+// are deleted or the family is invalidated.
+func (cache *Cache) TTL(family string) time.Duration {
+	if ttl, ok := cache.config.TTL[family]; ok {
+		return ttl
+	}
+	return cache.config.DefaultTTL
+}
+
+// Get returns the cached value of key in family, ErrMiss when it is not cached
+// and pgx.ErrNoRows when the row is cached as missing.
+func Get[T any](c context.Context, cache *Cache, family string, key string) (T, error) {
+	versioned, err := cache.key(c, family, key)
+	if err != nil {
+		var value T
+		return value, err
+	}
+	return get[T](c, cache, family, versioned)
+}
+
+// GetOrLoad returns the cached value of key in family, on a miss it is loaded
+// once however many callers miss it at the same time and cached for the TTL of
+// family. A load failing with pgx.ErrNoRows is cached for the negative TTL.
+// Redis failures are logged and the value is loaded from the database.
+//
+// The shared load is not canceled with the caller that started it, a caller
+// whose context is done stops waiting for it instead.
+func GetOrLoad[T any](
+	c context.Context,
+	cache *Cache,
+	family string,
+	key string,
+	load func(context.Context) (T, error),
+) (T, error) {
+	logger := zerolog.Ctx(c).
+		With().
+		Str(constants.KEY_CACHE_FAMILY, family).
+		Str(constants.KEY_CACHE_KEY, key).
+		Logger()
+
+	versioned, err := cache.key(c, family, key)
+	if err != nil {
+		logger.Warn().Err(err).Msg(err.Error())
+		return load(c)
+	}
+	value, err := get[T](c, cache, family, versioned)
+	if err == nil || errors.Is(err, pgx.ErrNoRows) {
+		return value, err
+	}
+	if !errors.Is(err, ErrMiss) {
+		logger.Warn().Err(err).Msg(err.Error())
+	}
+
+	loading := cache.group.DoChan(versioned, func() (interface{}, error) {
+		c := context.WithoutCancel(c)
+		value, err := load(c)
+		if errors.Is(err, pgx.ErrNoRows) && cache.config.NegativeTTL > 0 {
+			setErr := cache.Client.Set(c, versioned, tombstone, cache.config.NegativeTTL).Err()
+			if setErr != nil {
+				setErr = fmt.Errorf("failed caching missing key=%s with error=%w", versioned, setErr)
+				logger.Warn().Err(setErr).Msg(setErr.Error())
+			}
+		}
+		if err != nil {
+			return value, err
+		}
+		setErr := cache.set(c, family, versioned, value)
+		if setErr != nil {
+			logger.Warn().Err(setErr).Msg(setErr.Error())
+		}
+		return value, nil
+	})
+	select {
+	case <-c.Done():
+		var zero T
+		return zero, c.Err()
+	case result := <-loading:
+		value, ok := result.Val.(T)
+		if !ok || result.Err != nil {
+			var zero T
+			return zero, result.Err
+		}
+		return value, nil
+	}
+}
+
+// Set caches value as key in family for the TTL of family.
+func (cache *Cache) Set(c context.Context, family string, key string, value any) error {
+	versioned, err := cache.key(c, family, key)
+	if err != nil {
+		return err
+	}
+	return cache.set(c, family, versioned, value)
+}
+
+// Delete drops keys of family, whether they are cached or cached as missing.
+func (cache *Cache) Delete(c context.Context, family string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	version, err := cache.version(c, family)
+	if err != nil {
+		return err
+	}
+	versioned := make([]string, 0, len(keys))
+	for _, key := range keys {
+		versioned = append(versioned, versionedKey(family, version, key))
+	}
+	err = cache.Client.Del(c, versioned...).Err()
+	if err != nil {
+		return fmt.Errorf("failed deleting keys of family=%s with error=%w", family, err)
+	}
+	return nil
+}
+
+// Invalidate drops every entry of family by bumping its version, the entries
+// of the previous version are left to expire.
+func (cache *Cache) Invalidate(c context.Context, family string) error {
+	err := cache.Client.Incr(c, versionKey(family)).Err()
+	if err != nil {
+		return fmt.Errorf("failed invalidating family=%s with error=%w", family, err)
+	}
+	return nil
+}
+
+func get[T any](c context.Context, cache *Cache, family string, versioned string) (T, error) {
+	var value T
+	attrs := metric.WithAttributes(attribute.String(constants.KEY_CACHE_FAMILY, family))
+	raw, err := cache.Client.Get(c, versioned).Result()
+	if errors.Is(err, redis.Nil) {
+		cache.misses.Add(c, 1, attrs)
+		return value, ErrMiss
+	}
+	if err != nil {
+		cache.misses.Add(c, 1, attrs)
+		return value, fmt.Errorf("failed getting key=%s with error=%w", versioned, err)
+	}
+	cache.hits.Add(c, 1, attrs)
+	if raw == tombstone {
+		return value, fmt.Errorf("key=%s is cached as missing with error=%w", versioned, pgx.ErrNoRows)
+	}
+	err = json.Unmarshal([]byte(raw), &value)
+	if err != nil {
+		return value, fmt.Errorf("failed unmarshaling key=%s with error=%w", versioned, err)
+	}
+	return value, nil
+}
+
+func (cache *Cache) set(c context.Context, family string, versioned string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed marshaling key=%s with error=%w", versioned, err)
+	}
+	err = cache.Client.Set(c, versioned, raw, cache.TTL(family)).Err()
+	if err != nil {
+		return fmt.Errorf("failed setting key=%s with error=%w", versioned, err)
+	}
+	return nil
+}
+
+func (cache *Cache) key(c context.Context, family string, key string) (string, error) {
+	version, err := cache.version(c, family)
+	if err != nil {
+		return "", err
+	}
+	return versionedKey(family, version, key), nil
+}
+
+func (cache *Cache) version(c context.Context, family string) (int64, error) {
+	version, err := cache.Client.Get(c, versionKey(family)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed getting version of family=%s with error=%w", family, err)
+	}
+	return version, nil
+}
+
+func versionKey(family string) string {
+	return family + ":version"
+}
+
+func versionedKey(family string, version int64, key string) string {
+	return fmt.Sprintf("%s:v%d:%s", family, version, key)
+}
@@ -0,0 +1,283 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/config"
+)
+
+// fakeRedis answers the commands the cache sends from memory, failing every
+// command when down is set or its context is done.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+	gets   map[string]int
+	down   bool
+}
+
+func newFakeCache(t *testing.T, cfg config.Cache) (*Cache, *fakeRedis) {
+	fake := &fakeRedis{values: map[string]string{}, ttls: map[string]time.Duration{}, gets: map[string]int{}}
+	client := redis.NewClient(&redis.Options{
+		Dialer: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("fake redis does not dial")
+		},
+	})
+	client.AddHook(fake)
+	t.Cleanup(func() { client.Close() })
+	return New(client, cfg), fake
+}
+
+func (fake *fakeRedis) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (fake *fakeRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (fake *fakeRedis) ProcessHook(redis.ProcessHook) redis.ProcessHook {
+	return func(c context.Context, cmd redis.Cmder) error {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		if fake.down {
+			cmd.SetErr(errors.New("connection refused"))
+			return cmd.Err()
+		}
+		if c.Err() != nil {
+			cmd.SetErr(c.Err())
+			return cmd.Err()
+		}
+		args := cmd.Args()
+		key := args[1].(string)
+		switch cmd := cmd.(type) {
+		case *redis.StringCmd:
+			fake.gets[key]++
+			value, ok := fake.values[key]
+			if !ok {
+				cmd.SetErr(redis.Nil)
+				return redis.Nil
+			}
+			cmd.SetVal(value)
+		case *redis.StatusCmd:
+			switch value := args[2].(type) {
+			case string:
+				fake.values[key] = value
+			case []byte:
+				fake.values[key] = string(value)
+			}
+			fake.ttls[key] = 0
+			if len(args) > 4 {
+				fake.ttls[key] = time.Duration(args[4].(int64)) * time.Millisecond
+				if args[3] == "ex" {
+					fake.ttls[key] = time.Duration(args[4].(int64)) * time.Second
+				}
+			}
+			cmd.SetVal("OK")
+		case *redis.IntCmd:
+			if args[0] == "del" {
+				for _, key := range args[1:] {
+					delete(fake.values, key.(string))
+				}
+				cmd.SetVal(int64(len(args) - 1))
+				return nil
+			}
+			version, _ := strconv.ParseInt(fake.values[key], 10, 64)
+			version++
+			fake.values[key] = strconv.FormatInt(version, 10)
+			cmd.SetVal(version)
+		}
+		return nil
+	}
+}
+
+func (fake *fakeRedis) getCount(key string) int {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return fake.gets[key]
+}
+
+func TestTTL(t *testing.T) {
+	cache := New(nil, config.Cache{
+		DefaultTTL: 10 * time.Minute,
+		TTL:        map[string]time.Duration{"carts": time.Minute, "users": 0},
+	})
+	assert.Equal(t, time.Minute, cache.TTL("carts"))
+	assert.Equal(t, time.Duration(0), cache.TTL("users"), "a family can keep its entries until invalidated")
+	assert.Equal(t, 10*time.Minute, cache.TTL("orders"))
+}
+
+func TestVersionedKey(t *testing.T) {
+	assert.Equal(t, "carts:v0:42", versionedKey("carts", 0, "42"))
+	assert.NotEqual(t, versionedKey("carts", 0, "42"), versionedKey("carts", 1, "42"))
+	assert.Equal(t, "carts:version", versionKey("carts"))
+}
+
+func TestGetOrLoadCachesMissingRows(t *testing.T) {
+	cache, fake := newFakeCache(t, config.Cache{DefaultTTL: time.Minute, NegativeTTL: 30 * time.Second})
+	loads := 0
+	load := func(context.Context) (string, error) {
+		loads++
+		return "", pgx.ErrNoRows
+	}
+
+	_, err := GetOrLoad(context.Background(), cache, "products", "42", load)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.Equal(t, tombstone, fake.values["products:v0:42"])
+	assert.Equal(t, 30*time.Second, fake.ttls["products:v0:42"])
+
+	_, err = GetOrLoad(context.Background(), cache, "products", "42", load)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "the tombstone answers without loading")
+	assert.Equal(t, 1, loads)
+}
+
+func TestGetOrLoadLoadsOnce(t *testing.T) {
+	cache, fake := newFakeCache(t, config.Cache{DefaultTTL: time.Minute})
+	callers := 10
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(context.Context) (string, error) {
+		loads.Add(1)
+		<-release
+		return "product", nil
+	}
+
+	values := make([]string, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := GetOrLoad(context.Background(), cache, "products", "42", load)
+			assert.NoError(t, err)
+			values[i] = value
+		}()
+	}
+	assert.Eventually(t, func() bool {
+		return fake.getCount("products:v0:42") == callers
+	}, time.Second, time.Millisecond, "every caller misses before the load returns")
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+	for _, value := range values {
+		assert.Equal(t, "product", value)
+	}
+}
+
+func TestGetOrLoadCanceledCaller(t *testing.T) {
+	cache, fake := newFakeCache(t, config.Cache{DefaultTTL: time.Minute})
+	release := make(chan struct{})
+	load := func(c context.Context) (string, error) {
+		<-release
+		if c.Err() != nil {
+			return "", c.Err()
+		}
+		return "product", nil
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := GetOrLoad(first, cache, "products", "42", load)
+		canceled <- err
+	}()
+	assert.Eventually(t, func() bool {
+		return fake.getCount("products:v0:42") == 1
+	}, time.Second, time.Millisecond)
+
+	loaded := make(chan string)
+	go func() {
+		value, err := GetOrLoad(context.Background(), cache, "products", "42", load)
+		assert.NoError(t, err, "the load of a canceled caller is shared with the others")
+		loaded <- value
+	}()
+	assert.Eventually(t, func() bool {
+		return fake.getCount("products:v0:42") == 2
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled, "a canceled caller stops waiting for the load")
+	close(release)
+	assert.Equal(t, "product", <-loaded)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Equal(t, `"product"`, fake.values["products:v0:42"], "the loaded value is cached after the first caller is gone")
+}
+
+func TestGetOrLoadNilValue(t *testing.T) {
+	cache, _ := newFakeCache(t, config.Cache{DefaultTTL: time.Minute})
+	failed := errors.New("database is down")
+
+	value, err := GetOrLoad(context.Background(), cache, "products", "42", func(context.Context) (fmt.Stringer, error) {
+		return nil, failed
+	})
+	assert.ErrorIs(t, err, failed, "a nil value of an interface type should not panic")
+	assert.Nil(t, value)
+
+	value, err = GetOrLoad(context.Background(), cache, "products", "43", func(context.Context) (fmt.Stringer, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestGetOrLoadAfterInvalidate(t *testing.T) {
+	cache, _ := newFakeCache(t, config.Cache{DefaultTTL: time.Minute})
+	c := context.Background()
+	stored := "first"
+	load := func(context.Context) (string, error) {
+		return stored, nil
+	}
+
+	value, err := GetOrLoad(c, cache, "products", "42", load)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	stored = "second"
+	value, err = GetOrLoad(c, cache, "products", "42", load)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", value, "the cached value is served until the family is invalidated")
+
+	assert.NoError(t, cache.Invalidate(c, "products"))
+	value, err = GetOrLoad(c, cache, "products", "42", load)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", value)
+}
+
+func TestGetOrLoadWithoutRedis(t *testing.T) {
+	cache, fake := newFakeCache(t, config.Cache{DefaultTTL: time.Minute, NegativeTTL: time.Minute})
+	fake.down = true
+	c := context.Background()
+	loads := 0
+	load := func(context.Context) (string, error) {
+		loads++
+		return "product", nil
+	}
+
+	for range 2 {
+		value, err := GetOrLoad(c, cache, "products", "42", load)
+		assert.NoError(t, err, "redis failures fall back to the database")
+		assert.Equal(t, "product", value)
+	}
+	assert.Equal(t, 2, loads)
+
+	_, err := GetOrLoad(c, cache, "products", "43", func(context.Context) (string, error) {
+		return "", pgx.ErrNoRows
+	})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/rs/zerolog"
@@ -46,15 +48,26 @@ func (d Database) MarshalZerologObject(e *zerolog.Event) {
 		Str("password", "***")
 }
 
+// Cache is the redis server entries are cached in. An entry of a family, e.g.
+// carts, lives for the TTL of its family or DefaultTTL when the family has none,
+// zero keeps it until it is invalidated. A missing row is cached for
+// NegativeTTL, zero does not cache missing rows.
 type Cache struct {
-	Host     string `mapstructure:"host"     json:"host"`
-	Password string `mapstructure:"password" json:"password"`
-	Database int    `mapstructure:"database" json:"database"`
-	Port     uint16 `mapstructure:"port"     json:"port"`
+	Host        string                   `mapstructure:"host"         json:"host"`
+	Password    string                   `mapstructure:"password"     json:"password"`
+	Database    int                      `mapstructure:"database"     json:"database"`
+	Port        uint16                   `mapstructure:"port"         json:"port"`
+	DefaultTTL  time.Duration            `mapstructure:"default_ttl"  json:"default_ttl"`
+	NegativeTTL time.Duration            `mapstructure:"negative_ttl" json:"negative_ttl"`
+	TTL         map[string]time.Duration `mapstructure:"ttl"          json:"ttl"`
 }
 
 func (c Cache) MarshalZerologObject(e *zerolog.Event) {
-	e.Str("host", c.Host).Int("database", c.Database).Str("password", "***")
+	e.Str("host", c.Host).
+		Int("database", c.Database).
+		Str("password", "***").
+		Dur("default_ttl", c.DefaultTTL).
+		Dur("negative_ttl", c.NegativeTTL)
 }
 
 func (c Cache) MarshalJSON() ([]byte, error) {
@@ -68,11 +81,249 @@ type Otel struct {
 	Port int    `mapstructure:"port" json:"port"`
 }
 
+type Retry struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"    json:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff" json:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"     json:"max_backoff"`
+}
+
+const (
+	IsolationReadCommitted  = "read committed"
+	IsolationRepeatableRead = "repeatable read"
+	IsolationSerializable   = "serializable"
+)
+
+type Checkout struct {
+	IsolationLevel          string `mapstructure:"isolation_level"           json:"isolation_level"`
+	MaxSerializationRetries int    `mapstructure:"max_serialization_retries" json:"max_serialization_retries"`
+}
+
+// Isolation returns the normalized isolation level, "serializable" and
+// "SERIALIZABLE" are both accepted as well as "repeatable_read" and
+// "repeatable read". It defaults to read committed when it is not set.
+func (c Checkout) Isolation() string {
+	level := strings.ToLower(strings.TrimSpace(c.IsolationLevel))
+	level = strings.ReplaceAll(level, "_", " ")
+	if level == "" {
+		return IsolationReadCommitted
+	}
+	return level
+}
+
+type Allocator struct {
+	Interval  time.Duration `mapstructure:"interval"   json:"interval"`
+	BatchSize int           `mapstructure:"batch_size" json:"batch_size"`
+}
+
+// Expiry expires the orders that are not paid within PaymentTTL, zero keeps
+// them waiting for payment.
+type Expiry struct {
+	PaymentTTL time.Duration `mapstructure:"payment_ttl" json:"payment_ttl"`
+	Interval   time.Duration `mapstructure:"interval"    json:"interval"`
+	BatchSize  int           `mapstructure:"batch_size"  json:"batch_size"`
+}
+
+const (
+	FulfillmentClosest      = "closest"
+	FulfillmentFewestSplits = "fewest_splits"
+)
+
+type Fulfillment struct {
+	Strategy string `mapstructure:"strategy" json:"strategy"`
+}
+
+type Order struct {
+	Retry       `mapstructure:"retry"       json:"retry"`
+	Allocator   `mapstructure:"allocator"   json:"allocator"`
+	Expiry      `mapstructure:"expiry"      json:"expiry"`
+	Fulfillment `mapstructure:"fulfillment" json:"fulfillment"`
+	Checkout    map[string]Checkout `mapstructure:"checkout"    json:"checkout"`
+}
+
+func (o Order) Validate() error {
+	switch o.Fulfillment.Strategy {
+	case "", FulfillmentClosest, FulfillmentFewestSplits:
+	default:
+		return fmt.Errorf("invalid fulfillment strategy=%s", o.Fulfillment.Strategy)
+	}
+	for strategy, checkout := range o.Checkout {
+		switch checkout.Isolation() {
+		case IsolationReadCommitted, IsolationRepeatableRead, IsolationSerializable:
+		default:
+			return fmt.Errorf(
+				"invalid isolation_level=%s for checkout strategy=%s",
+				checkout.IsolationLevel,
+				strategy,
+			)
+		}
+		if checkout.MaxSerializationRetries < 0 {
+			return fmt.Errorf(
+				"invalid max_serialization_retries=%d for checkout strategy=%s",
+				checkout.MaxSerializationRetries,
+				strategy,
+			)
+		}
+	}
+	return nil
+}
+
+type Reservation struct {
+	Enabled bool          `mapstructure:"enabled" json:"enabled"`
+	TTL     time.Duration `mapstructure:"ttl"     json:"ttl"`
+}
+
+type Cart struct {
+	Reservation `mapstructure:"reservation" json:"reservation"`
+}
+
+// Currency is the currency the catalog is priced and orders are charged in,
+// orders are presented in the preferred currency of their user.
+type Currency struct {
+	Base string `mapstructure:"base" json:"base"`
+}
+
+// BaseCurrency returns the upper case code of Base, USD when it is not set.
+func (c Currency) BaseCurrency() string {
+	if c.Base == "" {
+		return "USD"
+	}
+	return strings.ToUpper(c.Base)
+}
+
+type Pagination struct {
+	DefaultLimit int32 `mapstructure:"default_limit" json:"default_limit"`
+	MaxLimit     int32 `mapstructure:"max_limit"     json:"max_limit"`
+}
+
+// Pricing configures the activation of scheduled prices, Interval is the
+// longest the activator sleeps between two checks.
+type Pricing struct {
+	Interval time.Duration `mapstructure:"interval" json:"interval"`
+}
+
+// Recommendation configures the frequently bought together recommendations,
+// every Interval the co-purchase scores are recomputed from the orders
+// completed in the last Window.
+type Recommendation struct {
+	Interval time.Duration `mapstructure:"interval" json:"interval"`
+	Window   time.Duration `mapstructure:"window"   json:"window"`
+}
+
+// UpdateListener configures how the product service keeps cached products in
+// sync with stock changes made by other services. Every Interval the products
+// updated since the last run are reconciled, on start the products updated in
+// the last Lookback are.
+type UpdateListener struct {
+	Interval time.Duration `mapstructure:"interval" json:"interval"`
+	Lookback time.Duration `mapstructure:"lookback" json:"lookback"`
+}
+
+// StockAlert configures the stock level alerts, a product is low on stock once
+// its quantity is at or below LowStockThreshold unless it has its own.
+type StockAlert struct {
+	LowStockThreshold int32 `mapstructure:"low_stock_threshold" json:"low_stock_threshold"`
+}
+
+const (
+	BlobFilesystem = "filesystem"
+	BlobS3         = "s3"
+)
+
+type Filesystem struct {
+	Root string `mapstructure:"root" json:"root"`
+}
+
+// S3 is an S3 compatible object storage, Endpoint points at AWS or at a MinIO
+// server, e.g. http://minio:9000. Objects are addressed path style.
+type S3 struct {
+	Endpoint  string `mapstructure:"endpoint"   json:"endpoint"`
+	Region    string `mapstructure:"region"     json:"region"`
+	Bucket    string `mapstructure:"bucket"     json:"bucket"`
+	AccessKey string `mapstructure:"access_key" json:"access_key"`
+	SecretKey string `mapstructure:"secret_key" json:"secret_key"`
+}
+
+func (s S3) MarshalJSON() ([]byte, error) {
+	s.SecretKey = "***"
+	type S S3
+	return json.Marshal(S(s))
+}
+
+type Media struct {
+	Backend        string     `mapstructure:"backend"         json:"backend"`
+	BaseURL        string     `mapstructure:"base_url"        json:"base_url"`
+	MaxSize        int64      `mapstructure:"max_size"        json:"max_size"`
+	ContentTypes   []string   `mapstructure:"content_types"   json:"content_types"`
+	ThumbnailSizes []int      `mapstructure:"thumbnail_sizes" json:"thumbnail_sizes"`
+	Filesystem     Filesystem `mapstructure:"filesystem"      json:"filesystem"`
+	S3             S3         `mapstructure:"s3"              json:"s3"`
+}
+
+// MediaURL returns the URL media is downloaded from, it defaults to the
+// /media route of the product service.
+func (m Media) MediaURL(key string) string {
+	baseURL := strings.TrimSuffix(m.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "/media"
+	}
+	return baseURL + "/" + key
+}
+
+type Product struct {
+	Media          `mapstructure:"media"           json:"media"`
+	Pricing        `mapstructure:"pricing"         json:"pricing"`
+	UpdateListener `mapstructure:"update_listener" json:"update_listener"`
+	StockAlert     `mapstructure:"stock_alert"     json:"stock_alert"`
+	Recommendation `mapstructure:"recommendation"  json:"recommendation"`
+}
+
+// Smtp delivers alerts by email, a server without authentication such as
+// MailHog is used when Username is empty. No email is sent without Host.
+type Smtp struct {
+	Host       string   `mapstructure:"host"       json:"host"`
+	Port       int      `mapstructure:"port"       json:"port"`
+	Username   string   `mapstructure:"username"   json:"username"`
+	Password   string   `mapstructure:"password"   json:"password"`
+	From       string   `mapstructure:"from"       json:"from"`
+	Recipients []string `mapstructure:"recipients" json:"recipients"`
+}
+
+func (s Smtp) MarshalJSON() ([]byte, error) {
+	s.Password = "***"
+	type S Smtp
+	return json.Marshal(S(s))
+}
+
+// Webhook delivers alerts as a JSON POST to Url. No webhook is called without
+// Url.
+type Webhook struct {
+	Url     string        `mapstructure:"url"     json:"url"`
+	Timeout time.Duration `mapstructure:"timeout" json:"timeout"`
+}
+
+// Alert configures where stock alerts are delivered, an alert of the same kind
+// for the same product is delivered at most once per Cooldown.
+type Alert struct {
+	Cooldown time.Duration `mapstructure:"cooldown" json:"cooldown"`
+	Smtp     Smtp          `mapstructure:"smtp"     json:"smtp"`
+	Webhook  Webhook       `mapstructure:"webhook"  json:"webhook"`
+}
+
+type Notification struct {
+	Alert `mapstructure:"alert" json:"alert"`
+}
+
 type Config struct {
-	Database    `mapstructure:"db"          json:"db"`
-	Cache       `mapstructure:"cache"       json:"cache"`
-	Application `mapstructure:"application" json:"application"`
-	Otel        `mapstructure:"otel"        json:"otel"`
+	Database     `mapstructure:"db"           json:"db"`
+	Cache        `mapstructure:"cache"        json:"cache"`
+	Application  `mapstructure:"application"  json:"application"`
+	Otel         `mapstructure:"otel"         json:"otel"`
+	Order        `mapstructure:"order"        json:"order"`
+	Cart         `mapstructure:"cart"         json:"cart"`
+	Product      `mapstructure:"product"      json:"product"`
+	Notification `mapstructure:"notification" json:"notification"`
+	Pagination   `mapstructure:"pagination"   json:"pagination"`
+	Currency     `mapstructure:"currency"     json:"currency"`
 }
 
 var config Config
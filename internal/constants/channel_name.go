@@ -1,3 +1,10 @@
 package constants
 
-const UPDATE_PRODUCT_QUANTITY = "update-product-quantity"
+const (
+	ORDER_ALLOCATED         = "order-allocated"
+	PRODUCT_PRICE_SCHEDULED = "product-price-scheduled"
+	PRODUCT_RESTOCKED       = "product-restocked"
+	STOCK_ADJUSTED          = "stock-adjusted"
+	STOCK_ALERT             = "stock-alert"
+	UPDATE_PRODUCT_QUANTITY = "update-product-quantity"
+)
@@ -1,13 +1,25 @@
 package constants
 
 const (
+	KEY_ADMIN                      = "admin"
 	KEY_APP_NAME                   = "app"
+	KEY_ALLOCATIONS                = "allocations"
+	KEY_ARCHIVED_PRODUCT_IDS       = "archived_product_ids"
 	KEY_ARGUMENTS                  = "arguments"
+	KEY_ATTEMPTS                   = "attempts"
+	KEY_BACKOFF                    = "backoff"
+	KEY_BACKORDERS                 = "backorders"
+	KEY_BACKORDERS_ALLOCATED       = "backorders_allocated"
 	KEY_BATCH_ORDER_COUNT          = "batch_order_count"
+	KEY_BATCH_SPLIT_INDEX          = "batch_split_index"
 	KEY_AUTH                       = "auth"
+	KEY_BLOB_BACKEND               = "blob_backend"
+	KEY_BLOB_KEY                   = "blob_key"
 	KEY_CACHE_EXECUTED_COMMANDS    = "cache_executed_commands"
+	KEY_CACHE_FAMILY               = "cache_family"
 	KEY_CACHE_KEY                  = "cache_key"
 	KEY_CART                       = "cart"
+	KEY_CHECKOUT_STRATEGY          = "checkout_strategy"
 	KEY_CART_ID                    = "cart_id"
 	KEY_CART_ITEMS                 = "cart_items"
 	KEY_CART_ITEMS_INSERTED        = "cart_items_inserted"
@@ -17,13 +29,45 @@ const (
 	KEY_CART_ITEM_MERGED_QUANTITY  = "cart_item_merged_quantity"
 	KEY_CART_ITEM_QUANTITY         = "cart_item_quantity"
 	KEY_CART_RESPONSE              = "cart_response"
+	KEY_CATALOG_FAILED             = "catalog_failed"
+	KEY_CATALOG_FORMAT             = "catalog_format"
+	KEY_CATALOG_LINE               = "catalog_line"
+	KEY_CATALOG_ROWS               = "catalog_rows"
+	KEY_CATEGORIES                 = "categories"
+	KEY_CATEGORY                   = "category"
+	KEY_CATEGORY_ID                = "category_id"
+	KEY_CATEGORY_IDS               = "category_ids"
 	KEY_CONFIG                     = "config"
+	KEY_CONTENT_TYPE               = "content_type"
+	KEY_CO_PURCHASES               = "co_purchases"
+	KEY_CURRENCY                   = "currency"
 	KEY_DB_URL                     = "db_url"
 	KEY_EMAIL                      = "email"
+	KEY_ETAG                       = "etag"
+	KEY_EXCHANGE_RATE              = "exchange_rate"
+	KEY_EXCHANGE_RATES             = "exchange_rates"
+	KEY_EXPIRES_AT                 = "expires_at"
+	KEY_ERROR                      = "error"
+	KEY_FULFILLMENT_STRATEGY       = "fulfillment_strategy"
+	KEY_IDEMPOTENCY_KEY            = "idempotency_key"
+	KEY_IF_MATCH                   = "if_match"
+	KEY_ISOLATION_LEVEL            = "isolation_level"
+	KEY_IMPORT_OPTIONS             = "import_options"
+	KEY_INVENTORY_LEVEL            = "inventory_level"
+	KEY_INVENTORY_LEVELS           = "inventory_levels"
+	KEY_INVENTORY_MOVEMENTS        = "inventory_movements"
 	KEY_JSON_CACHE                 = "json_cache"
+	KEY_LIMIT                      = "limit"
+	KEY_LOW_STOCK_THRESHOLD        = "low_stock_threshold"
+	KEY_MAX_ATTEMPTS               = "max_attempts"
+	KEY_MEDIA                      = "media"
+	KEY_MEDIA_ID                   = "media_id"
+	KEY_MEDIA_SIZE                 = "media_size"
 	KEY_MAX_PRICE                  = "max_price"
 	KEY_MESSAGE                    = "message"
 	KEY_MIN_PRICE                  = "min_price"
+	KEY_NEXT_PRICE_CHANGE          = "next_price_change"
+	KEY_NOTIFIER                   = "notifier"
 	KEY_ORDER                      = "order"
 	KEY_ORDERS                     = "orders"
 	KEY_ORDER_AND_ORDER_ITEMS      = "order_and_order_items"
@@ -36,7 +80,11 @@ const (
 	KEY_ORDER_ITEM_QUANTITY        = "order_item_quantity"
 	KEY_PATH_VALUE                 = "path_value"
 	KEY_PATH_VALUES                = "path_values"
+	KEY_PAGE                       = "page"
+	KEY_PENDING_QUANTITIES         = "pending_quantities"
 	KEY_PRICE                      = "price"
+	KEY_PRICES                     = "prices"
+	KEY_PRICE_AT                   = "price_at"
 	KEY_PROCESS                    = "process"
 	KEY_QUERY                      = "query"
 	KEY_PRODUCT                    = "product"
@@ -52,6 +100,8 @@ const (
 	KEY_PRODUCT_UPDATED_LIST       = "product_updated_list"
 	KEY_PRODUCT_UPDATED            = "product_updated"
 	KEY_RABBIT_MQ_CONNECTION_URL   = "rabbitmq_connection_url"
+	KEY_RECOMMENDATIONS            = "recommendations"
+	KEY_REPLAYED                   = "replayed"
 	KEY_REQUEST                    = "request"
 	KEY_BODY                       = "body"
 	KEY_HEADER                     = "header"
@@ -62,11 +112,36 @@ const (
 	KEY_REQUEST_PROCESSED_AT       = "request_processed_at"
 	KEY_REQUEST_URI                = "uri"
 	KEY_REQUEST_URL                = "url"
+	KEY_RESERVATIONS_RELEASED      = "reservations_released"
+	KEY_RESERVED_QUANTITIES        = "reserved_quantities"
 	KEY_RESPONSE                   = "response"
+	KEY_REVIEW                     = "review"
+	KEY_REVIEWS                    = "reviews"
+	KEY_REVIEW_ID                  = "review_id"
+	KEY_ROLE                       = "role"
+	KEY_ROLES                      = "roles"
+	KEY_SERIALIZATION_FAILURES     = "serialization_failures"
+	KEY_SHIPMENTS                  = "shipments"
+	KEY_SQL_STATE                  = "sql_state"
+	KEY_STATUS                     = "status"
+	KEY_STOCK_ADJUSTMENT           = "stock_adjustment"
+	KEY_STOCK_ALERT                = "stock_alert"
+	KEY_STOCK_ALERTS               = "stock_alerts"
+	KEY_STOCK_AS_OF                = "stock_as_of"
 	KEY_TAG                        = "tag"
+	KEY_THUMBNAILS                 = "thumbnails"
 	KEY_TOKEN                      = "token"
+	KEY_UPDATED_SINCE              = "updated_since"
 	KEY_USER                       = "user"
+	KEY_VARIANT                    = "variant"
+	KEY_VARIANTS                   = "variants"
+	KEY_VARIANT_ID                 = "variant_id"
+	KEY_VARIANT_IDS                = "variant_ids"
 	KEY_USER_ID                    = "user_id"
+	KEY_UNALLOCATED_QUANTITY       = "unallocated_quantity"
+	KEY_WAREHOUSE                  = "warehouse"
+	KEY_WAREHOUSES                 = "warehouses"
+	KEY_WAREHOUSE_ID               = "warehouse_id"
 	KEY_CARTS                      = "carts"
 	KEY_TRACE_ID                   = "trace_id"
 	KEY_SPAN_ID                    = "span_id"
@@ -0,0 +1,7 @@
+package constants
+
+const (
+	ROLE_CUSTOMER       = "CUSTOMER"
+	ROLE_MERCHANT_STAFF = "MERCHANT_STAFF"
+	ROLE_ADMIN          = "ADMIN"
+)
@@ -6,8 +6,19 @@ import (
 
 var (
 	ErrEmptyAuth       = errors.New("missing authorization")
+	ErrForbidden       = errors.New("role is not allowed to access this resource")
 	ErrEmptySubject    = errors.New("missing subject")
 	ErrTokenInvalid    = errors.New("invalid token")
 	ErrFailedHashToken = errors.New("failed hashing token")
 	ErrOutOfStock      = errors.New("product is out of stock")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrCategoryCycle   = errors.New("category can not be moved under itself or its descendants")
+	ErrUnknownVariant  = errors.New("variant does not exist or belongs to another product")
+	ErrUnknownProduct  = errors.New("product does not exist")
+	ErrProductArchived = errors.New("product is archived")
+	ErrUncoveredStock  = errors.New("inventory levels do not cover the product quantity")
+	ErrNotCancellable  = errors.New("order can only be cancelled before it is paid")
+
+	ErrPreconditionRequired = errors.New("missing If-Match header")
+	ErrPreconditionFailed   = errors.New("resource was modified, If-Match does not match its ETag")
 )
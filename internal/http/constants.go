@@ -1,7 +1,12 @@
 package http
 
 const (
-	KEY_HEADER_CONTENT_TYPE       = "Content-Type"
-	VALUE_HEADER_APPLICATION_JSON = "application/json"
-	KEY_HEADER_REQUEST_ID         = "X-REQUEST-ID"
+	KEY_HEADER_CONTENT_TYPE        = "Content-Type"
+	VALUE_HEADER_APPLICATION_JSON  = "application/json"
+	KEY_HEADER_REQUEST_ID          = "X-REQUEST-ID"
+	KEY_HEADER_ETAG                = "ETag"
+	KEY_HEADER_IF_MATCH            = "If-Match"
+	KEY_HEADER_IF_NONE_MATCH       = "If-None-Match"
+	KEY_HEADER_IDEMPOTENCY_KEY     = "Idempotency-Key"
+	KEY_HEADER_IDEMPOTENT_REPLAYED = "Idempotent-Replayed"
 )
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Alturino/ecommerce/internal/errors"
+)
+
+// ContentETag returns the strong entity tag of the JSON representation of v,
+// for resources such as carts whose items change without bumping the
+// updated_at of the resource itself.
+func ContentETag(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// NotModified reports whether the If-None-Match header of r matches etag, in
+// which case a GET should respond with 304 Not Modified.
+func NotModified(r *http.Request, etag string) bool {
+	header := r.Header.Get(KEY_HEADER_IF_NONE_MATCH)
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatch returns the entity tags of the If-Match header of r or
+// ErrPreconditionRequired when the header is missing.
+func IfMatch(r *http.Request) ([]string, error) {
+	header := r.Header.Get(KEY_HEADER_IF_MATCH)
+	if header == "" {
+		return nil, errors.ErrPreconditionRequired
+	}
+	tags := []string{}
+	for _, tag := range strings.Split(header, ",") {
+		tags = append(tags, strings.TrimSpace(tag))
+	}
+	return tags, nil
+}
+
+// CheckIfMatch returns ErrPreconditionFailed unless one of tags, taken from an
+// If-Match header, is etag. Weak tags never match.
+func CheckIfMatch(tags []string, etag string) error {
+	for _, tag := range tags {
+		if tag == "*" || tag == etag {
+			return nil
+		}
+	}
+	return errors.ErrPreconditionFailed
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/errors"
+)
+
+func TestContentETag(t *testing.T) {
+	id, updatedAt := uuid.New(), time.Now()
+	etag, err := ContentETag(map[string]any{"id": id, "updated_at": updatedAt})
+	assert.NoError(t, err)
+	same, err := ContentETag(map[string]any{"id": id, "updated_at": updatedAt})
+	assert.NoError(t, err)
+	assert.Equal(t, etag, same)
+	updated, err := ContentETag(map[string]any{"id": id, "updated_at": updatedAt.Add(time.Microsecond)})
+	assert.NoError(t, err)
+	assert.NotEqual(t, etag, updated, "a new version should change the ETag")
+
+	r := httptest.NewRequest("GET", "/products", nil)
+	assert.False(t, NotModified(r, etag))
+	r.Header.Set(KEY_HEADER_IF_NONE_MATCH, `"stale", W/`+etag)
+	assert.True(t, NotModified(r, etag))
+
+	r = httptest.NewRequest("PUT", "/products", nil)
+	_, err = IfMatch(r)
+	assert.ErrorIs(t, err, errors.ErrPreconditionRequired)
+	r.Header.Set(KEY_HEADER_IF_MATCH, `"stale", `+etag)
+	tags, err := IfMatch(r)
+	assert.NoError(t, err)
+	assert.NoError(t, CheckIfMatch(tags, etag))
+	assert.ErrorIs(t, CheckIfMatch([]string{`"stale"`, "W/" + etag}, etag), errors.ErrPreconditionFailed)
+	assert.NoError(t, CheckIfMatch([]string{"*"}, etag))
+}
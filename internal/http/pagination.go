@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/errors"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Cursor is the position of the last item of a page. Lists are ordered by Key
+// and then by ID, Key holds the value of the column the list is sorted by.
+type Cursor struct {
+	Key string    `json:"k,omitempty"`
+	ID  uuid.UUID `json:"id"`
+}
+
+// Encode returns the cursor as an opaque string that clients send back in the
+// cursor query param.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// TimeCursor returns the cursor of an item in a list ordered by a timestamp.
+func TimeCursor(t time.Time, id uuid.UUID) Cursor {
+	return Cursor{Key: t.Format(time.RFC3339Nano), ID: id}
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("failed decoding cursor with error=%w", errors.ErrInvalidCursor)
+	}
+	cursor := Cursor{}
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return Cursor{}, fmt.Errorf("failed decoding cursor with error=%w", errors.ErrInvalidCursor)
+	}
+	return cursor, nil
+}
+
+// Page is the page of a list requested by a client, a nil Cursor is the first
+// page.
+type Page struct {
+	Cursor *Cursor `json:"cursor,omitempty"`
+	Limit  int32   `json:"limit"`
+}
+
+// TimeCursorParams returns the cursor of a list ordered by a timestamp as the
+// cursor_id and cursor_created_at params of a keyset query, both are null on
+// the first page.
+func (p Page) TimeCursorParams() (pgtype.UUID, pgtype.Timestamptz, error) {
+	if p.Cursor == nil {
+		return pgtype.UUID{}, pgtype.Timestamptz{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, p.Cursor.Key)
+	if err != nil {
+		return pgtype.UUID{}, pgtype.Timestamptz{}, fmt.Errorf(
+			"failed parsing cursor time with error=%w",
+			errors.ErrInvalidCursor,
+		)
+	}
+	return pgtype.UUID{Bytes: p.Cursor.ID, Valid: true},
+		pgtype.Timestamptz{Time: t, InfinityModifier: pgtype.Finite, Valid: true},
+		nil
+}
+
+// ParsePage reads the limit and cursor query params of r. The limit defaults to
+// cfg.DefaultLimit and must not exceed cfg.MaxLimit.
+func ParsePage(r *http.Request, cfg config.Pagination) (Page, error) {
+	defaultLimit, maxLimit := cfg.DefaultLimit, cfg.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = MaxPageLimit
+	}
+	if defaultLimit <= 0 || defaultLimit > maxLimit {
+		defaultLimit = min(DefaultPageLimit, maxLimit)
+	}
+
+	query := r.URL.Query()
+	page := Page{Limit: defaultLimit}
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		limit, err := strconv.ParseInt(rawLimit, 10, 32)
+		if err != nil || limit <= 0 || int32(limit) > maxLimit {
+			return Page{}, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		page.Limit = int32(limit)
+	}
+	if rawCursor := query.Get("cursor"); rawCursor != "" {
+		cursor, err := DecodeCursor(rawCursor)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Cursor = &cursor
+	}
+	return page, nil
+}
+
+// NextPage trims items, fetched with one more row than limit, to the page size
+// and returns the cursor of the next page, or an empty string on the last page.
+func NextPage[T any](items []T, limit int32, cursor func(T) Cursor) ([]T, string) {
+	if limit <= 0 || len(items) <= int(limit) {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, cursor(items[len(items)-1]).Encode()
+}
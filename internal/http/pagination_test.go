@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/errors"
+)
+
+func TestParsePage(t *testing.T) {
+	cfg := config.Pagination{DefaultLimit: 10, MaxLimit: 50}
+
+	page, err := ParsePage(httptest.NewRequest("GET", "/products", nil), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10), page.Limit)
+	assert.Nil(t, page.Cursor)
+
+	_, err = ParsePage(httptest.NewRequest("GET", "/products?limit=51", nil), cfg)
+	assert.Error(t, err, "limit above the configured maximum should be rejected")
+
+	_, err = ParsePage(httptest.NewRequest("GET", "/products?cursor=not-a-cursor", nil), cfg)
+	assert.ErrorIs(t, err, errors.ErrInvalidCursor)
+
+	cursor := TimeCursor(time.Now(), uuid.New())
+	page, err = ParsePage(httptest.NewRequest("GET", "/products?limit=5&cursor="+cursor.Encode(), nil), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), page.Limit)
+	assert.Equal(t, cursor, *page.Cursor)
+
+	id, createdAt, err := page.TimeCursorParams()
+	assert.NoError(t, err)
+	assert.Equal(t, cursor.ID, uuid.UUID(id.Bytes))
+	assert.Equal(t, cursor.Key, createdAt.Time.Format(time.RFC3339Nano))
+}
+
+func TestNextPage(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	cursor := func(id uuid.UUID) Cursor { return Cursor{ID: id} }
+
+	items, next := NextPage(ids, 3, cursor)
+	assert.Len(t, items, 3)
+	assert.Empty(t, next, "last page should not have a next cursor")
+
+	items, next = NextPage(ids, 2, cursor)
+	assert.Equal(t, ids[:2], items)
+	decoded, err := DecodeCursor(next)
+	assert.NoError(t, err)
+	assert.Equal(t, ids[1], decoded.ID)
+}
@@ -0,0 +1,45 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/blob"
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/otel"
+)
+
+func NewBlobStore(c context.Context, cfg config.Media) blob.BlobStore {
+	c, span := otel.Tracer.Start(c, "main NewBlobStore")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "main NewBlobStore").
+		Str(constants.KEY_PROCESS, "initializing blob store").
+		Str(constants.KEY_BLOB_BACKEND, cfg.Backend).
+		Logger()
+
+	logger.Info().Msg("initializing blob store")
+	var store blob.BlobStore
+	var err error
+	switch cfg.Backend {
+	case "", config.BlobFilesystem:
+		store, err = blob.NewFileSystem(cfg.Filesystem.Root)
+	case config.BlobS3:
+		store, err = blob.NewS3(cfg.S3, nil)
+	default:
+		err = fmt.Errorf("unknown backend=%s", cfg.Backend)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed initializing blob store with error=%w", err)
+		logger.Fatal().Err(err).Msg(err.Error())
+	}
+	logger.Info().Msg("initialized blob store")
+
+	return store
+}
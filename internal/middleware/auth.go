@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -70,3 +71,56 @@ func Auth(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequireRole only lets requests through whose token claims one of roles. It
+// must run after Auth, which attaches the verified token to the context.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, span := otel.Tracer.Start(r.Context(), "RequireRole")
+			defer span.End()
+
+			logger := zerolog.Ctx(c).
+				With().
+				Ctx(c).
+				Str(constants.KEY_TAG, "middleware RequireRole").
+				Strs(constants.KEY_ROLES, roles).
+				Logger()
+
+			logger = logger.With().Str(constants.KEY_PROCESS, "getting role").Logger()
+			logger.Trace().Msg("getting role")
+			c = logger.WithContext(c)
+			role, err := internal.RoleFromJwtToken(c)
+			if err != nil {
+				err = fmt.Errorf("failed getting role with error=%w", err)
+				otel.RecordError(err, span)
+				logger.Error().Err(err).Msg(err.Error())
+				commonHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+					"status":     "failed",
+					"statusCode": http.StatusUnauthorized,
+					"message":    errors.ErrTokenInvalid.Error(),
+				})
+				return
+			}
+			logger = logger.With().Str(constants.KEY_ROLE, role).Logger()
+			logger.Info().Msg("got role")
+
+			logger = logger.With().Str(constants.KEY_PROCESS, "checking role").Logger()
+			logger.Trace().Msg("checking role")
+			if !slices.Contains(roles, role) {
+				err = fmt.Errorf("failed checking role with error=%w", errors.ErrForbidden)
+				otel.RecordError(err, span)
+				logger.Error().Err(err).Msg(err.Error())
+				commonHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+					"status":     "failed",
+					"statusCode": http.StatusForbidden,
+					"message":    errors.ErrForbidden.Error(),
+				})
+				return
+			}
+			logger.Info().Msg("checked role")
+
+			next.ServeHTTP(w, r.WithContext(logger.WithContext(c)))
+		})
+	}
+}
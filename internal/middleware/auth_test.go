@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal"
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/log"
+)
+
+func TestRequireRole(t *testing.T) {
+	testCases := []struct {
+		name     string
+		token    *jwt.Token
+		expected int
+	}{
+		{"allowed role", &jwt.Token{Claims: &internal.Claims{Role: constants.ROLE_ADMIN}}, http.StatusOK},
+		{"other role", &jwt.Token{Claims: &internal.Claims{Role: constants.ROLE_MERCHANT_STAFF}}, http.StatusForbidden},
+		{"token without role", &jwt.Token{Claims: &internal.Claims{}}, http.StatusForbidden},
+		{"missing token", nil, http.StatusUnauthorized},
+	}
+	handler := RequireRole(constants.ROLE_ADMIN)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/exchange-rates/EUR", nil)
+			r = r.WithContext(log.AttachRequestIDToContext(r.Context(), "request-id"))
+			if tc.token != nil {
+				r = r.WithContext(internal.AttachJwtToken(r.Context(), tc.token))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Package money rounds and converts amounts in ISO 4217 currencies.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrUnsupportedCurrency = errors.New("currency is not supported")
+
+// minorUnits is the number of decimal places of the supported currencies.
+var minorUnits = map[string]int32{
+	"AUD": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"IDR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MYR": 2,
+	"SGD": 2,
+	"USD": 2,
+}
+
+// Normalize returns the upper case ISO 4217 code of currency or
+// ErrUnsupportedCurrency.
+func Normalize(currency string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(currency))
+	if _, ok := minorUnits[code]; !ok {
+		return "", fmt.Errorf("%s %w", currency, ErrUnsupportedCurrency)
+	}
+	return code, nil
+}
+
+// Round rounds amount half away from zero to the minor unit of currency, an
+// unsupported currency is rounded to 2 decimal places.
+func Round(amount decimal.Decimal, currency string) decimal.Decimal {
+	places, ok := minorUnits[currency]
+	if !ok {
+		places = 2
+	}
+	return amount.Round(places)
+}
+
+// Convert converts amount to currency at rate and rounds it to the minor unit
+// of currency.
+func Convert(amount decimal.Decimal, rate decimal.Decimal, currency string) decimal.Decimal {
+	return Round(amount.Mul(rate), currency)
+}
@@ -0,0 +1,43 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	code, err := Normalize(" eur ")
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", code)
+
+	_, err = Normalize("XYZ")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestRound(t *testing.T) {
+	testCases := []struct {
+		name     string
+		amount   string
+		currency string
+		expected string
+	}{
+		{"two decimal places", "10.005", "USD", "10.01"},
+		{"no decimal places", "1234.5", "JPY", "1235"},
+		{"three decimal places", "1.23456", "KWD", "1.235"},
+		{"unsupported currency", "1.234", "XYZ", "1.23"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := Round(decimal.RequireFromString(tc.amount), tc.currency)
+			assert.Equal(t, tc.expected, actual.String())
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	amount := decimal.RequireFromString("19.99")
+	assert.Equal(t, "3023", Convert(amount, decimal.RequireFromString("151.23"), "JPY").String())
+	assert.Equal(t, "18.47", Convert(amount, decimal.RequireFromString("0.924"), "EUR").String())
+}
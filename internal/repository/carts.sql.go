@@ -36,7 +36,7 @@ func (q *Queries) DeleteCartByIdAndUserId(ctx context.Context, arg DeleteCartByI
 
 const deleteCartItemFromCartsById = `-- name: DeleteCartItemFromCartsById :one
 delete from cart_items
-where id = $1 and cart_id = $2 returning id, cart_id, product_id, quantity, price, created_at, updated_at
+where id = $1 and cart_id = $2 returning id, cart_id, product_id, quantity, price, created_at, updated_at, variant_id, currency
 `
 
 type DeleteCartItemFromCartsByIdParams struct {
@@ -55,24 +55,82 @@ func (q *Queries) DeleteCartItemFromCartsById(ctx context.Context, arg DeleteCar
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VariantID,
+		&i.Currency,
 	)
 	return i, err
 }
 
+const deleteCartItemsByCartId = `-- name: DeleteCartItemsByCartId :exec
+delete from cart_items
+where cart_id = $1
+`
+
+func (q *Queries) DeleteCartItemsByCartId(ctx context.Context, cartID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCartItemsByCartId, cartID)
+	return err
+}
+
+const findActiveCartByUserId = `-- name: FindActiveCartByUserId :one
+select
+    c.id, c.user_id, c.created_at, c.updated_at,
+    coalesce(json_agg(to_json(ci.*)) filter (where ci.id is not null), '[]')::json as cart_items
+from active_carts as ac
+inner join carts as c on ac.cart_id = c.id
+left join cart_items as ci on c.id = ci.cart_id
+where ac.user_id = $1
+group by c.id, c.user_id, c.created_at, c.updated_at
+`
+
+type FindActiveCartByUserIdRow struct {
+	ID        uuid.UUID          `db:"id" json:"id"`
+	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	CartItems []byte             `db:"cart_items" json:"cart_items"`
+}
+
+func (q *Queries) FindActiveCartByUserId(ctx context.Context, userID uuid.UUID) (FindActiveCartByUserIdRow, error) {
+	row := q.db.QueryRow(ctx, findActiveCartByUserId, userID)
+	var i FindActiveCartByUserIdRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CartItems,
+	)
+	return i, err
+}
+
+const findActiveCartIdByUserIdForUpdate = `-- name: FindActiveCartIdByUserIdForUpdate :one
+select cart_id from active_carts
+where user_id = $1
+for update
+`
+
+func (q *Queries) FindActiveCartIdByUserIdForUpdate(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, findActiveCartIdByUserIdForUpdate, userID)
+	var cart_id uuid.UUID
+	err := row.Scan(&cart_id)
+	return cart_id, err
+}
+
 const findCartById = `-- name: FindCartById :one
 select
     c.id, c.user_id, c.created_at, c.updated_at,
-    json_agg(to_json(ci.*)) as cart_items
-from users as u
-inner join carts as c on u.id = c.user_id
-inner join cart_items as ci on c.id = ci.cart_id
-where u.id = $1 and c.id = $2
+    coalesce(json_agg(to_json(ci.*)) filter (where ci.id is not null), '[]')::json as cart_items
+from carts as c
+left join cart_items as ci on c.id = ci.cart_id
+where
+    c.id = $1
+    and ($2::uuid is null or c.user_id = $2)
 group by c.id, c.user_id, c.created_at, c.updated_at
 `
 
 type FindCartByIdParams struct {
-	ID   uuid.UUID `db:"id" json:"id"`
-	ID_2 uuid.UUID `db:"id_2" json:"id_2"`
+	ID     uuid.UUID   `db:"id" json:"id"`
+	UserID pgtype.UUID `db:"user_id" json:"user_id"`
 }
 
 type FindCartByIdRow struct {
@@ -84,7 +142,7 @@ type FindCartByIdRow struct {
 }
 
 func (q *Queries) FindCartById(ctx context.Context, arg FindCartByIdParams) (FindCartByIdRow, error) {
-	row := q.db.QueryRow(ctx, findCartById, arg.ID, arg.ID_2)
+	row := q.db.QueryRow(ctx, findCartById, arg.ID, arg.UserID)
 	var i FindCartByIdRow
 	err := row.Scan(
 		&i.ID,
@@ -141,7 +199,7 @@ func (q *Queries) FindCartByUserId(ctx context.Context, id uuid.UUID) ([]FindCar
 }
 
 const findCartItemByCartId = `-- name: FindCartItemByCartId :many
-select id, cart_id, product_id, quantity, price, created_at, updated_at from cart_items
+select id, cart_id, product_id, quantity, price, created_at, updated_at, variant_id, currency from cart_items
 where cart_id = $1
 `
 
@@ -162,6 +220,8 @@ func (q *Queries) FindCartItemByCartId(ctx context.Context, cartID uuid.UUID) ([
 			&i.Price,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.VariantID,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -174,7 +234,7 @@ func (q *Queries) FindCartItemByCartId(ctx context.Context, cartID uuid.UUID) ([
 }
 
 const findCartItemById = `-- name: FindCartItemById :one
-select id, cart_id, product_id, quantity, price, created_at, updated_at from cart_items
+select id, cart_id, product_id, quantity, price, created_at, updated_at, variant_id, currency from cart_items
 where id = $1
 `
 
@@ -189,10 +249,93 @@ func (q *Queries) FindCartItemById(ctx context.Context, id uuid.UUID) (CartItem,
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VariantID,
+		&i.Currency,
 	)
 	return i, err
 }
 
+const findCartsByUserId = `-- name: FindCartsByUserId :many
+select
+    c.id, c.user_id, c.created_at, c.updated_at,
+    coalesce(json_agg(to_json(ci.*)) filter (where ci.id is not null), '[]')::json as cart_items
+from carts as c
+left join cart_items as ci on c.id = ci.cart_id
+where
+    c.user_id = $1
+    and (
+        $2::uuid is null
+        or (c.created_at, c.id) < ($3::timestamptz, $2::uuid)
+    )
+group by c.id, c.user_id, c.created_at, c.updated_at
+order by c.created_at desc, c.id desc
+limit $4
+`
+
+type FindCartsByUserIdParams struct {
+	UserID          uuid.UUID          `db:"user_id" json:"user_id"`
+	CursorID        pgtype.UUID        `db:"cursor_id" json:"cursor_id"`
+	CursorCreatedAt pgtype.Timestamptz `db:"cursor_created_at" json:"cursor_created_at"`
+	ResultLimit     int32              `db:"result_limit" json:"result_limit"`
+}
+
+type FindCartsByUserIdRow struct {
+	ID        uuid.UUID          `db:"id" json:"id"`
+	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	CartItems []byte             `db:"cart_items" json:"cart_items"`
+}
+
+func (q *Queries) FindCartsByUserId(ctx context.Context, arg FindCartsByUserIdParams) ([]FindCartsByUserIdRow, error) {
+	rows, err := q.db.Query(ctx, findCartsByUserId,
+		arg.UserID,
+		arg.CursorID,
+		arg.CursorCreatedAt,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindCartsByUserIdRow
+	for rows.Next() {
+		var i FindCartsByUserIdRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CartItems,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertActiveCart = `-- name: InsertActiveCart :one
+insert into active_carts (user_id, cart_id) values ($1, $2)
+on conflict (user_id) do nothing
+returning cart_id
+`
+
+type InsertActiveCartParams struct {
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	CartID uuid.UUID `db:"cart_id" json:"cart_id"`
+}
+
+func (q *Queries) InsertActiveCart(ctx context.Context, arg InsertActiveCartParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, insertActiveCart, arg.UserID, arg.CartID)
+	var cart_id uuid.UUID
+	err := row.Scan(&cart_id)
+	return cart_id, err
+}
+
 const insertCart = `-- name: InsertCart :one
 insert into carts (user_id) values ($1) returning id, user_id, created_at, updated_at
 `
@@ -210,9 +353,9 @@ func (q *Queries) InsertCart(ctx context.Context, userID uuid.UUID) (Cart, error
 }
 
 const insertCartItem = `-- name: InsertCartItem :one
-insert into cart_items (id, cart_id, product_id, quantity, price) values (
-    $1, $2, $3, $4, $5
-) returning id, cart_id, product_id, quantity, price, created_at, updated_at
+insert into cart_items (id, cart_id, product_id, quantity, price, variant_id, currency) values (
+    $1, $2, $3, $4, $5, $6, $7
+) returning id, cart_id, product_id, quantity, price, created_at, updated_at, variant_id, currency
 `
 
 type InsertCartItemParams struct {
@@ -221,6 +364,8 @@ type InsertCartItemParams struct {
 	ProductID uuid.UUID      `db:"product_id" json:"product_id"`
 	Quantity  int32          `db:"quantity" json:"quantity"`
 	Price     pgtype.Numeric `db:"price" json:"price"`
+	VariantID pgtype.UUID    `db:"variant_id" json:"variant_id"`
+	Currency  string         `db:"currency" json:"currency"`
 }
 
 func (q *Queries) InsertCartItem(ctx context.Context, arg InsertCartItemParams) (CartItem, error) {
@@ -230,6 +375,8 @@ func (q *Queries) InsertCartItem(ctx context.Context, arg InsertCartItemParams)
 		arg.ProductID,
 		arg.Quantity,
 		arg.Price,
+		arg.VariantID,
+		arg.Currency,
 	)
 	var i CartItem
 	err := row.Scan(
@@ -240,6 +387,8 @@ func (q *Queries) InsertCartItem(ctx context.Context, arg InsertCartItemParams)
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VariantID,
+		&i.Currency,
 	)
 	return i, err
 }
@@ -250,4 +399,47 @@ type InsertCartItemsParams struct {
 	ProductID uuid.UUID      `db:"product_id" json:"product_id"`
 	Quantity  int32          `db:"quantity" json:"quantity"`
 	Price     pgtype.Numeric `db:"price" json:"price"`
+	VariantID pgtype.UUID    `db:"variant_id" json:"variant_id"`
+	Currency  string         `db:"currency" json:"currency"`
+}
+
+const touchCart = `-- name: TouchCart :exec
+update carts set updated_at = now()
+where id = $1
+`
+
+func (q *Queries) TouchCart(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, touchCart, id)
+	return err
+}
+
+const updateCartItemQuantity = `-- name: UpdateCartItemQuantity :one
+update cart_items set
+    quantity = $1,
+    updated_at = now()
+where id = $2 and cart_id = $3
+returning id, cart_id, product_id, quantity, price, created_at, updated_at, variant_id, currency
+`
+
+type UpdateCartItemQuantityParams struct {
+	Quantity int32     `db:"quantity" json:"quantity"`
+	ID       uuid.UUID `db:"id" json:"id"`
+	CartID   uuid.UUID `db:"cart_id" json:"cart_id"`
+}
+
+func (q *Queries) UpdateCartItemQuantity(ctx context.Context, arg UpdateCartItemQuantityParams) (CartItem, error) {
+	row := q.db.QueryRow(ctx, updateCartItemQuantity, arg.Quantity, arg.ID, arg.CartID)
+	var i CartItem
+	err := row.Scan(
+		&i.ID,
+		&i.CartID,
+		&i.ProductID,
+		&i.Quantity,
+		&i.Price,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VariantID,
+		&i.Currency,
+	)
+	return i, err
 }
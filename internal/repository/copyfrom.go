@@ -9,6 +9,42 @@ import (
 	"context"
 )
 
+// iteratorForInsertBackorders implements pgx.CopyFromSource.
+type iteratorForInsertBackorders struct {
+	rows                 []InsertBackordersParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertBackorders) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertBackorders) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].OrderID,
+		r.rows[0].OrderItemID,
+		r.rows[0].ProductID,
+		r.rows[0].Quantity,
+		r.rows[0].CreatedAt,
+	}, nil
+}
+
+func (r iteratorForInsertBackorders) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertBackorders(ctx context.Context, arg []InsertBackordersParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"backorders"}, []string{"order_id", "order_item_id", "product_id", "quantity", "created_at"}, &iteratorForInsertBackorders{rows: arg})
+}
+
 // iteratorForInsertCartItems implements pgx.CopyFromSource.
 type iteratorForInsertCartItems struct {
 	rows                 []InsertCartItemsParams
@@ -34,6 +70,8 @@ func (r iteratorForInsertCartItems) Values() ([]interface{}, error) {
 		r.rows[0].ProductID,
 		r.rows[0].Quantity,
 		r.rows[0].Price,
+		r.rows[0].VariantID,
+		r.rows[0].Currency,
 	}, nil
 }
 
@@ -42,7 +80,46 @@ func (r iteratorForInsertCartItems) Err() error {
 }
 
 func (q *Queries) InsertCartItems(ctx context.Context, arg []InsertCartItemsParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"cart_items"}, []string{"id", "cart_id", "product_id", "quantity", "price"}, &iteratorForInsertCartItems{rows: arg})
+	return q.db.CopyFrom(ctx, []string{"cart_items"}, []string{"id", "cart_id", "product_id", "quantity", "price", "variant_id", "currency"}, &iteratorForInsertCartItems{rows: arg})
+}
+
+// iteratorForInsertInventoryMovements implements pgx.CopyFromSource.
+type iteratorForInsertInventoryMovements struct {
+	rows                 []InsertInventoryMovementsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertInventoryMovements) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertInventoryMovements) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ProductID,
+		r.rows[0].VariantID,
+		r.rows[0].WarehouseID,
+		r.rows[0].Quantity,
+		r.rows[0].Reason,
+		r.rows[0].ReferenceID,
+		r.rows[0].Actor,
+		r.rows[0].CreatedAt,
+	}, nil
+}
+
+func (r iteratorForInsertInventoryMovements) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertInventoryMovements(ctx context.Context, arg []InsertInventoryMovementsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"inventory_movements"}, []string{"product_id", "variant_id", "warehouse_id", "quantity", "reason", "reference_id", "actor", "created_at"}, &iteratorForInsertInventoryMovements{rows: arg})
 }
 
 // iteratorForInsertOrderItem implements pgx.CopyFromSource.
@@ -72,6 +149,8 @@ func (r iteratorForInsertOrderItem) Values() ([]interface{}, error) {
 		r.rows[0].Price,
 		r.rows[0].CreatedAt,
 		r.rows[0].UpdatedAt,
+		r.rows[0].VariantID,
+		r.rows[0].Currency,
 	}, nil
 }
 
@@ -80,7 +159,43 @@ func (r iteratorForInsertOrderItem) Err() error {
 }
 
 func (q *Queries) InsertOrderItem(ctx context.Context, arg []InsertOrderItemParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"order_items"}, []string{"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at"}, &iteratorForInsertOrderItem{rows: arg})
+	return q.db.CopyFrom(ctx, []string{"order_items"}, []string{"id", "order_id", "product_id", "quantity", "price", "created_at", "updated_at", "variant_id", "currency"}, &iteratorForInsertOrderItem{rows: arg})
+}
+
+// iteratorForInsertOrderItemAllocations implements pgx.CopyFromSource.
+type iteratorForInsertOrderItemAllocations struct {
+	rows                 []InsertOrderItemAllocationsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertOrderItemAllocations) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertOrderItemAllocations) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].OrderItemID,
+		r.rows[0].ShipmentID,
+		r.rows[0].WarehouseID,
+		r.rows[0].Quantity,
+		r.rows[0].CreatedAt,
+	}, nil
+}
+
+func (r iteratorForInsertOrderItemAllocations) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertOrderItemAllocations(ctx context.Context, arg []InsertOrderItemAllocationsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"order_item_allocations"}, []string{"order_item_id", "shipment_id", "warehouse_id", "quantity", "created_at"}, &iteratorForInsertOrderItemAllocations{rows: arg})
 }
 
 // iteratorForInsertOrders implements pgx.CopyFromSource.
@@ -105,8 +220,12 @@ func (r iteratorForInsertOrders) Values() ([]interface{}, error) {
 	return []interface{}{
 		r.rows[0].ID,
 		r.rows[0].UserID,
+		r.rows[0].Status,
 		r.rows[0].CreatedAt,
 		r.rows[0].UpdatedAt,
+		r.rows[0].Currency,
+		r.rows[0].PresentmentCurrency,
+		r.rows[0].ExchangeRate,
 	}, nil
 }
 
@@ -115,5 +234,78 @@ func (r iteratorForInsertOrders) Err() error {
 }
 
 func (q *Queries) InsertOrders(ctx context.Context, arg []InsertOrdersParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"orders"}, []string{"id", "user_id", "created_at", "updated_at"}, &iteratorForInsertOrders{rows: arg})
+	return q.db.CopyFrom(ctx, []string{"orders"}, []string{"id", "user_id", "status", "created_at", "updated_at", "currency", "presentment_currency", "exchange_rate"}, &iteratorForInsertOrders{rows: arg})
+}
+
+// iteratorForInsertShipments implements pgx.CopyFromSource.
+type iteratorForInsertShipments struct {
+	rows                 []InsertShipmentsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertShipments) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertShipments) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ID,
+		r.rows[0].OrderID,
+		r.rows[0].WarehouseID,
+		r.rows[0].CreatedAt,
+		r.rows[0].UpdatedAt,
+	}, nil
+}
+
+func (r iteratorForInsertShipments) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertShipments(ctx context.Context, arg []InsertShipmentsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"shipments"}, []string{"id", "order_id", "warehouse_id", "created_at", "updated_at"}, &iteratorForInsertShipments{rows: arg})
+}
+
+// iteratorForInsertStockReservations implements pgx.CopyFromSource.
+type iteratorForInsertStockReservations struct {
+	rows                 []InsertStockReservationsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertStockReservations) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertStockReservations) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].CartID,
+		r.rows[0].CartItemID,
+		r.rows[0].ProductID,
+		r.rows[0].Quantity,
+		r.rows[0].ExpiresAt,
+		r.rows[0].VariantID,
+	}, nil
+}
+
+func (r iteratorForInsertStockReservations) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertStockReservations(ctx context.Context, arg []InsertStockReservationsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"stock_reservations"}, []string{"cart_id", "cart_item_id", "product_id", "quantity", "expires_at", "variant_id"}, &iteratorForInsertStockReservations{rows: arg})
 }
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NewInventoryMovement returns the params to record a quantity change of a
+// product in the inventory_movements ledger. A positive quantity increases the
+// stock and a negative one decreases it, warehouseId and referenceId are stored
+// as null when they are uuid.Nil.
+func NewInventoryMovement(
+	productId uuid.UUID,
+	warehouseId uuid.UUID,
+	quantity int32,
+	reason InventoryMovementReason,
+	referenceId uuid.UUID,
+	actor string,
+) InsertInventoryMovementsParams {
+	return InsertInventoryMovementsParams{
+		ProductID:   productId,
+		WarehouseID: pgtype.UUID{Bytes: warehouseId, Valid: warehouseId != uuid.Nil},
+		Quantity:    quantity,
+		Reason:      reason,
+		ReferenceID: pgtype.UUID{Bytes: referenceId, Valid: referenceId != uuid.Nil},
+		Actor:       actor,
+		CreatedAt: pgtype.Timestamptz{
+			Time:             time.Now(),
+			InfinityModifier: pgtype.Finite,
+			Valid:            true,
+		},
+	}
+}
+
+// NewVariantInventoryMovement returns the params to record a quantity change of
+// a variant of a product. Warehouses only track the stock of products, so the
+// movement has no warehouse.
+func NewVariantInventoryMovement(
+	productId uuid.UUID,
+	variantId uuid.UUID,
+	quantity int32,
+	reason InventoryMovementReason,
+	referenceId uuid.UUID,
+	actor string,
+) InsertInventoryMovementsParams {
+	movement := NewInventoryMovement(productId, uuid.Nil, quantity, reason, referenceId, actor)
+	movement.VariantID = pgtype.UUID{Bytes: variantId, Valid: true}
+	return movement
+}
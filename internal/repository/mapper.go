@@ -2,7 +2,9 @@ package repository
 
 import (
 	"encoding/json"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
 	cartResponse "github.com/Alturino/ecommerce/cart/pkg/response"
@@ -11,13 +13,151 @@ import (
 )
 
 func (p Product) Response() productResponse.Product {
+	var releaseDate *time.Time
+	if p.ReleaseDate.Valid {
+		releaseDate = &p.ReleaseDate.Time
+	}
+	var archivedAt *time.Time
+	if p.ArchivedAt.Valid {
+		archivedAt = &p.ArchivedAt.Time
+	}
 	return productResponse.Product{
-		ID:        p.ID,
-		Name:      p.Name,
-		Price:     decimal.NewFromBigInt(p.Price.Int, p.Price.Exp),
-		Quantity:  p.Quantity,
-		CreatedAt: p.CreatedAt.Time,
-		UpdatedAt: p.UpdatedAt.Time,
+		ID:             p.ID,
+		Name:           p.Name,
+		Description:    p.Description,
+		Price:          decimal.NewFromBigInt(p.Price.Int, p.Price.Exp),
+		Quantity:       p.Quantity,
+		Backorderable:  p.Backorderable,
+		Preorderable:   p.Preorderable,
+		ReleaseDate:    releaseDate,
+		BackorderLimit: p.BackorderLimit,
+		CreatedAt:      p.CreatedAt.Time,
+		UpdatedAt:      p.UpdatedAt.Time,
+		ArchivedAt:     archivedAt,
+	}
+}
+
+func (r SearchProductsRow) Response() productResponse.Product {
+	return Product{
+		ID:             r.ID,
+		Name:           r.Name,
+		Price:          r.Price,
+		Quantity:       r.Quantity,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+		Backorderable:  r.Backorderable,
+		Preorderable:   r.Preorderable,
+		ReleaseDate:    r.ReleaseDate,
+		BackorderLimit: r.BackorderLimit,
+		Description:    r.Description,
+		ArchivedAt:     r.ArchivedAt,
+	}.Response()
+}
+
+func (p ProductPrice) Response() productResponse.Price {
+	var effectiveTo *time.Time
+	if p.EffectiveTo.Valid {
+		effectiveTo = &p.EffectiveTo.Time
+	}
+	return productResponse.Price{
+		ID:            p.ID,
+		ProductID:     p.ProductID,
+		Price:         decimal.NewFromBigInt(p.Price.Int, p.Price.Exp),
+		EffectiveFrom: p.EffectiveFrom.Time,
+		EffectiveTo:   effectiveTo,
+		CreatedAt:     p.CreatedAt.Time,
+	}
+}
+
+func (e ExchangeRate) Response() productResponse.ExchangeRate {
+	return productResponse.ExchangeRate{
+		BaseCurrency:  e.BaseCurrency,
+		QuoteCurrency: e.QuoteCurrency,
+		Rate:          decimal.NewFromBigInt(e.Rate.Int, e.Rate.Exp),
+		UpdatedAt:     e.UpdatedAt.Time,
+	}
+}
+
+func (r ProductReview) Response() productResponse.Review {
+	var moderatedAt *time.Time
+	if r.ModeratedAt.Valid {
+		moderatedAt = &r.ModeratedAt.Time
+	}
+	return productResponse.Review{
+		ID:           r.ID,
+		ProductId:    r.ProductID,
+		UserId:       r.UserID,
+		Rating:       r.Rating,
+		Body:         r.Body,
+		MediaUrls:    r.MediaUrls,
+		Status:       string(r.Status),
+		HelpfulCount: r.HelpfulCount,
+		ModeratedAt:  moderatedAt,
+		CreatedAt:    r.CreatedAt.Time,
+		UpdatedAt:    r.UpdatedAt.Time,
+	}
+}
+
+func (v ProductVariant) Response() (productResponse.Variant, error) {
+	options := map[string]string{}
+	err := json.Unmarshal(v.Options, &options)
+	if err != nil {
+		return productResponse.Variant{}, err
+	}
+	var price *decimal.Decimal
+	if v.Price.Valid {
+		p := decimal.NewFromBigInt(v.Price.Int, v.Price.Exp)
+		price = &p
+	}
+	return productResponse.Variant{
+		ID:        v.ID,
+		ProductID: v.ProductID,
+		Sku:       v.Sku,
+		Options:   options,
+		Price:     price,
+		Quantity:  v.Quantity,
+		CreatedAt: v.CreatedAt.Time,
+		UpdatedAt: v.UpdatedAt.Time,
+	}, nil
+}
+
+// Response maps the media to its response, url returns the URL of a blob key.
+func (m ProductMedium) Response(url func(key string) string) (productResponse.Media, error) {
+	keys := map[string]string{}
+	err := json.Unmarshal(m.Thumbnails, &keys)
+	if err != nil {
+		return productResponse.Media{}, err
+	}
+	thumbnails := make(map[string]string, len(keys))
+	for size, key := range keys {
+		thumbnails[size] = url(key)
+	}
+	return productResponse.Media{
+		ID:          m.ID,
+		URL:         url(m.Key),
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		Thumbnails:  thumbnails,
+		CreatedAt:   m.CreatedAt.Time,
+	}, nil
+}
+
+func (c Category) Response() productResponse.Category {
+	var parentId *uuid.UUID
+	if c.ParentID.Valid {
+		id := uuid.UUID(c.ParentID.Bytes)
+		parentId = &id
+	}
+	return productResponse.Category{
+		ID:        c.ID,
+		ParentID:  parentId,
+		Name:      c.Name,
+		Slug:      c.Slug,
+		Position:  c.Position,
+		CreatedAt: c.CreatedAt.Time,
+		UpdatedAt: c.UpdatedAt.Time,
 	}
 }
 
@@ -28,13 +168,16 @@ func (o GetOrdersRow) Response() (orderResponse.Order, error) {
 		return orderResponse.Order{}, err
 	}
 	return orderResponse.Order{
-		CreatedAt:  o.CreatedAt.Time,
-		UpdatedAt:  o.UpdatedAt.Time,
-		OrderItems: orderItems,
-		Status:     string(o.Status),
-		ID:         o.ID,
-		UserId:     o.UserID,
-	}, nil
+		CreatedAt:           o.CreatedAt.Time,
+		UpdatedAt:           o.UpdatedAt.Time,
+		OrderItems:          orderItems,
+		Status:              string(o.Status),
+		Currency:            o.Currency,
+		PresentmentCurrency: o.PresentmentCurrency,
+		ExchangeRate:        decimal.NewFromBigInt(o.ExchangeRate.Int, o.ExchangeRate.Exp),
+		ID:                  o.ID,
+		UserId:              o.UserID,
+	}.Present(), nil
 }
 
 func (f FindCartByIdRow) Response() (cartResponse.Cart, error) {
@@ -52,6 +195,36 @@ func (f FindCartByIdRow) Response() (cartResponse.Cart, error) {
 	}, nil
 }
 
+func (f FindActiveCartByUserIdRow) Response() (cartResponse.Cart, error) {
+	cartItems := []cartResponse.CartItem{}
+	err := json.Unmarshal(f.CartItems, &cartItems)
+	if err != nil {
+		return cartResponse.Cart{}, err
+	}
+	return cartResponse.Cart{
+		ID:        f.ID,
+		UserID:    f.UserID,
+		CartItems: cartItems,
+		CreatedAt: f.CreatedAt.Time,
+		UpdatedAt: f.UpdatedAt.Time,
+	}, nil
+}
+
+func (f FindCartsByUserIdRow) Response() (cartResponse.Cart, error) {
+	cartItems := []cartResponse.CartItem{}
+	err := json.Unmarshal(f.CartItems, &cartItems)
+	if err != nil {
+		return cartResponse.Cart{}, err
+	}
+	return cartResponse.Cart{
+		ID:        f.ID,
+		UserID:    f.UserID,
+		CartItems: cartItems,
+		CreatedAt: f.CreatedAt.Time,
+		UpdatedAt: f.UpdatedAt.Time,
+	}, nil
+}
+
 func (f FindOrderByIdRow) ResponseOrder() (orderResponse.Order, error) {
 	orderItems := []orderResponse.OrderItem{}
 	err := json.Unmarshal(f.OrderItems, &orderItems)
@@ -59,11 +232,14 @@ func (f FindOrderByIdRow) ResponseOrder() (orderResponse.Order, error) {
 		return orderResponse.Order{}, err
 	}
 	return orderResponse.Order{
-		ID:         f.ID,
-		UserId:     f.UserID,
-		Status:     string(f.Status),
-		OrderItems: orderItems,
-		CreatedAt:  f.CreatedAt.Time,
-		UpdatedAt:  f.UpdatedAt.Time,
-	}, nil
+		ID:                  f.ID,
+		UserId:              f.UserID,
+		Status:              string(f.Status),
+		OrderItems:          orderItems,
+		Currency:            f.Currency,
+		PresentmentCurrency: f.PresentmentCurrency,
+		ExchangeRate:        decimal.NewFromBigInt(f.ExchangeRate.Int, f.ExchangeRate.Exp),
+		CreatedAt:           f.CreatedAt.Time,
+		UpdatedAt:           f.UpdatedAt.Time,
+	}.Present(), nil
 }
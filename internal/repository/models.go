@@ -12,6 +12,52 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type InventoryMovementReason string
+
+const (
+	InventoryMovementReasonSALE       InventoryMovementReason = "SALE"
+	InventoryMovementReasonCANCEL     InventoryMovementReason = "CANCEL"
+	InventoryMovementReasonEXPIRE     InventoryMovementReason = "EXPIRE"
+	InventoryMovementReasonRESTOCK    InventoryMovementReason = "RESTOCK"
+	InventoryMovementReasonADJUSTMENT InventoryMovementReason = "ADJUSTMENT"
+	InventoryMovementReasonRETURN     InventoryMovementReason = "RETURN"
+)
+
+func (e *InventoryMovementReason) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InventoryMovementReason(s)
+	case string:
+		*e = InventoryMovementReason(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InventoryMovementReason: %T", src)
+	}
+	return nil
+}
+
+type NullInventoryMovementReason struct {
+	InventoryMovementReason InventoryMovementReason `json:"inventory_movement_reason"`
+	Valid                   bool                    `json:"valid"` // Valid is true if InventoryMovementReason is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInventoryMovementReason) Scan(value interface{}) error {
+	if value == nil {
+		ns.InventoryMovementReason, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InventoryMovementReason.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInventoryMovementReason) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InventoryMovementReason), nil
+}
+
 type OrderStatus string
 
 const (
@@ -20,6 +66,8 @@ const (
 	OrderStatusCOMPLETED      OrderStatus = "COMPLETED"
 	OrderStatusEXPIRED        OrderStatus = "EXPIRED"
 	OrderStatusCANCELLED      OrderStatus = "CANCELLED"
+	OrderStatusBACKORDERED    OrderStatus = "BACKORDERED"
+	OrderStatusPREORDER       OrderStatus = "PREORDER"
 )
 
 func (e *OrderStatus) Scan(src interface{}) error {
@@ -57,6 +105,193 @@ func (ns NullOrderStatus) Value() (driver.Value, error) {
 	return string(ns.OrderStatus), nil
 }
 
+type ReviewStatus string
+
+const (
+	ReviewStatusPENDING  ReviewStatus = "PENDING"
+	ReviewStatusAPPROVED ReviewStatus = "APPROVED"
+	ReviewStatusREJECTED ReviewStatus = "REJECTED"
+)
+
+func (e *ReviewStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReviewStatus(s)
+	case string:
+		*e = ReviewStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReviewStatus: %T", src)
+	}
+	return nil
+}
+
+type NullReviewStatus struct {
+	ReviewStatus ReviewStatus `json:"review_status"`
+	Valid        bool         `json:"valid"` // Valid is true if ReviewStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReviewStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReviewStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReviewStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReviewStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReviewStatus), nil
+}
+
+type ShipmentStatus string
+
+const (
+	ShipmentStatusPENDING   ShipmentStatus = "PENDING"
+	ShipmentStatusSHIPPED   ShipmentStatus = "SHIPPED"
+	ShipmentStatusDELIVERED ShipmentStatus = "DELIVERED"
+)
+
+func (e *ShipmentStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ShipmentStatus(s)
+	case string:
+		*e = ShipmentStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ShipmentStatus: %T", src)
+	}
+	return nil
+}
+
+type NullShipmentStatus struct {
+	ShipmentStatus ShipmentStatus `json:"shipment_status"`
+	Valid          bool           `json:"valid"` // Valid is true if ShipmentStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullShipmentStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ShipmentStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ShipmentStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullShipmentStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ShipmentStatus), nil
+}
+
+type StockLevel string
+
+const (
+	StockLevelINSTOCK  StockLevel = "IN_STOCK"
+	StockLevelLOWSTOCK StockLevel = "LOW_STOCK"
+	StockLevelSOLDOUT  StockLevel = "SOLD_OUT"
+)
+
+func (e *StockLevel) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StockLevel(s)
+	case string:
+		*e = StockLevel(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StockLevel: %T", src)
+	}
+	return nil
+}
+
+type NullStockLevel struct {
+	StockLevel StockLevel `json:"stock_level"`
+	Valid      bool       `json:"valid"` // Valid is true if StockLevel is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStockLevel) Scan(value interface{}) error {
+	if value == nil {
+		ns.StockLevel, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StockLevel.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStockLevel) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StockLevel), nil
+}
+
+type UserRole string
+
+const (
+	UserRoleCUSTOMER      UserRole = "CUSTOMER"
+	UserRoleMERCHANTSTAFF UserRole = "MERCHANT_STAFF"
+	UserRoleADMIN         UserRole = "ADMIN"
+)
+
+func (e *UserRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UserRole(s)
+	case string:
+		*e = UserRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UserRole: %T", src)
+	}
+	return nil
+}
+
+type NullUserRole struct {
+	UserRole UserRole `json:"user_role"`
+	Valid    bool     `json:"valid"` // Valid is true if UserRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUserRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.UserRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UserRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUserRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UserRole), nil
+}
+
+type ActiveCart struct {
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	CartID uuid.UUID `db:"cart_id" json:"cart_id"`
+}
+
+type Backorder struct {
+	ID          uuid.UUID          `db:"id" json:"id"`
+	OrderID     uuid.UUID          `db:"order_id" json:"order_id"`
+	OrderItemID uuid.UUID          `db:"order_item_id" json:"order_item_id"`
+	ProductID   uuid.UUID          `db:"product_id" json:"product_id"`
+	Quantity    int32              `db:"quantity" json:"quantity"`
+	AllocatedAt pgtype.Timestamptz `db:"allocated_at" json:"allocated_at"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
 type Cart struct {
 	ID        uuid.UUID          `db:"id" json:"id"`
 	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
@@ -72,16 +307,67 @@ type CartItem struct {
 	Price     pgtype.Numeric     `db:"price" json:"price"`
 	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	VariantID pgtype.UUID        `db:"variant_id" json:"variant_id"`
+	Currency  string             `db:"currency" json:"currency"`
 }
 
-type Order struct {
+type Category struct {
 	ID        uuid.UUID          `db:"id" json:"id"`
-	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
-	Status    OrderStatus        `db:"status" json:"status"`
+	ParentID  pgtype.UUID        `db:"parent_id" json:"parent_id"`
+	Name      string             `db:"name" json:"name"`
+	Slug      string             `db:"slug" json:"slug"`
+	Position  int32              `db:"position" json:"position"`
 	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
+type DeadLetterOrder struct {
+	ID        uuid.UUID          `db:"id" json:"id"`
+	OrderID   uuid.UUID          `db:"order_id" json:"order_id"`
+	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
+	Payload   []byte             `db:"payload" json:"payload"`
+	Error     string             `db:"error" json:"error"`
+	Attempts  int32              `db:"attempts" json:"attempts"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type ExchangeRate struct {
+	BaseCurrency  string             `db:"base_currency" json:"base_currency"`
+	QuoteCurrency string             `db:"quote_currency" json:"quote_currency"`
+	Rate          pgtype.Numeric     `db:"rate" json:"rate"`
+	UpdatedAt     pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type InventoryLevel struct {
+	ProductID   uuid.UUID          `db:"product_id" json:"product_id"`
+	WarehouseID uuid.UUID          `db:"warehouse_id" json:"warehouse_id"`
+	Quantity    int32              `db:"quantity" json:"quantity"`
+	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type InventoryMovement struct {
+	ID          uuid.UUID               `db:"id" json:"id"`
+	ProductID   uuid.UUID               `db:"product_id" json:"product_id"`
+	WarehouseID pgtype.UUID             `db:"warehouse_id" json:"warehouse_id"`
+	Quantity    int32                   `db:"quantity" json:"quantity"`
+	Reason      InventoryMovementReason `db:"reason" json:"reason"`
+	ReferenceID pgtype.UUID             `db:"reference_id" json:"reference_id"`
+	Actor       string                  `db:"actor" json:"actor"`
+	CreatedAt   pgtype.Timestamptz      `db:"created_at" json:"created_at"`
+	VariantID   pgtype.UUID             `db:"variant_id" json:"variant_id"`
+}
+
+type Order struct {
+	ID                  uuid.UUID          `db:"id" json:"id"`
+	UserID              uuid.UUID          `db:"user_id" json:"user_id"`
+	Status              OrderStatus        `db:"status" json:"status"`
+	CreatedAt           pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Currency            string             `db:"currency" json:"currency"`
+	PresentmentCurrency string             `db:"presentment_currency" json:"presentment_currency"`
+	ExchangeRate        pgtype.Numeric     `db:"exchange_rate" json:"exchange_rate"`
+}
+
 type OrderItem struct {
 	ID        uuid.UUID          `db:"id" json:"id"`
 	OrderID   uuid.UUID          `db:"order_id" json:"order_id"`
@@ -90,22 +376,164 @@ type OrderItem struct {
 	Price     pgtype.Numeric     `db:"price" json:"price"`
 	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	VariantID pgtype.UUID        `db:"variant_id" json:"variant_id"`
+	Currency  string             `db:"currency" json:"currency"`
+}
+
+type OrderItemAllocation struct {
+	ID          uuid.UUID          `db:"id" json:"id"`
+	OrderItemID uuid.UUID          `db:"order_item_id" json:"order_item_id"`
+	ShipmentID  uuid.UUID          `db:"shipment_id" json:"shipment_id"`
+	WarehouseID uuid.UUID          `db:"warehouse_id" json:"warehouse_id"`
+	Quantity    int32              `db:"quantity" json:"quantity"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
 }
 
 type Product struct {
+	ID             uuid.UUID          `db:"id" json:"id"`
+	Name           string             `db:"name" json:"name"`
+	Price          pgtype.Numeric     `db:"price" json:"price"`
+	Quantity       int32              `db:"quantity" json:"quantity"`
+	CreatedAt      pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Backorderable  bool               `db:"backorderable" json:"backorderable"`
+	Preorderable   bool               `db:"preorderable" json:"preorderable"`
+	ReleaseDate    pgtype.Timestamptz `db:"release_date" json:"release_date"`
+	BackorderLimit int32              `db:"backorder_limit" json:"backorder_limit"`
+	Description    string             `db:"description" json:"description"`
+	ArchivedAt     pgtype.Timestamptz `db:"archived_at" json:"archived_at"`
+}
+
+type ProductCategory struct {
+	ProductID  uuid.UUID          `db:"product_id" json:"product_id"`
+	CategoryID uuid.UUID          `db:"category_id" json:"category_id"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type ProductCoPurchase struct {
+	ProductID        uuid.UUID          `db:"product_id" json:"product_id"`
+	RelatedProductID uuid.UUID          `db:"related_product_id" json:"related_product_id"`
+	Score            int32              `db:"score" json:"score"`
+	UpdatedAt        pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type ProductMedium struct {
+	ID          uuid.UUID          `db:"id" json:"id"`
+	ProductID   uuid.UUID          `db:"product_id" json:"product_id"`
+	Key         string             `db:"key" json:"key"`
+	ContentType string             `db:"content_type" json:"content_type"`
+	Size        int64              `db:"size" json:"size"`
+	Width       int32              `db:"width" json:"width"`
+	Height      int32              `db:"height" json:"height"`
+	Thumbnails  []byte             `db:"thumbnails" json:"thumbnails"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type ProductPrice struct {
+	ID            uuid.UUID          `db:"id" json:"id"`
+	ProductID     uuid.UUID          `db:"product_id" json:"product_id"`
+	Price         pgtype.Numeric     `db:"price" json:"price"`
+	EffectiveFrom pgtype.Timestamptz `db:"effective_from" json:"effective_from"`
+	EffectiveTo   pgtype.Timestamptz `db:"effective_to" json:"effective_to"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type ProductRating struct {
+	ProductID   uuid.UUID          `db:"product_id" json:"product_id"`
+	RatingCount int32              `db:"rating_count" json:"rating_count"`
+	RatingSum   int32              `db:"rating_sum" json:"rating_sum"`
+	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type ProductReview struct {
+	ID           uuid.UUID          `db:"id" json:"id"`
+	ProductID    uuid.UUID          `db:"product_id" json:"product_id"`
+	UserID       uuid.UUID          `db:"user_id" json:"user_id"`
+	OrderID      uuid.UUID          `db:"order_id" json:"order_id"`
+	Rating       int32              `db:"rating" json:"rating"`
+	Body         string             `db:"body" json:"body"`
+	MediaUrls    []string           `db:"media_urls" json:"media_urls"`
+	Status       ReviewStatus       `db:"status" json:"status"`
+	HelpfulCount int32              `db:"helpful_count" json:"helpful_count"`
+	ModeratedAt  pgtype.Timestamptz `db:"moderated_at" json:"moderated_at"`
+	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type ProductReviewVote struct {
+	ReviewID  uuid.UUID          `db:"review_id" json:"review_id"`
+	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type ProductStockAlert struct {
+	ProductID         uuid.UUID          `db:"product_id" json:"product_id"`
+	LowStockThreshold pgtype.Int4        `db:"low_stock_threshold" json:"low_stock_threshold"`
+	Level             StockLevel         `db:"level" json:"level"`
+	UpdatedAt         pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type ProductVariant struct {
 	ID        uuid.UUID          `db:"id" json:"id"`
-	Name      string             `db:"name" json:"name"`
+	ProductID uuid.UUID          `db:"product_id" json:"product_id"`
+	Sku       string             `db:"sku" json:"sku"`
+	Options   []byte             `db:"options" json:"options"`
 	Price     pgtype.Numeric     `db:"price" json:"price"`
 	Quantity  int32              `db:"quantity" json:"quantity"`
 	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
+type Shipment struct {
+	ID          uuid.UUID          `db:"id" json:"id"`
+	OrderID     uuid.UUID          `db:"order_id" json:"order_id"`
+	WarehouseID uuid.UUID          `db:"warehouse_id" json:"warehouse_id"`
+	Status      ShipmentStatus     `db:"status" json:"status"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type StockAdjustment struct {
+	ID                uuid.UUID               `db:"id" json:"id"`
+	ProductID         uuid.UUID               `db:"product_id" json:"product_id"`
+	WarehouseID       uuid.UUID               `db:"warehouse_id" json:"warehouse_id"`
+	IdempotencyKey    string                  `db:"idempotency_key" json:"idempotency_key"`
+	Delta             int32                   `db:"delta" json:"delta"`
+	Reason            InventoryMovementReason `db:"reason" json:"reason"`
+	Actor             string                  `db:"actor" json:"actor"`
+	WarehouseQuantity int32                   `db:"warehouse_quantity" json:"warehouse_quantity"`
+	ProductQuantity   int32                   `db:"product_quantity" json:"product_quantity"`
+	CreatedAt         pgtype.Timestamptz      `db:"created_at" json:"created_at"`
+}
+
+type StockReservation struct {
+	ID         uuid.UUID          `db:"id" json:"id"`
+	CartID     uuid.UUID          `db:"cart_id" json:"cart_id"`
+	CartItemID uuid.UUID          `db:"cart_item_id" json:"cart_item_id"`
+	ProductID  uuid.UUID          `db:"product_id" json:"product_id"`
+	Quantity   int32              `db:"quantity" json:"quantity"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	VariantID  pgtype.UUID        `db:"variant_id" json:"variant_id"`
+}
+
 type User struct {
+	ID                uuid.UUID          `db:"id" json:"id"`
+	Username          string             `db:"username" json:"username"`
+	Email             string             `db:"email" json:"email"`
+	Password          string             `db:"password" json:"password"`
+	CreatedAt         pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	PreferredCurrency pgtype.Text        `db:"preferred_currency" json:"preferred_currency"`
+	Role              UserRole           `db:"role" json:"role"`
+}
+
+type Warehouse struct {
 	ID        uuid.UUID          `db:"id" json:"id"`
-	Username  string             `db:"username" json:"username"`
-	Email     string             `db:"email" json:"email"`
-	Password  string             `db:"password" json:"password"`
+	Name      string             `db:"name" json:"name"`
+	Latitude  float64            `db:"latitude" json:"latitude"`
+	Longitude float64            `db:"longitude" json:"longitude"`
+	IsDefault bool               `db:"is_default" json:"is_default"`
 	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
@@ -12,9 +12,39 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelOrder = `-- name: CancelOrder :one
+update orders set status = 'CANCELLED', updated_at = now()
+where
+    id = $1
+    and ($2::uuid is null or user_id = $2)
+    and status in ('WAITING_PAYMENT', 'BACKORDERED', 'PREORDER')
+returning id, user_id, status, created_at, updated_at, currency, presentment_currency, exchange_rate
+`
+
+type CancelOrderParams struct {
+	ID     uuid.UUID   `db:"id" json:"id"`
+	UserID pgtype.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) CancelOrder(ctx context.Context, arg CancelOrderParams) (Order, error) {
+	row := q.db.QueryRow(ctx, cancelOrder, arg.ID, arg.UserID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Currency,
+		&i.PresentmentCurrency,
+		&i.ExchangeRate,
+	)
+	return i, err
+}
+
 const deleteOrderItemFromOrdersById = `-- name: DeleteOrderItemFromOrdersById :one
 delete from order_items
-where id = $1 returning id, order_id, product_id, quantity, price, created_at, updated_at
+where id = $1 returning id, order_id, product_id, quantity, price, created_at, updated_at, variant_id, currency
 `
 
 func (q *Queries) DeleteOrderItemFromOrdersById(ctx context.Context, id uuid.UUID) (OrderItem, error) {
@@ -28,37 +58,89 @@ func (q *Queries) DeleteOrderItemFromOrdersById(ctx context.Context, id uuid.UUI
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VariantID,
+		&i.Currency,
 	)
 	return i, err
 }
 
+const expireOrders = `-- name: ExpireOrders :many
+update orders set status = 'EXPIRED', updated_at = now()
+where id in (
+    select o.id from orders as o
+    where o.status = 'WAITING_PAYMENT' and o.updated_at <= $1
+    order by o.updated_at, o.id
+    limit $2
+    for update skip locked
+)
+returning id, user_id, status, created_at, updated_at, currency, presentment_currency, exchange_rate
+`
+
+type ExpireOrdersParams struct {
+	UpdatedBefore pgtype.Timestamptz `db:"updated_before" json:"updated_before"`
+	ResultLimit   int32              `db:"result_limit" json:"result_limit"`
+}
+
+func (q *Queries) ExpireOrders(ctx context.Context, arg ExpireOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, expireOrders, arg.UpdatedBefore, arg.ResultLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+			&i.PresentmentCurrency,
+			&i.ExchangeRate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const findOrderById = `-- name: FindOrderById :one
 select
-    o.id, o.user_id, o.status, o.created_at, o.updated_at,
+    o.id, o.user_id, o.status, o.created_at, o.updated_at, o.currency, o.presentment_currency, o.exchange_rate,
     json_agg(to_json(oi.*)) as order_items
-from users as u
-inner join orders as o on u.id = o.user_id
+from orders as o
 inner join order_items as oi on o.id = oi.order_id
-where u.id = $1 and o.id = $2
+where
+    o.id = $1
+    and ($2::uuid is null or o.user_id = $2)
 group by o.id, o.user_id, o.created_at, o.updated_at
 `
 
 type FindOrderByIdParams struct {
-	ID   uuid.UUID `db:"id" json:"id"`
-	ID_2 uuid.UUID `db:"id_2" json:"id_2"`
+	ID     uuid.UUID   `db:"id" json:"id"`
+	UserID pgtype.UUID `db:"user_id" json:"user_id"`
 }
 
 type FindOrderByIdRow struct {
-	ID         uuid.UUID          `db:"id" json:"id"`
-	UserID     uuid.UUID          `db:"user_id" json:"user_id"`
-	Status     OrderStatus        `db:"status" json:"status"`
-	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
-	OrderItems []byte             `db:"order_items" json:"order_items"`
+	ID                  uuid.UUID          `db:"id" json:"id"`
+	UserID              uuid.UUID          `db:"user_id" json:"user_id"`
+	Status              OrderStatus        `db:"status" json:"status"`
+	CreatedAt           pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Currency            string             `db:"currency" json:"currency"`
+	PresentmentCurrency string             `db:"presentment_currency" json:"presentment_currency"`
+	ExchangeRate        pgtype.Numeric     `db:"exchange_rate" json:"exchange_rate"`
+	OrderItems          []byte             `db:"order_items" json:"order_items"`
 }
 
 func (q *Queries) FindOrderById(ctx context.Context, arg FindOrderByIdParams) (FindOrderByIdRow, error) {
-	row := q.db.QueryRow(ctx, findOrderById, arg.ID, arg.ID_2)
+	row := q.db.QueryRow(ctx, findOrderById, arg.ID, arg.UserID)
 	var i FindOrderByIdRow
 	err := row.Scan(
 		&i.ID,
@@ -66,13 +148,16 @@ func (q *Queries) FindOrderById(ctx context.Context, arg FindOrderByIdParams) (F
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.PresentmentCurrency,
+		&i.ExchangeRate,
 		&i.OrderItems,
 	)
 	return i, err
 }
 
 const findOrderByUserId = `-- name: FindOrderByUserId :many
-select id, user_id, status, created_at, updated_at from orders
+select id, user_id, status, created_at, updated_at, currency, presentment_currency, exchange_rate from orders
 where user_id = $1
 `
 
@@ -91,6 +176,9 @@ func (q *Queries) FindOrderByUserId(ctx context.Context, userID uuid.UUID) ([]Or
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.PresentmentCurrency,
+			&i.ExchangeRate,
 		); err != nil {
 			return nil, err
 		}
@@ -103,7 +191,7 @@ func (q *Queries) FindOrderByUserId(ctx context.Context, userID uuid.UUID) ([]Or
 }
 
 const findOrderItemById = `-- name: FindOrderItemById :many
-select id, order_id, product_id, quantity, price, created_at, updated_at from order_items
+select id, order_id, product_id, quantity, price, created_at, updated_at, variant_id, currency from order_items
 where id = $1
 `
 
@@ -124,6 +212,8 @@ func (q *Queries) FindOrderItemById(ctx context.Context, id uuid.UUID) ([]OrderI
 			&i.Price,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.VariantID,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -136,7 +226,7 @@ func (q *Queries) FindOrderItemById(ctx context.Context, id uuid.UUID) ([]OrderI
 }
 
 const findOrderItemByIdAndUserId = `-- name: FindOrderItemByIdAndUserId :many
-select oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price, oi.created_at, oi.updated_at
+select oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price, oi.created_at, oi.updated_at, oi.variant_id, oi.currency
 from orders as o
 inner join order_items as oi on o.id = oi.order_id
 where
@@ -165,6 +255,43 @@ func (q *Queries) FindOrderItemByIdAndUserId(ctx context.Context, arg FindOrderI
 			&i.Price,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.VariantID,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findOrderItemsByOrderIds = `-- name: FindOrderItemsByOrderIds :many
+select id, order_id, product_id, quantity, price, created_at, updated_at, variant_id, currency from order_items
+where order_id = any($1::uuid [])
+`
+
+func (q *Queries) FindOrderItemsByOrderIds(ctx context.Context, dollar_1 []uuid.UUID) ([]OrderItem, error) {
+	rows, err := q.db.Query(ctx, findOrderItemsByOrderIds, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderItem
+	for rows.Next() {
+		var i OrderItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.ProductID,
+			&i.Quantity,
+			&i.Price,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.VariantID,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -177,7 +304,7 @@ func (q *Queries) FindOrderItemByIdAndUserId(ctx context.Context, arg FindOrderI
 }
 
 const findOrderUserId = `-- name: FindOrderUserId :many
-select o.id, o.user_id, o.status, o.created_at, o.updated_at
+select o.id, o.user_id, o.status, o.created_at, o.updated_at, o.currency, o.presentment_currency, o.exchange_rate
 from users as u
 inner join orders as o on u.id = o.user_id
 where u.id = $1
@@ -198,6 +325,62 @@ func (q *Queries) FindOrderUserId(ctx context.Context, id uuid.UUID) ([]Order, e
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.PresentmentCurrency,
+			&i.ExchangeRate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findOrdersByUserId = `-- name: FindOrdersByUserId :many
+select id, user_id, status, created_at, updated_at, currency, presentment_currency, exchange_rate from orders
+where
+    user_id = $1
+    and (
+        $2::uuid is null
+        or (created_at, id) < ($3::timestamptz, $2::uuid)
+    )
+order by created_at desc, id desc
+limit $4
+`
+
+type FindOrdersByUserIdParams struct {
+	UserID          uuid.UUID          `db:"user_id" json:"user_id"`
+	CursorID        pgtype.UUID        `db:"cursor_id" json:"cursor_id"`
+	CursorCreatedAt pgtype.Timestamptz `db:"cursor_created_at" json:"cursor_created_at"`
+	ResultLimit     int32              `db:"result_limit" json:"result_limit"`
+}
+
+func (q *Queries) FindOrdersByUserId(ctx context.Context, arg FindOrdersByUserIdParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, findOrdersByUserId,
+		arg.UserID,
+		arg.CursorID,
+		arg.CursorCreatedAt,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+			&i.PresentmentCurrency,
+			&i.ExchangeRate,
 		); err != nil {
 			return nil, err
 		}
@@ -211,7 +394,7 @@ func (q *Queries) FindOrderUserId(ctx context.Context, id uuid.UUID) ([]Order, e
 
 const getOrders = `-- name: GetOrders :many
 select
-    o.id, o.user_id, o.status, o.created_at, o.updated_at,
+    o.id, o.user_id, o.status, o.created_at, o.updated_at, o.currency, o.presentment_currency, o.exchange_rate,
     json_agg(to_json(oi.*)) as order_items
 from orders as o
 inner join order_items as oi on o.id = oi.order_id
@@ -220,12 +403,15 @@ group by o.id, o.user_id, o.created_at, o.updated_at
 `
 
 type GetOrdersRow struct {
-	ID         uuid.UUID          `db:"id" json:"id"`
-	UserID     uuid.UUID          `db:"user_id" json:"user_id"`
-	Status     OrderStatus        `db:"status" json:"status"`
-	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
-	OrderItems []byte             `db:"order_items" json:"order_items"`
+	ID                  uuid.UUID          `db:"id" json:"id"`
+	UserID              uuid.UUID          `db:"user_id" json:"user_id"`
+	Status              OrderStatus        `db:"status" json:"status"`
+	CreatedAt           pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Currency            string             `db:"currency" json:"currency"`
+	PresentmentCurrency string             `db:"presentment_currency" json:"presentment_currency"`
+	ExchangeRate        pgtype.Numeric     `db:"exchange_rate" json:"exchange_rate"`
+	OrderItems          []byte             `db:"order_items" json:"order_items"`
 }
 
 func (q *Queries) GetOrders(ctx context.Context, dollar_1 []uuid.UUID) ([]GetOrdersRow, error) {
@@ -243,6 +429,9 @@ func (q *Queries) GetOrders(ctx context.Context, dollar_1 []uuid.UUID) ([]GetOrd
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
+			&i.PresentmentCurrency,
+			&i.ExchangeRate,
 			&i.OrderItems,
 		); err != nil {
 			return nil, err
@@ -255,8 +444,43 @@ func (q *Queries) GetOrders(ctx context.Context, dollar_1 []uuid.UUID) ([]GetOrd
 	return items, nil
 }
 
+const insertDeadLetterOrder = `-- name: InsertDeadLetterOrder :one
+insert into dead_letter_orders (order_id, user_id, payload, error, attempts) values (
+    $1, $2, $3, $4, $5
+) returning id, order_id, user_id, payload, error, attempts, created_at
+`
+
+type InsertDeadLetterOrderParams struct {
+	OrderID  uuid.UUID `db:"order_id" json:"order_id"`
+	UserID   uuid.UUID `db:"user_id" json:"user_id"`
+	Payload  []byte    `db:"payload" json:"payload"`
+	Error    string    `db:"error" json:"error"`
+	Attempts int32     `db:"attempts" json:"attempts"`
+}
+
+func (q *Queries) InsertDeadLetterOrder(ctx context.Context, arg InsertDeadLetterOrderParams) (DeadLetterOrder, error) {
+	row := q.db.QueryRow(ctx, insertDeadLetterOrder,
+		arg.OrderID,
+		arg.UserID,
+		arg.Payload,
+		arg.Error,
+		arg.Attempts,
+	)
+	var i DeadLetterOrder
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.UserID,
+		&i.Payload,
+		&i.Error,
+		&i.Attempts,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const insertOrder = `-- name: InsertOrder :one
-insert into orders (id, user_id) values ($1, $2) returning id, user_id, status, created_at, updated_at
+insert into orders (id, user_id) values ($1, $2) returning id, user_id, status, created_at, updated_at, currency, presentment_currency, exchange_rate
 `
 
 type InsertOrderParams struct {
@@ -273,6 +497,9 @@ func (q *Queries) InsertOrder(ctx context.Context, arg InsertOrderParams) (Order
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+		&i.PresentmentCurrency,
+		&i.ExchangeRate,
 	)
 	return i, err
 }
@@ -285,11 +512,58 @@ type InsertOrderItemParams struct {
 	Price     pgtype.Numeric     `db:"price" json:"price"`
 	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	VariantID pgtype.UUID        `db:"variant_id" json:"variant_id"`
+	Currency  string             `db:"currency" json:"currency"`
 }
 
 type InsertOrdersParams struct {
-	ID        uuid.UUID          `db:"id" json:"id"`
-	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
-	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	ID                  uuid.UUID          `db:"id" json:"id"`
+	UserID              uuid.UUID          `db:"user_id" json:"user_id"`
+	Status              OrderStatus        `db:"status" json:"status"`
+	CreatedAt           pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Currency            string             `db:"currency" json:"currency"`
+	PresentmentCurrency string             `db:"presentment_currency" json:"presentment_currency"`
+	ExchangeRate        pgtype.Numeric     `db:"exchange_rate" json:"exchange_rate"`
+}
+
+const updateAllocatedOrders = `-- name: UpdateAllocatedOrders :many
+update orders set status = 'WAITING_PAYMENT', updated_at = now()
+where
+    id = any($1::uuid [])
+    and status in ('BACKORDERED', 'PREORDER')
+    and not exists (
+        select 1 from backorders as b
+        where b.order_id = orders.id and b.allocated_at is null
+    )
+returning id, user_id, status, created_at, updated_at, currency, presentment_currency, exchange_rate
+`
+
+func (q *Queries) UpdateAllocatedOrders(ctx context.Context, dollar_1 []uuid.UUID) ([]Order, error) {
+	rows, err := q.db.Query(ctx, updateAllocatedOrders, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+			&i.PresentmentCurrency,
+			&i.ExchangeRate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
@@ -12,13 +12,13 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const deleteProduct = `-- name: DeleteProduct :one
-delete from products
-where id = $1 returning id, name, price, quantity, created_at, updated_at
+const archiveProduct = `-- name: ArchiveProduct :one
+update products set archived_at = now(), updated_at = now()
+where id = $1 and archived_at is null returning id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at
 `
 
-func (q *Queries) DeleteProduct(ctx context.Context, id uuid.UUID) (Product, error) {
-	row := q.db.QueryRow(ctx, deleteProduct, id)
+func (q *Queries) ArchiveProduct(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, archiveProduct, id)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -27,12 +27,73 @@ func (q *Queries) DeleteProduct(ctx context.Context, id uuid.UUID) (Product, err
 		&i.Quantity,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
 
+const decreaseProductQuantity = `-- name: DecreaseProductQuantity :one
+update products set quantity = quantity - $1::integer, updated_at = now()
+where id = $2 returning id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at
+`
+
+type DecreaseProductQuantityParams struct {
+	Quantity int32     `db:"quantity" json:"quantity"`
+	ID       uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *Queries) DecreaseProductQuantity(ctx context.Context, arg DecreaseProductQuantityParams) (Product, error) {
+	row := q.db.QueryRow(ctx, decreaseProductQuantity, arg.Quantity, arg.ID)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const findArchivedProductIds = `-- name: FindArchivedProductIds :many
+select id from products
+where id = any($1::uuid []) and archived_at is not null
+`
+
+func (q *Queries) FindArchivedProductIds(ctx context.Context, dollar_1 []uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, findArchivedProductIds, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const findProductById = `-- name: FindProductById :one
-select id, name, price, quantity, created_at, updated_at from products
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
 where id = $1
 `
 
@@ -46,12 +107,43 @@ func (q *Queries) FindProductById(ctx context.Context, id uuid.UUID) (Product, e
 		&i.Quantity,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const findProductByIdForUpdate = `-- name: FindProductByIdForUpdate :one
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
+where id = $1 for update
+`
+
+func (q *Queries) FindProductByIdForUpdate(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, findProductByIdForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
 
 const findProductByIdLock = `-- name: FindProductByIdLock :one
-select id, name, price, quantity, created_at, updated_at from products
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
 where id = $1 for update skip locked
 `
 
@@ -65,12 +157,18 @@ func (q *Queries) FindProductByIdLock(ctx context.Context, id uuid.UUID) (Produc
 		&i.Quantity,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
 
 const findProductByName = `-- name: FindProductByName :one
-select id, name, price, quantity, created_at, updated_at from products
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
 where name = $1
 `
 
@@ -84,12 +182,43 @@ func (q *Queries) FindProductByName(ctx context.Context, name string) (Product,
 		&i.Quantity,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const findProductByNameForUpdate = `-- name: FindProductByNameForUpdate :one
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
+where name = $1 for update
+`
+
+func (q *Queries) FindProductByNameForUpdate(ctx context.Context, name string) (Product, error) {
+	row := q.db.QueryRow(ctx, findProductByNameForUpdate, name)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
 
 const findProducts = `-- name: FindProducts :many
-select id, name, price, quantity, created_at, updated_at from products
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
 `
 
 func (q *Queries) FindProducts(ctx context.Context) ([]Product, error) {
@@ -108,6 +237,57 @@ func (q *Queries) FindProducts(ctx context.Context) ([]Product, error) {
 			&i.Quantity,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Backorderable,
+			&i.Preorderable,
+			&i.ReleaseDate,
+			&i.BackorderLimit,
+			&i.Description,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findProductsAfterName = `-- name: FindProductsAfterName :many
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
+where name > $1
+order by name
+limit $2
+`
+
+type FindProductsAfterNameParams struct {
+	Name  string `db:"name" json:"name"`
+	Limit int32  `db:"limit" json:"limit"`
+}
+
+func (q *Queries) FindProductsAfterName(ctx context.Context, arg FindProductsAfterNameParams) ([]Product, error) {
+	rows, err := q.db.Query(ctx, findProductsAfterName, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Quantity,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Backorderable,
+			&i.Preorderable,
+			&i.ReleaseDate,
+			&i.BackorderLimit,
+			&i.Description,
+			&i.ArchivedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -120,7 +300,7 @@ func (q *Queries) FindProducts(ctx context.Context) ([]Product, error) {
 }
 
 const findProductsByIds = `-- name: FindProductsByIds :many
-select id, name, price, quantity, created_at, updated_at from products
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
 where id = any($1::uuid [])
 `
 
@@ -140,6 +320,50 @@ func (q *Queries) FindProductsByIds(ctx context.Context, dollar_1 []uuid.UUID) (
 			&i.Quantity,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Backorderable,
+			&i.Preorderable,
+			&i.ReleaseDate,
+			&i.BackorderLimit,
+			&i.Description,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findProductsByIdsForUpdate = `-- name: FindProductsByIdsForUpdate :many
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
+where id = any($1::uuid []) for update
+`
+
+func (q *Queries) FindProductsByIdsForUpdate(ctx context.Context, dollar_1 []uuid.UUID) ([]Product, error) {
+	rows, err := q.db.Query(ctx, findProductsByIdsForUpdate, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Quantity,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Backorderable,
+			&i.Preorderable,
+			&i.ReleaseDate,
+			&i.BackorderLimit,
+			&i.Description,
+			&i.ArchivedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -152,7 +376,7 @@ func (q *Queries) FindProductsByIds(ctx context.Context, dollar_1 []uuid.UUID) (
 }
 
 const findProductsByIdsLock = `-- name: FindProductsByIdsLock :many
-select id, name, price, quantity, created_at, updated_at from products
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
 where id = any($1::uuid []) for share
 `
 
@@ -172,6 +396,58 @@ func (q *Queries) FindProductsByIdsLock(ctx context.Context, dollar_1 []uuid.UUI
 			&i.Quantity,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Backorderable,
+			&i.Preorderable,
+			&i.ReleaseDate,
+			&i.BackorderLimit,
+			&i.Description,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findProductsUpdatedAfter = `-- name: FindProductsUpdatedAfter :many
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
+where (updated_at, id) > ($1::timestamptz, $2::uuid)
+order by updated_at, id
+limit $3
+`
+
+type FindProductsUpdatedAfterParams struct {
+	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	ID          uuid.UUID          `db:"id" json:"id"`
+	ResultLimit int32              `db:"result_limit" json:"result_limit"`
+}
+
+func (q *Queries) FindProductsUpdatedAfter(ctx context.Context, arg FindProductsUpdatedAfterParams) ([]Product, error) {
+	rows, err := q.db.Query(ctx, findProductsUpdatedAfter, arg.UpdatedAt, arg.ID, arg.ResultLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Quantity,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Backorderable,
+			&i.Preorderable,
+			&i.ReleaseDate,
+			&i.BackorderLimit,
+			&i.Description,
+			&i.ArchivedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -184,7 +460,7 @@ func (q *Queries) FindProductsByIdsLock(ctx context.Context, dollar_1 []uuid.UUI
 }
 
 const getProducts = `-- name: GetProducts :many
-select id, name, price, quantity, created_at, updated_at from products
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at from products
 `
 
 func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
@@ -203,6 +479,12 @@ func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
 			&i.Quantity,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Backorderable,
+			&i.Preorderable,
+			&i.ReleaseDate,
+			&i.BackorderLimit,
+			&i.Description,
+			&i.ArchivedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -214,18 +496,81 @@ func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
 	return items, nil
 }
 
+const increaseProductQuantities = `-- name: IncreaseProductQuantities :exec
+update products as p set
+    quantity = p.quantity + d.quantity,
+    updated_at = now()
+from (
+    select
+        unnest($1::uuid []) as id,
+        unnest($2::integer []) as quantity
+) as d
+where p.id = d.id
+`
+
+type IncreaseProductQuantitiesParams struct {
+	ProductIds []uuid.UUID `db:"product_ids" json:"product_ids"`
+	Quantities []int32     `db:"quantities" json:"quantities"`
+}
+
+func (q *Queries) IncreaseProductQuantities(ctx context.Context, arg IncreaseProductQuantitiesParams) error {
+	_, err := q.db.Exec(ctx, increaseProductQuantities, arg.ProductIds, arg.Quantities)
+	return err
+}
+
 const insertProduct = `-- name: InsertProduct :one
-insert into products (name, price, quantity) values ($1, $2, $3) returning id, name, price, quantity, created_at, updated_at
+insert into products (
+    name, price, quantity, backorderable, preorderable, release_date, backorder_limit, description
+) values ($1, $2, $3, $4, $5, $6, $7, $8) returning id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at
 `
 
 type InsertProductParams struct {
-	Name     string         `db:"name" json:"name"`
-	Price    pgtype.Numeric `db:"price" json:"price"`
-	Quantity int32          `db:"quantity" json:"quantity"`
+	Name           string             `db:"name" json:"name"`
+	Price          pgtype.Numeric     `db:"price" json:"price"`
+	Quantity       int32              `db:"quantity" json:"quantity"`
+	Backorderable  bool               `db:"backorderable" json:"backorderable"`
+	Preorderable   bool               `db:"preorderable" json:"preorderable"`
+	ReleaseDate    pgtype.Timestamptz `db:"release_date" json:"release_date"`
+	BackorderLimit int32              `db:"backorder_limit" json:"backorder_limit"`
+	Description    string             `db:"description" json:"description"`
 }
 
 func (q *Queries) InsertProduct(ctx context.Context, arg InsertProductParams) (Product, error) {
-	row := q.db.QueryRow(ctx, insertProduct, arg.Name, arg.Price, arg.Quantity)
+	row := q.db.QueryRow(ctx, insertProduct,
+		arg.Name,
+		arg.Price,
+		arg.Quantity,
+		arg.Backorderable,
+		arg.Preorderable,
+		arg.ReleaseDate,
+		arg.BackorderLimit,
+		arg.Description,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Quantity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const restoreProduct = `-- name: RestoreProduct :one
+update products set archived_at = null, updated_at = now()
+where id = $1 and archived_at is not null returning id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at
+`
+
+func (q *Queries) RestoreProduct(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, restoreProduct, id)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -234,20 +579,196 @@ func (q *Queries) InsertProduct(ctx context.Context, arg InsertProductParams) (P
 		&i.Quantity,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
 
+const searchProducts = `-- name: SearchProducts :many
+with recursive category_tree as (
+    select categories.id from categories
+    where categories.id = $1::uuid
+    union all
+    select c.id from categories as c
+    inner join category_tree as ct on c.parent_id = ct.id
+),
+
+ranked as (
+    select
+        id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at,
+        ts_rank(
+            to_tsvector('english', name || ' ' || description),
+            websearch_to_tsquery('english', coalesce($2::text, ''))
+        )::real as rank
+    from products
+    where
+        (
+            $2::text is null
+            or to_tsvector('english', name || ' ' || description)
+            @@ websearch_to_tsquery('english', $2::text)
+        )
+        and ($3::numeric is null or price >= $3::numeric)
+        and ($4::numeric is null or price <= $4::numeric)
+        and (not $5::boolean or quantity > 0)
+        and archived_at is null
+        and (
+            $1::uuid is null
+            or exists (
+                select 1 from product_categories as pc
+                inner join category_tree as ct on pc.category_id = ct.id
+                where pc.product_id = products.id
+            )
+        )
+)
+
+select id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at, rank from ranked
+where
+    $6::uuid is null
+    or (
+        $7::text = 'price_asc'
+        and (
+            price > $8::numeric
+            or (price = $8::numeric and id > $6::uuid)
+        )
+    )
+    or (
+        $7::text = 'price_desc'
+        and (
+            price < $8::numeric
+            or (price = $8::numeric and id > $6::uuid)
+        )
+    )
+    or (
+        $7::text = 'newest'
+        and (
+            created_at < $9::timestamptz
+            or (
+                created_at = $9::timestamptz
+                and id > $6::uuid
+            )
+        )
+    )
+    or (
+        $7::text = 'relevance'
+        and (
+            rank < $10::real
+            or (rank = $10::real and id > $6::uuid)
+        )
+    )
+order by
+    case when $7::text = 'price_asc' then price end asc,
+    case when $7::text = 'price_desc' then price end desc,
+    case when $7::text = 'newest' then created_at end desc,
+    case when $7::text = 'relevance' then rank end desc,
+    id asc
+limit $11
+`
+
+type SearchProductsParams struct {
+	CategoryID      pgtype.UUID        `db:"category_id" json:"category_id"`
+	Query           pgtype.Text        `db:"query" json:"query"`
+	MinPrice        pgtype.Numeric     `db:"min_price" json:"min_price"`
+	MaxPrice        pgtype.Numeric     `db:"max_price" json:"max_price"`
+	InStock         bool               `db:"in_stock" json:"in_stock"`
+	CursorID        pgtype.UUID        `db:"cursor_id" json:"cursor_id"`
+	SortBy          string             `db:"sort_by" json:"sort_by"`
+	CursorPrice     pgtype.Numeric     `db:"cursor_price" json:"cursor_price"`
+	CursorCreatedAt pgtype.Timestamptz `db:"cursor_created_at" json:"cursor_created_at"`
+	CursorRank      pgtype.Float4      `db:"cursor_rank" json:"cursor_rank"`
+	ResultLimit     int32              `db:"result_limit" json:"result_limit"`
+}
+
+type SearchProductsRow struct {
+	ID             uuid.UUID          `db:"id" json:"id"`
+	Name           string             `db:"name" json:"name"`
+	Price          pgtype.Numeric     `db:"price" json:"price"`
+	Quantity       int32              `db:"quantity" json:"quantity"`
+	CreatedAt      pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Backorderable  bool               `db:"backorderable" json:"backorderable"`
+	Preorderable   bool               `db:"preorderable" json:"preorderable"`
+	ReleaseDate    pgtype.Timestamptz `db:"release_date" json:"release_date"`
+	BackorderLimit int32              `db:"backorder_limit" json:"backorder_limit"`
+	Description    string             `db:"description" json:"description"`
+	ArchivedAt     pgtype.Timestamptz `db:"archived_at" json:"archived_at"`
+	Rank           float32            `db:"rank" json:"rank"`
+}
+
+func (q *Queries) SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error) {
+	rows, err := q.db.Query(ctx, searchProducts,
+		arg.CategoryID,
+		arg.Query,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.InStock,
+		arg.CursorID,
+		arg.SortBy,
+		arg.CursorPrice,
+		arg.CursorCreatedAt,
+		arg.CursorRank,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchProductsRow
+	for rows.Next() {
+		var i SearchProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Quantity,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Backorderable,
+			&i.Preorderable,
+			&i.ReleaseDate,
+			&i.BackorderLimit,
+			&i.Description,
+			&i.ArchivedAt,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateProduct = `-- name: UpdateProduct :one
-update products set name = $1, price = $2, quantity = $3, updated_at = now()
-where id = $4 returning id, name, price, quantity, created_at, updated_at
+update products set
+    name = $1,
+    price = $2,
+    quantity = $3,
+    backorderable = $4,
+    preorderable = $5,
+    release_date = $6,
+    backorder_limit = $7,
+    description = $8,
+    updated_at = now()
+where id = $9 returning id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at
 `
 
 type UpdateProductParams struct {
-	Name     string         `db:"name" json:"name"`
-	Price    pgtype.Numeric `db:"price" json:"price"`
-	Quantity int32          `db:"quantity" json:"quantity"`
-	ID       uuid.UUID      `db:"id" json:"id"`
+	Name           string             `db:"name" json:"name"`
+	Price          pgtype.Numeric     `db:"price" json:"price"`
+	Quantity       int32              `db:"quantity" json:"quantity"`
+	Backorderable  bool               `db:"backorderable" json:"backorderable"`
+	Preorderable   bool               `db:"preorderable" json:"preorderable"`
+	ReleaseDate    pgtype.Timestamptz `db:"release_date" json:"release_date"`
+	BackorderLimit int32              `db:"backorder_limit" json:"backorder_limit"`
+	Description    string             `db:"description" json:"description"`
+	ID             uuid.UUID          `db:"id" json:"id"`
 }
 
 func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
@@ -255,6 +776,11 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		arg.Name,
 		arg.Price,
 		arg.Quantity,
+		arg.Backorderable,
+		arg.Preorderable,
+		arg.ReleaseDate,
+		arg.BackorderLimit,
+		arg.Description,
 		arg.ID,
 	)
 	var i Product
@@ -265,22 +791,29 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		&i.Quantity,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
 
-const updateProductQuantity = `-- name: UpdateProductQuantity :one
-update products set quantity = $2
-where id = $1 returning id, name, price, quantity, created_at, updated_at
+const updateProductQuantityFromInventory = `-- name: UpdateProductQuantityFromInventory :one
+update products set
+    quantity = (
+        select coalesce(sum(il.quantity), 0)::integer
+        from inventory_levels as il
+        where il.product_id = $1
+    ),
+    updated_at = now()
+where id = $1 returning id, name, price, quantity, created_at, updated_at, backorderable, preorderable, release_date, backorder_limit, description, archived_at
 `
 
-type UpdateProductQuantityParams struct {
-	ID       uuid.UUID `db:"id" json:"id"`
-	Quantity int32     `db:"quantity" json:"quantity"`
-}
-
-func (q *Queries) UpdateProductQuantity(ctx context.Context, arg UpdateProductQuantityParams) (Product, error) {
-	row := q.db.QueryRow(ctx, updateProductQuantity, arg.ID, arg.Quantity)
+func (q *Queries) UpdateProductQuantityFromInventory(ctx context.Context, productID uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductQuantityFromInventory, productID)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -289,6 +822,12 @@ func (q *Queries) UpdateProductQuantity(ctx context.Context, arg UpdateProductQu
 		&i.Quantity,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Backorderable,
+		&i.Preorderable,
+		&i.ReleaseDate,
+		&i.BackorderLimit,
+		&i.Description,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
@@ -8,42 +8,149 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	ActivateProductPrices(ctx context.Context) ([]Product, error)
+	AddProductRating(ctx context.Context, arg AddProductRatingParams) error
+	AdjustInventoryLevel(ctx context.Context, arg AdjustInventoryLevelParams) (InventoryLevel, error)
+	ArchiveProduct(ctx context.Context, id uuid.UUID) (Product, error)
+	CancelOrder(ctx context.Context, arg CancelOrderParams) (Order, error)
+	DecreaseInventoryLevels(ctx context.Context, arg DecreaseInventoryLevelsParams) error
+	DecreaseProductQuantity(ctx context.Context, arg DecreaseProductQuantityParams) (Product, error)
 	DeleteCartByIdAndUserId(ctx context.Context, arg DeleteCartByIdAndUserIdParams) (Cart, error)
 	DeleteCartItemFromCartsById(ctx context.Context, arg DeleteCartItemFromCartsByIdParams) (CartItem, error)
+	DeleteCartItemsByCartId(ctx context.Context, cartID uuid.UUID) error
+	DeleteCategory(ctx context.Context, id uuid.UUID) (Category, error)
+	DeleteExpiredStockReservations(ctx context.Context) (int64, error)
 	DeleteOrderItemFromOrdersById(ctx context.Context, id uuid.UUID) (OrderItem, error)
-	DeleteProduct(ctx context.Context, id uuid.UUID) (Product, error)
+	DeletePendingBackordersByOrderIds(ctx context.Context, dollar_1 []uuid.UUID) error
+	DeletePendingShipmentsByOrderIds(ctx context.Context, dollar_1 []uuid.UUID) error
+	DeleteProductCategories(ctx context.Context, productID uuid.UUID) error
+	DeleteProductCoPurchases(ctx context.Context) error
+	DeleteProductMedia(ctx context.Context, arg DeleteProductMediaParams) (ProductMedium, error)
+	DeleteProductPrice(ctx context.Context, id uuid.UUID) error
+	DeleteProductVariant(ctx context.Context, arg DeleteProductVariantParams) (ProductVariant, error)
+	DeleteStockReservationsByCartIds(ctx context.Context, dollar_1 []uuid.UUID) error
+	ExpireOrders(ctx context.Context, arg ExpireOrdersParams) ([]Order, error)
+	FindActiveCartByUserId(ctx context.Context, userID uuid.UUID) (FindActiveCartByUserIdRow, error)
+	FindActiveCartIdByUserIdForUpdate(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	FindAllocatableBackorders(ctx context.Context, limit int32) ([]Backorder, error)
+	FindApprovedProductReviews(ctx context.Context, arg FindApprovedProductReviewsParams) ([]ProductReview, error)
+	FindArchivedProductIds(ctx context.Context, dollar_1 []uuid.UUID) ([]uuid.UUID, error)
 	FindByEmail(ctx context.Context, email string) (User, error)
 	FindById(ctx context.Context, id uuid.UUID) (User, error)
 	FindCartById(ctx context.Context, arg FindCartByIdParams) (FindCartByIdRow, error)
 	FindCartByUserId(ctx context.Context, id uuid.UUID) ([]FindCartByUserIdRow, error)
 	FindCartItemByCartId(ctx context.Context, cartID uuid.UUID) ([]CartItem, error)
 	FindCartItemById(ctx context.Context, id uuid.UUID) (CartItem, error)
+	FindCartsByUserId(ctx context.Context, arg FindCartsByUserIdParams) ([]FindCartsByUserIdRow, error)
+	FindCategories(ctx context.Context) ([]Category, error)
+	FindCategoryBestsellers(ctx context.Context, arg FindCategoryBestsellersParams) ([]Product, error)
+	FindCategoryById(ctx context.Context, id uuid.UUID) (Category, error)
+	FindCategoryDescendantIds(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+	FindCompletedOrderIdByProduct(ctx context.Context, arg FindCompletedOrderIdByProductParams) (uuid.UUID, error)
+	FindCurrentProductPrices(ctx context.Context, productIds []uuid.UUID) ([]ProductPrice, error)
+	FindDefaultWarehouse(ctx context.Context) (Warehouse, error)
+	FindExchangeRates(ctx context.Context, baseCurrency string) ([]ExchangeRate, error)
+	FindFrequentlyBoughtTogether(ctx context.Context, arg FindFrequentlyBoughtTogetherParams) ([]Product, error)
+	FindInventoryLevelsByProductId(ctx context.Context, productID uuid.UUID) ([]InventoryLevel, error)
+	FindInventoryLevelsByProductIdsForUpdate(ctx context.Context, dollar_1 []uuid.UUID) ([]InventoryLevel, error)
+	FindInventoryMovementsByProductId(ctx context.Context, arg FindInventoryMovementsByProductIdParams) ([]InventoryMovement, error)
+	FindNextProductPriceChange(ctx context.Context) (pgtype.Timestamptz, error)
+	FindOpenProductPricesForUpdate(ctx context.Context, arg FindOpenProductPricesForUpdateParams) ([]ProductPrice, error)
 	FindOrderById(ctx context.Context, arg FindOrderByIdParams) (FindOrderByIdRow, error)
 	FindOrderByUserId(ctx context.Context, userID uuid.UUID) ([]Order, error)
+	FindOrderItemAllocationsByOrderId(ctx context.Context, orderID uuid.UUID) ([]FindOrderItemAllocationsByOrderIdRow, error)
+	FindOrderItemAllocationsByOrderIds(ctx context.Context, dollar_1 []uuid.UUID) ([]FindOrderItemAllocationsByOrderIdsRow, error)
 	FindOrderItemById(ctx context.Context, id uuid.UUID) ([]OrderItem, error)
 	FindOrderItemByIdAndUserId(ctx context.Context, arg FindOrderItemByIdAndUserIdParams) ([]OrderItem, error)
+	FindOrderItemsByOrderIds(ctx context.Context, dollar_1 []uuid.UUID) ([]OrderItem, error)
 	FindOrderUserId(ctx context.Context, id uuid.UUID) ([]Order, error)
+	FindOrdersByUserId(ctx context.Context, arg FindOrdersByUserIdParams) ([]Order, error)
+	FindPendingBackorderQuantities(ctx context.Context, dollar_1 []uuid.UUID) ([]FindPendingBackorderQuantitiesRow, error)
+	FindPresentmentRates(ctx context.Context, arg FindPresentmentRatesParams) ([]FindPresentmentRatesRow, error)
+	FindProductBreadcrumbs(ctx context.Context, productIds []uuid.UUID) ([]FindProductBreadcrumbsRow, error)
 	FindProductById(ctx context.Context, id uuid.UUID) (Product, error)
+	FindProductByIdForUpdate(ctx context.Context, id uuid.UUID) (Product, error)
 	FindProductByIdLock(ctx context.Context, id uuid.UUID) (Product, error)
 	FindProductByName(ctx context.Context, name string) (Product, error)
+	FindProductByNameForUpdate(ctx context.Context, name string) (Product, error)
+	FindProductMediaByProductIds(ctx context.Context, dollar_1 []uuid.UUID) ([]ProductMedium, error)
+	FindProductPriceAt(ctx context.Context, arg FindProductPriceAtParams) (ProductPrice, error)
+	FindProductPricesByProductId(ctx context.Context, productID uuid.UUID) ([]ProductPrice, error)
+	FindProductRatingsByProductIds(ctx context.Context, dollar_1 []uuid.UUID) ([]ProductRating, error)
+	FindProductReviewByIdForUpdate(ctx context.Context, arg FindProductReviewByIdForUpdateParams) (ProductReview, error)
+	FindProductStockAlert(ctx context.Context, productID uuid.UUID) (ProductStockAlert, error)
+	FindProductVariantBySkuForUpdate(ctx context.Context, sku string) (ProductVariant, error)
+	FindProductVariantsByIds(ctx context.Context, dollar_1 []uuid.UUID) ([]ProductVariant, error)
+	FindProductVariantsByIdsForUpdate(ctx context.Context, dollar_1 []uuid.UUID) ([]ProductVariant, error)
+	FindProductVariantsByProductIds(ctx context.Context, dollar_1 []uuid.UUID) ([]ProductVariant, error)
 	FindProducts(ctx context.Context) ([]Product, error)
+	FindProductsAfterName(ctx context.Context, arg FindProductsAfterNameParams) ([]Product, error)
 	FindProductsByIds(ctx context.Context, dollar_1 []uuid.UUID) ([]Product, error)
+	FindProductsByIdsForUpdate(ctx context.Context, dollar_1 []uuid.UUID) ([]Product, error)
 	FindProductsByIdsLock(ctx context.Context, dollar_1 []uuid.UUID) ([]Product, error)
+	FindProductsUpdatedAfter(ctx context.Context, arg FindProductsUpdatedAfterParams) ([]Product, error)
+	FindReservedQuantities(ctx context.Context, arg FindReservedQuantitiesParams) ([]FindReservedQuantitiesRow, error)
+	FindReservedVariantQuantities(ctx context.Context, arg FindReservedVariantQuantitiesParams) ([]FindReservedVariantQuantitiesRow, error)
+	FindShipmentsByOrderId(ctx context.Context, arg FindShipmentsByOrderIdParams) ([]Shipment, error)
+	FindStockAdjustmentByIdempotencyKey(ctx context.Context, arg FindStockAdjustmentByIdempotencyKeyParams) (StockAdjustment, error)
+	FindStockAlertStatesForUpdate(ctx context.Context, productIds []uuid.UUID) ([]FindStockAlertStatesForUpdateRow, error)
+	FindStockAsOf(ctx context.Context, arg FindStockAsOfParams) (int32, error)
+	FindWarehousedQuantity(ctx context.Context, productID uuid.UUID) (int32, error)
+	FindWarehouses(ctx context.Context) ([]Warehouse, error)
 	GetOrders(ctx context.Context, dollar_1 []uuid.UUID) ([]GetOrdersRow, error)
 	GetProducts(ctx context.Context) ([]Product, error)
+	IncreaseInventoryLevels(ctx context.Context, arg IncreaseInventoryLevelsParams) error
+	IncreaseProductQuantities(ctx context.Context, arg IncreaseProductQuantitiesParams) error
+	IncreaseProductVariantQuantities(ctx context.Context, arg IncreaseProductVariantQuantitiesParams) error
+	IncrementProductReviewHelpfulCount(ctx context.Context, id uuid.UUID) (ProductReview, error)
+	InsertActiveCart(ctx context.Context, arg InsertActiveCartParams) (uuid.UUID, error)
+	InsertBackorders(ctx context.Context, arg []InsertBackordersParams) (int64, error)
 	InsertCart(ctx context.Context, userID uuid.UUID) (Cart, error)
 	InsertCartItem(ctx context.Context, arg InsertCartItemParams) (CartItem, error)
 	InsertCartItems(ctx context.Context, arg []InsertCartItemsParams) (int64, error)
+	InsertCategory(ctx context.Context, arg InsertCategoryParams) (Category, error)
+	InsertDeadLetterOrder(ctx context.Context, arg InsertDeadLetterOrderParams) (DeadLetterOrder, error)
+	InsertInventoryMovements(ctx context.Context, arg []InsertInventoryMovementsParams) (int64, error)
 	InsertOrder(ctx context.Context, arg InsertOrderParams) (Order, error)
 	InsertOrderItem(ctx context.Context, arg []InsertOrderItemParams) (int64, error)
+	InsertOrderItemAllocations(ctx context.Context, arg []InsertOrderItemAllocationsParams) (int64, error)
 	InsertOrders(ctx context.Context, arg []InsertOrdersParams) (int64, error)
 	InsertProduct(ctx context.Context, arg InsertProductParams) (Product, error)
+	InsertProductCategories(ctx context.Context, arg InsertProductCategoriesParams) error
+	InsertProductCoPurchases(ctx context.Context, since pgtype.Timestamptz) (int64, error)
+	InsertProductMedia(ctx context.Context, arg InsertProductMediaParams) (ProductMedium, error)
+	InsertProductPrice(ctx context.Context, arg InsertProductPriceParams) (ProductPrice, error)
+	InsertProductReview(ctx context.Context, arg InsertProductReviewParams) (ProductReview, error)
+	InsertProductReviewVote(ctx context.Context, arg InsertProductReviewVoteParams) (int64, error)
+	InsertProductVariant(ctx context.Context, arg InsertProductVariantParams) (ProductVariant, error)
+	InsertShipments(ctx context.Context, arg []InsertShipmentsParams) (int64, error)
+	InsertStockAdjustment(ctx context.Context, arg InsertStockAdjustmentParams) (StockAdjustment, error)
+	InsertStockReservations(ctx context.Context, arg []InsertStockReservationsParams) (int64, error)
 	InsertUser(ctx context.Context, arg InsertUserParams) (User, error)
+	InsertWarehouse(ctx context.Context, arg InsertWarehouseParams) (Warehouse, error)
+	RestoreProduct(ctx context.Context, id uuid.UUID) (Product, error)
+	SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error)
+	SyncDefaultInventoryLevel(ctx context.Context, id uuid.UUID) error
+	TouchCart(ctx context.Context, id uuid.UUID) error
+	UpdateAllocatedOrders(ctx context.Context, dollar_1 []uuid.UUID) ([]Order, error)
+	UpdateBackordersAllocated(ctx context.Context, dollar_1 []uuid.UUID) error
+	UpdateCartItemQuantity(ctx context.Context, arg UpdateCartItemQuantityParams) (CartItem, error)
+	UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error)
+	UpdatePreferredCurrency(ctx context.Context, arg UpdatePreferredCurrencyParams) (User, error)
 	UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error)
-	UpdateProductQuantity(ctx context.Context, arg UpdateProductQuantityParams) (Product, error)
+	UpdateProductPriceRange(ctx context.Context, arg UpdateProductPriceRangeParams) (ProductPrice, error)
+	UpdateProductQuantityFromInventory(ctx context.Context, productID uuid.UUID) (Product, error)
+	UpdateProductReviewStatus(ctx context.Context, arg UpdateProductReviewStatusParams) (ProductReview, error)
+	UpdateProductVariant(ctx context.Context, arg UpdateProductVariantParams) (ProductVariant, error)
+	UpdateStockLevel(ctx context.Context, arg UpdateStockLevelParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error)
+	UpsertInventoryLevel(ctx context.Context, arg UpsertInventoryLevelParams) (InventoryLevel, error)
+	UpsertLowStockThreshold(ctx context.Context, arg UpsertLowStockThresholdParams) (ProductStockAlert, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -13,7 +13,7 @@ import (
 )
 
 const findByEmail = `-- name: FindByEmail :one
-select id, username, email, password, created_at, updated_at from users
+select id, username, email, password, created_at, updated_at, preferred_currency, role from users
 where email = $1
 `
 
@@ -27,12 +27,14 @@ func (q *Queries) FindByEmail(ctx context.Context, email string) (User, error) {
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PreferredCurrency,
+		&i.Role,
 	)
 	return i, err
 }
 
 const findById = `-- name: FindById :one
-select id, username, email, password, created_at, updated_at from users
+select id, username, email, password, created_at, updated_at, preferred_currency, role from users
 where id = $1
 `
 
@@ -46,14 +48,58 @@ func (q *Queries) FindById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PreferredCurrency,
+		&i.Role,
 	)
 	return i, err
 }
 
+const findPresentmentRates = `-- name: FindPresentmentRates :many
+select
+    u.id as user_id,
+    u.preferred_currency::varchar as currency,
+    r.rate
+from users as u
+inner join exchange_rates as r
+    on r.base_currency = $1 and u.preferred_currency = r.quote_currency
+where u.id = any($2::uuid [])
+`
+
+type FindPresentmentRatesParams struct {
+	BaseCurrency string      `db:"base_currency" json:"base_currency"`
+	UserIds      []uuid.UUID `db:"user_ids" json:"user_ids"`
+}
+
+type FindPresentmentRatesRow struct {
+	UserID   uuid.UUID      `db:"user_id" json:"user_id"`
+	Currency string         `db:"currency" json:"currency"`
+	Rate     pgtype.Numeric `db:"rate" json:"rate"`
+}
+
+func (q *Queries) FindPresentmentRates(ctx context.Context, arg FindPresentmentRatesParams) ([]FindPresentmentRatesRow, error) {
+	rows, err := q.db.Query(ctx, findPresentmentRates, arg.BaseCurrency, arg.UserIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindPresentmentRatesRow
+	for rows.Next() {
+		var i FindPresentmentRatesRow
+		if err := rows.Scan(&i.UserID, &i.Currency, &i.Rate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const insertUser = `-- name: InsertUser :one
 insert into users (username, email, password, created_at, updated_at) values (
     $1, $2, $3, $4, $5
-) returning id, username, email, password, created_at, updated_at
+) returning id, username, email, password, created_at, updated_at, preferred_currency, role
 `
 
 type InsertUserParams struct {
@@ -80,6 +126,62 @@ func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) (User, e
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PreferredCurrency,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updatePreferredCurrency = `-- name: UpdatePreferredCurrency :one
+update users set preferred_currency = $2, updated_at = now()
+where id = $1
+returning id, username, email, password, created_at, updated_at, preferred_currency, role
+`
+
+type UpdatePreferredCurrencyParams struct {
+	ID                uuid.UUID   `db:"id" json:"id"`
+	PreferredCurrency pgtype.Text `db:"preferred_currency" json:"preferred_currency"`
+}
+
+func (q *Queries) UpdatePreferredCurrency(ctx context.Context, arg UpdatePreferredCurrencyParams) (User, error) {
+	row := q.db.QueryRow(ctx, updatePreferredCurrency, arg.ID, arg.PreferredCurrency)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PreferredCurrency,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+update users set role = $2, updated_at = now()
+where id = $1
+returning id, username, email, password, created_at, updated_at, preferred_currency, role
+`
+
+type UpdateUserRoleParams struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Role UserRole  `db:"role" json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PreferredCurrency,
+		&i.Role,
 	)
 	return i, err
 }
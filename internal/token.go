@@ -14,6 +14,13 @@ import (
 	"github.com/Alturino/ecommerce/internal/otel"
 )
 
+// Claims are the claims of the tokens the user service issues on login, Role is
+// the role of the user when the token was issued.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role"`
+}
+
 func VerifyToken(c context.Context, token string) (*jwt.Token, error) {
 	c, span := otel.Tracer.Start(c, "VerifyToken")
 	defer span.End()
@@ -33,7 +40,7 @@ func VerifyToken(c context.Context, token string) (*jwt.Token, error) {
 	logger = logger.With().Str(constants.KEY_PROCESS, "parsing claims").Logger()
 	logger.Trace().Msg("parsing claims")
 	jwtToken, err := jwt.ParseWithClaims(token,
-		&jwt.RegisteredClaims{},
+		&Claims{},
 		func(t *jwt.Token) (interface{}, error) {
 			return []byte(cfg.SecretKey), nil
 		},
@@ -72,7 +79,8 @@ func AttachJwtToken(c context.Context, jwt *jwt.Token) context.Context {
 }
 
 func JwtTokenFromContext(c context.Context) *jwt.Token {
-	return c.Value(jwtToken{}).(*jwt.Token)
+	token, _ := c.Value(jwtToken{}).(*jwt.Token)
+	return token
 }
 
 func UserIdFromJwtToken(c context.Context) (uuid.UUID, error) {
@@ -85,6 +93,12 @@ func UserIdFromJwtToken(c context.Context) (uuid.UUID, error) {
 	logger.Trace().Msg("getting jwtToken from context")
 	span.AddEvent("getting jwtToken from context")
 	jwt := JwtTokenFromContext(c)
+	if jwt == nil {
+		err := fmt.Errorf("failed getting jwtToken from context with error=%w", errors.ErrTokenInvalid)
+		otel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return uuid.Nil, err
+	}
 	subject, err := jwt.Claims.GetSubject()
 	if err != nil {
 		err = fmt.Errorf("failed getting subject from jwt with error=%w", err)
@@ -110,3 +124,73 @@ func UserIdFromJwtToken(c context.Context) (uuid.UUID, error) {
 
 	return userId, nil
 }
+
+// RoleFromJwtToken returns the role claimed by the token attached to c, tokens
+// issued before roles existed are treated as customer tokens.
+func RoleFromJwtToken(c context.Context) (string, error) {
+	c, span := otel.Tracer.Start(c, "RoleFromJwtToken")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).With().Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting role from jwtToken").Logger()
+	logger.Trace().Msg("getting jwtToken from context")
+	span.AddEvent("getting jwtToken from context")
+	jwt := JwtTokenFromContext(c)
+	if jwt == nil {
+		err := fmt.Errorf("failed getting jwtToken from context with error=%w", errors.ErrTokenInvalid)
+		otel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return "", err
+	}
+	claims, ok := jwt.Claims.(*Claims)
+	if !ok {
+		err := fmt.Errorf("failed getting claims from jwt with error=%w", errors.ErrTokenInvalid)
+		otel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return "", err
+	}
+	role := claims.Role
+	if role == "" {
+		role = constants.ROLE_CUSTOMER
+	}
+	span.AddEvent("got role from jwtToken")
+	logger = logger.With().Str(constants.KEY_ROLE, role).Logger()
+	logger.Info().Msg("got role from jwtToken")
+
+	return role, nil
+}
+
+// Owner is the user a request acts for, derived from its verified token. Admin
+// requests are not scoped to an owner and may access the resources of every
+// user.
+type Owner struct {
+	UserId uuid.UUID
+	Admin  bool
+}
+
+// OwnerFromJwtToken returns the owner of the token attached to c.
+func OwnerFromJwtToken(c context.Context) (Owner, error) {
+	userId, err := UserIdFromJwtToken(c)
+	if err != nil {
+		return Owner{}, err
+	}
+	role, err := RoleFromJwtToken(c)
+	if err != nil {
+		return Owner{}, err
+	}
+	return Owner{UserId: userId, Admin: role == constants.ROLE_ADMIN}, nil
+}
+
+// Owns reports whether o may access the resources of userId.
+func (o Owner) Owns(userId uuid.UUID) bool {
+	return o.Admin || o.UserId == userId
+}
+
+// Scope returns the user id queries of o are scoped to, uuid.Nil for admins.
+func (o Owner) Scope() uuid.UUID {
+	if o.Admin {
+		return uuid.Nil
+	}
+	return o.UserId
+}
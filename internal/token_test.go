@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+)
+
+func TestOwnerFromJwtToken(t *testing.T) {
+	userId, otherId := uuid.New(), uuid.New()
+	token := func(role string) context.Context {
+		return AttachJwtToken(context.Background(), &jwt.Token{Claims: &Claims{
+			RegisteredClaims: jwt.RegisteredClaims{Subject: userId.String()},
+			Role:             role,
+		}})
+	}
+
+	customer, err := OwnerFromJwtToken(token(constants.ROLE_CUSTOMER))
+	assert.NoError(t, err)
+	assert.True(t, customer.Owns(userId))
+	assert.False(t, customer.Owns(otherId), "customer should not access other users")
+	assert.Equal(t, userId, customer.Scope())
+
+	staff, err := OwnerFromJwtToken(token(constants.ROLE_MERCHANT_STAFF))
+	assert.NoError(t, err)
+	assert.False(t, staff.Owns(otherId), "only admins bypass ownership")
+
+	admin, err := OwnerFromJwtToken(token(constants.ROLE_ADMIN))
+	assert.NoError(t, err)
+	assert.True(t, admin.Owns(otherId))
+	assert.Equal(t, uuid.Nil, admin.Scope())
+
+	_, err = OwnerFromJwtToken(context.Background())
+	assert.Error(t, err)
+}
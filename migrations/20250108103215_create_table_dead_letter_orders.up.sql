@@ -0,0 +1,11 @@
+create table if not exists dead_letter_orders (
+    id uuid primary key not null default (gen_random_uuid()),
+    order_id uuid not null,
+    user_id uuid not null,
+    payload jsonb not null default '{}',
+    error text not null default '',
+    attempts integer not null default 0,
+    created_at timestamptz not null default current_timestamp
+);
+
+create index if not exists idx_dead_letter_orders_order_id on dead_letter_orders (order_id);
@@ -0,0 +1,22 @@
+alter type order_status add value if not exists 'BACKORDERED';
+alter type order_status add value if not exists 'PREORDER';
+
+alter table products
+add column if not exists backorderable boolean not null default false,
+add column if not exists preorderable boolean not null default false,
+add column if not exists release_date timestamptz,
+add column if not exists backorder_limit integer not null default 0;
+
+create table if not exists backorders (
+    id uuid primary key not null default (gen_random_uuid()),
+    order_id uuid not null references orders (id) on delete cascade,
+    order_item_id uuid not null references order_items (id) on delete cascade,
+    product_id uuid not null references products (id),
+    quantity integer not null,
+    allocated_at timestamptz,
+    created_at timestamptz not null default current_timestamp
+);
+
+create index if not exists idx_backorders_pending on backorders (
+    product_id, created_at
+) where allocated_at is null;
@@ -0,0 +1,8 @@
+drop index if exists idx_order_item_allocations_order_item_id;
+drop table if exists order_item_allocations;
+drop index if exists idx_shipments_order_id;
+drop table if exists shipments;
+drop table if exists inventory_levels;
+drop index if exists idx_warehouses_default;
+drop table if exists warehouses;
+drop type if exists shipment_status;
@@ -0,0 +1,5 @@
+drop trigger if exists trg_inventory_movements_append_only on inventory_movements;
+drop function if exists reject_inventory_movement_change;
+drop index if exists idx_inventory_movements_product_id_created_at;
+drop table if exists inventory_movements;
+drop type if exists inventory_movement_reason;
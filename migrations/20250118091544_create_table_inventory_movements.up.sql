@@ -0,0 +1,37 @@
+create type inventory_movement_reason as enum (
+    'SALE', 'CANCEL', 'EXPIRE', 'RESTOCK', 'ADJUSTMENT', 'RETURN'
+);
+
+create table if not exists inventory_movements (
+    id uuid primary key not null default (gen_random_uuid()),
+    product_id uuid not null,
+    warehouse_id uuid null references warehouses (id),
+    quantity integer not null check (quantity <> 0),
+    reason inventory_movement_reason not null,
+    reference_id uuid null,
+    actor varchar(128) not null,
+    created_at timestamptz not null default current_timestamp
+);
+
+create index if not exists idx_inventory_movements_product_id_created_at on inventory_movements (
+    product_id, created_at
+);
+
+create or replace function reject_inventory_movement_change() returns trigger as $$
+begin
+    raise exception 'inventory_movements is append-only';
+end;
+$$ language plpgsql;
+
+create or replace trigger trg_inventory_movements_append_only
+before update or delete on inventory_movements
+for each row execute function reject_inventory_movement_change();
+
+insert into inventory_movements (product_id, quantity, reason, actor)
+select
+    id,
+    quantity,
+    'ADJUSTMENT',
+    'migration'
+from products
+where quantity <> 0;
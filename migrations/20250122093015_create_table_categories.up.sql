@@ -0,0 +1,21 @@
+create table if not exists categories (
+    id uuid primary key not null default (gen_random_uuid()),
+    parent_id uuid null references categories (id) on delete restrict,
+    name varchar(128) not null,
+    slug varchar(128) unique not null,
+    position integer not null default 0,
+    created_at timestamptz not null default current_timestamp,
+    updated_at timestamptz not null default current_timestamp,
+    check (parent_id <> id)
+);
+
+create index if not exists idx_categories_parent_id_position on categories (parent_id, position);
+
+create table if not exists product_categories (
+    product_id uuid not null references products (id) on delete cascade,
+    category_id uuid not null references categories (id) on delete cascade,
+    created_at timestamptz not null default current_timestamp,
+    primary key (product_id, category_id)
+);
+
+create index if not exists idx_product_categories_category_id on product_categories (category_id);
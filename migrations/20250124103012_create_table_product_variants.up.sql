@@ -0,0 +1,18 @@
+create table if not exists product_variants (
+    id uuid primary key not null default (gen_random_uuid()),
+    product_id uuid not null references products (id) on delete cascade,
+    sku varchar(64) unique not null,
+    options jsonb not null default '{}',
+    price numeric null check (price >= 0),
+    quantity integer not null default 0 check (quantity >= 0),
+    created_at timestamptz not null default current_timestamp,
+    updated_at timestamptz not null default current_timestamp
+);
+
+create index if not exists idx_product_variants_product_id on product_variants (product_id);
+
+alter table cart_items add column if not exists variant_id uuid null references product_variants (id) on delete cascade;
+
+alter table order_items add column if not exists variant_id uuid null references product_variants (id);
+
+alter table stock_reservations add column if not exists variant_id uuid null references product_variants (id) on delete cascade;
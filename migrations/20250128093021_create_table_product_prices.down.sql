@@ -0,0 +1,3 @@
+drop index if exists idx_product_prices_effective_from;
+drop index if exists idx_product_prices_product_id;
+drop table if exists product_prices;
@@ -0,0 +1,19 @@
+create table if not exists product_prices (
+    id uuid primary key not null default (gen_random_uuid()),
+    product_id uuid not null references products (id) on delete cascade,
+    price numeric not null check (price >= 0),
+    effective_from timestamptz not null,
+    effective_to timestamptz,
+    created_at timestamptz not null default current_timestamp,
+    check (effective_to is null or effective_to > effective_from)
+);
+
+create index if not exists idx_product_prices_product_id on product_prices (product_id, effective_from);
+create index if not exists idx_product_prices_effective_from on product_prices (effective_from);
+
+insert into product_prices (product_id, price, effective_from)
+select
+    id,
+    price,
+    created_at
+from products;
@@ -0,0 +1,2 @@
+drop table if exists product_stock_alerts;
+drop type if exists stock_level;
@@ -0,0 +1,8 @@
+create type stock_level as enum ('IN_STOCK', 'LOW_STOCK', 'SOLD_OUT');
+
+create table if not exists product_stock_alerts (
+    product_id uuid primary key not null references products (id) on delete cascade,
+    low_stock_threshold integer null check (low_stock_threshold >= 0),
+    level stock_level not null default 'IN_STOCK',
+    updated_at timestamptz not null default current_timestamp
+);
@@ -0,0 +1,2 @@
+drop index if exists idx_product_co_purchases_product_id_score;
+drop table if exists product_co_purchases;
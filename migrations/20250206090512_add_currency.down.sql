@@ -0,0 +1,12 @@
+alter table order_items drop column if exists currency;
+
+alter table orders
+drop column if exists exchange_rate,
+drop column if exists presentment_currency,
+drop column if exists currency;
+
+alter table cart_items drop column if exists currency;
+
+alter table users drop column if exists preferred_currency;
+
+drop table if exists exchange_rates;
@@ -0,0 +1,18 @@
+create table if not exists exchange_rates (
+    base_currency varchar(3) not null,
+    quote_currency varchar(3) not null,
+    rate numeric not null check (rate > 0),
+    updated_at timestamptz not null default current_timestamp,
+    primary key (base_currency, quote_currency)
+);
+
+alter table users add column if not exists preferred_currency varchar(3);
+
+alter table cart_items add column if not exists currency varchar(3) not null default 'USD';
+
+alter table orders
+add column if not exists currency varchar(3) not null default 'USD',
+add column if not exists presentment_currency varchar(3) not null default 'USD',
+add column if not exists exchange_rate numeric not null default 1 check (exchange_rate > 0);
+
+alter table order_items add column if not exists currency varchar(3) not null default 'USD';
@@ -0,0 +1,3 @@
+alter table users drop column if exists role;
+
+drop type if exists user_role;
@@ -0,0 +1,2 @@
+drop index if exists idx_stock_adjustments_product_id_idempotency_key;
+drop table if exists stock_adjustments;
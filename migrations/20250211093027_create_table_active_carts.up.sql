@@ -0,0 +1,12 @@
+create table if not exists active_carts (
+    user_id uuid primary key not null references users (id) on delete cascade,
+    cart_id uuid not null unique references carts (id) on delete cascade
+);
+
+insert into active_carts (user_id, cart_id)
+select distinct on (user_id)
+    user_id,
+    id
+from carts
+order by user_id asc, created_at desc
+on conflict (user_id) do nothing;
@@ -0,0 +1,8 @@
+-- variant movements would otherwise count towards the stock of their product
+alter table inventory_movements disable trigger trg_inventory_movements_append_only;
+delete from inventory_movements where variant_id is not null;
+alter table inventory_movements enable trigger trg_inventory_movements_append_only;
+
+drop index if exists idx_inventory_movements_variant_id_created_at;
+
+alter table inventory_movements drop column if exists variant_id;
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/log"
+	"github.com/Alturino/ecommerce/notification/internal/service"
+	orderResponse "github.com/Alturino/ecommerce/order/pkg/response"
+	productResponse "github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+type OrderAllocatedListener struct {
+	svc   *service.NotificationService
+	cache *redis.Client
+}
+
+func NewOrderAllocatedListener(
+	svc *service.NotificationService,
+	cache *redis.Client,
+) *OrderAllocatedListener {
+	return &OrderAllocatedListener{svc: svc, cache: cache}
+}
+
+func (l OrderAllocatedListener) StartListener(c context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Str(constants.KEY_TAG, "OrderAllocatedListener StartListener").
+		Str(constants.KEY_PROCESS, "listening order allocated").
+		Logger()
+
+	pubsub := l.cache.Subscribe(c, constants.ORDER_ALLOCATED)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			reqId := uuid.NewString()
+			lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+			lg.Trace().Msg("received order allocated")
+
+			event := orderResponse.OrderAllocated{}
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				err = fmt.Errorf("failed unmarshaling order allocated with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+				continue
+			}
+
+			ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+			if err := l.svc.NotifyOrderAllocated(ctx, event); err != nil {
+				err = fmt.Errorf("failed notifying order allocated with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+				continue
+			}
+			lg.Info().Msg("notified order allocated")
+		}
+	}
+}
+
+type StockAlertListener struct {
+	svc   *service.NotificationService
+	cache *redis.Client
+}
+
+func NewStockAlertListener(
+	svc *service.NotificationService,
+	cache *redis.Client,
+) *StockAlertListener {
+	return &StockAlertListener{svc: svc, cache: cache}
+}
+
+func (l StockAlertListener) StartListener(c context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Str(constants.KEY_TAG, "StockAlertListener StartListener").
+		Str(constants.KEY_PROCESS, "listening stock alert").
+		Logger()
+
+	pubsub := l.cache.Subscribe(c, constants.STOCK_ALERT)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			reqId := uuid.NewString()
+			lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+			lg.Trace().Msg("received stock alert")
+
+			alert := productResponse.StockAlert{}
+			if err := json.Unmarshal([]byte(msg.Payload), &alert); err != nil {
+				err = fmt.Errorf("failed unmarshaling stock alert with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+				continue
+			}
+
+			ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+			if err := l.svc.NotifyStockAlert(ctx, alert); err != nil {
+				err = fmt.Errorf("failed notifying stock alert with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+				continue
+			}
+			lg.Info().Msg("notified stock alert")
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -15,9 +16,13 @@ import (
 
 	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/infra"
 	"github.com/Alturino/ecommerce/internal/log"
 	"github.com/Alturino/ecommerce/internal/middleware"
 	"github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/notification/internal/notifier"
+	"github.com/Alturino/ecommerce/notification/internal/service"
 )
 
 func RunNotificationService(c context.Context) {
@@ -46,6 +51,50 @@ func RunNotificationService(c context.Context) {
 	}
 	logger.Info().Msg("initialized otel sdk")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing database").Logger()
+	logger.Info().Msg("initializing database")
+	db := infra.NewDatabaseClient(c, cfg.Database)
+	defer func() {
+		logger = logger.With().Str(constants.KEY_PROCESS, "closing database").Logger()
+		logger.Info().Msg("closing database")
+		db.Close()
+		logger.Info().Msg("closed database")
+	}()
+	queries := repository.New(db)
+	logger.Info().Msg("initialized database")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing cache").Logger()
+	logger.Info().Msg("initializing cache")
+	cache := infra.NewCacheClient(c, cfg.Cache)
+	defer func() {
+		logger = logger.With().Str(constants.KEY_PROCESS, "closing cache").Logger()
+		logger.Info().Msg("closing cache")
+		if err := cache.Close(); err != nil {
+			err = fmt.Errorf("failed closing cache with error=%w", err)
+			otel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return
+		}
+		logger.Info().Msg("closed cache")
+	}()
+	logger.Info().Msg("initialized cache")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing notification service").Logger()
+	logger.Info().Msg("initializing notification service")
+	notifiers, err := notifier.FromConfig(cfg.Notification.Alert)
+	if err != nil {
+		err = fmt.Errorf("failed initializing notifiers with error=%w", err)
+		otel.RecordError(err, span)
+		logger.Fatal().Err(err).Msg(err.Error())
+	}
+	notificationService := service.NewNotificationService(
+		queries,
+		cache,
+		notifiers,
+		cfg.Notification.Alert,
+	)
+	logger.Info().Int(constants.KEY_NOTIFIER, len(notifiers)).Msg("initialized notification service")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing router").Logger()
 	logger.Info().Msg("initializing router")
 	mux := mux.NewRouter()
@@ -92,7 +141,23 @@ func RunNotificationService(c context.Context) {
 		logger.Info().Msg("shutdown server")
 	}()
 
+	orderAllocatedListener := NewOrderAllocatedListener(notificationService, cache)
+	logger = logger.With().Str(constants.KEY_PROCESS, "start order allocated listener").Logger()
+	logger.Info().Msg("start order allocated listener")
+	span.AddEvent("start order allocated listener")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go orderAllocatedListener.StartListener(logger.WithContext(c), &wg)
+
+	stockAlertListener := NewStockAlertListener(notificationService, cache)
+	logger = logger.With().Str(constants.KEY_PROCESS, "start stock alert listener").Logger()
+	logger.Info().Msg("start stock alert listener")
+	span.AddEvent("start stock alert listener")
+	wg.Add(1)
+	go stockAlertListener.StartListener(logger.WithContext(c), &wg)
+
 	<-c.Done()
+	wg.Wait()
 	logger = logger.With().Str(constants.KEY_PROCESS, "shutdown server").Logger()
 	logger.Info().Msg("received interuption signal shutting down")
 
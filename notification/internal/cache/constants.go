@@ -0,0 +1,5 @@
+package cache
+
+const (
+	KEY_STOCK_ALERTS = "stock_alerts:product_id:%s:kind:%s"
+)
@@ -0,0 +1,116 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	orderResponse "github.com/Alturino/ecommerce/order/pkg/response"
+	productResponse "github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+type sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// Email sends alerts through an SMTP server. net/smtp does not take a
+// context, a slow server holds the alert until it answers.
+type Email struct {
+	config config.Smtp
+	send   sendMail
+}
+
+func NewEmail(cfg config.Smtp) (Email, error) {
+	if cfg.From == "" {
+		return Email{}, errors.New("from is required")
+	}
+	if len(cfg.Recipients) == 0 {
+		return Email{}, errors.New("recipients are required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 25
+	}
+	return Email{config: cfg, send: smtp.SendMail}, nil
+}
+
+func (e Email) Name() string {
+	return "email"
+}
+
+func (e Email) Notify(c context.Context, alert productResponse.StockAlert) error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	err := e.send(addr, auth, e.config.From, e.config.Recipients, e.message(alert))
+	if err != nil {
+		return fmt.Errorf("failed sending email to addr=%s with error=%w", addr, err)
+	}
+	return nil
+}
+
+// NotifyOrderAllocated mails the customer at email instead of the configured
+// recipients.
+func (e Email) NotifyOrderAllocated(
+	c context.Context,
+	email string,
+	event orderResponse.OrderAllocated,
+) error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	if email == "" {
+		return errors.New("email is required")
+	}
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	err := e.send(addr, auth, e.config.From, []string{email}, e.orderMessage(email, event))
+	if err != nil {
+		return fmt.Errorf("failed sending email to addr=%s with error=%w", addr, err)
+	}
+	return nil
+}
+
+func (e Email) message(alert productResponse.StockAlert) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.config.From)
+	for _, recipient := range e.config.Recipients {
+		fmt.Fprintf(&msg, "To: %s\r\n", recipient)
+	}
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject(alert))
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.At.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\n", subject(alert))
+	fmt.Fprintf(&msg, "Product: %s\r\n", alert.ProductId)
+	fmt.Fprintf(&msg, "Quantity: %d\r\n", alert.Quantity)
+	fmt.Fprintf(&msg, "Low stock threshold: %d\r\n", alert.Threshold)
+	return msg.Bytes()
+}
+
+func (e Email) orderMessage(email string, event orderResponse.OrderAllocated) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", orderSubject(event))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.AllocatedAt.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\n", orderSubject(event))
+	fmt.Fprintf(&msg, "Order: %s\r\n", event.ID)
+	fmt.Fprintf(&msg, "Status: %s\r\n", event.Status)
+	return msg.Bytes()
+}
@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	orderResponse "github.com/Alturino/ecommerce/order/pkg/response"
+	productResponse "github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+// Notifier delivers stock alerts to the recipients of one channel, and tells a
+// customer at email through the same channel that their order is allocated.
+type Notifier interface {
+	Name() string
+	Notify(c context.Context, alert productResponse.StockAlert) error
+	NotifyOrderAllocated(c context.Context, email string, event orderResponse.OrderAllocated) error
+}
+
+// FromConfig returns a notifier for every channel configured in cfg, a channel
+// without a host or url is left out.
+func FromConfig(cfg config.Alert) ([]Notifier, error) {
+	notifiers := []Notifier{}
+	if cfg.Smtp.Host != "" {
+		email, err := NewEmail(cfg.Smtp)
+		if err != nil {
+			return nil, fmt.Errorf("failed initializing email notifier with error=%w", err)
+		}
+		notifiers = append(notifiers, email)
+	}
+	if cfg.Webhook.Url != "" {
+		webhook, err := NewWebhook(cfg.Webhook, &http.Client{Timeout: cfg.Webhook.Timeout})
+		if err != nil {
+			return nil, fmt.Errorf("failed initializing webhook notifier with error=%w", err)
+		}
+		notifiers = append(notifiers, webhook)
+	}
+	return notifiers, nil
+}
+
+// subject returns the one line summary of an alert.
+func subject(alert productResponse.StockAlert) string {
+	switch alert.Kind {
+	case productResponse.StockAlertLowStock:
+		return fmt.Sprintf("Low stock: %s", alert.Name)
+	case productResponse.StockAlertSoldOut:
+		return fmt.Sprintf("Sold out: %s", alert.Name)
+	case productResponse.StockAlertBackInStock:
+		return fmt.Sprintf("Back in stock: %s", alert.Name)
+	}
+	return fmt.Sprintf("%s: %s", alert.Kind, alert.Name)
+}
+
+// orderSubject returns the one line summary of an allocated order.
+func orderSubject(event orderResponse.OrderAllocated) string {
+	return fmt.Sprintf("Order %s is ready for payment", event.ID)
+}
@@ -0,0 +1,141 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	orderResponse "github.com/Alturino/ecommerce/order/pkg/response"
+	productResponse "github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+func testAlert() productResponse.StockAlert {
+	return productResponse.StockAlert{
+		At:        time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC),
+		Kind:      productResponse.StockAlertLowStock,
+		Name:      "Mechanical Keyboard",
+		ProductId: uuid.New(),
+		Quantity:  3,
+		Threshold: 5,
+	}
+}
+
+func TestEmail(t *testing.T) {
+	email, err := NewEmail(config.Smtp{
+		Host:       "mailhog",
+		Port:       1025,
+		From:       "alerts@ecommerce.local",
+		Recipients: []string{"merchandising@ecommerce.local"},
+	})
+	require.NoError(t, err)
+
+	var sentAddr string
+	var sentAuth smtp.Auth
+	var sent []byte
+	email.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentAddr, sentAuth, sent = addr, a, msg
+		assert.Equal(t, "alerts@ecommerce.local", from)
+		assert.Equal(t, []string{"merchandising@ecommerce.local"}, to)
+		return nil
+	}
+
+	alert := testAlert()
+	require.NoError(t, email.Notify(context.Background(), alert))
+	assert.Equal(t, "mailhog:1025", sentAddr)
+	assert.Nil(t, sentAuth, "a server without username should not authenticate")
+	assert.Contains(t, string(sent), "Subject: Low stock: Mechanical Keyboard\r\n")
+	assert.Contains(t, string(sent), "Product: "+alert.ProductId.String())
+	assert.Contains(t, string(sent), "Quantity: 3\r\n")
+
+	_, err = NewEmail(config.Smtp{Host: "mailhog", From: "alerts@ecommerce.local"})
+	assert.Error(t, err, "an email without recipients should fail")
+}
+
+func TestWebhook(t *testing.T) {
+	alert := testAlert()
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body := struct {
+			Subject string                     `json:"subject"`
+			Alert   productResponse.StockAlert `json:"alert"`
+		}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "Low stock: Mechanical Keyboard", body.Subject)
+		assert.Equal(t, alert.ProductId, body.Alert.ProductId)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhook, err := NewWebhook(config.Webhook{Url: server.URL}, server.Client())
+	require.NoError(t, err)
+	require.NoError(t, webhook.Notify(context.Background(), alert))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, webhook.Notify(context.Background(), alert))
+
+	_, err = NewWebhook(config.Webhook{Url: "not a url"}, nil)
+	assert.Error(t, err)
+}
+
+func testOrderAllocated() orderResponse.OrderAllocated {
+	return orderResponse.OrderAllocated{
+		AllocatedAt: time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC),
+		Status:      "WAITING_PAYMENT",
+		ID:          uuid.New(),
+		UserId:      uuid.New(),
+	}
+}
+
+func TestEmailNotifyOrderAllocated(t *testing.T) {
+	email, err := NewEmail(config.Smtp{
+		Host:       "mailhog",
+		From:       "alerts@ecommerce.local",
+		Recipients: []string{"merchandising@ecommerce.local"},
+	})
+	require.NoError(t, err)
+
+	var sent []byte
+	email.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = msg
+		assert.Equal(t, []string{"customer@ecommerce.local"}, to, "the customer should be mailed, not the recipients")
+		return nil
+	}
+
+	event := testOrderAllocated()
+	require.NoError(t, email.NotifyOrderAllocated(context.Background(), "customer@ecommerce.local", event))
+	assert.Contains(t, string(sent), "To: customer@ecommerce.local\r\n")
+	assert.Contains(t, string(sent), "Subject: Order "+event.ID.String()+" is ready for payment\r\n")
+	assert.Contains(t, string(sent), "Status: WAITING_PAYMENT\r\n")
+
+	assert.Error(t, email.NotifyOrderAllocated(context.Background(), "", event))
+}
+
+func TestWebhookNotifyOrderAllocated(t *testing.T) {
+	event := testOrderAllocated()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := struct {
+			Email string                       `json:"email"`
+			Order orderResponse.OrderAllocated `json:"order"`
+		}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "customer@ecommerce.local", body.Email)
+		assert.Equal(t, event.ID, body.Order.ID)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	webhook, err := NewWebhook(config.Webhook{Url: server.URL}, server.Client())
+	require.NoError(t, err)
+	assert.Error(t, webhook.NotifyOrderAllocated(context.Background(), "customer@ecommerce.local", event))
+}
@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	orderResponse "github.com/Alturino/ecommerce/order/pkg/response"
+	productResponse "github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+// Webhook posts alerts as JSON to a url, any status other than 2xx fails the
+// delivery.
+type Webhook struct {
+	client *http.Client
+	url    string
+}
+
+func NewWebhook(cfg config.Webhook, client *http.Client) (Webhook, error) {
+	u, err := url.Parse(cfg.Url)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed parsing url=%s with error=%w", cfg.Url, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return Webhook{}, fmt.Errorf("invalid url=%s", cfg.Url)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return Webhook{client: client, url: cfg.Url}, nil
+}
+
+func (wh Webhook) Name() string {
+	return "webhook"
+}
+
+func (wh Webhook) Notify(c context.Context, alert productResponse.StockAlert) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"subject": subject(alert),
+		"alert":   alert,
+	})
+	if err != nil {
+		return fmt.Errorf("failed marshaling alert with error=%w", err)
+	}
+	return wh.post(c, body)
+}
+
+func (wh Webhook) NotifyOrderAllocated(
+	c context.Context,
+	email string,
+	event orderResponse.OrderAllocated,
+) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"subject": orderSubject(event),
+		"email":   email,
+		"order":   event,
+	})
+	if err != nil {
+		return fmt.Errorf("failed marshaling order with error=%w", err)
+	}
+	return wh.post(c, body)
+}
+
+func (wh Webhook) post(c context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(c, http.MethodPost, wh.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed creating request with error=%w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed calling webhook with error=%w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status=%d", resp.StatusCode)
+	}
+	return nil
+}
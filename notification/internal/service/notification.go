@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/notification/internal/cache"
+	"github.com/Alturino/ecommerce/notification/internal/notifier"
+	"github.com/Alturino/ecommerce/notification/internal/otel"
+	orderResponse "github.com/Alturino/ecommerce/order/pkg/response"
+	productResponse "github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+const defaultAlertCooldown = time.Hour
+
+type NotificationService struct {
+	queries   *repository.Queries
+	cache     *redis.Client
+	notifiers []notifier.Notifier
+	config    config.Alert
+}
+
+func NewNotificationService(
+	queries *repository.Queries,
+	cache *redis.Client,
+	notifiers []notifier.Notifier,
+	cfg config.Alert,
+) *NotificationService {
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultAlertCooldown
+	}
+	return &NotificationService{queries: queries, cache: cache, notifiers: notifiers, config: cfg}
+}
+
+// NotifyOrderAllocated notifies the customer that their backordered or
+// pre-ordered order is allocated and waiting for payment.
+func (svc NotificationService) NotifyOrderAllocated(
+	c context.Context,
+	event orderResponse.OrderAllocated,
+) error {
+	c, span := otel.Tracer.Start(c, "NotificationService NotifyOrderAllocated")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "NotificationService NotifyOrderAllocated").
+		Str(constants.KEY_ORDER_ID, event.ID.String()).
+		Str(constants.KEY_USER_ID, event.UserId.String()).
+		Logger()
+
+	if len(svc.notifiers) == 0 {
+		err := errors.New("no notifier configured to notify user")
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding user").Logger()
+	logger.Trace().Msg("finding user")
+	span.AddEvent("finding user")
+	user, err := svc.queries.FindById(c, event.UserId)
+	if err != nil {
+		err = fmt.Errorf("failed finding user with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	logger.Info().Msg("found user")
+	span.AddEvent("found user")
+
+	logger = logger.With().
+		Str(constants.KEY_PROCESS, "notifying user").
+		Str(constants.KEY_EMAIL, user.Email).
+		Logger()
+	logger.Trace().Msg("notifying user")
+	span.AddEvent("notifying user")
+	errs := []error{}
+	for _, n := range svc.notifiers {
+		lg := logger.With().Str(constants.KEY_NOTIFIER, n.Name()).Logger()
+		if err := n.NotifyOrderAllocated(c, user.Email, event); err != nil {
+			err = fmt.Errorf("failed notifying user through notifier=%s with error=%w", n.Name(), err)
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		lg.Info().Msg("notified user")
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	span.AddEvent("notified user")
+	logger.Info().Msg("notified user")
+
+	return nil
+}
+
+// NotifyStockAlert delivers a stock alert through every configured notifier. An
+// alert of the same kind for the same product is delivered once per cooldown,
+// so a product hovering around its threshold does not alert on every order.
+func (svc NotificationService) NotifyStockAlert(
+	c context.Context,
+	alert productResponse.StockAlert,
+) error {
+	c, span := otel.Tracer.Start(c, "NotificationService NotifyStockAlert")
+	defer span.End()
+
+	cacheKey := fmt.Sprintf(cache.KEY_STOCK_ALERTS, alert.ProductId, alert.Kind)
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "NotificationService NotifyStockAlert").
+		Str(constants.KEY_PRODUCT_ID, alert.ProductId.String()).
+		Any(constants.KEY_STOCK_ALERT, alert).
+		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Logger()
+
+	if len(svc.notifiers) == 0 {
+		logger.Warn().Msg("no notifier configured, skipped stock alert")
+		return nil
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "deduplicating stock alert").Logger()
+	logger.Trace().Msg("deduplicating stock alert")
+	span.AddEvent("deduplicating stock alert")
+	first, err := svc.cache.SetNX(c, cacheKey, alert.At, svc.config.Cooldown).Result()
+	if err != nil {
+		err = fmt.Errorf("failed deduplicating stock alert with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	if !first {
+		span.AddEvent("skipped duplicated stock alert")
+		logger.Info().Msg("skipped duplicated stock alert")
+		return nil
+	}
+	span.AddEvent("deduplicated stock alert")
+	logger.Info().Msg("deduplicated stock alert")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "delivering stock alert").Logger()
+	logger.Trace().Msg("delivering stock alert")
+	span.AddEvent("delivering stock alert")
+	errs := []error{}
+	for _, n := range svc.notifiers {
+		lg := logger.With().Str(constants.KEY_NOTIFIER, n.Name()).Logger()
+		if err := n.Notify(c, alert); err != nil {
+			err = fmt.Errorf("failed delivering stock alert through notifier=%s with error=%w", n.Name(), err)
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		lg.Info().Msg("delivered stock alert")
+	}
+	if len(errs) == len(svc.notifiers) {
+		// nobody was told, let the next alert of this kind through
+		if err := svc.cache.Del(c, cacheKey).Err(); err != nil {
+			err = fmt.Errorf("failed removing stock alert in cache with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	span.AddEvent("delivered stock alert")
+	logger.Info().Msg("delivered stock alert")
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/log"
+	"github.com/Alturino/ecommerce/order/internal/service"
+)
+
+const defaultAllocatorInterval = time.Minute
+
+type BackorderAllocator struct {
+	svc      *service.OrderService
+	cache    *redis.Client
+	interval time.Duration
+}
+
+func NewBackorderAllocator(
+	svc *service.OrderService,
+	cache *redis.Client,
+	interval time.Duration,
+) *BackorderAllocator {
+	if interval <= 0 {
+		interval = defaultAllocatorInterval
+	}
+	return &BackorderAllocator{svc: svc, cache: cache, interval: interval}
+}
+
+// StartAllocator allocates backorders whenever a product is restocked and on
+// every interval, the interval picks up released pre-orders and restock events
+// that were missed while the allocator was down.
+func (a BackorderAllocator) StartAllocator(c context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Reset().
+		Str(constants.KEY_TAG, "BackorderAllocator StartAllocator").
+		Str(constants.KEY_PROCESS, "starting allocator").
+		Str(constants.KEY_APP_NAME, constants.APP_ORDER_WORKER).
+		Logger()
+
+	pubsub := a.cache.Subscribe(c, constants.PRODUCT_RESTOCKED)
+	defer pubsub.Close()
+	restocked := pubsub.Channel()
+	tick := time.Tick(a.interval)
+
+	allocate := func(trigger string) {
+		reqId := uuid.NewString()
+		lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+		lg.Trace().Msgf("start allocating backorders triggered by %s", trigger)
+		ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+		orders, err := a.svc.AllocateBackorders(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed allocating backorders with error=%w", err)
+			lg.Error().Err(err).Msg(err.Error())
+			return
+		}
+		lg.Info().Any(constants.KEY_ORDERS, orders).Msg("allocated backorders")
+	}
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-tick:
+			allocate("interval")
+		case msg, ok := <-restocked:
+			if !ok {
+				return
+			}
+			logger.Info().Str(constants.KEY_PRODUCT_ID, msg.Payload).Msg("received product restocked")
+			allocate(constants.PRODUCT_RESTOCKED)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/log"
+	"github.com/Alturino/ecommerce/order/internal/service"
+)
+
+const defaultExpirerInterval = time.Minute
+
+type OrderExpirer struct {
+	svc      *service.OrderService
+	interval time.Duration
+}
+
+func NewOrderExpirer(svc *service.OrderService, interval time.Duration) *OrderExpirer {
+	if interval <= 0 {
+		interval = defaultExpirerInterval
+	}
+	return &OrderExpirer{svc: svc, interval: interval}
+}
+
+// StartExpirer expires the orders that were not paid in time on every
+// interval, putting the stock they took back.
+func (e OrderExpirer) StartExpirer(c context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Reset().
+		Str(constants.KEY_TAG, "OrderExpirer StartExpirer").
+		Str(constants.KEY_PROCESS, "starting expirer").
+		Str(constants.KEY_APP_NAME, constants.APP_ORDER_WORKER).
+		Logger()
+
+	tick := time.Tick(e.interval)
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-tick:
+			reqId := uuid.NewString()
+			lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+			lg.Trace().Msg("start expiring orders")
+			ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+			orders, err := e.svc.ExpireOrders(ctx)
+			if err != nil {
+				err = fmt.Errorf("failed expiring orders with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+				continue
+			}
+			lg.Info().Any(constants.KEY_ORDERS, orders).Msg("expired orders")
+		}
+	}
+}
@@ -51,13 +51,13 @@ func (wrk OrderWorker) StartWorker(c context.Context, wg *sync.WaitGroup) {
 			logger.Trace().Msg("start batch create order")
 			c = log.AttachRequestIDToContext(logger.WithContext(c), reqId)
 			resOrder, err := wrk.svc.BatchCreateOrder(c, batch)
+			batch = batch[:0]
 			if err != nil {
 				err = fmt.Errorf("failed batch create order with error=%w", err)
-				logger.Error().Err(err).Msg(err.Error())
+				logger.Error().Err(err).Any(constants.KEY_ORDERS, resOrder).Msg(err.Error())
 				continue
 			}
 			logger.Info().Any(constants.KEY_ORDERS, resOrder).Msg("batch create order completed")
-			batch = batch[:0]
 		case order := <-wrk.queue:
 			logger = logger.With().Any(constants.KEY_ORDER, order).Logger()
 			logger.Info().Msg("received request create order")
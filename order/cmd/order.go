@@ -13,6 +13,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	inCache "github.com/Alturino/ecommerce/internal/cache"
 	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
 	"github.com/Alturino/ecommerce/internal/infra"
@@ -37,6 +38,15 @@ func RunOrderService(c context.Context) {
 		Str(constants.KEY_TAG, "main RunOrderService").
 		Logger()
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating order config").Logger()
+	logger.Info().Msg("validating order config")
+	if err := cfg.Order.Validate(); err != nil {
+		err = fmt.Errorf("failed validating order config with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Fatal().Err(err).Msg(err.Error())
+	}
+	logger.Info().Msg("validated order config")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing router").Logger()
 	logger.Info().Msg("initializing router")
 	mux := mux.NewRouter()
@@ -98,14 +108,14 @@ func RunOrderService(c context.Context) {
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing order service").Logger()
 	logger.Info().Msg("initializing order service")
 	c = logger.WithContext(c)
-	orderService := service.NewOrderService(db, queries, cache)
+	orderService := service.NewOrderService(db, queries, inCache.New(cache, cfg.Cache), cfg.Order, cfg.Currency)
 	logger.Info().Msg("initialized order service")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing order controller").Logger()
 	logger.Info().Msg("initializing order controller")
 	queue := make(chan request.CreateOrder, 1)
 	defer close(queue)
-	controller.AttachOrderController(mux, orderService, queue)
+	controller.AttachOrderController(mux, orderService, queue, cfg.Pagination)
 	logger.Info().Msg("initializing order controller")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing server").Logger()
@@ -168,6 +178,22 @@ func RunOrderService(c context.Context) {
 	wg.Add(1)
 	c = logger.WithContext(c)
 	go orderWorker.StartWorker(c, &wg)
+
+	backorderAllocator := NewBackorderAllocator(orderService, cache, cfg.Order.Allocator.Interval)
+	logger = logger.With().Str(constants.KEY_PROCESS, "start-allocator").Logger()
+	logger.Info().Msg("start backorder allocator")
+	span.AddEvent("start backorder allocator")
+	wg.Add(1)
+	c = logger.WithContext(c)
+	go backorderAllocator.StartAllocator(c, &wg)
+
+	orderExpirer := NewOrderExpirer(orderService, cfg.Order.Expiry.Interval)
+	logger = logger.With().Str(constants.KEY_PROCESS, "start-expirer").Logger()
+	logger.Info().Msg("start order expirer")
+	span.AddEvent("start order expirer")
+	wg.Add(1)
+	c = logger.WithContext(c)
+	go orderExpirer.StartExpirer(c, &wg)
 	wg.Wait()
 
 	<-c.Done()
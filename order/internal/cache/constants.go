@@ -1,6 +1,6 @@
 package cache
 
+// families of the cached entries of the order service, see internal/cache.
 const (
-	KEY_ORDER    = "order:%s"
-	KEY_PRODUCTS = "products:"
+	KEY_ORDERS = "orders"
 )
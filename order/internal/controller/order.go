@@ -11,13 +11,16 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/Alturino/ecommerce/internal"
+	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
 	inHttp "github.com/Alturino/ecommerce/internal/http"
 	"github.com/Alturino/ecommerce/internal/middleware"
 	inOtel "github.com/Alturino/ecommerce/internal/otel"
@@ -28,16 +31,18 @@ import (
 )
 
 type OrderController struct {
-	service *service.OrderService
-	queue   chan<- request.CreateOrder
+	service    *service.OrderService
+	queue      chan<- request.CreateOrder
+	pagination config.Pagination
 }
 
 func AttachOrderController(
 	mux *mux.Router,
 	service *service.OrderService,
 	queue chan<- request.CreateOrder,
+	pagination config.Pagination,
 ) {
-	controller := OrderController{service: service, queue: queue}
+	controller := OrderController{service: service, queue: queue, pagination: pagination}
 
 	router := mux.PathPrefix("/orders").Subrouter()
 	router.Use(
@@ -48,6 +53,8 @@ func AttachOrderController(
 	)
 	router.HandleFunc("", controller.FindOrders).Methods(http.MethodGet)
 	router.HandleFunc("/{orderId}", controller.FindOrderById).Methods(http.MethodGet)
+	router.HandleFunc("/{orderId}/shipments", controller.FindShipments).Methods(http.MethodGet)
+	router.HandleFunc("/{orderId}/cancel", controller.CancelOrder).Methods(http.MethodPost)
 	router.HandleFunc("/checkout", controller.Checkout).Methods(http.MethodPost)
 	// router.HandleFunc("/checkout", controller.CreateOrderOptimisticLock).Methods(http.MethodPost)
 }
@@ -70,20 +77,54 @@ func (ctrl OrderController) FindOrderById(w http.ResponseWriter, r *http.Request
 		err = fmt.Errorf("failed validating orderId=%s with error=%w", orderId.String(), err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
 		return
 	}
 	logger.Info().Msg("validated orderId")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting owner from jwtToken").Logger()
+	logger.Trace().Msg("getting owner from jwtToken")
+	span.AddEvent("getting owner from jwtToken")
+	owner, err := internal.OwnerFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting owner from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got owner from jwtToken")
+	logger = logger.With().
+		Str(constants.KEY_USER_ID, owner.UserId.String()).
+		Bool(constants.KEY_ADMIN, owner.Admin).
+		Logger()
+	logger.Debug().Msg("got owner from jwtToken")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "finding orders").Logger()
 	logger.Info().Msg("finding orders")
 	c = logger.WithContext(c)
-	orders, err := ctrl.service.FindOrderById(c, request.FindOrderById{OrderId: orderId})
+	orders, err := ctrl.service.FindOrderById(
+		c,
+		request.FindOrderById{UserId: owner.Scope(), OrderId: orderId},
+	)
 	if err != nil {
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
 			"status":     "failed",
-			"statusCode": http.StatusBadRequest,
+			"statusCode": statusCode,
 			"message":    fmt.Sprintf("order with id=%s and not found", orderId.String()),
 		})
 		return
@@ -101,6 +142,171 @@ func (ctrl OrderController) FindOrderById(w http.ResponseWriter, r *http.Request
 	})
 }
 
+func (ctrl OrderController) FindShipments(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "OrderController FindShipments")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderController FindShipments").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating orderId").Logger()
+	logger.Trace().Msg("validating orderId")
+	orderId, err := uuid.Parse(mux.Vars(r)["orderId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating orderId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	logger = logger.With().Str(constants.KEY_ORDER_ID, orderId.String()).Logger()
+	logger.Info().Msg("validated orderId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting owner from jwtToken").Logger()
+	logger.Trace().Msg("getting owner from jwtToken")
+	span.AddEvent("getting owner from jwtToken")
+	owner, err := internal.OwnerFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting owner from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got owner from jwtToken")
+	logger = logger.With().
+		Str(constants.KEY_USER_ID, owner.UserId.String()).
+		Bool(constants.KEY_ADMIN, owner.Admin).
+		Logger()
+	logger.Debug().Msg("got owner from jwtToken")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding shipments").Logger()
+	logger.Trace().Msg("finding shipments")
+	c = logger.WithContext(c)
+	shipments, err := ctrl.service.FindShipmentsByOrderId(
+		c,
+		request.FindShipments{UserId: owner.Scope(), OrderId: orderId},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed finding shipments with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	logger.Info().Msg("found shipments")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "found shipments",
+		"data": map[string]interface{}{
+			"shipments": shipments,
+		},
+	})
+}
+
+func (ctrl OrderController) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "OrderController CancelOrder")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderController CancelOrder").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating orderId").Logger()
+	logger.Trace().Msg("validating orderId")
+	orderId, err := uuid.Parse(mux.Vars(r)["orderId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating orderId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	logger = logger.With().Str(constants.KEY_ORDER_ID, orderId.String()).Logger()
+	logger.Info().Msg("validated orderId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting owner from jwtToken").Logger()
+	logger.Trace().Msg("getting owner from jwtToken")
+	span.AddEvent("getting owner from jwtToken")
+	owner, err := internal.OwnerFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting owner from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got owner from jwtToken")
+	logger = logger.With().
+		Str(constants.KEY_USER_ID, owner.UserId.String()).
+		Bool(constants.KEY_ADMIN, owner.Admin).
+		Logger()
+	logger.Debug().Msg("got owner from jwtToken")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "cancelling order").Logger()
+	logger.Trace().Msg("cancelling order")
+	c = logger.WithContext(c)
+	order, err := ctrl.service.CancelOrder(
+		c,
+		request.CancelOrder{UserId: owner.Scope(), ActorId: owner.UserId, OrderId: orderId},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed cancelling order with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, inErrors.ErrNotCancellable):
+			statusCode = http.StatusConflict
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
+		return
+	}
+	logger.Info().Msg("cancelled order")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "cancelled order",
+		"data": map[string]interface{}{
+			"order": order,
+		},
+	})
+}
+
 func (ctrl OrderController) FindOrders(w http.ResponseWriter, r *http.Request) {
 	c, span := otel.Tracer.Start(r.Context(), "OrderController FindOrders")
 	defer span.End()
@@ -112,11 +318,12 @@ func (ctrl OrderController) FindOrders(w http.ResponseWriter, r *http.Request) {
 		Str(constants.KEY_PROCESS, "finding orders").
 		Logger()
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "validating userId and orderId").Logger()
-	logger.Info().Msg("validating userId")
-	userId, err := uuid.Parse(r.URL.Query().Get("userId"))
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting owner from jwtToken").Logger()
+	logger.Trace().Msg("getting owner from jwtToken")
+	span.AddEvent("getting owner from jwtToken")
+	owner, err := internal.OwnerFromJwtToken(c)
 	if err != nil {
-		err = fmt.Errorf("failed validating userId=%s with error=%w", userId.String(), err)
+		err = fmt.Errorf("failed getting owner from jwtToken with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
@@ -124,15 +331,46 @@ func (ctrl OrderController) FindOrders(w http.ResponseWriter, r *http.Request) {
 			"statusCode": http.StatusBadRequest,
 			"message":    err.Error(),
 		})
+		return
+	}
+	span.AddEvent("got owner from jwtToken")
+	logger = logger.With().
+		Str(constants.KEY_USER_ID, owner.UserId.String()).
+		Bool(constants.KEY_ADMIN, owner.Admin).
+		Logger()
+	logger.Debug().Msg("got owner from jwtToken")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating userId and page").Logger()
+	logger.Info().Msg("validating userId")
+	userId := owner.UserId
+	if rawUserId := r.URL.Query().Get("userId"); rawUserId != "" {
+		userId, err = uuid.Parse(rawUserId)
+		if err == nil && !owner.Owns(userId) {
+			err = inErrors.ErrForbidden
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating userId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, inErrors.ErrForbidden) {
+			statusCode = http.StatusForbidden
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
 
 		return
 	}
 	logger.Info().Msg("validated userId")
 
-	logger.Info().Msg("validating orderId")
-	orderId, err := uuid.Parse(r.URL.Query().Get("orderId"))
+	logger.Info().Msg("validating page")
+	page, err := inHttp.ParsePage(r, ctrl.pagination)
 	if err != nil {
-		err = fmt.Errorf("failed validating orderId=%s with error=%w", orderId.String(), err)
+		err = fmt.Errorf("failed validating page with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
@@ -144,17 +382,17 @@ func (ctrl OrderController) FindOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	logger = logger.With().
-		Str(constants.KEY_ORDER_ID, orderId.String()).
 		Str(constants.KEY_USER_ID, userId.String()).
+		Any(constants.KEY_PAGE, page).
 		Logger()
-	logger.Info().Msg("validated orderId")
+	logger.Info().Msg("validated page")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "finding orders").Logger()
 	logger.Info().Msg("finding orders")
 	c = logger.WithContext(c)
-	orders, err := ctrl.service.FindOrders(
+	orders, nextCursor, err := ctrl.service.FindOrders(
 		c,
-		request.FindOrders{OrderId: orderId, UserId: userId},
+		request.FindOrders{UserId: userId, Page: page},
 	)
 	if err != nil {
 		err = fmt.Errorf("failed finding orders with error=%w", err)
@@ -168,12 +406,13 @@ func (ctrl OrderController) FindOrders(w http.ResponseWriter, r *http.Request) {
 
 		return
 	}
-	logger.Info().Any(constants.KEY_ORDERS, orders).Msg("found orders")
+	logger.Info().Int(constants.KEY_ORDERS, len(orders)).Msg("found orders")
 
 	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
-		"status":     "success",
-		"statusCode": http.StatusOK,
-		"message":    "found orders",
+		"status":      "success",
+		"statusCode":  http.StatusOK,
+		"message":     "found orders",
+		"next_cursor": nextCursor,
 		"data": map[string]interface{}{
 			"orders": orders,
 		},
@@ -229,15 +468,36 @@ func (ctrl OrderController) Checkout(w http.ResponseWriter, r *http.Request) {
 	span.AddEvent("decoded request body")
 	logger = logger.With().
 		Str(constants.KEY_ORDER_ID, param.ID.String()).
-		Str(constants.KEY_USER_ID, param.UserId.String()).
 		Any(constants.KEY_ORDER, param).
 		Logger()
+	logger.Info().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating userId").Logger()
+	logger.Trace().Msg("validating userId")
+	span.AddEvent("validating userId")
+	if param.UserId != uuid.Nil && param.UserId != userId {
+		err = fmt.Errorf(
+			"failed validating userId=%s with error=%w",
+			param.UserId.String(),
+			inErrors.ErrForbidden,
+		)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusForbidden,
+			"message":    err.Error(),
+		})
+		return
+	}
+	param.UserId = userId
 	param.TraceLink = trace.LinkFromContext(c)
 	span.SetAttributes(
 		attribute.String(constants.KEY_ORDER_ID, param.ID.String()),
 		attribute.String(constants.KEY_USER_ID, param.UserId.String()),
 	)
-	logger.Info().Msg("decoded request body")
+	span.AddEvent("validated userId")
+	logger.Info().Msg("validated userId")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "validating request body").Logger()
 	logger.Trace().Msg("validating request body")
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal"
+	"github.com/Alturino/ecommerce/internal/log"
+	"github.com/Alturino/ecommerce/order/internal/response"
+	"github.com/Alturino/ecommerce/order/pkg/request"
+)
+
+func TestCheckoutUserId(t *testing.T) {
+	userId := uuid.New()
+	testCases := []struct {
+		name       string
+		bodyUserId uuid.UUID
+		expected   int
+	}{
+		{"another user", uuid.New(), http.StatusForbidden},
+		{"same user", userId, http.StatusCreated},
+		{"omitted user", uuid.Nil, http.StatusCreated},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			queue := make(chan request.CreateOrder, 1)
+			ctrl := OrderController{queue: queue}
+			now := time.Now()
+			orderId := uuid.New()
+			body, err := json.Marshal(request.CreateOrder{
+				OrderItems: []request.OrderItem{{
+					CreatedAt: now,
+					UpdatedAt: now,
+					ID:        uuid.New(),
+					OrderID:   orderId,
+					ProductID: uuid.New(),
+					Price:     decimal.NewFromInt(10),
+					Quantity:  1,
+				}},
+				CreatedAt: now,
+				UpdatedAt: now,
+				ID:        orderId,
+				UserId:    tc.bodyUserId,
+			})
+			assert.NoError(t, err)
+			token := &jwt.Token{Claims: &internal.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: userId.String()},
+			}}
+			r := httptest.NewRequest(http.MethodPost, "/orders/checkout", bytes.NewReader(body))
+			r = r.WithContext(log.AttachRequestIDToContext(r.Context(), "request-id"))
+			r = r.WithContext(internal.AttachJwtToken(r.Context(), token))
+			w := httptest.NewRecorder()
+
+			go func() {
+				order, ok := <-queue
+				if !ok {
+					return
+				}
+				assert.Equal(t, userId, order.UserId, "orders should be placed for the authenticated user")
+				order.ResultChannel <- response.Result{}
+			}()
+			ctrl.Checkout(w, r)
+			close(queue)
+
+			assert.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/order/internal/otel"
+	"github.com/Alturino/ecommerce/order/pkg/response"
+)
+
+const defaultAllocatorBatchSize = 500
+
+// AllocateBackorders allocates the pending backorders and released pre-orders
+// in FIFO order from the current product quantity. Orders whose pending items
+// are all allocated are moved to WAITING_PAYMENT and published to the
+// ORDER_ALLOCATED channel so the customer can be notified.
+func (s OrderService) AllocateBackorders(c context.Context) ([]repository.Order, error) {
+	c, span := otel.Tracer.Start(c, "OrderService AllocateBackorders")
+	defer span.End()
+
+	batchSize := s.config.Allocator.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultAllocatorBatchSize
+	}
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService AllocateBackorders").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := s.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding allocatable backorders").Logger()
+	logger.Trace().Msg("finding allocatable backorders")
+	span.AddEvent("finding allocatable backorders")
+	backorders, err := s.queries.WithTx(tx).FindAllocatableBackorders(c, int32(batchSize))
+	if err != nil {
+		err = fmt.Errorf("failed finding allocatable backorders with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger = logger.With().Int(constants.KEY_BACKORDERS, len(backorders)).Logger()
+	logger.Info().Msg("found allocatable backorders")
+	span.AddEvent("found allocatable backorders")
+	if len(backorders) == 0 {
+		return nil, nil
+	}
+
+	productIds := make([]uuid.UUID, 0, len(backorders))
+	seen := map[uuid.UUID]bool{}
+	for _, backorder := range backorders {
+		if seen[backorder.ProductID] {
+			continue
+		}
+		seen[backorder.ProductID] = true
+		productIds = append(productIds, backorder.ProductID)
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking products").Logger()
+	logger.Trace().Msg("locking products")
+	span.AddEvent("locking products")
+	products, err := s.queries.WithTx(tx).FindProductsByIdsForUpdate(c, productIds)
+	if err != nil {
+		err = fmt.Errorf("failed locking products with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Msg("locked products")
+	span.AddEvent("locked products")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding reserved stock").Logger()
+	logger.Trace().Msg("finding reserved stock")
+	span.AddEvent("finding reserved stock")
+	reserved, err := s.queries.WithTx(tx).FindReservedQuantities(
+		c,
+		repository.FindReservedQuantitiesParams{ProductIds: productIds, ExcludedCartIds: []uuid.UUID{}},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed finding reserved stock with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Any(constants.KEY_RESERVED_QUANTITIES, reserved).Msg("found reserved stock")
+	span.AddEvent("found reserved stock")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "allocating backorders").Logger()
+	logger.Trace().Msg("allocating backorders")
+	span.AddEvent("allocating backorders")
+	allocatedIds, orderIds, quantities := allocateBackorders(
+		backorders,
+		excludeReservedQuantity(products, reserved),
+	)
+	logger = logger.With().
+		Int(constants.KEY_BACKORDERS_ALLOCATED, len(allocatedIds)).
+		Any(constants.KEY_ORDER_IDS, orderIds).
+		Logger()
+	logger.Info().Msg("allocated backorders")
+	span.AddEvent("allocated backorders")
+	if len(allocatedIds) == 0 {
+		return nil, nil
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decreasing product quantity").Logger()
+	logger.Trace().Msg("decreasing product quantity")
+	span.AddEvent("decreasing product quantity")
+	for productId, quantity := range quantities {
+		_, err = s.queries.WithTx(tx).DecreaseProductQuantity(
+			c,
+			repository.DecreaseProductQuantityParams{ID: productId, Quantity: quantity},
+		)
+		if err != nil {
+			err = fmt.Errorf("failed decreasing product quantity with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return nil, err
+		}
+	}
+	logger.Info().Msg("decreased product quantity")
+	span.AddEvent("decreased product quantity")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "marking backorders allocated").Logger()
+	logger.Trace().Msg("marking backorders allocated")
+	span.AddEvent("marking backorders allocated")
+	err = s.queries.WithTx(tx).UpdateBackordersAllocated(c, allocatedIds)
+	if err != nil {
+		err = fmt.Errorf("failed marking backorders allocated with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Msg("marked backorders allocated")
+	span.AddEvent("marked backorders allocated")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "fulfilling backorders").Logger()
+	logger.Trace().Msg("fulfilling backorders from warehouses")
+	span.AddEvent("fulfilling backorders from warehouses")
+	err = s.fulfillOrders(c, tx, backorderFulfillmentItems(backorders, allocatedIds), nil, nil)
+	if err != nil {
+		err = fmt.Errorf("failed fulfilling backorders from warehouses with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Msg("fulfilled backorders from warehouses")
+	span.AddEvent("fulfilled backorders from warehouses")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating allocated orders").Logger()
+	logger.Trace().Msg("updating allocated orders")
+	span.AddEvent("updating allocated orders")
+	orders, err := s.queries.WithTx(tx).UpdateAllocatedOrders(c, orderIds)
+	if err != nil {
+		err = fmt.Errorf("failed updating allocated orders with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Msg("updated allocated orders")
+	span.AddEvent("updated allocated orders")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Msg("committed transaction")
+	span.AddEvent("committed transaction")
+	span.SetAttributes(attribute.Int(constants.KEY_ORDERS, len(orders)))
+
+	updatedIds := make([]uuid.UUID, 0, len(quantities))
+	for productId := range quantities {
+		updatedIds = append(updatedIds, productId)
+	}
+	s.publishQuantityUpdated(c, updatedIds)
+	s.invalidateOrders(c, orderIds)
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "publishing allocated orders").Logger()
+	for _, order := range orders {
+		lg := logger.With().Str(constants.KEY_ORDER_ID, order.ID.String()).Logger()
+		lg.Trace().Msg("publishing allocated order")
+		event, err := json.Marshal(response.OrderAllocated{
+			AllocatedAt: order.UpdatedAt.Time,
+			Status:      string(order.Status),
+			ID:          order.ID,
+			UserId:      order.UserID,
+		})
+		if err != nil {
+			err = fmt.Errorf("failed marshaling allocated order with error=%w", err)
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			continue
+		}
+		err = s.cache.Publish(c, constants.ORDER_ALLOCATED, event).Err()
+		if err != nil {
+			err = fmt.Errorf("failed publishing allocated order with error=%w", err)
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			continue
+		}
+		lg.Info().Msg("published allocated order")
+	}
+
+	return orders, nil
+}
+
+// allocateBackorders walks the backorders, which are sorted by creation time,
+// and allocates every order whose backorders can all be filled from the
+// remaining product quantity. Once an order can not be filled the products it
+// waits for are blocked so later orders can not jump the queue. It returns the
+// allocated backorder ids, the ids of the orders they belong to and how much
+// the quantity of every allocated product has to be decreased by. products only
+// decide what can be filled, so stock held by carts may be excluded from them
+// without being written back.
+func allocateBackorders(
+	backorders []repository.Backorder,
+	products []repository.Product,
+) ([]uuid.UUID, []uuid.UUID, map[uuid.UUID]int32) {
+	available := make(map[uuid.UUID]int32, len(products))
+	for _, product := range products {
+		available[product.ID] = product.Quantity
+	}
+
+	orderIds := []uuid.UUID{}
+	mapBackorders := map[uuid.UUID][]repository.Backorder{}
+	for _, backorder := range backorders {
+		if _, ok := mapBackorders[backorder.OrderID]; !ok {
+			orderIds = append(orderIds, backorder.OrderID)
+		}
+		mapBackorders[backorder.OrderID] = append(mapBackorders[backorder.OrderID], backorder)
+	}
+
+	allocatedIds := []uuid.UUID{}
+	allocatedOrderIds := []uuid.UUID{}
+	quantities := map[uuid.UUID]int32{}
+	blocked := map[uuid.UUID]bool{}
+	for _, orderId := range orderIds {
+		needed := map[uuid.UUID]int32{}
+		for _, backorder := range mapBackorders[orderId] {
+			needed[backorder.ProductID] += backorder.Quantity
+		}
+
+		fillable := true
+		for productId, quantity := range needed {
+			quantityAvailable, ok := available[productId]
+			if !ok || blocked[productId] || quantityAvailable < quantity {
+				fillable = false
+				break
+			}
+		}
+		if !fillable {
+			for productId := range needed {
+				blocked[productId] = true
+			}
+			continue
+		}
+
+		for productId, quantity := range needed {
+			available[productId] -= quantity
+			quantities[productId] += quantity
+		}
+		for _, backorder := range mapBackorders[orderId] {
+			allocatedIds = append(allocatedIds, backorder.ID)
+		}
+		allocatedOrderIds = append(allocatedOrderIds, orderId)
+	}
+
+	return allocatedIds, allocatedOrderIds, quantities
+}
+
+// backorderFulfillmentItems returns the allocated backorders as items to be
+// fulfilled from warehouses. Backorders do not carry a shipping location so they
+// are fulfilled with the fewest splits.
+func backorderFulfillmentItems(
+	backorders []repository.Backorder,
+	allocatedIds []uuid.UUID,
+) []fulfillmentItem {
+	allocated := make(map[uuid.UUID]bool, len(allocatedIds))
+	for _, id := range allocatedIds {
+		allocated[id] = true
+	}
+
+	items := make([]fulfillmentItem, 0, len(allocatedIds))
+	for _, backorder := range backorders {
+		if !allocated[backorder.ID] {
+			continue
+		}
+		items = append(items, fulfillmentItem{
+			OrderID:     backorder.OrderID,
+			OrderItemID: backorder.OrderItemID,
+			ProductID:   backorder.ProductID,
+			Quantity:    backorder.Quantity,
+		})
+	}
+	return items
+}
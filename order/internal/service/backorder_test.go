@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/order/pkg/request"
+)
+
+func TestAllocateBackorders(t *testing.T) {
+	productA, productB := uuid.New(), uuid.New()
+	firstOrder, secondOrder, thirdOrder := uuid.New(), uuid.New(), uuid.New()
+	backorders := []repository.Backorder{
+		{ID: uuid.New(), OrderID: firstOrder, ProductID: productA, Quantity: 3},
+		{ID: uuid.New(), OrderID: secondOrder, ProductID: productA, Quantity: 1},
+		{ID: uuid.New(), OrderID: thirdOrder, ProductID: productB, Quantity: 2},
+	}
+	products := []repository.Product{
+		{ID: productA, Quantity: 2},
+		{ID: productB, Quantity: 5},
+	}
+
+	allocatedIds, orderIds, quantities := allocateBackorders(backorders, products)
+
+	assert.Equal(t, []uuid.UUID{backorders[2].ID}, allocatedIds, "second order should not jump the queue of product A")
+	assert.Equal(t, []uuid.UUID{thirdOrder}, orderIds)
+	assert.Equal(t, map[uuid.UUID]int32{productB: 2}, quantities)
+}
+
+func TestAllocateBackordersWithReservations(t *testing.T) {
+	productId, orderId := uuid.New(), uuid.New()
+	backorders := []repository.Backorder{{ID: uuid.New(), OrderID: orderId, ProductID: productId, Quantity: 2}}
+	stock := int32(10)
+	products := excludeReservedQuantity(
+		[]repository.Product{{ID: productId, Quantity: stock}},
+		[]repository.FindReservedQuantitiesRow{{ProductID: productId, Quantity: 3}},
+	)
+
+	allocatedIds, _, quantities := allocateBackorders(backorders, products)
+
+	assert.Equal(t, []uuid.UUID{backorders[0].ID}, allocatedIds)
+	assert.Equal(t, map[uuid.UUID]int32{productId: 2}, quantities, "only the allocated quantity is decreased")
+	assert.Equal(t, int32(8), stock-quantities[productId], "stock held by carts should stay on the product")
+
+	backorders[0].Quantity = 8
+	allocatedIds, _, quantities = allocateBackorders(backorders, excludeReservedQuantity(
+		[]repository.Product{{ID: productId, Quantity: stock}},
+		[]repository.FindReservedQuantitiesRow{{ProductID: productId, Quantity: 3}},
+	))
+	assert.Empty(t, allocatedIds, "stock held by carts can not be allocated")
+	assert.Empty(t, quantities)
+}
+
+func TestCheckDecreaseQuantityAfterRestock(t *testing.T) {
+	c := context.Background()
+	backorderable, discontinued := uuid.New(), uuid.New()
+	firstOrder, secondOrder, thirdOrder := uuid.New(), uuid.New(), uuid.New()
+	params := []request.CreateOrder{
+		{
+			ID: firstOrder,
+			OrderItems: []request.OrderItem{
+				{ID: uuid.New(), OrderID: firstOrder, ProductID: backorderable, Quantity: 4},
+			},
+		},
+		{
+			ID: secondOrder,
+			OrderItems: []request.OrderItem{
+				{ID: uuid.New(), OrderID: secondOrder, ProductID: discontinued, Quantity: 2},
+			},
+		},
+		{
+			ID: thirdOrder,
+			OrderItems: []request.OrderItem{
+				{ID: uuid.New(), OrderID: thirdOrder, ProductID: discontinued, Quantity: 1},
+			},
+		},
+	}
+	// both products were restocked to 5 while 3 items of each wait as backorders
+	stock := []repository.Product{
+		{ID: backorderable, Quantity: 5, Backorderable: true},
+		{ID: discontinued, Quantity: 5},
+	}
+	pendingQuantities := map[string]int32{backorderable.String(): 3, discontinued.String(): 3}
+
+	merged, mapOrder, _, _, _ := mergeOrderItems(c, params)
+	merged, mapOrder = checkDecreaseQuantity(c, stock, pendingQuantities, merged, mapOrder)
+
+	assert.Zero(t, merged[backorderable.String()].OrderedItemQuantity, "new order should not take the restocked units")
+	assert.Len(t, merged[backorderable.String()].PendingItems, 1, "new order should wait behind the backorders")
+	assert.Equal(t, repository.OrderStatusBACKORDERED, merged[backorderable.String()].PendingItems[0].Status)
+	assert.Equal(t, int32(2), merged[discontinued.String()].OrderedItemQuantity, "only the units left after the backorders are available")
+	assert.Len(t, mapOrder[secondOrder.String()].OrderItems, 1)
+	assert.Empty(t, mapOrder[thirdOrder.String()].OrderItems)
+}
+
+func TestWithinBackorderLimit(t *testing.T) {
+	assert.True(t, withinBackorderLimit(repository.Product{BackorderLimit: 0}, 100, 5), "zero limit should not cap backorders")
+	assert.True(t, withinBackorderLimit(repository.Product{BackorderLimit: 10}, 5, 5))
+	assert.False(t, withinBackorderLimit(repository.Product{BackorderLimit: 10}, 6, 5))
+}
+
+func TestExcludeReservedQuantity(t *testing.T) {
+	productA, productB := uuid.New(), uuid.New()
+	products := excludeReservedQuantity(
+		[]repository.Product{{ID: productA, Quantity: 10}, {ID: productB, Quantity: 4}},
+		[]repository.FindReservedQuantitiesRow{{ProductID: productA, Quantity: 3}},
+	)
+
+	assert.Equal(t, int32(7), products[0].Quantity)
+	assert.Equal(t, int32(4), products[1].Quantity)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+)
+
+// TestFindCartByIdAfterClearing runs against the postgres of the order tests,
+// the cart service has no database harness of its own.
+func TestFindCartByIdAfterClearing(t *testing.T) {
+	c := context.Background()
+	redis, pool, pgContainer, redisContainer, queries, _ := setup(t)(
+		c,
+		filepath.Join("seed", "products.seed.sql"),
+	)
+	defer teardown(t)(redis, pool, pgContainer, redisContainer)
+
+	userId := uuid.MustParse("33d683cf-a550-4d6d-9e41-d23783470682")
+	cart, err := queries.InsertCart(c, userId)
+	require.NoError(t, err)
+	price := decimal.NewFromInt(100)
+	_, err = queries.InsertCartItem(c, repository.InsertCartItemParams{
+		ID:        uuid.New(),
+		CartID:    cart.ID,
+		ProductID: uuid.MustParse("f1acad78-2399-41d4-ae41-bd712ebcc982"),
+		Quantity:  2,
+		Price:     pgtype.Numeric{Int: price.Coefficient(), Exp: price.Exponent(), Valid: true},
+		Currency:  "USD",
+	})
+	require.NoError(t, err)
+
+	found, err := queries.FindCartById(c, repository.FindCartByIdParams{ID: cart.ID})
+	require.NoError(t, err)
+	res, err := found.Response()
+	require.NoError(t, err)
+	assert.Len(t, res.CartItems, 1)
+
+	require.NoError(t, queries.DeleteCartItemsByCartId(c, cart.ID))
+
+	found, err = queries.FindCartById(c, repository.FindCartByIdParams{
+		ID:     cart.ID,
+		UserID: pgtype.UUID{Bytes: userId, Valid: true},
+	})
+	require.NoError(t, err, "an emptied cart should still be found")
+	res, err = found.Response()
+	require.NoError(t, err)
+	assert.Equal(t, cart.ID, res.ID)
+	assert.Empty(t, res.CartItems)
+	assert.NotNil(t, res.CartItems, "an emptied cart should respond with no items instead of null")
+}
@@ -0,0 +1,573 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/order/internal/otel"
+	"github.com/Alturino/ecommerce/order/pkg/request"
+	"github.com/Alturino/ecommerce/order/pkg/response"
+)
+
+const earthRadiusKm = 6371.0
+
+// fulfillmentItem is the part of an order item that is taken from stock and has
+// to be shipped from a warehouse.
+type fulfillmentItem struct {
+	OrderID     uuid.UUID `json:"order_id"`
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	Quantity    int32     `json:"quantity"`
+}
+
+type warehouseAllocation struct {
+	OrderID     uuid.UUID `json:"order_id"`
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	WarehouseID uuid.UUID `json:"warehouse_id"`
+	Quantity    int32     `json:"quantity"`
+}
+
+// fulfillOrders chooses the warehouses that fulfill items, records the
+// allocation of every order item, decreases the inventory level of the chosen
+// warehouses, creates one shipment per order and warehouse and records the sale
+// in the inventory movements ledger on behalf of the actor of each order. It
+// must run in the same transaction that decreases the product quantity, and
+// fails with ErrUncoveredStock when the inventory levels can not fill every
+// item so the product quantity never drifts from the sum of its levels.
+func (s OrderService) fulfillOrders(
+	c context.Context,
+	tx pgx.Tx,
+	items []fulfillmentItem,
+	locations map[uuid.UUID]*request.Location,
+	actors map[uuid.UUID]string,
+) error {
+	c, span := otel.Tracer.Start(c, "OrderService fulfillOrders")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService fulfillOrders").
+		Str(constants.KEY_FULFILLMENT_STRATEGY, s.config.Fulfillment.Strategy).
+		Logger()
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	productIds := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, item := range items {
+		if seen[item.ProductID] {
+			continue
+		}
+		seen[item.ProductID] = true
+		productIds = append(productIds, item.ProductID)
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding warehouses").Logger()
+	logger.Trace().Msg("finding warehouses")
+	span.AddEvent("finding warehouses")
+	warehouses, err := s.queries.WithTx(tx).FindWarehouses(c)
+	if err != nil {
+		err = fmt.Errorf("failed finding warehouses with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	logger.Info().Int(constants.KEY_WAREHOUSES, len(warehouses)).Msg("found warehouses")
+	span.AddEvent("found warehouses")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking inventory levels").Logger()
+	logger.Trace().Msg("locking inventory levels")
+	span.AddEvent("locking inventory levels")
+	levels, err := s.queries.WithTx(tx).FindInventoryLevelsByProductIdsForUpdate(c, productIds)
+	if err != nil {
+		err = fmt.Errorf("failed locking inventory levels with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	logger.Info().Any(constants.KEY_INVENTORY_LEVELS, levels).Msg("locked inventory levels")
+	span.AddEvent("locked inventory levels")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "allocating warehouses").Logger()
+	logger.Trace().Msg("allocating warehouses")
+	span.AddEvent("allocating warehouses")
+	stock := warehouseStock(levels)
+	orderIds := []uuid.UUID{}
+	mapItems := map[uuid.UUID][]fulfillmentItem{}
+	for _, item := range items {
+		if _, ok := mapItems[item.OrderID]; !ok {
+			orderIds = append(orderIds, item.OrderID)
+		}
+		mapItems[item.OrderID] = append(mapItems[item.OrderID], item)
+	}
+	allocations := []warehouseAllocation{}
+	for _, orderId := range orderIds {
+		allocations = append(allocations, allocateWarehouses(
+			s.config.Fulfillment.Strategy,
+			mapItems[orderId],
+			warehouses,
+			stock,
+			locations[orderId],
+		)...)
+	}
+	logger = logger.With().Any(constants.KEY_ALLOCATIONS, allocations).Logger()
+	if unallocated := unallocatedQuantity(items, allocations); unallocated > 0 {
+		err = fmt.Errorf(
+			"failed allocating warehouses for unallocated quantity=%d with error=%w",
+			unallocated,
+			inErrors.ErrUncoveredStock,
+		)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Int32(constants.KEY_UNALLOCATED_QUANTITY, unallocated).Msg(err.Error())
+		return err
+	}
+	logger.Info().Msg("allocated warehouses")
+	span.AddEvent("allocated warehouses")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting inventory movements").Logger()
+	logger.Trace().Msg("inserting inventory movements")
+	span.AddEvent("inserting inventory movements")
+	_, err = s.queries.WithTx(tx).InsertInventoryMovements(c, saleMovements(allocations, actors))
+	if err != nil {
+		err = fmt.Errorf("failed inserting inventory movements with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	logger.Info().Msg("inserted inventory movements")
+	span.AddEvent("inserted inventory movements")
+
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	shipmentArgs, allocationArgs, decreaseArgs := prepareFulfillmentArgs(allocations)
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting shipments").Logger()
+	logger.Trace().Msg("inserting shipments")
+	span.AddEvent("inserting shipments")
+	_, err = s.queries.WithTx(tx).InsertShipments(c, shipmentArgs)
+	if err != nil {
+		err = fmt.Errorf("failed inserting shipments with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	logger.Info().Int(constants.KEY_SHIPMENTS, len(shipmentArgs)).Msg("inserted shipments")
+	span.AddEvent("inserted shipments")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting order item allocations").Logger()
+	logger.Trace().Msg("inserting order item allocations")
+	span.AddEvent("inserting order item allocations")
+	_, err = s.queries.WithTx(tx).InsertOrderItemAllocations(c, allocationArgs)
+	if err != nil {
+		err = fmt.Errorf("failed inserting order item allocations with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	logger.Info().Msg("inserted order item allocations")
+	span.AddEvent("inserted order item allocations")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decreasing inventory levels").Logger()
+	logger.Trace().Msg("decreasing inventory levels")
+	span.AddEvent("decreasing inventory levels")
+	err = s.queries.WithTx(tx).DecreaseInventoryLevels(c, decreaseArgs)
+	if err != nil {
+		err = fmt.Errorf("failed decreasing inventory levels with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	logger.Info().Msg("decreased inventory levels")
+	span.AddEvent("decreased inventory levels")
+
+	return nil
+}
+
+// FindShipmentsByOrderId returns the shipments of an order together with the
+// order items allocated to each of them. Orders of other users have no
+// shipments.
+func (s OrderService) FindShipmentsByOrderId(
+	c context.Context,
+	param request.FindShipments,
+) ([]response.Shipment, error) {
+	c, span := otel.Tracer.Start(c, "OrderService FindShipmentsByOrderId")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService FindShipmentsByOrderId").
+		Str(constants.KEY_ORDER_ID, param.OrderId.String()).
+		Str(constants.KEY_USER_ID, param.UserId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding shipments").Logger()
+	logger.Trace().Msg("finding shipments")
+	span.AddEvent("finding shipments")
+	shipments, err := s.queries.FindShipmentsByOrderId(c, repository.FindShipmentsByOrderIdParams{
+		OrderID: param.OrderId,
+		UserID:  toScope(param.UserId),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed finding shipments with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Int(constants.KEY_SHIPMENTS, len(shipments)).Msg("found shipments")
+	span.AddEvent("found shipments")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding order item allocations").Logger()
+	logger.Trace().Msg("finding order item allocations")
+	span.AddEvent("finding order item allocations")
+	allocations, err := s.queries.FindOrderItemAllocationsByOrderId(c, param.OrderId)
+	if err != nil {
+		err = fmt.Errorf("failed finding order item allocations with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Any(constants.KEY_ALLOCATIONS, allocations).Msg("found order item allocations")
+	span.AddEvent("found order item allocations")
+
+	mapItems := map[uuid.UUID][]response.ShipmentItem{}
+	for _, allocation := range allocations {
+		mapItems[allocation.ShipmentID] = append(mapItems[allocation.ShipmentID], response.ShipmentItem{
+			OrderItemId: allocation.OrderItemID,
+			ProductId:   allocation.ProductID,
+			Quantity:    allocation.Quantity,
+		})
+	}
+	res := make([]response.Shipment, 0, len(shipments))
+	for _, shipment := range shipments {
+		res = append(res, response.Shipment{
+			CreatedAt:   shipment.CreatedAt.Time,
+			UpdatedAt:   shipment.UpdatedAt.Time,
+			Items:       mapItems[shipment.ID],
+			Status:      string(shipment.Status),
+			ID:          shipment.ID,
+			OrderId:     shipment.OrderID,
+			WarehouseId: shipment.WarehouseID,
+		})
+	}
+	return res, nil
+}
+
+// allocateWarehouses allocates the items of a single order and decreases stock
+// accordingly. The closest strategy takes every item from the closest warehouse
+// that can fill it and falls back to fewest splits when the order has no
+// shipping location. The fewest splits strategy repeatedly picks the warehouse
+// that can fill the most remaining items. Items that no single warehouse can
+// fill are split across warehouses in the same preference order.
+func allocateWarehouses(
+	strategy string,
+	items []fulfillmentItem,
+	warehouses []repository.Warehouse,
+	stock map[uuid.UUID]map[uuid.UUID]int32,
+	location *request.Location,
+) []warehouseAllocation {
+	allocations := []warehouseAllocation{}
+	remaining := items
+
+	if strategy == config.FulfillmentClosest && location != nil {
+		closest := make([]repository.Warehouse, len(warehouses))
+		copy(closest, warehouses)
+		sort.SliceStable(closest, func(i, j int) bool {
+			return distanceKm(*location, closest[i]) < distanceKm(*location, closest[j])
+		})
+		for _, item := range remaining {
+			allocations = append(allocations, splitItem(item, closest, stock)...)
+		}
+		return allocations
+	}
+
+	for len(remaining) > 0 {
+		best, bestCount := uuid.Nil, 0
+		for _, warehouse := range warehouses {
+			count := 0
+			for _, item := range remaining {
+				if stock[item.ProductID][warehouse.ID] >= item.Quantity {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = warehouse.ID, count
+			}
+		}
+		if bestCount == 0 {
+			break
+		}
+
+		unfilled := []fulfillmentItem{}
+		for _, item := range remaining {
+			if stock[item.ProductID][best] < item.Quantity {
+				unfilled = append(unfilled, item)
+				continue
+			}
+			stock[item.ProductID][best] -= item.Quantity
+			allocations = append(allocations, newWarehouseAllocation(item, best, item.Quantity))
+		}
+		remaining = unfilled
+	}
+
+	for _, item := range remaining {
+		byStock := make([]repository.Warehouse, len(warehouses))
+		copy(byStock, warehouses)
+		sort.SliceStable(byStock, func(i, j int) bool {
+			return stock[item.ProductID][byStock[i].ID] > stock[item.ProductID][byStock[j].ID]
+		})
+		allocations = append(allocations, splitItem(item, byStock, stock)...)
+	}
+	return allocations
+}
+
+// splitItem takes item from the first warehouse in warehouses that can fill it
+// completely, otherwise it takes what is left in each warehouse in order until
+// the item is filled.
+func splitItem(
+	item fulfillmentItem,
+	warehouses []repository.Warehouse,
+	stock map[uuid.UUID]map[uuid.UUID]int32,
+) []warehouseAllocation {
+	for _, warehouse := range warehouses {
+		if stock[item.ProductID][warehouse.ID] >= item.Quantity {
+			stock[item.ProductID][warehouse.ID] -= item.Quantity
+			return []warehouseAllocation{newWarehouseAllocation(item, warehouse.ID, item.Quantity)}
+		}
+	}
+
+	allocations := []warehouseAllocation{}
+	needed := item.Quantity
+	for _, warehouse := range warehouses {
+		if needed == 0 {
+			break
+		}
+		available := stock[item.ProductID][warehouse.ID]
+		if available <= 0 {
+			continue
+		}
+		quantity := min(available, needed)
+		stock[item.ProductID][warehouse.ID] -= quantity
+		needed -= quantity
+		allocations = append(allocations, newWarehouseAllocation(item, warehouse.ID, quantity))
+	}
+	return allocations
+}
+
+func newWarehouseAllocation(
+	item fulfillmentItem,
+	warehouseId uuid.UUID,
+	quantity int32,
+) warehouseAllocation {
+	return warehouseAllocation{
+		OrderID:     item.OrderID,
+		OrderItemID: item.OrderItemID,
+		ProductID:   item.ProductID,
+		WarehouseID: warehouseId,
+		Quantity:    quantity,
+	}
+}
+
+func warehouseStock(levels []repository.InventoryLevel) map[uuid.UUID]map[uuid.UUID]int32 {
+	stock := map[uuid.UUID]map[uuid.UUID]int32{}
+	for _, level := range levels {
+		if _, ok := stock[level.ProductID]; !ok {
+			stock[level.ProductID] = map[uuid.UUID]int32{}
+		}
+		stock[level.ProductID][level.WarehouseID] = level.Quantity
+	}
+	return stock
+}
+
+func unallocatedQuantity(items []fulfillmentItem, allocations []warehouseAllocation) int32 {
+	var quantity int32
+	for _, item := range items {
+		quantity += item.Quantity
+	}
+	for _, allocation := range allocations {
+		quantity -= allocation.Quantity
+	}
+	return quantity
+}
+
+// distanceKm returns the great-circle distance between location and warehouse.
+func distanceKm(location request.Location, warehouse repository.Warehouse) float64 {
+	lat1 := location.Latitude * math.Pi / 180
+	lat2 := warehouse.Latitude * math.Pi / 180
+	deltaLat := (warehouse.Latitude - location.Latitude) * math.Pi / 180
+	deltaLon := (warehouse.Longitude - location.Longitude) * math.Pi / 180
+
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+func prepareFulfillmentArgs(
+	allocations []warehouseAllocation,
+) ([]repository.InsertShipmentsParams, []repository.InsertOrderItemAllocationsParams, repository.DecreaseInventoryLevelsParams) {
+	now := pgtype.Timestamptz{Time: time.Now(), InfinityModifier: pgtype.Finite, Valid: true}
+
+	type shipmentKey struct{ orderId, warehouseId uuid.UUID }
+	type levelKey struct{ productId, warehouseId uuid.UUID }
+
+	shipmentIds := map[shipmentKey]uuid.UUID{}
+	shipmentArgs := []repository.InsertShipmentsParams{}
+	allocationArgs := make([]repository.InsertOrderItemAllocationsParams, 0, len(allocations))
+	decreased := map[levelKey]int32{}
+	levelKeys := []levelKey{}
+	for _, allocation := range allocations {
+		key := shipmentKey{allocation.OrderID, allocation.WarehouseID}
+		shipmentId, ok := shipmentIds[key]
+		if !ok {
+			shipmentId = uuid.New()
+			shipmentIds[key] = shipmentId
+			shipmentArgs = append(shipmentArgs, repository.InsertShipmentsParams{
+				ID:          shipmentId,
+				OrderID:     allocation.OrderID,
+				WarehouseID: allocation.WarehouseID,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			})
+		}
+		allocationArgs = append(allocationArgs, repository.InsertOrderItemAllocationsParams{
+			OrderItemID: allocation.OrderItemID,
+			ShipmentID:  shipmentId,
+			WarehouseID: allocation.WarehouseID,
+			Quantity:    allocation.Quantity,
+			CreatedAt:   now,
+		})
+
+		level := levelKey{allocation.ProductID, allocation.WarehouseID}
+		if _, ok := decreased[level]; !ok {
+			levelKeys = append(levelKeys, level)
+		}
+		decreased[level] += allocation.Quantity
+	}
+
+	decreaseArgs := repository.DecreaseInventoryLevelsParams{
+		ProductIds:   make([]uuid.UUID, 0, len(levelKeys)),
+		WarehouseIds: make([]uuid.UUID, 0, len(levelKeys)),
+		Quantities:   make([]int32, 0, len(levelKeys)),
+	}
+	for _, level := range levelKeys {
+		decreaseArgs.ProductIds = append(decreaseArgs.ProductIds, level.productId)
+		decreaseArgs.WarehouseIds = append(decreaseArgs.WarehouseIds, level.warehouseId)
+		decreaseArgs.Quantities = append(decreaseArgs.Quantities, decreased[level])
+	}
+	return shipmentArgs, allocationArgs, decreaseArgs
+}
+
+// prepareFulfillmentItems returns the order items that decreased the product
+// quantity grouped by order in the order of orderIds, pending items are
+// fulfilled once their backorder is allocated. Variant items are not allocated
+// to warehouses, warehouses only track the stock of products, their sale is
+// recorded by variantSaleMovements.
+func prepareFulfillmentItems(
+	orderIds []uuid.UUID,
+	mapMergedOrderItem map[string]mergedOrderItem,
+) []fulfillmentItem {
+	mapItems := map[uuid.UUID][]fulfillmentItem{}
+	for _, merged := range mapMergedOrderItem {
+		if merged.Variant {
+			continue
+		}
+		for _, orderItem := range merged.Items {
+			mapItems[orderItem.OrderID] = append(mapItems[orderItem.OrderID], fulfillmentItem{
+				OrderID:     orderItem.OrderID,
+				OrderItemID: orderItem.ID,
+				ProductID:   orderItem.ProductID,
+				Quantity:    orderItem.Quantity,
+			})
+		}
+	}
+
+	items := []fulfillmentItem{}
+	seen := map[uuid.UUID]bool{}
+	for _, orderId := range orderIds {
+		if seen[orderId] {
+			continue
+		}
+		seen[orderId] = true
+		orderItems := mapItems[orderId]
+		sort.SliceStable(orderItems, func(i, j int) bool {
+			return orderItems[i].OrderItemID.String() < orderItems[j].OrderItemID.String()
+		})
+		items = append(items, orderItems...)
+	}
+	return items
+}
+
+// saleMovements returns the inventory movements that take items out of stock,
+// one per warehouse allocation. Orders without an actor are recorded as the
+// order service.
+func saleMovements(
+	allocations []warehouseAllocation,
+	actors map[uuid.UUID]string,
+) []repository.InsertInventoryMovementsParams {
+	movements := make([]repository.InsertInventoryMovementsParams, 0, len(allocations))
+	for _, allocation := range allocations {
+		movements = append(movements, repository.NewInventoryMovement(
+			allocation.ProductID,
+			allocation.WarehouseID,
+			-allocation.Quantity,
+			repository.InventoryMovementReasonSALE,
+			allocation.OrderID,
+			orderActor(actors, allocation.OrderID),
+		))
+	}
+	return movements
+}
+
+// variantSaleMovements returns the inventory movements that take the ordered
+// variants out of stock, one per order item. Variants are not stocked in
+// warehouses, so the movements have none.
+func variantSaleMovements(
+	variantItems map[string]mergedOrderItem,
+	actors map[uuid.UUID]string,
+) []repository.InsertInventoryMovementsParams {
+	movements := []repository.InsertInventoryMovementsParams{}
+	for _, merged := range variantItems {
+		for _, orderItem := range merged.Items {
+			if orderItem.VariantID == nil {
+				continue
+			}
+			movements = append(movements, repository.NewVariantInventoryMovement(
+				orderItem.ProductID,
+				*orderItem.VariantID,
+				-orderItem.Quantity,
+				repository.InventoryMovementReasonSALE,
+				orderItem.OrderID,
+				orderActor(actors, orderItem.OrderID),
+			))
+		}
+	}
+	return movements
+}
+
+// orderActor returns the actor that placed orderId, orders without one are
+// recorded as the order service.
+func orderActor(actors map[uuid.UUID]string, orderId uuid.UUID) string {
+	if actor, ok := actors[orderId]; ok && actor != "" {
+		return actor
+	}
+	return constants.APP_ORDER_SERVICE
+}
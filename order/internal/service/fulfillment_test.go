@@ -0,0 +1,146 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/order/pkg/request"
+)
+
+func TestAllocateWarehousesFewestSplits(t *testing.T) {
+	productA, productB := uuid.New(), uuid.New()
+	north := repository.Warehouse{ID: uuid.New(), Name: "north"}
+	south := repository.Warehouse{ID: uuid.New(), Name: "south"}
+	orderId := uuid.New()
+	items := []fulfillmentItem{
+		{OrderID: orderId, OrderItemID: uuid.New(), ProductID: productA, Quantity: 2},
+		{OrderID: orderId, OrderItemID: uuid.New(), ProductID: productB, Quantity: 1},
+	}
+	stock := map[uuid.UUID]map[uuid.UUID]int32{
+		productA: {north.ID: 5, south.ID: 5},
+		productB: {south.ID: 1},
+	}
+
+	allocations := allocateWarehouses(
+		config.FulfillmentFewestSplits,
+		items,
+		[]repository.Warehouse{north, south},
+		stock,
+		nil,
+	)
+
+	assert.Len(t, allocations, 2)
+	for _, allocation := range allocations {
+		assert.Equal(t, south.ID, allocation.WarehouseID, "order should ship from a single warehouse")
+	}
+	assert.Equal(t, int32(3), stock[productA][south.ID])
+	assert.Equal(t, int32(0), stock[productB][south.ID])
+}
+
+func TestAllocateWarehousesClosest(t *testing.T) {
+	product := uuid.New()
+	jakarta := repository.Warehouse{ID: uuid.New(), Name: "jakarta", Latitude: -6.2, Longitude: 106.8}
+	surabaya := repository.Warehouse{ID: uuid.New(), Name: "surabaya", Latitude: -7.25, Longitude: 112.75}
+	items := []fulfillmentItem{
+		{OrderID: uuid.New(), OrderItemID: uuid.New(), ProductID: product, Quantity: 4},
+	}
+	stock := map[uuid.UUID]map[uuid.UUID]int32{product: {jakarta.ID: 3, surabaya.ID: 10}}
+
+	allocations := allocateWarehouses(
+		config.FulfillmentClosest,
+		items,
+		[]repository.Warehouse{jakarta, surabaya},
+		stock,
+		&request.Location{Latitude: -6.9, Longitude: 107.6},
+	)
+
+	assert.Len(t, allocations, 1, "closest warehouse that can fill the item should be chosen")
+	assert.Equal(t, surabaya.ID, allocations[0].WarehouseID)
+	assert.Equal(t, int32(4), allocations[0].Quantity)
+}
+
+func TestAllocateWarehousesSplitsItem(t *testing.T) {
+	product := uuid.New()
+	north := repository.Warehouse{ID: uuid.New(), Name: "north"}
+	south := repository.Warehouse{ID: uuid.New(), Name: "south"}
+	items := []fulfillmentItem{
+		{OrderID: uuid.New(), OrderItemID: uuid.New(), ProductID: product, Quantity: 5},
+	}
+	stock := map[uuid.UUID]map[uuid.UUID]int32{product: {north.ID: 2, south.ID: 4}}
+
+	allocations := allocateWarehouses(
+		config.FulfillmentFewestSplits,
+		items,
+		[]repository.Warehouse{north, south},
+		stock,
+		nil,
+	)
+
+	assert.Equal(t, []warehouseAllocation{
+		newWarehouseAllocation(items[0], south.ID, 4),
+		newWarehouseAllocation(items[0], north.ID, 1),
+	}, allocations)
+	assert.Equal(t, int32(0), unallocatedQuantity(items, allocations))
+
+	shipments, allocationArgs, decreaseArgs := prepareFulfillmentArgs(allocations)
+	assert.Len(t, shipments, 2, "an item split across warehouses should produce a shipment per warehouse")
+	assert.Len(t, allocationArgs, 2)
+	assert.Equal(t, []int32{4, 1}, decreaseArgs.Quantities)
+}
+
+func TestSaleMovements(t *testing.T) {
+	productId, firstWarehouse, secondWarehouse := uuid.New(), uuid.New(), uuid.New()
+	orderId, orderItemId, userId := uuid.New(), uuid.New(), uuid.New()
+	allocations := []warehouseAllocation{
+		{OrderID: orderId, OrderItemID: orderItemId, ProductID: productId, WarehouseID: firstWarehouse, Quantity: 3},
+		{OrderID: orderId, OrderItemID: orderItemId, ProductID: productId, WarehouseID: secondWarehouse, Quantity: 2},
+	}
+
+	movements := saleMovements(allocations, map[uuid.UUID]string{orderId: userId.String()})
+
+	assert.Len(t, movements, 2)
+	assert.Equal(t, int32(-3), movements[0].Quantity)
+	assert.Equal(t, firstWarehouse, uuid.UUID(movements[0].WarehouseID.Bytes))
+	assert.Equal(t, int32(-2), movements[1].Quantity)
+	assert.Equal(t, secondWarehouse, uuid.UUID(movements[1].WarehouseID.Bytes))
+	for _, movement := range movements {
+		assert.Equal(t, repository.InventoryMovementReasonSALE, movement.Reason)
+		assert.Equal(t, orderId, uuid.UUID(movement.ReferenceID.Bytes))
+		assert.Equal(t, userId.String(), movement.Actor)
+	}
+
+	movements = saleMovements(allocations, nil)
+	assert.Equal(t, constants.APP_ORDER_SERVICE, movements[0].Actor)
+}
+
+func TestVariantSaleMovements(t *testing.T) {
+	productId, variantId, orderId, userId := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	variantItems := map[string]mergedOrderItem{
+		variantId.String(): {
+			Items: []request.OrderItem{
+				{OrderID: orderId, ProductID: productId, VariantID: &variantId, Quantity: 2},
+			},
+			PendingItems: []pendingOrderItem{
+				{OrderItem: request.OrderItem{OrderID: orderId, ProductID: productId, VariantID: &variantId, Quantity: 4}},
+			},
+			OrderedItemQuantity: 2,
+			Variant:             true,
+		},
+	}
+
+	movements := variantSaleMovements(variantItems, map[uuid.UUID]string{orderId: userId.String()})
+
+	assert.Len(t, movements, 1)
+	assert.Equal(t, int32(-2), movements[0].Quantity)
+	assert.Equal(t, productId, movements[0].ProductID)
+	assert.Equal(t, variantId, uuid.UUID(movements[0].VariantID.Bytes))
+	assert.False(t, movements[0].WarehouseID.Valid)
+	assert.Equal(t, repository.InventoryMovementReasonSALE, movements[0].Reason)
+	assert.Equal(t, orderId, uuid.UUID(movements[0].ReferenceID.Bytes))
+	assert.Equal(t, userId.String(), movements[0].Actor)
+}
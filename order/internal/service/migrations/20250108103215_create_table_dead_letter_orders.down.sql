@@ -0,0 +1 @@
+drop table if exists dead_letter_orders;
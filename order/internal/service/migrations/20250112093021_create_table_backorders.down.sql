@@ -0,0 +1,18 @@
+drop index if exists idx_backorders_pending;
+drop table if exists backorders;
+
+alter table products
+drop column if exists backorderable,
+drop column if exists preorderable,
+drop column if exists release_date,
+drop column if exists backorder_limit;
+
+update orders set status = 'CANCELLED' where status in ('BACKORDERED', 'PREORDER');
+alter type order_status rename to order_status_old;
+create type order_status as enum (
+    'WAITING_PAYMENT', 'SHIPPING', 'COMPLETED', 'EXPIRED', 'CANCELLED'
+);
+alter table orders alter column status drop default;
+alter table orders alter column status type order_status using status::text::order_status;
+alter table orders alter column status set default 'WAITING_PAYMENT';
+drop type order_status_old;
@@ -0,0 +1,3 @@
+drop index if exists idx_stock_reservations_cart_id;
+drop index if exists idx_stock_reservations_product_id_expires_at;
+drop table if exists stock_reservations;
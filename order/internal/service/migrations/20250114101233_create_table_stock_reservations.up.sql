@@ -0,0 +1,14 @@
+create table if not exists stock_reservations (
+    id uuid primary key not null default (gen_random_uuid()),
+    cart_id uuid not null references carts (id) on delete cascade,
+    cart_item_id uuid not null references cart_items (id) on delete cascade,
+    product_id uuid not null references products (id),
+    quantity integer not null,
+    expires_at timestamptz not null,
+    created_at timestamptz not null default current_timestamp
+);
+
+create index if not exists idx_stock_reservations_product_id_expires_at on stock_reservations (
+    product_id, expires_at
+);
+create index if not exists idx_stock_reservations_cart_id on stock_reservations (cart_id);
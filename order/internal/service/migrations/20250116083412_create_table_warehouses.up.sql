@@ -0,0 +1,58 @@
+create type shipment_status as enum ('PENDING', 'SHIPPED', 'DELIVERED');
+
+create table if not exists warehouses (
+    id uuid primary key not null default (gen_random_uuid()),
+    name varchar(128) unique not null,
+    latitude double precision not null,
+    longitude double precision not null,
+    is_default boolean not null default false,
+    created_at timestamptz not null default current_timestamp,
+    updated_at timestamptz not null default current_timestamp
+);
+
+create unique index if not exists idx_warehouses_default on warehouses (is_default) where is_default;
+
+create table if not exists inventory_levels (
+    product_id uuid not null references products (id) on delete cascade,
+    warehouse_id uuid not null references warehouses (id) on delete cascade,
+    quantity integer not null default 0 check (quantity >= 0),
+    updated_at timestamptz not null default current_timestamp,
+    primary key (product_id, warehouse_id)
+);
+
+create table if not exists shipments (
+    id uuid primary key not null default (gen_random_uuid()),
+    order_id uuid not null references orders (id) on delete cascade,
+    warehouse_id uuid not null references warehouses (id),
+    status shipment_status not null default 'PENDING',
+    created_at timestamptz not null default current_timestamp,
+    updated_at timestamptz not null default current_timestamp
+);
+
+create index if not exists idx_shipments_order_id on shipments (order_id);
+
+create table if not exists order_item_allocations (
+    id uuid primary key not null default (gen_random_uuid()),
+    order_item_id uuid not null references order_items (id) on delete cascade,
+    shipment_id uuid not null references shipments (id) on delete cascade,
+    warehouse_id uuid not null references warehouses (id),
+    quantity integer not null check (quantity > 0),
+    created_at timestamptz not null default current_timestamp
+);
+
+create index if not exists idx_order_item_allocations_order_item_id on order_item_allocations (
+    order_item_id
+);
+
+insert into warehouses (name, latitude, longitude, is_default) values ('default', 0, 0, true)
+on conflict do nothing;
+
+insert into inventory_levels (product_id, warehouse_id, quantity)
+select
+    p.id,
+    w.id,
+    p.quantity
+from products as p
+cross join warehouses as w
+where w.is_default
+on conflict do nothing;
@@ -0,0 +1,6 @@
+drop index if exists idx_products_created_at;
+drop index if exists idx_products_price;
+drop index if exists idx_products_search;
+
+alter table products
+drop column if exists description;
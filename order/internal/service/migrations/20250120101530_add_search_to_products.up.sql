@@ -0,0 +1,10 @@
+alter table products
+add column if not exists description text not null default '';
+
+create index if not exists idx_products_search on products using gin (
+    to_tsvector('english', name || ' ' || description)
+);
+
+create index if not exists idx_products_price on products (price);
+
+create index if not exists idx_products_created_at on products (created_at);
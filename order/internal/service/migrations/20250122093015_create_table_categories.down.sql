@@ -0,0 +1,4 @@
+drop index if exists idx_product_categories_category_id;
+drop table if exists product_categories;
+drop index if exists idx_categories_parent_id_position;
+drop table if exists categories;
@@ -0,0 +1,5 @@
+alter table stock_reservations drop column if exists variant_id;
+alter table order_items drop column if exists variant_id;
+alter table cart_items drop column if exists variant_id;
+drop index if exists idx_product_variants_product_id;
+drop table if exists product_variants;
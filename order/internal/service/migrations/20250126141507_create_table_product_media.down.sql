@@ -0,0 +1,2 @@
+drop index if exists idx_product_media_product_id;
+drop table if exists product_media;
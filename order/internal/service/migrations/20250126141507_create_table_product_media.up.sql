@@ -0,0 +1,13 @@
+create table if not exists product_media (
+    id uuid primary key not null default (gen_random_uuid()),
+    product_id uuid not null references products (id) on delete cascade,
+    key varchar(255) unique not null,
+    content_type varchar(64) not null,
+    size bigint not null check (size > 0),
+    width integer not null check (width > 0),
+    height integer not null check (height > 0),
+    thumbnails jsonb not null default '{}',
+    created_at timestamptz not null default current_timestamp
+);
+
+create index if not exists idx_product_media_product_id on product_media (product_id, created_at);
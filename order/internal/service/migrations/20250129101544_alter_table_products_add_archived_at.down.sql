@@ -0,0 +1,2 @@
+drop index if exists idx_products_archived_at;
+alter table products drop column if exists archived_at;
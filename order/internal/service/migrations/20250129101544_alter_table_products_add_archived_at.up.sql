@@ -0,0 +1,3 @@
+alter table products add column if not exists archived_at timestamptz;
+
+create index if not exists idx_products_archived_at on products (archived_at) where archived_at is not null;
@@ -0,0 +1,6 @@
+drop table if exists product_ratings;
+drop table if exists product_review_votes;
+drop index if exists idx_product_reviews_product_id_helpful_count;
+drop index if exists idx_product_reviews_product_id_created_at;
+drop table if exists product_reviews;
+drop type if exists review_status;
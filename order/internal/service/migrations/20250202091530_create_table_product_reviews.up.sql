@@ -0,0 +1,39 @@
+create type review_status as enum ('PENDING', 'APPROVED', 'REJECTED');
+
+create table if not exists product_reviews (
+    id uuid primary key not null default (gen_random_uuid()),
+    product_id uuid not null references products (id),
+    user_id uuid not null references users (id),
+    order_id uuid not null references orders (id),
+    rating integer not null check (rating between 1 and 5),
+    body text not null default '',
+    media_urls text [] not null default '{}',
+    status review_status not null default 'PENDING',
+    helpful_count integer not null default 0 check (helpful_count >= 0),
+    moderated_at timestamptz null,
+    created_at timestamptz not null default current_timestamp,
+    updated_at timestamptz not null default current_timestamp,
+    unique (product_id, user_id)
+);
+
+create index if not exists idx_product_reviews_product_id_created_at on product_reviews (
+    product_id, created_at desc, id
+) where status = 'APPROVED';
+
+create index if not exists idx_product_reviews_product_id_helpful_count on product_reviews (
+    product_id, helpful_count desc, id
+) where status = 'APPROVED';
+
+create table if not exists product_review_votes (
+    review_id uuid not null references product_reviews (id) on delete cascade,
+    user_id uuid not null references users (id),
+    created_at timestamptz not null default current_timestamp,
+    primary key (review_id, user_id)
+);
+
+create table if not exists product_ratings (
+    product_id uuid primary key not null references products (id) on delete cascade,
+    rating_count integer not null default 0 check (rating_count >= 0),
+    rating_sum integer not null default 0 check (rating_sum >= 0),
+    updated_at timestamptz not null default current_timestamp
+);
@@ -0,0 +1,11 @@
+create table if not exists product_co_purchases (
+    product_id uuid not null references products (id) on delete cascade,
+    related_product_id uuid not null references products (id) on delete cascade,
+    score integer not null check (score > 0),
+    updated_at timestamptz not null default current_timestamp,
+    primary key (product_id, related_product_id)
+);
+
+create index if not exists idx_product_co_purchases_product_id_score on product_co_purchases (
+    product_id, score desc
+);
@@ -0,0 +1,3 @@
+create type user_role as enum ('CUSTOMER', 'MERCHANT_STAFF', 'ADMIN');
+
+alter table users add column if not exists role user_role not null default 'CUSTOMER';
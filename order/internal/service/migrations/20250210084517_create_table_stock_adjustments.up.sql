@@ -0,0 +1,16 @@
+create table if not exists stock_adjustments (
+    id uuid primary key not null default (gen_random_uuid()),
+    product_id uuid not null references products (id) on delete cascade,
+    warehouse_id uuid not null references warehouses (id) on delete cascade,
+    idempotency_key varchar(255) not null,
+    delta integer not null check (delta <> 0),
+    reason inventory_movement_reason not null,
+    actor varchar(128) not null,
+    warehouse_quantity integer not null,
+    product_quantity integer not null,
+    created_at timestamptz not null default current_timestamp
+);
+
+create unique index if not exists idx_stock_adjustments_product_id_idempotency_key on stock_adjustments (
+    product_id, idempotency_key
+);
@@ -0,0 +1,15 @@
+alter table inventory_movements add column if not exists variant_id uuid null;
+
+create index if not exists idx_inventory_movements_variant_id_created_at on inventory_movements (
+    variant_id, created_at
+) where variant_id is not null;
+
+insert into inventory_movements (product_id, variant_id, quantity, reason, actor)
+select
+    product_id,
+    id,
+    quantity,
+    'ADJUSTMENT',
+    'migration'
+from product_variants
+where quantity <> 0;
@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -12,34 +14,43 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
+	inCache "github.com/Alturino/ecommerce/internal/cache"
+	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
 	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
 	inOtel "github.com/Alturino/ecommerce/internal/otel"
 	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/order/internal/cache"
 	"github.com/Alturino/ecommerce/order/internal/otel"
 	inResponse "github.com/Alturino/ecommerce/order/internal/response"
 	"github.com/Alturino/ecommerce/order/pkg/request"
 	"github.com/Alturino/ecommerce/order/pkg/response"
 )
 
+const CheckoutStrategyBatch = "batch"
+
 type OrderService struct {
-	pool    *pgxpool.Pool
-	queries *repository.Queries
-	cache   *redis.Client
+	pool     *pgxpool.Pool
+	queries  *repository.Queries
+	cache    *inCache.Cache
+	config   config.Order
+	currency config.Currency
 }
 
 func NewOrderService(
 	pool *pgxpool.Pool,
 	queries *repository.Queries,
-	cache *redis.Client,
+	cache *inCache.Cache,
+	config config.Order,
+	currency config.Currency,
 ) *OrderService {
-	return &OrderService{pool: pool, queries: queries, cache: cache}
+	return &OrderService{pool: pool, queries: queries, cache: cache, config: config, currency: currency}
 }
 
 func (s OrderService) FindOrderById(
@@ -49,17 +60,32 @@ func (s OrderService) FindOrderById(
 	c, span := otel.Tracer.Start(c, "OrderService FindOrderById")
 	defer span.End()
 
+	cacheKey := param.OrderId.String()
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
 		Str(constants.KEY_TAG, "OrderService FindOrderById").
+		Str(constants.KEY_CACHE_KEY, cacheKey).
 		Str(constants.KEY_PROCESS, "finding order by id").
 		Logger()
 
 	logger.Info().Msg("finding order by id")
-	order, err := s.queries.FindOrderById(
+	c = logger.WithContext(c)
+	order, err := inCache.GetOrLoad(
 		c,
-		repository.FindOrderByIdParams{ID: param.UserId, ID_2: param.OrderId},
+		s.cache,
+		cache.KEY_ORDERS,
+		cacheKey,
+		func(c context.Context) (response.Order, error) {
+			order, err := s.queries.FindOrderById(
+				c,
+				repository.FindOrderByIdParams{ID: param.OrderId},
+			)
+			if err != nil {
+				return response.Order{}, err
+			}
+			return order.ResponseOrder()
+		},
 	)
 	if err != nil {
 		err = fmt.Errorf("failed finding order by id with error=%w", err)
@@ -69,58 +95,25 @@ func (s OrderService) FindOrderById(
 	}
 	logger.Info().Msg("found order by id")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "mapping order").Logger()
-	logger.Info().Msg("mapping order")
-	res, err := order.ResponseOrder()
-	if err != nil {
-		err = fmt.Errorf("failed mapping order with error=%w", err)
+	logger = logger.With().Str(constants.KEY_PROCESS, "checking order owner").Logger()
+	logger.Trace().Msg("checking order owner")
+	span.AddEvent("checking order owner")
+	if param.UserId != uuid.Nil && order.UserId != param.UserId {
+		err = fmt.Errorf("failed checking order owner with error=%w", pgx.ErrNoRows)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		return response.Order{}, err
 	}
-	logger.Info().Msg("mapped order")
-
-	logger = logger.With().Str(constants.KEY_PROCESS, "finding order item by id").Logger()
-	logger.Info().Msgf("finding order item by orderId=%s", param.OrderId)
-	repoOrderItem, err := s.queries.FindOrderItemById(c, param.OrderId)
-	if err != nil {
-		err = fmt.Errorf("failed finding order by id=%s with error=%w", param.OrderId, err)
-		inOtel.RecordError(err, span)
-		logger.Error().Err(err).Msg(err.Error())
-		return response.Order{}, err
-	}
-	logger.Info().
-		Any(constants.KEY_ORDER_ITEMS, repoOrderItem).
-		Msgf("found order item by id=%s", param.OrderId)
-
-	logger = logger.With().
-		Str(constants.KEY_PROCESS, "mapping orderItems").
-		Logger()
-
-	logger.Info().Msgf("mapping orderItems")
-	orderItems := []response.OrderItem{}
-	for _, i := range repoOrderItem {
-		orderItems = append(
-			orderItems,
-			response.OrderItem{
-				ID:        i.ID,
-				OrderId:   i.OrderID,
-				ProductId: i.ProductID,
-				Quantity:  i.Quantity,
-				Price:     decimal.New(i.Price.Int.Int64(), i.Price.Exp),
-			},
-		)
-	}
-	res.OrderItems = orderItems
-	logger.Info().Msgf("mapped orderItems")
+	span.AddEvent("checked order owner")
+	logger.Debug().Msg("checked order owner")
 
-	return res, nil
+	return order, nil
 }
 
 func (s OrderService) FindOrders(
 	c context.Context,
 	param request.FindOrders,
-) ([]repository.Order, error) {
+) ([]repository.Order, string, error) {
 	c, span := otel.Tracer.Start(c, "OrderService FindOrders")
 	defer span.End()
 
@@ -128,34 +121,65 @@ func (s OrderService) FindOrders(
 		With().
 		Ctx(c).
 		Str(constants.KEY_TAG, "OrderService FindOrders").
-		Str(constants.KEY_PROCESS, "finding order by userId").
 		Str(constants.KEY_USER_ID, param.UserId.String()).
-		Str(constants.KEY_ORDER_ID, param.OrderId.String()).
+		Any(constants.KEY_PAGE, param.Page).
 		Logger()
 
-	logger.Info().Msg("finding orders")
-	orders, err := s.queries.FindOrderUserId(c, param.UserId)
+	cursorId, cursorCreatedAt, err := param.Page.TimeCursorParams()
 	if err != nil {
-		err = fmt.Errorf(
-			"failed finding order by id=%s userId=%s with error=%w",
-			param.OrderId,
-			param.UserId,
-			err,
-		)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		return nil, err
+		return nil, "", err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding orders by userId").Logger()
+	logger.Trace().Msg("finding orders")
+	span.AddEvent("finding orders")
+	orders, err := s.queries.FindOrdersByUserId(c, repository.FindOrdersByUserIdParams{
+		UserID:          param.UserId,
+		CursorID:        cursorId,
+		CursorCreatedAt: cursorCreatedAt,
+		ResultLimit:     param.Page.Limit + 1,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed finding orders by userId=%s with error=%w", param.UserId, err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
 	}
-	logger.Info().Any(constants.KEY_ORDERS, orders).Msg("found orders")
+	orders, nextCursor := inHttp.NextPage(
+		orders,
+		param.Page.Limit,
+		func(order repository.Order) inHttp.Cursor {
+			return inHttp.TimeCursor(order.CreatedAt.Time, order.ID)
+		},
+	)
+	span.AddEvent("found orders")
+	logger.Info().Int(constants.KEY_ORDERS, len(orders)).Msg("found orders")
 
-	return orders, nil
+	return orders, nextCursor, nil
 }
 
 type mergedOrderItem struct {
 	Items               []request.OrderItem `json:"items"`
+	PendingItems        []pendingOrderItem  `json:"pending_items"`
 	OrderedItemQuantity int32               `json:"ordered_item_quantity"`
+	Variant             bool                `json:"variant"`
+}
+
+// pendingOrderItem is an order item that is accepted without decreasing the
+// product quantity, it waits in the backorders table until it is allocated.
+type pendingOrderItem struct {
+	request.OrderItem
+	Status repository.OrderStatus `json:"status"`
 }
 
+// BatchCreateOrder creates every order in params and delivers exactly one
+// result to each order's ResultChannel. Transient failures are retried with
+// backoff, a batch that keeps failing is bisected to isolate the poison order,
+// after another backoff when the failure is transient, and orders that fail
+// for good are stored in the dead letter table while the rest of the batch
+// still succeeds.
 func (s OrderService) BatchCreateOrder(
 	c context.Context,
 	params []request.CreateOrder,
@@ -185,23 +209,239 @@ func (s OrderService) BatchCreateOrder(
 		return map[string]response.Order{}, err
 	}
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "creating orders").Logger()
+	logger.Trace().Msg("creating orders")
+	span.AddEvent("creating orders")
+	c = logger.WithContext(c)
+	mapResponseOrder, attempts, err := s.createOrdersWithRetry(c, params)
+	if err == nil {
+		span.AddEvent("created orders")
+		logger.Info().Int(constants.KEY_ATTEMPTS, attempts).Msg("created orders")
+
+		logger = logger.With().Str(constants.KEY_PROCESS, "sending result").Logger()
+		logger.Trace().Msg("sending result to the orders")
+		span.AddEvent("sending result to the orders")
+		returnOrderResult(c, params, mapResponseOrder)
+		span.AddEvent("sent result to each order")
+		logger.Info().Msg("sent result to each order")
+
+		return mapResponseOrder, nil
+	}
+	err = fmt.Errorf("failed creating orders after attempts=%d with error=%w", attempts, err)
+	inOtel.RecordError(err, span)
+	logger.Error().Err(err).Int(constants.KEY_ATTEMPTS, attempts).Msg(err.Error())
+
+	if backoff := bisectBackoff(s.config.Retry, orderCount, err, attempts); backoff > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "backing off").Logger()
+		logger.Trace().Dur(constants.KEY_BACKOFF, backoff).Msg("backing off before bisecting batch")
+		span.AddEvent("backing off before bisecting batch")
+		_ = sleepWithContext(c, backoff)
+		span.AddEvent("backed off before bisecting batch")
+		logger.Info().Dur(constants.KEY_BACKOFF, backoff).Msg("backed off before bisecting batch")
+	}
+
+	if orderCount == 1 || c.Err() != nil {
+		logger = logger.With().Str(constants.KEY_PROCESS, "dead lettering orders").Logger()
+		logger.Trace().Msg("dead lettering orders")
+		span.AddEvent("dead lettering orders")
+		s.deadLetterOrders(c, params, attempts, err)
+		returnOrderError(c, params, err)
+		span.AddEvent("dead lettered orders")
+		logger.Info().Msg("dead lettered orders")
+		return map[string]response.Order{}, err
+	}
+
+	mid := orderCount / 2
+	logger = logger.With().
+		Str(constants.KEY_PROCESS, "bisecting batch").
+		Int(constants.KEY_BATCH_SPLIT_INDEX, mid).
+		Logger()
+	logger.Trace().Msg("bisecting batch to isolate failing order")
+	span.AddEvent("bisecting batch to isolate failing order")
+	c = logger.WithContext(c)
+	left, leftErr := s.BatchCreateOrder(c, params[:mid])
+	right, rightErr := s.BatchCreateOrder(c, params[mid:])
+	for orderId, order := range right {
+		left[orderId] = order
+	}
+	span.AddEvent("bisected batch")
+	logger.Info().Msgf("bisected batch created %d orders", len(left))
+
+	return left, errors.Join(leftErr, rightErr)
+}
+
+// createOrdersWithRetry runs createOrders until it succeeds, fails with a non
+// retryable error or runs out of attempts. Serialization failures and deadlocks
+// are capped by the checkout strategy max_serialization_retries, any other
+// transient failure is capped by retry max_attempts. It returns the number of
+// attempts that were made.
+func (s OrderService) createOrdersWithRetry(
+	c context.Context,
+	params []request.CreateOrder,
+) (map[string]response.Order, int, error) {
+	checkout := s.config.Checkout[CheckoutStrategyBatch]
+	isolation := checkout.Isolation()
+	maxAttempts := retryMaxAttempts(s.config.Retry)
+	maxSerializationRetries := checkout.MaxSerializationRetries
+
+	c, span := otel.Tracer.Start(
+		c,
+		"OrderService createOrdersWithRetry",
+		trace.WithAttributes(
+			attribute.String(constants.KEY_CHECKOUT_STRATEGY, CheckoutStrategyBatch),
+			attribute.String(constants.KEY_ISOLATION_LEVEL, isolation),
+		),
+	)
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService createOrdersWithRetry").
+		Str(constants.KEY_CHECKOUT_STRATEGY, CheckoutStrategyBatch).
+		Str(constants.KEY_ISOLATION_LEVEL, isolation).
+		Int(constants.KEY_MAX_ATTEMPTS, maxAttempts).
+		Logger()
+
+	txOptions := pgx.TxOptions{IsoLevel: pgx.TxIsoLevel(isolation)}
+	attempt, transientFailures, serializationFailures := 0, 0, 0
+	for {
+		attempt++
+		lg := logger.With().Int(constants.KEY_ATTEMPTS, attempt).Logger()
+
+		mapResponseOrder, err := s.createOrders(lg.WithContext(c), params, txOptions)
+		if err == nil {
+			span.SetAttributes(
+				attribute.Int(constants.KEY_ATTEMPTS, attempt),
+				attribute.Int(constants.KEY_SERIALIZATION_FAILURES, serializationFailures),
+			)
+			return mapResponseOrder, attempt, nil
+		}
+
+		switch {
+		case isSerializationFailure(err):
+			serializationFailures++
+			if serializationFailures > maxSerializationRetries {
+				lg.Info().Err(err).Msg("serialization retries exhausted")
+				span.AddEvent("serialization retries exhausted")
+				return nil, attempt, err
+			}
+		case isRetryableError(err):
+			transientFailures++
+			if transientFailures >= maxAttempts {
+				lg.Info().Err(err).Msg("retry attempts exhausted")
+				span.AddEvent("retry attempts exhausted")
+				return nil, attempt, err
+			}
+		default:
+			lg.Info().Err(err).Msg("create orders failed with non retryable error")
+			span.AddEvent("create orders failed with non retryable error")
+			return nil, attempt, err
+		}
+
+		backoff := retryBackoff(s.config.Retry, transientFailures+serializationFailures)
+		lg.Warn().
+			Err(err).
+			Str(constants.KEY_SQL_STATE, sqlState(err)).
+			Dur(constants.KEY_BACKOFF, backoff).
+			Msg("retrying create orders")
+		span.AddEvent(
+			"retrying create orders",
+			trace.WithAttributes(
+				attribute.Int(constants.KEY_ATTEMPTS, attempt),
+				attribute.String(constants.KEY_BACKOFF, backoff.String()),
+				attribute.String(constants.KEY_SQL_STATE, sqlState(err)),
+				attribute.String(constants.KEY_ISOLATION_LEVEL, isolation),
+				attribute.String(constants.KEY_ERROR, err.Error()),
+			),
+		)
+		if sleepErr := sleepWithContext(c, backoff); sleepErr != nil {
+			return nil, attempt, errors.Join(err, sleepErr)
+		}
+	}
+}
+
+// deadLetterOrders stores orders that could not be created so they can be
+// inspected and replayed later.
+func (s OrderService) deadLetterOrders(
+	c context.Context,
+	params []request.CreateOrder,
+	attempts int,
+	cause error,
+) {
+	c, span := otel.Tracer.Start(context.WithoutCancel(c), "OrderService deadLetterOrders")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService deadLetterOrders").
+		Logger()
+
+	for _, param := range params {
+		lg := logger.With().Str(constants.KEY_ORDER_ID, param.ID.String()).Logger()
+		lg.Trace().Msg("marshaling dead letter order")
+		payload, err := json.Marshal(param)
+		if err != nil {
+			err = fmt.Errorf("failed marshaling dead letter order with error=%w", err)
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			continue
+		}
+
+		lg.Trace().Msg("inserting dead letter order")
+		_, err = s.queries.InsertDeadLetterOrder(c, repository.InsertDeadLetterOrderParams{
+			OrderID:  param.ID,
+			UserID:   param.UserId,
+			Payload:  payload,
+			Error:    cause.Error(),
+			Attempts: int32(attempts),
+		})
+		if err != nil {
+			err = fmt.Errorf("failed inserting dead letter order with error=%w", err)
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			continue
+		}
+		lg.Info().Msg("inserted dead letter order")
+	}
+}
+
+// createOrders runs a single attempt of the batch in its own transaction. It
+// does not deliver any result to the orders, that is left to BatchCreateOrder.
+func (s OrderService) createOrders(
+	c context.Context,
+	params []request.CreateOrder,
+	txOptions pgx.TxOptions,
+) (map[string]response.Order, error) {
+	c, span := otel.Tracer.Start(c, "OrderService createOrders")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService createOrders").
+		Logger()
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "merge order items").Logger()
 	logger.Trace().Msg("merging order items quantity")
 	span.AddEvent("merging order items quantity")
-	mapMergedOrderItem, mapOrder, productIds, orderIds := mergeOrderItems(c, params)
-	logger = logger.With().Any(constants.KEY_PRODUCT_IDS, productIds).Logger()
+	mapMergedOrderItem, mapOrder, productIds, variantIds, orderIds := mergeOrderItems(c, params)
+	logger = logger.With().
+		Any(constants.KEY_PRODUCT_IDS, productIds).
+		Any(constants.KEY_VARIANT_IDS, variantIds).
+		Logger()
 	logger.Info().Msg("merged order items quantity")
 	span.AddEvent("merged order items quantity")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "initalizing transaction").Logger()
 	logger.Trace().Msg("initializing transaction")
 	span.AddEvent("initializing transaction")
-	tx, err := s.pool.BeginTx(c, pgx.TxOptions{})
+	tx, err := s.pool.BeginTx(c, txOptions)
 	if err != nil {
 		err = fmt.Errorf("failed initializing transaction with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		returnOrderError(c, params, err)
 		return map[string]response.Order{}, err
 	}
 	defer func() {
@@ -233,53 +473,215 @@ func (s OrderService) BatchCreateOrder(
 		err = fmt.Errorf("failed get products with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		returnOrderError(c, params, err)
 		return map[string]response.Order{}, err
 	}
 	logger = logger.With().Any(constants.KEY_PRODUCTS, products).Logger()
 	logger.Info().Msg("got product quantity")
 	span.AddEvent("got product quantity")
 
+	logger.Trace().Msg("finding stock reserved by other carts")
+	span.AddEvent("finding stock reserved by other carts")
+	reserved, err := s.queries.WithTx(tx).FindReservedQuantities(
+		c,
+		repository.FindReservedQuantitiesParams{ProductIds: productIds, ExcludedCartIds: orderIds},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed finding stock reserved by other carts with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return map[string]response.Order{}, err
+	}
+	products = excludeReservedQuantity(products, reserved)
+	logger.Info().Any(constants.KEY_RESERVED_QUANTITIES, reserved).Msg("found stock reserved by other carts")
+	span.AddEvent("found stock reserved by other carts")
+
+	stock := products
+	variants := []repository.ProductVariant{}
+	if len(variantIds) > 0 {
+		logger.Trace().Msg("get variant quantity")
+		span.AddEvent("get variant quantity")
+		variants, err = s.queries.WithTx(tx).FindProductVariantsByIds(c, variantIds)
+		if err != nil {
+			err = fmt.Errorf("failed get variants with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return map[string]response.Order{}, err
+		}
+		logger.Info().Any(constants.KEY_VARIANTS, variants).Msg("got variant quantity")
+		span.AddEvent("got variant quantity")
+
+		logger.Trace().Msg("finding variant stock reserved by other carts")
+		span.AddEvent("finding variant stock reserved by other carts")
+		reservedVariants, err := s.queries.WithTx(tx).FindReservedVariantQuantities(
+			c,
+			repository.FindReservedVariantQuantitiesParams{
+				VariantIds:      variantIds,
+				ExcludedCartIds: orderIds,
+			},
+		)
+		if err != nil {
+			err = fmt.Errorf(
+				"failed finding variant stock reserved by other carts with error=%w",
+				err,
+			)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return map[string]response.Order{}, err
+		}
+		stock = append(stock, variantStock(variants, reservedVariants)...)
+		logger.Info().
+			Any(constants.KEY_RESERVED_QUANTITIES, reservedVariants).
+			Msg("found variant stock reserved by other carts")
+		span.AddEvent("found variant stock reserved by other carts")
+	}
+
+	logger.Trace().Msg("finding archived products")
+	span.AddEvent("finding archived products")
+	archived, err := s.queries.WithTx(tx).FindArchivedProductIds(c, orderedProductIds(mapOrder))
+	if err != nil {
+		err = fmt.Errorf("failed finding archived products with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return map[string]response.Order{}, err
+	}
+	stock = withoutArchived(stock, variants, archived)
+	logger.Info().Any(constants.KEY_ARCHIVED_PRODUCT_IDS, archived).Msg("found archived products")
+	span.AddEvent("found archived products")
+
+	// pending backorders are read even for products that no longer accept
+	// them, their queue is still served before any new order
+	logger.Trace().Msg("getting pending backorder quantity")
+	span.AddEvent("getting pending backorder quantity")
+	rows, err := s.queries.WithTx(tx).FindPendingBackorderQuantities(c, productIds)
+	if err != nil {
+		err = fmt.Errorf("failed getting pending backorder quantity with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return map[string]response.Order{}, err
+	}
+	pendingQuantities := map[string]int32{}
+	for _, row := range rows {
+		pendingQuantities[row.ProductID.String()] = row.Quantity
+	}
+	logger.Info().Any(constants.KEY_PENDING_QUANTITIES, pendingQuantities).Msg("got pending backorder quantity")
+	span.AddEvent("got pending backorder quantity")
+
 	span.AddEvent("check and decrease product quantity")
 	logger.Trace().Msg("check and decrease product quantity")
-	mapMergedOrderItem, mapOrder = checkDecreaseQuantity(c, products, mapMergedOrderItem, mapOrder)
+	mapMergedOrderItem, mapOrder = checkDecreaseQuantity(
+		c,
+		stock,
+		pendingQuantities,
+		mapMergedOrderItem,
+		mapOrder,
+	)
 	span.AddEvent("checked and decreased product quantity")
 	logger = logger.With().Logger()
 	logger.Info().Msg("checked and decreased product quantity")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "update-product-quantity").Logger()
-	logger.Trace().Msg("preparing query for update product quantity")
-	span.AddEvent("preparing query for update product quantity")
-	query := buildQuery(c, mapMergedOrderItem)
-	logger = logger.With().Str(constants.KEY_QUERY, query).Logger()
-	logger.Info().Msg("prepared query for update product quantity")
-	span.AddEvent("prepared query for update product quantity")
-
-	logger.Trace().Msg("updating product quantity")
-	span.AddEvent("updating product quantity")
-	rows, err := tx.Query(c, query, productIds)
+	logger = logger.With().Str(constants.KEY_PROCESS, "resolve-price").Logger()
+	logger.Trace().Msg("finding current prices")
+	span.AddEvent("finding current prices")
+	prices, err := s.queries.WithTx(tx).FindCurrentProductPrices(c, orderedProductIds(mapOrder))
 	if err != nil {
-		err = fmt.Errorf("failed updating product quantity with error=%w", err)
-		logger.Error().Err(err).Msg(err.Error())
+		err = fmt.Errorf("failed finding current prices with error=%w", err)
 		inOtel.RecordError(err, span)
-		returnOrderError(c, params, err)
+		logger.Error().Err(err).Msg(err.Error())
 		return map[string]response.Order{}, err
 	}
-	_, err = pgx.CollectRows(rows, pgx.RowToStructByName[repository.Product])
+	mapMergedOrderItem, mapOrder = applyCurrentPrices(
+		mapMergedOrderItem,
+		mapOrder,
+		currentPrices(products, variants, prices),
+	)
+	logger.Info().Any(constants.KEY_PRICES, prices).Msg("found current prices")
+	span.AddEvent("found current prices")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "lock-exchange-rate").Logger()
+	logger.Trace().Msg("finding presentment rates")
+	span.AddEvent("finding presentment rates")
+	rates, err := s.queries.WithTx(tx).FindPresentmentRates(c, repository.FindPresentmentRatesParams{
+		BaseCurrency: s.currency.BaseCurrency(),
+		UserIds:      orderUserIds(mapOrder),
+	})
 	if err != nil {
-		err = fmt.Errorf("failed updating product quantity with error=%w", err)
-		logger.Error().Err(err).Msg(err.Error())
+		err = fmt.Errorf("failed finding presentment rates with error=%w", err)
 		inOtel.RecordError(err, span)
-		returnOrderError(c, params, err)
+		logger.Error().Err(err).Msg(err.Error())
 		return map[string]response.Order{}, err
 	}
-	logger.Info().Msg("updated product quantity")
-	span.AddEvent("updated product quantity")
+	logger.Info().Any(constants.KEY_EXCHANGE_RATES, rates).Msg("found presentment rates")
+	span.AddEvent("found presentment rates")
+
+	productItems, variantItems := splitMergedOrderItems(mapMergedOrderItem)
+	if len(productItems) > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "update-product-quantity").Logger()
+		logger.Trace().Msg("preparing query for update product quantity")
+		span.AddEvent("preparing query for update product quantity")
+		query := buildQuery(c, "products", productItems)
+		logger = logger.With().Str(constants.KEY_QUERY, query).Logger()
+		logger.Info().Msg("prepared query for update product quantity")
+		span.AddEvent("prepared query for update product quantity")
+
+		logger.Trace().Msg("updating product quantity")
+		span.AddEvent("updating product quantity")
+		rows, err := tx.Query(c, query, productIds)
+		if err != nil {
+			err = fmt.Errorf("failed updating product quantity with error=%w", err)
+			logger.Error().Err(err).Msg(err.Error())
+			inOtel.RecordError(err, span)
+			return map[string]response.Order{}, err
+		}
+		_, err = pgx.CollectRows(rows, pgx.RowToStructByName[repository.Product])
+		if err != nil {
+			err = fmt.Errorf("failed updating product quantity with error=%w", err)
+			logger.Error().Err(err).Msg(err.Error())
+			inOtel.RecordError(err, span)
+			return map[string]response.Order{}, err
+		}
+		logger.Info().Msg("updated product quantity")
+		span.AddEvent("updated product quantity")
+	}
+
+	if len(variantItems) > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "update-variant-quantity").Logger()
+		logger.Trace().Msg("preparing query for update variant quantity")
+		span.AddEvent("preparing query for update variant quantity")
+		query := buildQuery(c, "product_variants", variantItems)
+		logger = logger.With().Str(constants.KEY_QUERY, query).Logger()
+		logger.Info().Msg("prepared query for update variant quantity")
+		span.AddEvent("prepared query for update variant quantity")
+
+		logger.Trace().Msg("updating variant quantity")
+		span.AddEvent("updating variant quantity")
+		rows, err := tx.Query(c, query, variantIds)
+		if err != nil {
+			err = fmt.Errorf("failed updating variant quantity with error=%w", err)
+			logger.Error().Err(err).Msg(err.Error())
+			inOtel.RecordError(err, span)
+			return map[string]response.Order{}, err
+		}
+		_, err = pgx.CollectRows(rows, pgx.RowToStructByName[repository.ProductVariant])
+		if err != nil {
+			err = fmt.Errorf("failed updating variant quantity with error=%w", err)
+			logger.Error().Err(err).Msg(err.Error())
+			inOtel.RecordError(err, span)
+			return map[string]response.Order{}, err
+		}
+		logger.Info().Msg("updated variant quantity")
+		span.AddEvent("updated variant quantity")
+	}
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "create-order").Logger()
 	logger.Trace().Msg("preparing order args")
 	span.AddEvent("preparing order args")
-	insertOrderArgs := prepareOrderArgs(c, mapOrder)
+	insertOrderArgs := prepareOrderArgs(
+		c,
+		mapOrder,
+		pendingOrderStatus(mapMergedOrderItem),
+		s.currency.BaseCurrency(),
+		presentments(rates),
+	)
 	span.AddEvent("prepared order args")
 	logger = logger.With().Any("insert_order_args", insertOrderArgs).Logger()
 	logger.Info().Msg("prepared order args")
@@ -291,7 +693,6 @@ func (s OrderService) BatchCreateOrder(
 		err = fmt.Errorf("failed inserting order with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		returnOrderError(c, params, err)
 		return map[string]response.Order{}, err
 	}
 	logger.Info().Msg("inserted orders")
@@ -299,7 +700,7 @@ func (s OrderService) BatchCreateOrder(
 
 	logger.Trace().Msg("preparing insert order items args")
 	span.AddEvent("preparing insert order items args")
-	insertOrderItemArgs := prepareOrderItemArgs(c, mapMergedOrderItem)
+	insertOrderItemArgs := prepareOrderItemArgs(c, mapMergedOrderItem, s.currency.BaseCurrency())
 	span.AddEvent("prepared insert order items args")
 	logger = logger.With().Any("insert_order_item_args", insertOrderItemArgs).Logger()
 	logger.Info().Msg("prepared insert order items args")
@@ -311,12 +712,80 @@ func (s OrderService) BatchCreateOrder(
 		err = fmt.Errorf("failed inserting order items with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		returnOrderError(c, params, err)
 		return map[string]response.Order{}, err
 	}
 	logger.Info().Msg("inserted order items")
 	span.AddEvent("inserted order items")
 
+	insertBackorderArgs := prepareBackorderArgs(c, mapMergedOrderItem, mapOrder)
+	if len(insertBackorderArgs) > 0 {
+		logger = logger.With().Any(constants.KEY_BACKORDERS, insertBackorderArgs).Logger()
+		logger.Trace().Msg("inserting backorders")
+		span.AddEvent("inserting backorders")
+		_, err = s.queries.WithTx(tx).InsertBackorders(c, insertBackorderArgs)
+		if err != nil {
+			err = fmt.Errorf("failed inserting backorders with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return map[string]response.Order{}, err
+		}
+		logger.Info().Msg("inserted backorders")
+		span.AddEvent("inserted backorders")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "fulfill orders").Logger()
+	logger.Trace().Msg("fulfilling orders from warehouses")
+	span.AddEvent("fulfilling orders from warehouses")
+	locations := make(map[uuid.UUID]*request.Location, len(mapOrder))
+	actors := make(map[uuid.UUID]string, len(mapOrder))
+	for _, order := range mapOrder {
+		locations[order.ID] = order.ShippingLocation
+		actors[order.ID] = order.UserId.String()
+	}
+	err = s.fulfillOrders(
+		c,
+		tx,
+		prepareFulfillmentItems(orderIds, mapMergedOrderItem),
+		locations,
+		actors,
+	)
+	if err != nil {
+		err = fmt.Errorf("failed fulfilling orders from warehouses with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return map[string]response.Order{}, err
+	}
+	logger.Info().Msg("fulfilled orders from warehouses")
+	span.AddEvent("fulfilled orders from warehouses")
+
+	if movements := variantSaleMovements(variantItems, actors); len(movements) > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "inserting variant inventory movements").Logger()
+		logger.Trace().Msg("inserting variant inventory movements")
+		span.AddEvent("inserting variant inventory movements")
+		_, err = s.queries.WithTx(tx).InsertInventoryMovements(c, movements)
+		if err != nil {
+			err = fmt.Errorf("failed inserting variant inventory movements with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return map[string]response.Order{}, err
+		}
+		logger.Info().Msg("inserted variant inventory movements")
+		span.AddEvent("inserted variant inventory movements")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "release stock reservations").Logger()
+	logger.Trace().Msg("releasing stock reservations")
+	span.AddEvent("releasing stock reservations")
+	err = s.queries.WithTx(tx).DeleteStockReservationsByCartIds(c, orderIds)
+	if err != nil {
+		err = fmt.Errorf("failed releasing stock reservations with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return map[string]response.Order{}, err
+	}
+	logger.Info().Msg("released stock reservations")
+	span.AddEvent("released stock reservations")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "get orders").Logger()
 	logger.Trace().Msg("getting orders")
 	span.AddEvent("getting orders")
@@ -325,7 +794,6 @@ func (s OrderService) BatchCreateOrder(
 		err = fmt.Errorf("failed getting orders with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		returnOrderError(c, params, err)
 		return map[string]response.Order{}, err
 	}
 	logger.Info().Msg("got orders")
@@ -345,22 +813,94 @@ func (s OrderService) BatchCreateOrder(
 		err = fmt.Errorf("failed committing transaction with error=%w", err)
 		logger.Error().Err(err).Msg(err.Error())
 		inOtel.RecordError(err, span)
-		returnOrderError(c, params, err)
 		return map[string]response.Order{}, err
 	}
 	logger.Info().Msg("committed transaction")
 	span.AddEvent("committed transaction")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "sending result").Logger()
-	logger.Trace().Msg("sending result to the orders")
-	span.AddEvent("sending result to the orders")
-	returnOrderResult(c, params, mapResponseOrder)
-	span.AddEvent("sent result to each order")
-	logger.Info().Msg("sent result to each order")
+	if len(productItems) > 0 {
+		updatedIds := make([]uuid.UUID, 0, len(productItems))
+		for id := range productItems {
+			updatedIds = append(updatedIds, uuid.MustParse(id))
+		}
+		s.publishQuantityUpdated(c, updatedIds)
+	}
+
+	createdIds := make([]uuid.UUID, 0, len(mapResponseOrder))
+	for _, order := range mapResponseOrder {
+		createdIds = append(createdIds, order.ID)
+	}
+	s.invalidateOrders(c, createdIds)
 
 	return mapResponseOrder, nil
 }
 
+// invalidateOrders drops the cached orders of orderIds, including an order id
+// cached as missing before the order was created. The entries expire with the
+// TTL of their family so a failure is only logged.
+func (s OrderService) invalidateOrders(c context.Context, orderIds []uuid.UUID) {
+	c, span := otel.Tracer.Start(c, "OrderService invalidateOrders")
+	defer span.End()
+
+	keys := make([]string, 0, len(orderIds))
+	for _, id := range orderIds {
+		keys = append(keys, id.String())
+	}
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService invalidateOrders").
+		Strs(constants.KEY_CACHE_KEY, keys).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "invalidating orders in cache").Logger()
+	logger.Trace().Msg("invalidating orders in cache")
+	span.AddEvent("invalidating orders in cache")
+	err := s.cache.Delete(c, cache.KEY_ORDERS, keys...)
+	if err != nil {
+		err = fmt.Errorf("failed invalidating orders in cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return
+	}
+	span.AddEvent("invalidated orders in cache")
+	logger.Info().Msg("invalidated orders in cache")
+}
+
+// publishQuantityUpdated tells the product service which products had their
+// quantity changed, a lost message is picked up by its periodic reconciliation
+// so a failure is only logged.
+func (s OrderService) publishQuantityUpdated(c context.Context, productIds []uuid.UUID) {
+	c, span := otel.Tracer.Start(c, "OrderService publishQuantityUpdated")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService publishQuantityUpdated").
+		Any(constants.KEY_PRODUCT_IDS, productIds).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "publishing product quantity updated").Logger()
+	logger.Trace().Msg("publishing product quantity updated")
+	span.AddEvent("publishing product quantity updated")
+	event, err := json.Marshal(response.ProductQuantityUpdated{
+		UpdatedAt:  time.Now(),
+		ProductIds: productIds,
+	})
+	if err == nil {
+		err = s.cache.Publish(c, constants.UPDATE_PRODUCT_QUANTITY, event).Err()
+	}
+	if err != nil {
+		err = fmt.Errorf("failed publishing product quantity updated with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return
+	}
+	span.AddEvent("published product quantity updated")
+	logger.Info().Msg("published product quantity updated")
+}
+
 func returnOrderError(c context.Context, params []request.CreateOrder, err error) {
 	c, span := otel.Tracer.Start(c, "OrderService-returnOrderResult")
 	defer span.End()
@@ -441,18 +981,39 @@ func returnOrderResult(
 func prepareOrderArgs(
 	c context.Context,
 	mapOrder map[string]request.CreateOrder,
+	mapOrderStatus map[string]repository.OrderStatus,
+	currency string,
+	mapPresentment map[uuid.UUID]presentment,
 ) []repository.InsertOrdersParams {
 	_, span := otel.Tracer.Start(c, "OrderService prepareOrderArgs")
 	defer span.End()
 
 	insertOrderArgs := make([]repository.InsertOrdersParams, 0, len(mapOrder))
-	for _, order := range mapOrder {
+	for orderId, order := range mapOrder {
 		if len(order.OrderItems) == 0 {
 			continue
 		}
+		status, ok := mapOrderStatus[orderId]
+		if !ok {
+			status = repository.OrderStatusWAITINGPAYMENT
+		}
+		presented, ok := mapPresentment[order.UserId]
+		if !ok {
+			presented = presentment{Currency: currency, Rate: decimal.NewFromInt(1)}
+		}
 		insertOrderArgs = append(insertOrderArgs, repository.InsertOrdersParams{
-			ID:     order.ID,
-			UserID: order.UserId,
+			ID:                  order.ID,
+			UserID:              order.UserId,
+			Status:              status,
+			Currency:            currency,
+			PresentmentCurrency: presented.Currency,
+			ExchangeRate: pgtype.Numeric{
+				Exp:              presented.Rate.Exponent(),
+				InfinityModifier: pgtype.Finite,
+				Int:              presented.Rate.Coefficient(),
+				NaN:              false,
+				Valid:            true,
+			},
 			CreatedAt: pgtype.Timestamptz{
 				Time:             time.Now(),
 				InfinityModifier: pgtype.Finite,
@@ -471,18 +1032,25 @@ func prepareOrderArgs(
 func prepareOrderItemArgs(
 	c context.Context,
 	mapMergedOrderItem map[string]mergedOrderItem,
+	currency string,
 ) []repository.InsertOrderItemParams {
 	_, span := otel.Tracer.Start(c, "OrderService prepareOrderItemArgs")
 	defer span.End()
 
 	insertOrderItemArgs := make([]repository.InsertOrderItemParams, 0, len(mapMergedOrderItem))
 	for _, item := range mapMergedOrderItem {
-		for _, orderItem := range item.Items {
+		orderItems := item.Items
+		for _, pending := range item.PendingItems {
+			orderItems = append(orderItems, pending.OrderItem)
+		}
+		for _, orderItem := range orderItems {
 			insertOrderItemArgs = append(insertOrderItemArgs, repository.InsertOrderItemParams{
 				ID:        orderItem.ID,
 				OrderID:   orderItem.OrderID,
 				ProductID: orderItem.ProductID,
+				VariantID: toUUID(orderItem.VariantID),
 				Quantity:  orderItem.Quantity,
+				Currency:  currency,
 				CreatedAt: pgtype.Timestamptz{
 					Time:             time.Now(),
 					InfinityModifier: pgtype.Finite,
@@ -506,25 +1074,51 @@ func prepareOrderItemArgs(
 	return insertOrderItemArgs
 }
 
-func buildQuery(c context.Context, mapMergedOrderItem map[string]mergedOrderItem) string {
+// buildQuery returns the statement that decreases the quantity of every row of
+// table, products or product_variants, by its merged order item quantity. Only
+// the rows in the statement are locked, so a batch that orders one variant does
+// not wait on a batch that orders another variant of the same product.
+func buildQuery(
+	c context.Context,
+	table string,
+	mapMergedOrderItem map[string]mergedOrderItem,
+) string {
 	_, span := otel.Tracer.Start(c, "OrderService buildQuery")
 	defer span.End()
 
 	var sb strings.Builder
-	for productId, item := range mapMergedOrderItem {
-		sb.WriteString(fmt.Sprintf(`when id = '%s' then %d `, productId, item.OrderedItemQuantity))
+	for id, item := range mapMergedOrderItem {
+		sb.WriteString(fmt.Sprintf(`when id = '%s' then %d `, id, item.OrderedItemQuantity))
 	}
 	query := fmt.Sprintf(
-		`update products set updated_at = now(), quantity = quantity - case %s end where id = any($1::uuid[]) returning *;`,
+		`update %s set updated_at = now(), quantity = quantity - case %s end where id = any($1::uuid[]) returning *;`,
+		table,
 		sb.String(),
 	)
 	return query
 }
 
+// splitMergedOrderItems separates the merged order items of products from the
+// ones of variants, which are keyed by the variant id.
+func splitMergedOrderItems(
+	mapMergedOrderItem map[string]mergedOrderItem,
+) (map[string]mergedOrderItem, map[string]mergedOrderItem) {
+	products := map[string]mergedOrderItem{}
+	variants := map[string]mergedOrderItem{}
+	for id, item := range mapMergedOrderItem {
+		if item.Variant {
+			variants[id] = item
+			continue
+		}
+		products[id] = item
+	}
+	return products, variants
+}
+
 func mergeOrderItems(
 	c context.Context,
 	params []request.CreateOrder,
-) (map[string]mergedOrderItem, map[string]request.CreateOrder, []uuid.UUID, []uuid.UUID) {
+) (map[string]mergedOrderItem, map[string]request.CreateOrder, []uuid.UUID, []uuid.UUID, []uuid.UUID) {
 	c, span := otel.Tracer.Start(c, "OrderService mergeOrderItems")
 	defer span.End()
 
@@ -539,6 +1133,7 @@ func mergeOrderItems(
 	mapMergedOrderItem := make(map[string]mergedOrderItem, orderCount)
 	mapOrder := make(map[string]request.CreateOrder, orderCount)
 	productIds := make([]uuid.UUID, 0, orderCount)
+	variantIds := []uuid.UUID{}
 	orderIds := make([]uuid.UUID, 0, orderCount)
 	logger.Trace().Msg("merging order items quantity")
 	span.AddEvent("merging order items quantity")
@@ -550,30 +1145,46 @@ func mergeOrderItems(
 			mapOrder[orderId] = order
 		}
 		for _, orderItem := range order.OrderItems {
-			productId := orderItem.ProductID.String()
-			existing, ok := mapMergedOrderItem[productId]
+			stockId := orderItem.ProductID
+			if orderItem.VariantID != nil {
+				stockId = *orderItem.VariantID
+			}
+			existing, ok := mapMergedOrderItem[stockId.String()]
 			if !ok {
-				mapMergedOrderItem[productId] = mergedOrderItem{
+				mapMergedOrderItem[stockId.String()] = mergedOrderItem{
 					Items:               []request.OrderItem{orderItem},
 					OrderedItemQuantity: orderItem.Quantity,
+					Variant:             orderItem.VariantID != nil,
+				}
+				if orderItem.VariantID != nil {
+					variantIds = append(variantIds, stockId)
+					continue
 				}
-				productIds = append(productIds, orderItem.ProductID)
+				productIds = append(productIds, stockId)
 				continue
 			}
 			existing.OrderedItemQuantity += orderItem.Quantity
 			existing.Items = append(existing.Items, orderItem)
-			mapMergedOrderItem[productId] = existing
+			mapMergedOrderItem[stockId.String()] = existing
 		}
 	}
 	span.AddEvent("merged order items quantity")
 	logger.Info().Msg("merged order items quantity")
 
-	return mapMergedOrderItem, mapOrder, productIds, orderIds
+	return mapMergedOrderItem, mapOrder, productIds, variantIds, orderIds
 }
 
+// checkDecreaseQuantity removes the order items that can not be filled from the
+// product quantity, starting from the latest one. The quantity owed to pending
+// backorders is not available, so a restock serves the backorder queue before
+// any new order. Order items of a product that is not released yet are kept as
+// pre-orders and order items of a backorderable product are kept as backorders
+// as long as the product backorder limit allows it, neither of them decreases
+// the product quantity.
 func checkDecreaseQuantity(
 	c context.Context,
 	products []repository.Product,
+	pendingQuantities map[string]int32,
 	mapMergedOrderItem map[string]mergedOrderItem,
 	mapOrder map[string]request.CreateOrder,
 ) (map[string]mergedOrderItem, map[string]request.CreateOrder) {
@@ -586,6 +1197,7 @@ func checkDecreaseQuantity(
 		Str(constants.KEY_TAG, "OrderService checkDecreaseQuantity").
 		Logger()
 
+	now := time.Now()
 	logger.Trace().
 		Any(constants.KEY_ORDERS, mapOrder).
 		Any(constants.KEY_CART_ITEMS_MERGED, mapMergedOrderItem).
@@ -599,8 +1211,31 @@ func checkDecreaseQuantity(
 			lg.Debug().Msg("no order items with product id")
 			continue
 		}
+		pendingQuantity := pendingQuantities[productId]
+
+		if isPreorder(product, now) {
+			lg.Trace().Msg("accepting order items as pre-order")
+			for _, orderItem := range merged.Items {
+				if !withinBackorderLimit(product, pendingQuantity, orderItem.Quantity) {
+					lg.Debug().Str(constants.KEY_ORDER_ITEM_ID, orderItem.ID.String()).Msg("pre-order limit reached")
+					removeOrderItem(mapOrder, orderItem)
+					continue
+				}
+				pendingQuantity += orderItem.Quantity
+				merged.PendingItems = append(
+					merged.PendingItems,
+					pendingOrderItem{OrderItem: orderItem, Status: repository.OrderStatusPREORDER},
+				)
+			}
+			merged.Items = nil
+			merged.OrderedItemQuantity = 0
+			mapMergedOrderItem[productId] = merged
+			lg.Debug().Msg("accepted order items as pre-order")
+			continue
+		}
+		available := product.Quantity - pendingQuantity
 	check:
-		for product.Quantity-merged.OrderedItemQuantity < 0 {
+		for available-merged.OrderedItemQuantity < 0 {
 			if len(merged.Items) == 0 {
 				lg.Debug().Msg("order items empty")
 				break check
@@ -624,13 +1259,21 @@ func checkDecreaseQuantity(
 			merged.Items = merged.Items[:endOrderItemIndex]
 			ld.Debug().Msg("removed order item from merged order items")
 
+			if product.Backorderable && withinBackorderLimit(product, pendingQuantity, orderItem.Quantity) {
+				ld.Trace().Msg("accepting order item as backorder")
+				pendingQuantity += orderItem.Quantity
+				merged.PendingItems = append(
+					merged.PendingItems,
+					pendingOrderItem{OrderItem: orderItem, Status: repository.OrderStatusBACKORDERED},
+				)
+				ld.Debug().Msg("accepted order item as backorder")
+				continue
+			}
+
 			ld.Trace().Msg("checking if order item exist in map order")
-			if order, ok := mapOrder[orderId]; ok {
-				ld.Debug().Msg("order item exist in map order")
-				ld.Trace().Msg("removing order item from map order")
-				order.OrderItems = order.OrderItems[:len(order.OrderItems)-1]
-				mapOrder[orderId] = order
+			if removeOrderItem(mapOrder, orderItem) {
 				ld.Debug().Msg("removed order item from map order")
+				continue
 			}
 			ld.Debug().Msg("order item not exist in map order")
 		}
@@ -673,3 +1316,274 @@ func orderToResponse(
 	}
 	return mapResponseOrder
 }
+
+// removeOrderItem drops an order item that can not be filled from its order,
+// the order is not created once all of its order items are dropped.
+func removeOrderItem(mapOrder map[string]request.CreateOrder, orderItem request.OrderItem) bool {
+	orderId := orderItem.OrderID.String()
+	order, ok := mapOrder[orderId]
+	if !ok || len(order.OrderItems) == 0 {
+		return false
+	}
+	order.OrderItems = order.OrderItems[:len(order.OrderItems)-1]
+	mapOrder[orderId] = order
+	return true
+}
+
+// excludeReservedQuantity subtracts the stock held by carts that are not part of
+// the batch, the orders in the batch are created from carts so their own holds
+// are still available to them.
+func excludeReservedQuantity(
+	products []repository.Product,
+	reserved []repository.FindReservedQuantitiesRow,
+) []repository.Product {
+	mapReserved := make(map[uuid.UUID]int32, len(reserved))
+	for _, row := range reserved {
+		mapReserved[row.ProductID] = row.Quantity
+	}
+	for i, product := range products {
+		products[i].Quantity = product.Quantity - mapReserved[product.ID]
+	}
+	return products
+}
+
+// variantStock returns the variants as products whose quantity is the variant
+// quantity minus the stock held by carts that are not part of the batch, so
+// checkDecreaseQuantity can check them the same way. Variants are neither
+// backorderable nor pre-orderable.
+func variantStock(
+	variants []repository.ProductVariant,
+	reserved []repository.FindReservedVariantQuantitiesRow,
+) []repository.Product {
+	mapReserved := make(map[uuid.UUID]int32, len(reserved))
+	for _, row := range reserved {
+		mapReserved[row.VariantID] = row.Quantity
+	}
+	stock := make([]repository.Product, 0, len(variants))
+	for _, variant := range variants {
+		stock = append(stock, repository.Product{
+			ID:       variant.ID,
+			Quantity: variant.Quantity - mapReserved[variant.ID],
+		})
+	}
+	return stock
+}
+
+// presentment is the currency an order is presented to its user in and the
+// rate from the base currency locked when the order is placed.
+type presentment struct {
+	Currency string
+	Rate     decimal.Decimal
+}
+
+// presentments maps every user with a preferred currency that has an exchange
+// rate to its presentment. Users missing from it are presented the base
+// currency.
+func presentments(rates []repository.FindPresentmentRatesRow) map[uuid.UUID]presentment {
+	mapPresentment := make(map[uuid.UUID]presentment, len(rates))
+	for _, rate := range rates {
+		mapPresentment[rate.UserID] = presentment{
+			Currency: rate.Currency,
+			Rate:     decimal.NewFromBigInt(rate.Rate.Int, rate.Rate.Exp),
+		}
+	}
+	return mapPresentment
+}
+
+// orderUserIds returns the id of every user that placed an order in mapOrder.
+func orderUserIds(mapOrder map[string]request.CreateOrder) []uuid.UUID {
+	seen := map[uuid.UUID]bool{}
+	userIds := []uuid.UUID{}
+	for _, order := range mapOrder {
+		if seen[order.UserId] {
+			continue
+		}
+		seen[order.UserId] = true
+		userIds = append(userIds, order.UserId)
+	}
+	return userIds
+}
+
+// orderedProductIds returns the id of every product in mapOrder, including the
+// products of ordered variants.
+func orderedProductIds(mapOrder map[string]request.CreateOrder) []uuid.UUID {
+	seen := map[uuid.UUID]bool{}
+	productIds := []uuid.UUID{}
+	for _, order := range mapOrder {
+		for _, orderItem := range order.OrderItems {
+			if seen[orderItem.ProductID] {
+				continue
+			}
+			seen[orderItem.ProductID] = true
+			productIds = append(productIds, orderItem.ProductID)
+		}
+	}
+	return productIds
+}
+
+// currentPrices returns the price to charge by product and variant id. A
+// variant with its own price is charged that price, any other item is charged
+// the price of its product that is in effect now, falling back to the product
+// price for products without price history.
+func currentPrices(
+	products []repository.Product,
+	variants []repository.ProductVariant,
+	prices []repository.ProductPrice,
+) map[uuid.UUID]decimal.Decimal {
+	current := make(map[uuid.UUID]decimal.Decimal, len(products)+len(variants)+len(prices))
+	for _, product := range products {
+		if product.Price.Valid {
+			current[product.ID] = decimal.NewFromBigInt(product.Price.Int, product.Price.Exp)
+		}
+	}
+	for _, price := range prices {
+		current[price.ProductID] = decimal.NewFromBigInt(price.Price.Int, price.Price.Exp)
+	}
+	for _, variant := range variants {
+		if variant.Price.Valid {
+			current[variant.ID] = decimal.NewFromBigInt(variant.Price.Int, variant.Price.Exp)
+		}
+	}
+	return current
+}
+
+// applyCurrentPrices sets the price of every order item to its current price,
+// so an order is charged the price in effect at checkout rather than the one
+// it was added to the cart with. Items without a known price keep theirs.
+func applyCurrentPrices(
+	mapMergedOrderItem map[string]mergedOrderItem,
+	mapOrder map[string]request.CreateOrder,
+	prices map[uuid.UUID]decimal.Decimal,
+) (map[string]mergedOrderItem, map[string]request.CreateOrder) {
+	apply := func(orderItem *request.OrderItem) {
+		if orderItem.VariantID != nil {
+			if price, ok := prices[*orderItem.VariantID]; ok {
+				orderItem.Price = price
+				return
+			}
+		}
+		if price, ok := prices[orderItem.ProductID]; ok {
+			orderItem.Price = price
+		}
+	}
+	for id, item := range mapMergedOrderItem {
+		for i := range item.Items {
+			apply(&item.Items[i])
+		}
+		for i := range item.PendingItems {
+			apply(&item.PendingItems[i].OrderItem)
+		}
+		mapMergedOrderItem[id] = item
+	}
+	for id, order := range mapOrder {
+		order.OrderItems = slices.Clone(order.OrderItems)
+		for i := range order.OrderItems {
+			apply(&order.OrderItems[i])
+		}
+		mapOrder[id] = order
+	}
+	return mapMergedOrderItem, mapOrder
+}
+
+// withoutArchived empties the stock of archived products and of the variants of
+// archived products, their order items are rejected like out of stock items
+// and are neither backordered nor pre-ordered.
+func withoutArchived(
+	stock []repository.Product,
+	variants []repository.ProductVariant,
+	archived []uuid.UUID,
+) []repository.Product {
+	if len(archived) == 0 {
+		return stock
+	}
+	isArchived := make(map[uuid.UUID]bool, len(archived)+len(variants))
+	for _, id := range archived {
+		isArchived[id] = true
+	}
+	for _, variant := range variants {
+		if isArchived[variant.ProductID] {
+			isArchived[variant.ID] = true
+		}
+	}
+	result := make([]repository.Product, 0, len(stock))
+	for _, product := range stock {
+		if isArchived[product.ID] {
+			product.Quantity = 0
+			product.Backorderable = false
+			product.Preorderable = false
+		}
+		result = append(result, product)
+	}
+	return result
+}
+
+// isPreorder reports whether the product is pre-orderable and not released yet.
+func isPreorder(product repository.Product, now time.Time) bool {
+	return product.Preorderable && product.ReleaseDate.Valid && product.ReleaseDate.Time.After(now)
+}
+
+// withinBackorderLimit reports whether quantity more items can be backordered
+// or pre-ordered, a backorder limit of zero means there is no limit.
+func withinBackorderLimit(product repository.Product, pendingQuantity int32, quantity int32) bool {
+	return product.BackorderLimit == 0 || pendingQuantity+quantity <= product.BackorderLimit
+}
+
+// pendingOrderStatus returns the status of every order that has a pending order
+// item, an order waiting for a pre-order is PREORDER even if it also waits for
+// a backorder.
+func pendingOrderStatus(mapMergedOrderItem map[string]mergedOrderItem) map[string]repository.OrderStatus {
+	mapOrderStatus := map[string]repository.OrderStatus{}
+	for _, item := range mapMergedOrderItem {
+		for _, pending := range item.PendingItems {
+			orderId := pending.OrderID.String()
+			if mapOrderStatus[orderId] == repository.OrderStatusPREORDER {
+				continue
+			}
+			mapOrderStatus[orderId] = pending.Status
+		}
+	}
+	return mapOrderStatus
+}
+
+func prepareBackorderArgs(
+	c context.Context,
+	mapMergedOrderItem map[string]mergedOrderItem,
+	mapOrder map[string]request.CreateOrder,
+) []repository.InsertBackordersParams {
+	_, span := otel.Tracer.Start(c, "OrderService prepareBackorderArgs")
+	defer span.End()
+
+	insertBackorderArgs := []repository.InsertBackordersParams{}
+	for _, item := range mapMergedOrderItem {
+		for _, pending := range item.PendingItems {
+			createdAt := mapOrder[pending.OrderID.String()].CreatedAt
+			if createdAt.IsZero() {
+				createdAt = time.Now()
+			}
+			insertBackorderArgs = append(insertBackorderArgs, repository.InsertBackordersParams{
+				OrderID:     pending.OrderID,
+				OrderItemID: pending.ID,
+				ProductID:   pending.ProductID,
+				Quantity:    pending.Quantity,
+				CreatedAt: pgtype.Timestamptz{
+					Time:             createdAt,
+					InfinityModifier: pgtype.Finite,
+					Valid:            true,
+				},
+			})
+		}
+	}
+	return insertBackorderArgs
+}
+
+// toScope returns the owner a query is scoped to, none for uuid.Nil.
+func toScope(userId uuid.UUID) pgtype.UUID {
+	return pgtype.UUID{Bytes: userId, Valid: userId != uuid.Nil}
+}
+
+func toUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *id, Valid: true}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -350,3 +351,138 @@ func TestBatchOrderCreation(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckDecreaseQuantityPerVariant(t *testing.T) {
+	c := context.Background()
+	productId, sizeM, sizeL := uuid.New(), uuid.New(), uuid.New()
+	firstOrder, secondOrder := uuid.New(), uuid.New()
+	params := []request.CreateOrder{
+		{
+			ID: firstOrder,
+			OrderItems: []request.OrderItem{
+				{ID: uuid.New(), OrderID: firstOrder, ProductID: productId, VariantID: &sizeM, Quantity: 2},
+			},
+		},
+		{
+			ID: secondOrder,
+			OrderItems: []request.OrderItem{
+				{ID: uuid.New(), OrderID: secondOrder, ProductID: productId, VariantID: &sizeL, Quantity: 1},
+			},
+		},
+	}
+
+	merged, mapOrder, productIds, variantIds, _ := mergeOrderItems(c, params)
+	assert.Empty(t, productIds, "variant items should not lock the parent product")
+	assert.ElementsMatch(t, []uuid.UUID{sizeM, sizeL}, variantIds)
+
+	stock := variantStock(
+		[]repository.ProductVariant{{ID: sizeM, Quantity: 3}, {ID: sizeL, Quantity: 5}},
+		[]repository.FindReservedVariantQuantitiesRow{{VariantID: sizeM, Quantity: 2}},
+	)
+	merged, mapOrder = checkDecreaseQuantity(c, stock, map[string]int32{}, merged, mapOrder)
+
+	assert.Empty(t, mapOrder[firstOrder.String()].OrderItems, "size M should be out of stock")
+	assert.Len(t, mapOrder[secondOrder.String()].OrderItems, 1, "size L should not be affected by size M")
+	products, variants := splitMergedOrderItems(merged)
+	assert.Empty(t, products)
+	assert.Equal(t, int32(1), variants[sizeL.String()].OrderedItemQuantity)
+}
+
+func TestApplyCurrentPrices(t *testing.T) {
+	c := context.Background()
+	shirt, mug, sizeM, sizeL := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	orderId := uuid.New()
+	stale := decimal.RequireFromString("99")
+	params := []request.CreateOrder{
+		{
+			ID: orderId,
+			OrderItems: []request.OrderItem{
+				{ID: uuid.New(), OrderID: orderId, ProductID: mug, Price: stale, Quantity: 1},
+				{ID: uuid.New(), OrderID: orderId, ProductID: shirt, VariantID: &sizeM, Price: stale, Quantity: 1},
+				{ID: uuid.New(), OrderID: orderId, ProductID: shirt, VariantID: &sizeL, Price: stale, Quantity: 1},
+			},
+		},
+	}
+	numeric := func(s string) pgtype.Numeric {
+		d := decimal.RequireFromString(s)
+		return pgtype.Numeric{Int: d.Coefficient(), Exp: d.Exponent(), Valid: true}
+	}
+
+	prices := currentPrices(
+		[]repository.Product{{ID: mug, Price: numeric("12")}},
+		[]repository.ProductVariant{{ID: sizeM}, {ID: sizeL, Price: numeric("25")}},
+		[]repository.ProductPrice{
+			{ProductID: mug, Price: numeric("10")},
+			{ProductID: shirt, Price: numeric("20")},
+		},
+	)
+	merged, mapOrder, _, _, _ := mergeOrderItems(c, params)
+	merged, mapOrder = applyCurrentPrices(merged, mapOrder, prices)
+
+	mugPrice := merged[mug.String()].Items[0].Price
+	assert.True(t, decimal.RequireFromString("10").Equal(mugPrice), "scheduled price wins over product price")
+	sizeMPrice := merged[sizeM.String()].Items[0].Price
+	assert.True(t, decimal.RequireFromString("20").Equal(sizeMPrice), "variant without price uses product price")
+	sizeLPrice := merged[sizeL.String()].Items[0].Price
+	assert.True(t, decimal.RequireFromString("25").Equal(sizeLPrice), "variant price wins")
+	orderItems := mapOrder[orderId.String()].OrderItems
+	assert.True(t, decimal.RequireFromString("10").Equal(orderItems[0].Price))
+	assert.True(t, stale.Equal(params[0].OrderItems[0].Price), "request should not be modified")
+}
+
+func TestWithoutArchived(t *testing.T) {
+	mug, shirt, sizeM, lamp := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	stock := []repository.Product{
+		{ID: mug, Quantity: 5, Backorderable: true},
+		{ID: sizeM, Quantity: 3},
+		{ID: lamp, Quantity: 2},
+	}
+
+	stock = withoutArchived(
+		stock,
+		[]repository.ProductVariant{{ID: sizeM, ProductID: shirt}},
+		[]uuid.UUID{mug, shirt},
+	)
+
+	assert.Equal(t, int32(0), stock[0].Quantity)
+	assert.False(t, stock[0].Backorderable, "archived product should not be backordered")
+	assert.Equal(t, int32(0), stock[1].Quantity, "variant of archived product should be out of stock")
+	assert.Equal(t, int32(2), stock[2].Quantity)
+}
+
+func TestPrepareOrderArgsLocksExchangeRate(t *testing.T) {
+	c := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	aliceOrder, bobOrder := uuid.New(), uuid.New()
+	item := func(orderId uuid.UUID) []request.OrderItem {
+		return []request.OrderItem{{ID: uuid.New(), OrderID: orderId, ProductID: uuid.New(), Quantity: 1}}
+	}
+	mapOrder := map[string]request.CreateOrder{
+		aliceOrder.String(): {ID: aliceOrder, UserId: alice, OrderItems: item(aliceOrder)},
+		bobOrder.String():   {ID: bobOrder, UserId: bob, OrderItems: item(bobOrder)},
+	}
+	rate := decimal.RequireFromString("0.92")
+
+	args := prepareOrderArgs(
+		c,
+		mapOrder,
+		map[string]repository.OrderStatus{},
+		"USD",
+		presentments([]repository.FindPresentmentRatesRow{
+			{UserID: alice, Currency: "EUR", Rate: pgtype.Numeric{Int: rate.Coefficient(), Exp: rate.Exponent(), Valid: true}},
+		}),
+	)
+
+	assert.Len(t, args, 2)
+	for _, arg := range args {
+		assert.Equal(t, "USD", arg.Currency)
+		locked := decimal.NewFromBigInt(arg.ExchangeRate.Int, arg.ExchangeRate.Exp)
+		if arg.UserID == alice {
+			assert.Equal(t, "EUR", arg.PresentmentCurrency)
+			assert.True(t, rate.Equal(locked))
+			continue
+		}
+		assert.Equal(t, "USD", arg.PresentmentCurrency, "user without rate is presented the base currency")
+		assert.True(t, decimal.NewFromInt(1).Equal(locked))
+	}
+}
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/order/internal/otel"
+	"github.com/Alturino/ecommerce/order/pkg/request"
+)
+
+const defaultExpiryBatchSize = 500
+
+// CancelOrder cancels an order that waits for payment or for stock. The stock
+// it took goes back to the warehouses it was allocated from and is recorded as
+// CANCEL movements, its pending backorders are dropped. It fails with
+// pgx.ErrNoRows when the order does not exist or belongs to another user and
+// with ErrNotCancellable once the order is paid.
+func (s OrderService) CancelOrder(
+	c context.Context,
+	param request.CancelOrder,
+) (repository.Order, error) {
+	c, span := otel.Tracer.Start(c, "OrderService CancelOrder")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService CancelOrder").
+		Str(constants.KEY_ORDER_ID, param.OrderId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := s.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Order{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking ordered stock").Logger()
+	logger.Trace().Msg("locking ordered stock")
+	span.AddEvent("locking ordered stock")
+	items, err := s.lockOrderedStock(c, tx, []uuid.UUID{param.OrderId})
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Order{}, err
+	}
+	span.AddEvent("locked ordered stock")
+	logger.Info().Msg("locked ordered stock")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "cancelling order").Logger()
+	logger.Trace().Msg("cancelling order")
+	span.AddEvent("cancelling order")
+	userId := pgtype.UUID{Bytes: param.UserId, Valid: param.UserId != uuid.Nil}
+	order, err := s.queries.WithTx(tx).CancelOrder(
+		c,
+		repository.CancelOrderParams{ID: param.OrderId, UserID: userId},
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		_, findErr := s.queries.WithTx(tx).FindOrderById(
+			c,
+			repository.FindOrderByIdParams{ID: param.OrderId, UserID: userId},
+		)
+		if findErr == nil {
+			err = inErrors.ErrNotCancellable
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("failed cancelling order with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Order{}, err
+	}
+	span.AddEvent("cancelled order")
+	logger.Info().Msg("cancelled order")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "releasing ordered stock").Logger()
+	logger.Trace().Msg("releasing ordered stock")
+	span.AddEvent("releasing ordered stock")
+	productIds, err := s.releaseOrders(
+		c,
+		tx,
+		[]uuid.UUID{order.ID},
+		items,
+		repository.InventoryMovementReasonCANCEL,
+		param.ActorId.String(),
+	)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Order{}, err
+	}
+	span.AddEvent("released ordered stock")
+	logger.Info().Msg("released ordered stock")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Order{}, err
+	}
+	logger.Info().Msg("committed transaction")
+	span.AddEvent("committed transaction")
+
+	if len(productIds) > 0 {
+		s.publishQuantityUpdated(c, productIds)
+	}
+	s.invalidateOrders(c, []uuid.UUID{order.ID})
+
+	return order, nil
+}
+
+// ExpireOrders expires the orders that waited for payment longer than the
+// payment TTL, oldest first. The stock they took goes back to the warehouses
+// it was allocated from and is recorded as EXPIRE movements. Orders never
+// expire when the payment TTL is not set.
+func (s OrderService) ExpireOrders(c context.Context) ([]repository.Order, error) {
+	c, span := otel.Tracer.Start(c, "OrderService ExpireOrders")
+	defer span.End()
+
+	paymentTTL := s.config.Expiry.PaymentTTL
+	batchSize := s.config.Expiry.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultExpiryBatchSize
+	}
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "OrderService ExpireOrders").
+		Logger()
+
+	if paymentTTL <= 0 {
+		logger.Trace().Msg("payment ttl is not set, orders never expire")
+		return nil, nil
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := s.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "expiring orders").Logger()
+	logger.Trace().Msg("expiring orders")
+	span.AddEvent("expiring orders")
+	orders, err := s.queries.WithTx(tx).ExpireOrders(c, repository.ExpireOrdersParams{
+		UpdatedBefore: pgtype.Timestamptz{
+			Time:             time.Now().Add(-paymentTTL),
+			InfinityModifier: pgtype.Finite,
+			Valid:            true,
+		},
+		ResultLimit: int32(batchSize),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed expiring orders with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	orderIds := make([]uuid.UUID, 0, len(orders))
+	for _, order := range orders {
+		orderIds = append(orderIds, order.ID)
+	}
+	logger = logger.With().Any(constants.KEY_ORDER_IDS, orderIds).Logger()
+	span.AddEvent("expired orders")
+	logger.Info().Msg("expired orders")
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking ordered stock").Logger()
+	logger.Trace().Msg("locking ordered stock")
+	span.AddEvent("locking ordered stock")
+	items, err := s.lockOrderedStock(c, tx, orderIds)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("locked ordered stock")
+	logger.Info().Msg("locked ordered stock")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "releasing ordered stock").Logger()
+	logger.Trace().Msg("releasing ordered stock")
+	span.AddEvent("releasing ordered stock")
+	productIds, err := s.releaseOrders(
+		c,
+		tx,
+		orderIds,
+		items,
+		repository.InventoryMovementReasonEXPIRE,
+		constants.APP_ORDER_SERVICE,
+	)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("released ordered stock")
+	logger.Info().Msg("released ordered stock")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	logger.Info().Msg("committed transaction")
+	span.AddEvent("committed transaction")
+	span.SetAttributes(attribute.Int(constants.KEY_ORDERS, len(orders)))
+
+	if len(productIds) > 0 {
+		s.publishQuantityUpdated(c, productIds)
+	}
+	s.invalidateOrders(c, orderIds)
+
+	return orders, nil
+}
+
+// lockOrderedStock locks the products and variants ordered by orderIds, the
+// same way checkout locks them, and returns the ordered items.
+func (s OrderService) lockOrderedStock(
+	c context.Context,
+	tx pgx.Tx,
+	orderIds []uuid.UUID,
+) ([]repository.OrderItem, error) {
+	items, err := s.queries.WithTx(tx).FindOrderItemsByOrderIds(c, orderIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed finding order items with error=%w", err)
+	}
+
+	productIds, variantIds := []uuid.UUID{}, []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, item := range items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIds = append(productIds, item.ProductID)
+		}
+		if item.VariantID.Valid && !seen[item.VariantID.Bytes] {
+			seen[item.VariantID.Bytes] = true
+			variantIds = append(variantIds, item.VariantID.Bytes)
+		}
+	}
+	_, err = s.queries.WithTx(tx).FindProductsByIdsForUpdate(c, productIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed locking products with error=%w", err)
+	}
+	if len(variantIds) > 0 {
+		_, err = s.queries.WithTx(tx).FindProductVariantsByIdsForUpdate(c, variantIds)
+		if err != nil {
+			return nil, fmt.Errorf("failed locking variants with error=%w", err)
+		}
+	}
+	return items, nil
+}
+
+// releaseOrders puts the stock taken by orderIds back, drops their pending
+// backorders and shipments and records every release with reason on behalf of
+// actor. The products and variants of items must be locked by tx. It returns
+// the products whose quantity changed.
+func (s OrderService) releaseOrders(
+	c context.Context,
+	tx pgx.Tx,
+	orderIds []uuid.UUID,
+	items []repository.OrderItem,
+	reason repository.InventoryMovementReason,
+	actor string,
+) ([]uuid.UUID, error) {
+	queries := s.queries.WithTx(tx)
+
+	err := queries.DeletePendingBackordersByOrderIds(c, orderIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed deleting pending backorders with error=%w", err)
+	}
+
+	allocations, err := queries.FindOrderItemAllocationsByOrderIds(c, orderIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed finding order item allocations with error=%w", err)
+	}
+	released := releaseStock(allocations, items, reason, actor)
+
+	if len(released.levels.ProductIds) > 0 {
+		err = queries.IncreaseInventoryLevels(c, released.levels)
+		if err != nil {
+			return nil, fmt.Errorf("failed increasing inventory levels with error=%w", err)
+		}
+		err = queries.IncreaseProductQuantities(c, released.products)
+		if err != nil {
+			return nil, fmt.Errorf("failed increasing product quantities with error=%w", err)
+		}
+	}
+	if len(released.variants.VariantIds) > 0 {
+		err = queries.IncreaseProductVariantQuantities(c, released.variants)
+		if err != nil {
+			return nil, fmt.Errorf("failed increasing variant quantities with error=%w", err)
+		}
+	}
+
+	err = queries.DeletePendingShipmentsByOrderIds(c, orderIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed deleting pending shipments with error=%w", err)
+	}
+
+	if len(released.movements) > 0 {
+		_, err = queries.InsertInventoryMovements(c, released.movements)
+		if err != nil {
+			return nil, fmt.Errorf("failed inserting inventory movements with error=%w", err)
+		}
+	}
+	return released.productIds, nil
+}
+
+// releasedStock is the stock held by released orders. Products go back to the
+// warehouses they were allocated from, variants are not stocked in warehouses.
+type releasedStock struct {
+	levels     repository.IncreaseInventoryLevelsParams
+	products   repository.IncreaseProductQuantitiesParams
+	variants   repository.IncreaseProductVariantQuantitiesParams
+	movements  []repository.InsertInventoryMovementsParams
+	productIds []uuid.UUID
+}
+
+// releaseStock returns the stock to put back for the warehouse allocations of
+// product items and for the variant items of released orders, with one
+// movement per allocation and per variant item. Product items that are still
+// backordered have no allocation, so they took no stock.
+func releaseStock(
+	allocations []repository.FindOrderItemAllocationsByOrderIdsRow,
+	items []repository.OrderItem,
+	reason repository.InventoryMovementReason,
+	actor string,
+) releasedStock {
+	released := releasedStock{
+		levels:     repository.IncreaseInventoryLevelsParams{},
+		products:   repository.IncreaseProductQuantitiesParams{},
+		variants:   repository.IncreaseProductVariantQuantitiesParams{},
+		movements:  []repository.InsertInventoryMovementsParams{},
+		productIds: []uuid.UUID{},
+	}
+	changed := map[uuid.UUID]bool{}
+	markChanged := func(productId uuid.UUID) {
+		if !changed[productId] {
+			changed[productId] = true
+			released.productIds = append(released.productIds, productId)
+		}
+	}
+
+	type level struct{ productId, warehouseId uuid.UUID }
+	levels := map[level]int{}
+	products := map[uuid.UUID]int{}
+	for _, allocation := range allocations {
+		key := level{allocation.ProductID, allocation.WarehouseID}
+		if i, ok := levels[key]; ok {
+			released.levels.Quantities[i] += allocation.Quantity
+		} else {
+			levels[key] = len(released.levels.ProductIds)
+			released.levels.ProductIds = append(released.levels.ProductIds, allocation.ProductID)
+			released.levels.WarehouseIds = append(released.levels.WarehouseIds, allocation.WarehouseID)
+			released.levels.Quantities = append(released.levels.Quantities, allocation.Quantity)
+		}
+		if i, ok := products[allocation.ProductID]; ok {
+			released.products.Quantities[i] += allocation.Quantity
+		} else {
+			products[allocation.ProductID] = len(released.products.ProductIds)
+			released.products.ProductIds = append(released.products.ProductIds, allocation.ProductID)
+			released.products.Quantities = append(released.products.Quantities, allocation.Quantity)
+		}
+		markChanged(allocation.ProductID)
+		released.movements = append(released.movements, repository.NewInventoryMovement(
+			allocation.ProductID,
+			allocation.WarehouseID,
+			allocation.Quantity,
+			reason,
+			allocation.OrderID,
+			actor,
+		))
+	}
+
+	variants := map[uuid.UUID]int{}
+	for _, item := range items {
+		if !item.VariantID.Valid {
+			continue
+		}
+		variantId := uuid.UUID(item.VariantID.Bytes)
+		if i, ok := variants[variantId]; ok {
+			released.variants.Quantities[i] += item.Quantity
+		} else {
+			variants[variantId] = len(released.variants.VariantIds)
+			released.variants.VariantIds = append(released.variants.VariantIds, variantId)
+			released.variants.Quantities = append(released.variants.Quantities, item.Quantity)
+		}
+		markChanged(item.ProductID)
+		released.movements = append(released.movements, repository.NewVariantInventoryMovement(
+			item.ProductID,
+			variantId,
+			item.Quantity,
+			reason,
+			item.OrderID,
+			actor,
+		))
+	}
+	return released
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+)
+
+func TestReleaseStockOnCancel(t *testing.T) {
+	productId, variantProductId, variantId := uuid.New(), uuid.New(), uuid.New()
+	firstWarehouse, secondWarehouse := uuid.New(), uuid.New()
+	orderId, userId := uuid.New(), uuid.New()
+	allocations := []repository.FindOrderItemAllocationsByOrderIdsRow{
+		{OrderID: orderId, ProductID: productId, WarehouseID: firstWarehouse, Quantity: 3},
+		{OrderID: orderId, ProductID: productId, WarehouseID: secondWarehouse, Quantity: 2},
+	}
+	items := []repository.OrderItem{
+		{OrderID: orderId, ProductID: productId, Quantity: 5},
+		{
+			OrderID:   orderId,
+			ProductID: variantProductId,
+			VariantID: pgtype.UUID{Bytes: variantId, Valid: true},
+			Quantity:  4,
+		},
+	}
+
+	released := releaseStock(allocations, items, repository.InventoryMovementReasonCANCEL, userId.String())
+
+	assert.Equal(t, []uuid.UUID{productId, productId}, released.levels.ProductIds)
+	assert.Equal(t, []uuid.UUID{firstWarehouse, secondWarehouse}, released.levels.WarehouseIds)
+	assert.Equal(t, []int32{3, 2}, released.levels.Quantities)
+	assert.Equal(t, []uuid.UUID{productId}, released.products.ProductIds)
+	assert.Equal(t, []int32{5}, released.products.Quantities, "the product gets back what its warehouses get back")
+	assert.Equal(t, []uuid.UUID{variantId}, released.variants.VariantIds)
+	assert.Equal(t, []int32{4}, released.variants.Quantities)
+	assert.Equal(t, []uuid.UUID{productId, variantProductId}, released.productIds)
+
+	assert.Len(t, released.movements, 3)
+	assert.Equal(t, int32(3), released.movements[0].Quantity)
+	assert.Equal(t, firstWarehouse, uuid.UUID(released.movements[0].WarehouseID.Bytes))
+	assert.Equal(t, int32(2), released.movements[1].Quantity)
+	assert.Equal(t, secondWarehouse, uuid.UUID(released.movements[1].WarehouseID.Bytes))
+	assert.Equal(t, int32(4), released.movements[2].Quantity)
+	assert.Equal(t, variantId, uuid.UUID(released.movements[2].VariantID.Bytes))
+	assert.False(t, released.movements[2].WarehouseID.Valid)
+	for _, movement := range released.movements {
+		assert.Equal(t, repository.InventoryMovementReasonCANCEL, movement.Reason)
+		assert.Equal(t, orderId, uuid.UUID(movement.ReferenceID.Bytes))
+		assert.Equal(t, userId.String(), movement.Actor)
+	}
+}
+
+func TestReleaseStockOnExpire(t *testing.T) {
+	productId, warehouseId := uuid.New(), uuid.New()
+	firstOrder, secondOrder := uuid.New(), uuid.New()
+	allocations := []repository.FindOrderItemAllocationsByOrderIdsRow{
+		{OrderID: firstOrder, ProductID: productId, WarehouseID: warehouseId, Quantity: 1},
+		{OrderID: secondOrder, ProductID: productId, WarehouseID: warehouseId, Quantity: 2},
+	}
+
+	released := releaseStock(allocations, nil, repository.InventoryMovementReasonEXPIRE, "order-service")
+
+	assert.Equal(t, []int32{3}, released.levels.Quantities, "allocations of one warehouse are released together")
+	assert.Equal(t, []int32{3}, released.products.Quantities)
+	assert.Len(t, released.movements, 2, "every order keeps its own movement")
+	assert.Equal(t, firstOrder, uuid.UUID(released.movements[0].ReferenceID.Bytes))
+	assert.Equal(t, secondOrder, uuid.UUID(released.movements[1].ReferenceID.Bytes))
+	for _, movement := range released.movements {
+		assert.Equal(t, repository.InventoryMovementReasonEXPIRE, movement.Reason)
+	}
+}
+
+func TestReleaseStockOfBackorder(t *testing.T) {
+	items := []repository.OrderItem{{OrderID: uuid.New(), ProductID: uuid.New(), Quantity: 2}}
+
+	released := releaseStock(nil, items, repository.InventoryMovementReasonCANCEL, "order-service")
+
+	assert.Empty(t, released.levels.ProductIds, "a backordered item took no stock")
+	assert.Empty(t, released.products.ProductIds)
+	assert.Empty(t, released.movements)
+	assert.Empty(t, released.productIds)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Alturino/ecommerce/internal/config"
+)
+
+const (
+	pgClassConnectionException   = "08"
+	pgClassTransactionRollback   = "40"
+	pgClassInsufficientResources = "53"
+	pgClassOperatorIntervention  = "57"
+	pgCodeLockNotAvailable       = "55P03"
+	pgCodeSerializationFailure   = "40001"
+	pgCodeDeadlockDetected       = "40P01"
+
+	defaultRetryMaxAttempts      = 1
+	defaultRetryInitialBackoff   = 50 * time.Millisecond
+	defaultRetryMaxBackoffFactor = 20
+)
+
+// isRetryableError reports whether err is a transient failure that is worth
+// retrying with the same batch, as opposed to a failure caused by the data in
+// the batch itself.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if pgconn.Timeout(err) || pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		var connectErr *pgconn.ConnectError
+		return errors.As(err, &connectErr)
+	}
+	switch {
+	case pgErr.Code == pgCodeLockNotAvailable,
+		strings.HasPrefix(pgErr.Code, pgClassTransactionRollback),
+		strings.HasPrefix(pgErr.Code, pgClassConnectionException),
+		strings.HasPrefix(pgErr.Code, pgClassInsufficientResources),
+		strings.HasPrefix(pgErr.Code, pgClassOperatorIntervention):
+		return true
+	}
+	return false
+}
+
+// isSerializationFailure reports whether err is a serialization failure or a
+// deadlock, both of which are expected under contention and resolved by running
+// the whole transaction again.
+func isSerializationFailure(err error) bool {
+	switch sqlState(err) {
+	case pgCodeSerializationFailure, pgCodeDeadlockDetected:
+		return true
+	}
+	return false
+}
+
+func sqlState(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// retryBackoff returns the delay before the given attempt (starting from 1)
+// using exponential backoff capped at MaxBackoff with full jitter on the upper
+// half of the interval.
+func retryBackoff(cfg config.Retry, attempt int) time.Duration {
+	initial := cfg.InitialBackoff
+	if initial <= 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	maxBackoff := cfg.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = initial * defaultRetryMaxBackoffFactor
+	}
+
+	backoff := initial
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxBackoff)
+
+	half := backoff / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// bisectBackoff returns how long to wait before bisecting a batch of
+// orderCount orders that failed with err after attempts, a transient failure
+// that outlived its retries is given time to clear so the halves don't hit it
+// again. Batches that are not bisected and non transient failures don't wait.
+func bisectBackoff(cfg config.Retry, orderCount int, err error, attempts int) time.Duration {
+	if orderCount <= 1 || !isRetryableError(err) {
+		return 0
+	}
+	return retryBackoff(cfg, attempts+1)
+}
+
+func retryMaxAttempts(cfg config.Retry) int {
+	if cfg.MaxAttempts <= 0 {
+		return defaultRetryMaxAttempts
+	}
+	return cfg.MaxAttempts
+}
+
+func sleepWithContext(c context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c.Done():
+		return c.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "given nil error should not retry", err: nil, expected: false},
+		{
+			name:     "given serialization failure should retry",
+			err:      &pgconn.PgError{Code: "40001"},
+			expected: true,
+		},
+		{
+			name:     "given wrapped deadlock should retry",
+			err:      fmt.Errorf("failed updating product with error=%w", &pgconn.PgError{Code: "40P01"}),
+			expected: true,
+		},
+		{
+			name:     "given lock not available should retry",
+			err:      &pgconn.PgError{Code: "55P03"},
+			expected: true,
+		},
+		{
+			name:     "given foreign key violation should not retry",
+			err:      &pgconn.PgError{Code: "23503"},
+			expected: false,
+		},
+		{
+			name:     "given check violation should not retry",
+			err:      &pgconn.PgError{Code: "23514"},
+			expected: false,
+		},
+		{name: "given canceled context should not retry", err: context.Canceled, expected: false},
+		{name: "given out of stock should not retry", err: inErrors.ErrOutOfStock, expected: false},
+		{name: "given unknown error should not retry", err: errors.New("unknown"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isRetryableError(tt.err))
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	cfg := config.Retry{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond}
+	for attempt, upper := range []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		40 * time.Millisecond,
+	} {
+		backoff := retryBackoff(cfg, attempt+1)
+		assert.GreaterOrEqual(t, backoff, upper/2, "backoff should not be shorter than half the interval")
+		assert.LessOrEqual(t, backoff, upper, "backoff should not exceed the interval")
+	}
+}
+
+func TestBisectBackoff(t *testing.T) {
+	cfg := config.Retry{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond}
+	transient := fmt.Errorf("failed creating orders with error=%w", &pgconn.PgError{Code: pgCodeLockNotAvailable})
+
+	backoff := bisectBackoff(cfg, 4, transient, 1)
+	assert.Positive(t, backoff, "a batch that exhausted its retries should back off before it is bisected")
+	assert.LessOrEqual(t, backoff, 20*time.Millisecond)
+	assert.Zero(t, bisectBackoff(cfg, 1, transient, 1), "a single order is dead lettered without waiting")
+	assert.Zero(t, bisectBackoff(cfg, 4, inErrors.ErrUncoveredStock, 1), "a poison order is bisected right away")
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	assert.True(t, isSerializationFailure(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, isSerializationFailure(fmt.Errorf("wrapped error=%w", &pgconn.PgError{Code: "40P01"})))
+	assert.False(t, isSerializationFailure(&pgconn.PgError{Code: "55P03"}))
+	assert.False(t, isSerializationFailure(errors.New("unknown")))
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	testRedis "github.com/testcontainers/testcontainers-go/modules/redis"
 
+	inCache "github.com/Alturino/ecommerce/internal/cache"
+	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/repository"
 	"github.com/Alturino/ecommerce/order/pkg/request"
 	"github.com/Alturino/ecommerce/order/pkg/response"
@@ -47,6 +49,25 @@ func setup(t *testing.T) setupFunc {
 						filepath.Join("migrations", "20241112144824_create_table_users.up.sql"),
 						filepath.Join("migrations", "20241125115439_create_table_orders.up.sql"),
 						filepath.Join("migrations", "20241119141816_create_table_carts.up.sql"),
+						filepath.Join("migrations", "20250108103215_create_table_dead_letter_orders.up.sql"),
+						filepath.Join("migrations", "20250112093021_create_table_backorders.up.sql"),
+						filepath.Join("migrations", "20250114101233_create_table_stock_reservations.up.sql"),
+						filepath.Join("migrations", "20250116083412_create_table_warehouses.up.sql"),
+						filepath.Join("migrations", "20250118091544_create_table_inventory_movements.up.sql"),
+						filepath.Join("migrations", "20250120101530_add_search_to_products.up.sql"),
+						filepath.Join("migrations", "20250122093015_create_table_categories.up.sql"),
+						filepath.Join("migrations", "20250124103012_create_table_product_variants.up.sql"),
+						filepath.Join("migrations", "20250126141507_create_table_product_media.up.sql"),
+						filepath.Join("migrations", "20250128093021_create_table_product_prices.up.sql"),
+						filepath.Join("migrations", "20250129101544_alter_table_products_add_archived_at.up.sql"),
+						filepath.Join("migrations", "20250131090215_create_table_product_stock_alerts.up.sql"),
+						filepath.Join("migrations", "20250202091530_create_table_product_reviews.up.sql"),
+						filepath.Join("migrations", "20250204093011_create_table_product_co_purchases.up.sql"),
+						filepath.Join("migrations", "20250206090512_add_currency.up.sql"),
+						filepath.Join("migrations", "20250208091204_add_user_roles.up.sql"),
+						filepath.Join("migrations", "20250210084517_create_table_stock_adjustments.up.sql"),
+						filepath.Join("migrations", "20250211093027_create_table_active_carts.up.sql"),
+						filepath.Join("migrations", "20250212081536_alter_table_inventory_movements_add_variant_id.up.sql"),
 						filepath.Join("seed", "users.seed.sql"),
 					},
 					seedPaths...)...,
@@ -100,7 +121,13 @@ func setup(t *testing.T) setupFunc {
 		}
 
 		queries := repository.New(pool)
-		orderService := NewOrderService(pool, queries, redisClient)
+		orderService := NewOrderService(
+			pool,
+			queries,
+			inCache.New(redisClient, config.Cache{}),
+			config.Order{Retry: config.Retry{MaxAttempts: 3}},
+			config.Currency{},
+		)
 		return redisClient, pool, pgContainer, redisContainer, queries, orderService
 	}
 }
@@ -7,39 +7,66 @@ import (
 	"github.com/shopspring/decimal"
 	"go.opentelemetry.io/otel/trace"
 
+	inHttp "github.com/Alturino/ecommerce/internal/http"
 	"github.com/Alturino/ecommerce/order/internal/response"
 )
 
 type CreateOrder struct {
-	OrderItems    []OrderItem          `validate:"required,gt=0" json:"order_items"`
-	CreatedAt     time.Time            `validate:"required"      json:"created_at"`
-	UpdatedAt     time.Time            `validate:"required"      json:"updated_at"`
-	ID            uuid.UUID            `validate:"required,uuid" json:"id"`
-	UserId        uuid.UUID            `validate:"required,uuid" json:"user_id"`
-	ResultChannel chan response.Result `                         json:"-"`
-	TraceLink     trace.Link           `                         json:"-"`
+	OrderItems       []OrderItem          `validate:"required,gt=0" json:"order_items"`
+	CreatedAt        time.Time            `validate:"required"      json:"created_at"`
+	UpdatedAt        time.Time            `validate:"required"      json:"updated_at"`
+	ShippingLocation *Location            `validate:"omitempty"     json:"shipping_location,omitempty"`
+	ID               uuid.UUID            `validate:"required,uuid" json:"id"`
+	UserId           uuid.UUID            `validate:"required,uuid" json:"user_id"`
+	ResultChannel    chan response.Result `                         json:"-"`
+	TraceLink        trace.Link           `                         json:"-"`
+}
+
+// Location is the coordinate of a shipping address, it is used to fulfill
+// an order from the closest warehouse.
+type Location struct {
+	Latitude  float64 `validate:"gte=-90,lte=90"   json:"latitude"`
+	Longitude float64 `validate:"gte=-180,lte=180" json:"longitude"`
 }
 
 type FindOrderByUserId struct {
 	UserId uuid.UUID `validate:"uuid"`
 }
 
+// FindOrderById finds an order of UserId, uuid.Nil lets admins find every
+// order.
 type FindOrderById struct {
-	UserId  uuid.UUID `validate:"required,uuid"`
+	UserId  uuid.UUID
 	OrderId uuid.UUID `validate:"required,uuid"`
 }
 
-type FindOrders struct {
-	UserId  uuid.UUID `validate:"required,uuid"`
+// FindShipments finds the shipments of an order of UserId, uuid.Nil lets admins
+// find the shipments of every order.
+type FindShipments struct {
+	UserId  uuid.UUID
 	OrderId uuid.UUID `validate:"required,uuid"`
 }
 
+// CancelOrder cancels an order of UserId on behalf of ActorId, uuid.Nil lets
+// admins cancel every order.
+type CancelOrder struct {
+	UserId  uuid.UUID
+	ActorId uuid.UUID `validate:"required,uuid"`
+	OrderId uuid.UUID `validate:"required,uuid"`
+}
+
+type FindOrders struct {
+	UserId uuid.UUID `validate:"required,uuid"`
+	Page   inHttp.Page
+}
+
 type OrderItem struct {
 	CreatedAt time.Time       `validate:"required"       json:"created_at"`
 	UpdatedAt time.Time       `validate:"required"       json:"updated_at"`
 	ID        uuid.UUID       `validate:"required,uuid"  json:"id"`
 	OrderID   uuid.UUID       `validate:"required,uuid"  json:"order_id"`
 	ProductID uuid.UUID       `validate:"required,uuid"  json:"product_id"`
+	VariantID *uuid.UUID      `                          json:"variant_id,omitempty"`
 	Price     decimal.Decimal `validate:"required"       json:"price"`
 	Quantity  int32           `validate:"required,gte=1" json:"quantity"`
 }
@@ -5,23 +5,89 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+
+	"github.com/Alturino/ecommerce/internal/money"
 )
 
+// Order is charged in Currency, the base currency of the catalog, and presented
+// to its user in PresentmentCurrency using ExchangeRate, the rate locked when
+// the order was placed.
 type Order struct {
-	CreatedAt  time.Time   `json:"created_at"`
-	UpdatedAt  time.Time   `json:"updated_at"`
-	OrderItems []OrderItem `json:"order_items"`
-	Status     string      `json:"status"`
-	ID         uuid.UUID   `json:"id"`
-	UserId     uuid.UUID   `json:"user_id"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+	OrderItems          []OrderItem     `json:"order_items"`
+	Status              string          `json:"status"`
+	Currency            string          `json:"currency"`
+	PresentmentCurrency string          `json:"presentment_currency"`
+	Total               decimal.Decimal `json:"total"`
+	PresentmentTotal    decimal.Decimal `json:"presentment_total"`
+	ExchangeRate        decimal.Decimal `json:"exchange_rate"`
+	ID                  uuid.UUID       `json:"id"`
+	UserId              uuid.UUID       `json:"user_id"`
 }
 
 type OrderItem struct {
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
-	ID        uuid.UUID       `json:"id"`
-	OrderId   uuid.UUID       `json:"order_id"`
-	ProductId uuid.UUID       `json:"product_id"`
-	Price     decimal.Decimal `json:"price"`
-	Quantity  int32           `json:"quantity"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+	ID                  uuid.UUID       `json:"id"`
+	OrderId             uuid.UUID       `json:"order_id"`
+	ProductId           uuid.UUID       `json:"product_id"`
+	VariantId           *uuid.UUID      `json:"variant_id"`
+	Currency            string          `json:"currency"`
+	PresentmentCurrency string          `json:"presentment_currency"`
+	Price               decimal.Decimal `json:"price"`
+	PresentmentPrice    decimal.Decimal `json:"presentment_price"`
+	Quantity            int32           `json:"quantity"`
+}
+
+// Present sets the totals of o and the presentment price of its items using the
+// exchange rate locked when o was placed. Every amount is rounded to the minor
+// unit of its currency.
+func (o Order) Present() Order {
+	o.Total = decimal.Zero
+	o.PresentmentTotal = decimal.Zero
+	for i, item := range o.OrderItems {
+		quantity := decimal.NewFromInt32(item.Quantity)
+		item.Currency = o.Currency
+		item.PresentmentCurrency = o.PresentmentCurrency
+		item.PresentmentPrice = money.Convert(item.Price, o.ExchangeRate, o.PresentmentCurrency)
+		o.Total = o.Total.Add(item.Price.Mul(quantity))
+		o.PresentmentTotal = o.PresentmentTotal.Add(item.PresentmentPrice.Mul(quantity))
+		o.OrderItems[i] = item
+	}
+	o.Total = money.Round(o.Total, o.Currency)
+	o.PresentmentTotal = money.Round(o.PresentmentTotal, o.PresentmentCurrency)
+	return o
+}
+
+type OrderAllocated struct {
+	AllocatedAt time.Time `json:"allocated_at"`
+	Status      string    `json:"status"`
+	ID          uuid.UUID `json:"id"`
+	UserId      uuid.UUID `json:"user_id"`
+}
+
+// ProductQuantityUpdated is published after an order, a backorder allocation or
+// a stock change in the product service changed the quantity of products, the
+// product service refreshes its cached products and evaluates their stock
+// alerts from it.
+type ProductQuantityUpdated struct {
+	UpdatedAt  time.Time   `json:"updated_at"`
+	ProductIds []uuid.UUID `json:"product_ids"`
+}
+
+type Shipment struct {
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Items       []ShipmentItem `json:"items"`
+	Status      string         `json:"status"`
+	ID          uuid.UUID      `json:"id"`
+	OrderId     uuid.UUID      `json:"order_id"`
+	WarehouseId uuid.UUID      `json:"warehouse_id"`
+}
+
+type ShipmentItem struct {
+	OrderItemId uuid.UUID `json:"order_item_id"`
+	ProductId   uuid.UUID `json:"product_id"`
+	Quantity    int32     `json:"quantity"`
 }
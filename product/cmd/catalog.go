@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	inCache "github.com/Alturino/ecommerce/internal/cache"
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/infra"
+	"github.com/Alturino/ecommerce/internal/log"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/catalog"
+	"github.com/Alturino/ecommerce/product/internal/service"
+)
+
+// CatalogImport holds the flags of the catalog import command, an empty File
+// or - reads the catalog from stdin.
+type CatalogImport struct {
+	File      string
+	Format    string
+	Match     string
+	DryRun    bool
+	ChunkSize int
+}
+
+// CatalogExport holds the flags of the catalog export command, an empty File
+// or - writes the catalog to stdout.
+type CatalogExport struct {
+	File   string
+	Format string
+}
+
+// RunCatalogImport imports a catalog file straight into the product database
+// and prints the report to stdout, logs go to stderr.
+func RunCatalogImport(c context.Context, param CatalogImport) error {
+	c, logger, svc, shutdown := initCatalog(c, "RunCatalogImport")
+	defer shutdown()
+
+	opts := service.ImportOptions{Match: param.Match, DryRun: param.DryRun, ChunkSize: param.ChunkSize}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, opts); err != nil {
+		return fmt.Errorf("failed validating flags with error=%w", err)
+	}
+
+	format, err := catalogFormat(param.File, param.Format)
+	if err != nil {
+		return err
+	}
+	input := io.Reader(os.Stdin)
+	if param.File != "" && param.File != "-" {
+		file, err := os.Open(param.File)
+		if err != nil {
+			return fmt.Errorf("failed opening file=%s with error=%w", param.File, err)
+		}
+		defer file.Close()
+		input = file
+	}
+	reader, err := catalog.NewReader(format, input)
+	if err != nil {
+		return err
+	}
+
+	logger.Info().Any(constants.KEY_IMPORT_OPTIONS, opts).Msg("importing catalog")
+	report, importErr := svc.Import(c, reader, opts)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed writing report with error=%w", err)
+	}
+	if importErr != nil {
+		return importErr
+	}
+	logger.Info().
+		Int(constants.KEY_CATALOG_ROWS, report.Rows).
+		Int(constants.KEY_CATALOG_FAILED, report.Failed).
+		Msg("imported catalog")
+	if report.Failed > 0 {
+		return fmt.Errorf("failed importing %d of %d rows", report.Failed, report.Rows)
+	}
+	return nil
+}
+
+// RunCatalogExport writes every product of the product database to a file.
+func RunCatalogExport(c context.Context, param CatalogExport) error {
+	c, logger, svc, shutdown := initCatalog(c, "RunCatalogExport")
+	defer shutdown()
+
+	format, err := catalogFormat(param.File, param.Format)
+	if err != nil {
+		return err
+	}
+	output := io.Writer(os.Stdout)
+	if param.File != "" && param.File != "-" {
+		file, err := os.Create(param.File)
+		if err != nil {
+			return fmt.Errorf("failed creating file=%s with error=%w", param.File, err)
+		}
+		defer file.Close()
+		output = file
+	}
+	writer, err := catalog.NewWriter(format, output)
+	if err != nil {
+		return err
+	}
+
+	logger.Info().Str(constants.KEY_CATALOG_FORMAT, format).Msg("exporting catalog")
+	rows, err := svc.Export(c, writer)
+	if err != nil {
+		return err
+	}
+	logger.Info().Int(constants.KEY_CATALOG_ROWS, rows).Msg("exported catalog")
+	return nil
+}
+
+// initCatalog connects to the database and cache of the product service, the
+// logger writes to stderr so stdout only carries the catalog or the report.
+func initCatalog(
+	c context.Context,
+	tag string,
+) (context.Context, zerolog.Logger, service.CatalogService, func()) {
+	cfg := config.Get(c, constants.APP_PRODUCT_SERVICE)
+
+	reqId := uuid.NewString()
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}).
+		Level(zerolog.InfoLevel).
+		With().
+		Timestamp().
+		Str(constants.KEY_APP_NAME, constants.APP_PRODUCT_SERVICE).
+		Str(constants.KEY_TAG, "main "+tag).
+		Str(constants.KEY_REQUEST_ID, reqId).
+		Logger()
+	c = log.AttachRequestIDToContext(logger.WithContext(c), reqId)
+
+	db := infra.NewDatabaseClient(c, cfg.Database)
+	cache := infra.NewCacheClient(c, cfg.Cache)
+	svc := service.NewCatalogService(db, repository.New(db), inCache.New(cache, cfg.Cache))
+	shutdown := func() {
+		db.Close()
+		if err := cache.Close(); err != nil {
+			err = fmt.Errorf("failed closing cache with error=%w", err)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}
+	return c, logger, svc, shutdown
+}
+
+func catalogFormat(file string, format string) (string, error) {
+	if format != "" {
+		return format, nil
+	}
+	if file == "" || file == "-" {
+		return "", errors.New("format is required when reading stdin or writing stdout")
+	}
+	return catalog.FormatFromPath(file)
+}
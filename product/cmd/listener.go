@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/log"
+	orderResponse "github.com/Alturino/ecommerce/order/pkg/response"
+	"github.com/Alturino/ecommerce/product/internal/service"
+)
+
+const (
+	defaultReconcileInterval = time.Minute
+	defaultReconcileLookback = 24 * time.Hour
+	// reconcileOverlap covers updates committed after a reconciliation started
+	// whose updated_at is still older than the latest update it saw.
+	reconcileOverlap = time.Minute
+)
+
+type ProductUpdateListener struct {
+	svc      *service.ProductService
+	cache    *redis.Client
+	interval time.Duration
+	lookback time.Duration
+}
+
+func NewProductUpdateListener(
+	svc *service.ProductService,
+	cache *redis.Client,
+	interval time.Duration,
+	lookback time.Duration,
+) *ProductUpdateListener {
+	if interval <= 0 {
+		interval = defaultReconcileInterval
+	}
+	if lookback <= 0 {
+		lookback = defaultReconcileLookback
+	}
+	return &ProductUpdateListener{svc: svc, cache: cache, interval: interval, lookback: lookback}
+}
+
+// StartListener refreshes the cached products and evaluates the stock alerts of
+// the products whose quantity changed. Every interval it also reconciles the cache against the products
+// updated since the last reconciliation, so a missed message is only stale
+// until the next tick.
+func (l ProductUpdateListener) StartListener(c context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Reset().
+		Str(constants.KEY_TAG, "ProductUpdateListener StartListener").
+		Str(constants.KEY_PROCESS, "starting listener").
+		Str(constants.KEY_APP_NAME, constants.APP_PRODUCT_UPDATE_LISTENER).
+		Logger()
+
+	pubsub := l.cache.Subscribe(c, constants.UPDATE_PRODUCT_QUANTITY)
+	defer pubsub.Close()
+	updates := pubsub.Channel()
+
+	since := time.Now().Add(-l.lookback)
+	reconcile := func() {
+		reqId := uuid.NewString()
+		lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+		lg.Trace().Msg("start reconciling cached products")
+		ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+		latest, err := l.svc.ReconcileCachedProducts(ctx, since.Add(-reconcileOverlap))
+		if err != nil {
+			err = fmt.Errorf("failed reconciling cached products with error=%w", err)
+			lg.Error().Err(err).Msg(err.Error())
+			return
+		}
+		if latest.After(since) {
+			since = latest
+		}
+		lg.Debug().Time(constants.KEY_UPDATED_SINCE, since).Msg("reconciled cached products")
+	}
+
+	reconcile()
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			reconcile()
+		case msg, ok := <-updates:
+			if !ok {
+				return
+			}
+			reqId := uuid.NewString()
+			lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+			lg.Info().Msg("received product quantity updated")
+
+			event := orderResponse.ProductQuantityUpdated{}
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				err = fmt.Errorf("failed unmarshalling product quantity updated with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+				continue
+			}
+
+			ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+			if err := l.svc.RefreshCachedProducts(ctx, event.ProductIds); err != nil {
+				err = fmt.Errorf("failed refreshing cached products with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+			} else {
+				lg.Info().Any(constants.KEY_PRODUCT_IDS, event.ProductIds).Msg("refreshed cached products")
+			}
+
+			alerts, err := l.svc.EvaluateStockAlerts(ctx, event.ProductIds)
+			if err != nil {
+				err = fmt.Errorf("failed evaluating stock alerts with error=%w", err)
+				lg.Error().Err(err).Msg(err.Error())
+				continue
+			}
+			lg.Info().Int(constants.KEY_STOCK_ALERTS, len(alerts)).Msg("evaluated stock alerts")
+		}
+	}
+}
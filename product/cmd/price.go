@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/log"
+	"github.com/Alturino/ecommerce/product/internal/service"
+)
+
+const defaultActivatorInterval = time.Minute
+
+type PriceActivator struct {
+	svc      *service.ProductService
+	cache    *redis.Client
+	interval time.Duration
+}
+
+func NewPriceActivator(
+	svc *service.ProductService,
+	cache *redis.Client,
+	interval time.Duration,
+) *PriceActivator {
+	if interval <= 0 {
+		interval = defaultActivatorInterval
+	}
+	return &PriceActivator{svc: svc, cache: cache, interval: interval}
+}
+
+// StartActivator copies scheduled prices to their products at the moment they
+// take effect. It sleeps until the next scheduled price or at most interval,
+// a newly scheduled price wakes it up to look for an earlier change.
+func (a PriceActivator) StartActivator(c context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Reset().
+		Str(constants.KEY_TAG, "PriceActivator StartActivator").
+		Str(constants.KEY_PROCESS, "starting activator").
+		Str(constants.KEY_APP_NAME, constants.APP_PRODUCT_SERVICE).
+		Logger()
+
+	pubsub := a.cache.Subscribe(c, constants.PRODUCT_PRICE_SCHEDULED)
+	defer pubsub.Close()
+	scheduled := pubsub.Channel()
+
+	activate := func(trigger string) time.Duration {
+		reqId := uuid.NewString()
+		lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+		lg.Trace().Msgf("start activating prices triggered by %s", trigger)
+		ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+		products, err := a.svc.ActivatePrices(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed activating prices with error=%w", err)
+			lg.Error().Err(err).Msg(err.Error())
+			return a.interval
+		}
+		if len(products) > 0 {
+			lg.Info().Int(constants.KEY_PRODUCTS, len(products)).Msg("activated prices")
+		}
+
+		next, ok, err := a.svc.NextPriceChange(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed finding next price change with error=%w", err)
+			lg.Error().Err(err).Msg(err.Error())
+			return a.interval
+		}
+		if !ok {
+			return a.interval
+		}
+		lg.Debug().Time(constants.KEY_NEXT_PRICE_CHANGE, next).Msg("found next price change")
+		return min(max(time.Until(next), 0), a.interval)
+	}
+
+	timer := time.NewTimer(activate("start"))
+	defer timer.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-timer.C:
+			timer.Reset(activate("timer"))
+		case msg, ok := <-scheduled:
+			if !ok {
+				return
+			}
+			logger.Info().Str(constants.KEY_PRODUCT_ID, msg.Payload).Msg("received product price scheduled")
+			timer.Reset(activate(constants.PRODUCT_PRICE_SCHEDULED))
+		}
+	}
+}
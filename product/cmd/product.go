@@ -7,11 +7,13 @@ import (
 	"net"
 	"net/http"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	inCache "github.com/Alturino/ecommerce/internal/cache"
 	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
 	"github.com/Alturino/ecommerce/internal/infra"
@@ -103,14 +105,57 @@ func RunProductService(c context.Context) {
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing productService").Logger()
 	logger.Info().Msg("initializing productService")
 	queries := repository.New(db)
-	productService := service.NewProductService(db, queries, cache)
+	cacheAside := inCache.New(cache, cfg.Cache)
+	productService := service.NewProductService(db, queries, cacheAside, cfg.Product, cfg.Currency)
 	logger.Info().Msg("initialized productService")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing warehouseService").Logger()
+	logger.Info().Msg("initializing warehouseService")
+	warehouseService := service.NewWarehouseService(queries)
+	logger.Info().Msg("initialized warehouseService")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing categoryService").Logger()
+	logger.Info().Msg("initializing categoryService")
+	categoryService := service.NewCategoryService(db, queries)
+	logger.Info().Msg("initialized categoryService")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing mediaService").Logger()
+	logger.Info().Msg("initializing mediaService")
+	c = logger.WithContext(c)
+	store := infra.NewBlobStore(c, cfg.Product.Media)
+	mediaService := service.NewMediaService(queries, store, cfg.Product.Media)
+	logger.Info().Msg("initialized mediaService")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing catalogService").Logger()
+	logger.Info().Msg("initializing catalogService")
+	catalogService := service.NewCatalogService(db, queries, cacheAside)
+	logger.Info().Msg("initialized catalogService")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "attach product controller").Logger()
 	logger.Info().Msg("attaching product controller")
-	controller.AttachProductController(mux, &productService)
+	controller.AttachProductController(mux, &productService, cfg.Pagination)
 	logger.Info().Msg("attached product controller")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "attach warehouse controller").Logger()
+	logger.Info().Msg("attaching warehouse controller")
+	controller.AttachWarehouseController(mux, &warehouseService)
+	logger.Info().Msg("attached warehouse controller")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "attach category controller").Logger()
+	logger.Info().Msg("attaching category controller")
+	controller.AttachCategoryController(mux, &categoryService, &productService, cfg.Pagination)
+	logger.Info().Msg("attached category controller")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "attach media controller").Logger()
+	logger.Info().Msg("attaching media controller")
+	controller.AttachMediaController(mux, &mediaService)
+	logger.Info().Msg("attached media controller")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "attach catalog controller").Logger()
+	logger.Info().Msg("attaching catalog controller")
+	controller.AttachCatalogController(mux, &catalogService)
+	logger.Info().Msg("attached catalog controller")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing server").Logger()
 	logger.Info().Msg("initializing server")
 	server := http.Server{
@@ -164,6 +209,35 @@ func RunProductService(c context.Context) {
 		logger.Info().Msg("shutdown server")
 	}()
 
+	priceActivator := NewPriceActivator(&productService, cache, cfg.Product.Pricing.Interval)
+	logger = logger.With().Str(constants.KEY_PROCESS, "start-price-activator").Logger()
+	logger.Info().Msg("start price activator")
+	span.AddEvent("start price activator")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	c = logger.WithContext(c)
+	go priceActivator.StartActivator(c, &wg)
+
+	updateListener := NewProductUpdateListener(
+		&productService,
+		cache,
+		cfg.Product.UpdateListener.Interval,
+		cfg.Product.UpdateListener.Lookback,
+	)
+	logger = logger.With().Str(constants.KEY_PROCESS, "start-product-update-listener").Logger()
+	logger.Info().Msg("start product update listener")
+	span.AddEvent("start product update listener")
+	wg.Add(1)
+	go updateListener.StartListener(c, &wg)
+
+	recommendationComputer := NewRecommendationComputer(&productService, cfg.Product.Recommendation.Interval)
+	logger = logger.With().Str(constants.KEY_PROCESS, "start-recommendation-computer").Logger()
+	logger.Info().Msg("start recommendation computer")
+	span.AddEvent("start recommendation computer")
+	wg.Add(1)
+	go recommendationComputer.StartComputer(c, &wg)
+	wg.Wait()
+
 	<-c.Done()
 	logger = logger.With().Str(constants.KEY_PROCESS, "shutdown server").Logger()
 	logger.Info().Msg("received interuption signal shutting down")
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/log"
+	"github.com/Alturino/ecommerce/product/internal/service"
+)
+
+const defaultRecommendationInterval = time.Hour
+
+type RecommendationComputer struct {
+	svc      *service.ProductService
+	interval time.Duration
+}
+
+func NewRecommendationComputer(
+	svc *service.ProductService,
+	interval time.Duration,
+) *RecommendationComputer {
+	if interval <= 0 {
+		interval = defaultRecommendationInterval
+	}
+	return &RecommendationComputer{svc: svc, interval: interval}
+}
+
+// StartComputer recomputes the co-purchase scores behind the frequently bought
+// together recommendations on start and then every interval.
+func (r RecommendationComputer) StartComputer(c context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Reset().
+		Str(constants.KEY_TAG, "RecommendationComputer StartComputer").
+		Str(constants.KEY_PROCESS, "starting computer").
+		Str(constants.KEY_APP_NAME, constants.APP_PRODUCT_SERVICE).
+		Logger()
+
+	compute := func() {
+		reqId := uuid.NewString()
+		lg := logger.With().Str(constants.KEY_REQUEST_ID, reqId).Logger()
+		lg.Trace().Msg("start computing recommendations")
+		ctx := log.AttachRequestIDToContext(lg.WithContext(c), reqId)
+		pairs, err := r.svc.ComputeRecommendations(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed computing recommendations with error=%w", err)
+			lg.Error().Err(err).Msg(err.Error())
+			return
+		}
+		lg.Info().Int64(constants.KEY_CO_PURCHASES, pairs).Msg("computed recommendations")
+	}
+
+	compute()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			compute()
+		}
+	}
+}
@@ -1,7 +1,13 @@
 package cache
 
+// families of the cached entries of the product service, see internal/cache.
+// The key of an entry of KEY_PRODUCTS_QUERY and KEY_PRODUCTS_RECOMMENDATIONS is
+// formatted with FORMAT_PRODUCTS_QUERY and FORMAT_PRODUCTS_RECOMMENDATIONS.
 const (
-	KEY_PRODUCTS         = "products:"
-	KEY_PRODUCTS_QUERY   = "products:name:%s:min_price:%s:max_price:%s"
-	KEY_PRODUCTS_USER_ID = "products:user_id:%s"
+	KEY_PRODUCTS                 = "products"
+	KEY_PRODUCTS_QUERY           = "products_query"
+	KEY_PRODUCTS_RECOMMENDATIONS = "products_recommendations"
+
+	FORMAT_PRODUCTS_QUERY           = "name:%s:min_price:%s:max_price:%s:in_stock:%t:category:%s:sort:%s:cursor:%s:limit:%d"
+	FORMAT_PRODUCTS_RECOMMENDATIONS = "%s:limit:%d"
 )
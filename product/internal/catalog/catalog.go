@@ -0,0 +1,380 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// maxLineSize is the longest JSON Lines row that is accepted.
+const maxLineSize = 1 << 20
+
+var (
+	ErrUnknownFormat = errors.New("catalog format is not supported")
+	ErrInvalidHeader = errors.New("catalog header is invalid")
+)
+
+// columns are the CSV columns in the order they are exported, a row with a sku
+// describes a variant of the product named in the same row.
+var columns = []string{
+	"name",
+	"description",
+	"price",
+	"quantity",
+	"backorderable",
+	"preorderable",
+	"release_date",
+	"backorder_limit",
+	"sku",
+	"options",
+	"variant_price",
+	"variant_quantity",
+}
+
+// Row is one line of a catalog file, it holds a product and optionally one of
+// its variants.
+type Row struct {
+	Line int `json:"-"`
+	request.Product
+	Variant *request.Variant `json:"variant,omitempty"`
+}
+
+// RowError is returned by a Reader for a row that can not be parsed, the
+// reader can keep reading after it.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err.Error())
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+type Reader interface {
+	// Read returns the next row, a *RowError for a malformed row and io.EOF
+	// after the last row.
+	Read() (Row, error)
+}
+
+type Writer interface {
+	Write(row Row) error
+	Flush() error
+}
+
+// FormatFromPath returns the format of a catalog file by its extension.
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	}
+	return "", fmt.Errorf("failed detecting format of file=%s with error=%w", path, ErrUnknownFormat)
+}
+
+func ContentType(format string) string {
+	if format == FormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCsvReader(r)
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &jsonlReader{scanner: scanner}, nil
+	}
+	return nil, fmt.Errorf("failed reading format=%s with error=%w", format, ErrUnknownFormat)
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		return &jsonlWriter{writer: bufio.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("failed writing format=%s with error=%w", format, ErrUnknownFormat)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	indexes map[string]int
+}
+
+func newCsvReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading header with error=%w", err)
+	}
+	indexes := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(columns, column) {
+			return nil, fmt.Errorf("%w: unknown column=%s", ErrInvalidHeader, column)
+		}
+		if _, ok := indexes[column]; ok {
+			return nil, fmt.Errorf("%w: duplicate column=%s", ErrInvalidHeader, column)
+		}
+		indexes[column] = i
+	}
+	if _, ok := indexes["name"]; !ok {
+		return nil, fmt.Errorf("%w: missing column=name", ErrInvalidHeader)
+	}
+	return &csvReader{reader: reader, indexes: indexes}, nil
+}
+
+func (r *csvReader) Read() (Row, error) {
+	record, err := r.reader.Read()
+	parseError := &csv.ParseError{}
+	if errors.As(err, &parseError) && errors.Is(err, csv.ErrFieldCount) {
+		return Row{Line: parseError.Line}, &RowError{Line: parseError.Line, Err: err}
+	}
+	if err != nil {
+		return Row{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	field := func(column string) string {
+		i, ok := r.indexes[column]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	row := Row{Line: line}
+	errs := []error{}
+	row.Name = field("name")
+	row.Description = field("description")
+	if row.Price, err = parseDecimal(field("price")); err != nil {
+		errs = append(errs, fmt.Errorf("invalid price with error=%w", err))
+	}
+	if row.Quantity, err = parseInt(field("quantity")); err != nil {
+		errs = append(errs, fmt.Errorf("invalid quantity with error=%w", err))
+	}
+	if row.Backorderable, err = parseBool(field("backorderable")); err != nil {
+		errs = append(errs, fmt.Errorf("invalid backorderable with error=%w", err))
+	}
+	if row.Preorderable, err = parseBool(field("preorderable")); err != nil {
+		errs = append(errs, fmt.Errorf("invalid preorderable with error=%w", err))
+	}
+	if row.ReleaseDate, err = parseTime(field("release_date")); err != nil {
+		errs = append(errs, fmt.Errorf("invalid release_date with error=%w", err))
+	}
+	if row.BackorderLimit, err = parseInt(field("backorder_limit")); err != nil {
+		errs = append(errs, fmt.Errorf("invalid backorder_limit with error=%w", err))
+	}
+
+	sku, options := field("sku"), field("options")
+	variantPrice, variantQuantity := field("variant_price"), field("variant_quantity")
+	if sku != "" || options != "" || variantPrice != "" || variantQuantity != "" {
+		variant := &request.Variant{Sku: sku}
+		if variant.Options, err = parseOptions(options); err != nil {
+			errs = append(errs, fmt.Errorf("invalid options with error=%w", err))
+		}
+		if variantPrice != "" {
+			price, err := parseDecimal(variantPrice)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid variant_price with error=%w", err))
+			}
+			variant.Price = &price
+		}
+		if variant.Quantity, err = parseInt(variantQuantity); err != nil {
+			errs = append(errs, fmt.Errorf("invalid variant_quantity with error=%w", err))
+		}
+		row.Variant = variant
+	}
+
+	if len(errs) > 0 {
+		return row, &RowError{Line: line, Err: errors.Join(errs...)}
+	}
+	return row, nil
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *jsonlReader) Read() (Row, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		row := Row{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&row)
+		row.Line = r.line
+		if err != nil {
+			return row, &RowError{Line: r.line, Err: err}
+		}
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Row{}, err
+	}
+	return Row{}, io.EOF
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+func (w *csvWriter) Write(row Row) error {
+	if !w.header {
+		w.header = true
+		if err := w.writer.Write(columns); err != nil {
+			return err
+		}
+	}
+	record := []string{
+		row.Name,
+		row.Description,
+		row.Price.String(),
+		strconv.Itoa(row.Quantity),
+		strconv.FormatBool(row.Backorderable),
+		strconv.FormatBool(row.Preorderable),
+		"",
+		strconv.Itoa(row.BackorderLimit),
+		"",
+		"",
+		"",
+		"",
+	}
+	if row.ReleaseDate != nil {
+		record[6] = row.ReleaseDate.Format(time.RFC3339)
+	}
+	if row.Variant != nil {
+		record[8] = row.Variant.Sku
+		record[9] = formatOptions(row.Variant.Options)
+		if row.Variant.Price != nil {
+			record[10] = row.Variant.Price.String()
+		}
+		record[11] = strconv.Itoa(row.Variant.Quantity)
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) Flush() error {
+	if !w.header {
+		w.header = true
+		if err := w.writer.Write(columns); err != nil {
+			return err
+		}
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonlWriter struct {
+	writer *bufio.Writer
+}
+
+func (w *jsonlWriter) Write(row Row) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.writer.Write(data)
+	return err
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+func parseDecimal(s string) (decimal.Decimal, error) {
+	if s == "" {
+		return decimal.Decimal{}, nil
+	}
+	return decimal.NewFromString(s)
+}
+
+func parseInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func parseBool(s string) (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseOptions parses variant options written as key=value pairs separated by
+// semicolons, e.g. color=red;size=M.
+func parseOptions(s string) (map[string]string, error) {
+	options := map[string]string{}
+	if s == "" {
+		return options, nil
+	}
+	for _, pair := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("option=%q is not a key=value pair", pair)
+		}
+		options[key] = strings.TrimSpace(value)
+	}
+	return options, nil
+}
+
+func formatOptions(options map[string]string) string {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+options[key])
+	}
+	return strings.Join(pairs, ";")
+}
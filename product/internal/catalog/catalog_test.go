@@ -0,0 +1,140 @@
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+func readAll(t *testing.T, reader Reader) ([]Row, []*RowError) {
+	rows, rowErrors := []Row{}, []*RowError{}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, rowErrors
+		}
+		rowError := &RowError{}
+		if errors.As(err, &rowError) {
+			rowErrors = append(rowErrors, rowError)
+			continue
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestCsvReader(t *testing.T) {
+	reader, err := NewReader(FormatCSV, strings.NewReader(
+		"name,price,quantity,sku,options,variant_quantity\n"+
+			"Mug,12.50,3,,,\n"+
+			"Shirt,20,0,SHIRT-M,color=red;size=M,4\n"+
+			"Cap,free,-1,,,\n"+
+			"Hat,1\n",
+	))
+	require.NoError(t, err)
+
+	rows, rowErrors := readAll(t, reader)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, "Mug", rows[0].Name)
+	assert.True(t, decimal.RequireFromString("12.5").Equal(rows[0].Price))
+	assert.Equal(t, 3, rows[0].Quantity)
+	assert.Nil(t, rows[0].Variant)
+	assert.Equal(t, &request.Variant{
+		Sku:      "SHIRT-M",
+		Options:  map[string]string{"color": "red", "size": "M"},
+		Quantity: 4,
+	}, rows[1].Variant)
+
+	require.Len(t, rowErrors, 2)
+	assert.Equal(t, 4, rowErrors[0].Line)
+	assert.ErrorContains(t, rowErrors[0], "invalid price")
+	assert.Equal(t, 5, rowErrors[1].Line)
+}
+
+func TestCsvReaderHeader(t *testing.T) {
+	_, err := NewReader(FormatCSV, strings.NewReader("name,colour\n"))
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+
+	_, err = NewReader(FormatCSV, strings.NewReader("price,quantity\n"))
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+
+	_, err = NewReader("xml", strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestJsonlReader(t *testing.T) {
+	reader, err := NewReader(FormatJSONL, strings.NewReader(
+		`{"name":"Mug","price":"12.50","quantity":3}`+"\n"+
+			"\n"+
+			`{"name":"Shirt","price":"20","variant":{"sku":"SHIRT-M","quantity":4}}`+"\n"+
+			`{"name":"Cap","colour":"red"}`+"\n",
+	))
+	require.NoError(t, err)
+
+	rows, rowErrors := readAll(t, reader)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 1, rows[0].Line)
+	assert.Equal(t, 3, rows[1].Line)
+	assert.Equal(t, "SHIRT-M", rows[1].Variant.Sku)
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, 4, rowErrors[0].Line)
+}
+
+func TestRoundTrip(t *testing.T) {
+	price := decimal.RequireFromString("18.75")
+	rows := []Row{
+		{Product: request.Product{Name: "Mug", Description: "Stoneware, 350ml", Price: decimal.RequireFromString("12.5"), Quantity: 3}},
+		{
+			Product: request.Product{Name: "Shirt", Price: decimal.RequireFromString("20"), Backorderable: true, BackorderLimit: 5},
+			Variant: &request.Variant{Sku: "SHIRT-M", Options: map[string]string{"size": "M"}, Price: &price, Quantity: 4},
+		},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		buffer := &bytes.Buffer{}
+		writer, err := NewWriter(format, buffer)
+		require.NoError(t, err)
+		for _, row := range rows {
+			require.NoError(t, writer.Write(row))
+		}
+		require.NoError(t, writer.Flush())
+
+		reader, err := NewReader(format, buffer)
+		require.NoError(t, err)
+		read, rowErrors := readAll(t, reader)
+		assert.Empty(t, rowErrors, format)
+		require.Len(t, read, 2, format)
+		for i := range rows {
+			assert.Equal(t, rows[i].Name, read[i].Name, format)
+			assert.Equal(t, rows[i].Description, read[i].Description, format)
+			assert.True(t, rows[i].Price.Equal(read[i].Price), format)
+			assert.Equal(t, rows[i].Backorderable, read[i].Backorderable, format)
+			assert.Equal(t, rows[i].BackorderLimit, read[i].BackorderLimit, format)
+		}
+		assert.Equal(t, "SHIRT-M", read[1].Variant.Sku, format)
+		assert.Equal(t, map[string]string{"size": "M"}, read[1].Variant.Options, format)
+		assert.True(t, price.Equal(*read[1].Variant.Price), format)
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := Report{}
+	report.Add(Change{Line: 1, Action: ActionCreate})
+	report.Add(Change{Line: 2, Action: ActionUnchanged})
+	report.Add(Change{Line: 3, Action: ActionFailed, Errors: []string{"price is required"}})
+
+	assert.Equal(t, 3, report.Rows)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, 1, report.Failed)
+	assert.Len(t, report.Changes, 2)
+}
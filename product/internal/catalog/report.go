@@ -0,0 +1,52 @@
+package catalog
+
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+	ActionFailed    = "failed"
+)
+
+// FieldChange is the value of a field before and after a row is imported.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Change is what importing a row did, or would do on a dry run.
+type Change struct {
+	Line   int                    `json:"line"`
+	Name   string                 `json:"name"`
+	Sku    string                 `json:"sku,omitempty"`
+	Action string                 `json:"action"`
+	Fields map[string]FieldChange `json:"fields,omitempty"`
+	Errors []string               `json:"errors,omitempty"`
+}
+
+// Report summarizes an import, unchanged rows are counted but left out of the
+// changes.
+type Report struct {
+	DryRun    bool     `json:"dry_run"`
+	Rows      int      `json:"rows"`
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Failed    int      `json:"failed"`
+	Changes   []Change `json:"changes"`
+}
+
+func (r *Report) Add(change Change) {
+	r.Rows++
+	switch change.Action {
+	case ActionCreate:
+		r.Created++
+	case ActionUpdate:
+		r.Updated++
+	case ActionUnchanged:
+		r.Unchanged++
+		return
+	case ActionFailed:
+		r.Failed++
+	}
+	r.Changes = append(r.Changes, change)
+}
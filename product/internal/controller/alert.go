@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+func (p ProductController) SetStockAlert(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController SetStockAlert")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController SetStockAlert").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.StockAlert{}
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err == nil {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.StructCtx(c, reqBody)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "setting stock alert").Logger()
+	logger.Trace().Msg("setting stock alert")
+	span.AddEvent("setting stock alert")
+	c = logger.WithContext(c)
+	alert, err := p.service.SetStockAlert(c, productId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed setting stock alert with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": archiveStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("set stock alert")
+	logger.Info().Msg("set stock alert")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully set stock alert",
+		"data": map[string]interface{}{
+			"stock_alert": alert,
+		},
+	})
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	"github.com/Alturino/ecommerce/internal/middleware"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/catalog"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/service"
+)
+
+type CatalogController struct {
+	service *service.CatalogService
+}
+
+func AttachCatalogController(mux *mux.Router, service *service.CatalogService) {
+	controller := CatalogController{service: service}
+
+	router := mux.PathPrefix("/catalog").Subrouter()
+	router.Use(
+		otelmux.Middleware(constants.APP_PRODUCT_SERVICE),
+		middleware.Logging,
+		middleware.RecoverPanic,
+		middleware.Auth,
+		middleware.RequireRole(constants.ROLE_ADMIN, constants.ROLE_MERCHANT_STAFF),
+	)
+	router.HandleFunc("/import", controller.Import).Methods(http.MethodPost)
+	router.HandleFunc("/export", controller.Export).Methods(http.MethodGet)
+}
+
+// Import reads a catalog streamed in the request body, the format is taken from
+// the format query param and defaults to csv.
+func (ctrl CatalogController) Import(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CatalogController Import")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CatalogController Import").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating query params").Logger()
+	logger.Trace().Msg("validating query params")
+	span.AddEvent("validating query params")
+	format, opts, err := importParams(r)
+	if err == nil {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.StructCtx(c, opts)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating query params with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated query params")
+	logger = logger.With().
+		Str(constants.KEY_CATALOG_FORMAT, format).
+		Any(constants.KEY_IMPORT_OPTIONS, opts).
+		Logger()
+	logger.Debug().Msg("validated query params")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "reading catalog header").Logger()
+	logger.Trace().Msg("reading catalog header")
+	span.AddEvent("reading catalog header")
+	extendDeadlines(c, w)
+	reader, err := catalog.NewReader(format, r.Body)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("read catalog header")
+	logger.Debug().Msg("read catalog header")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "importing catalog").Logger()
+	logger.Trace().Msg("importing catalog")
+	span.AddEvent("importing catalog")
+	c = logger.WithContext(c)
+	report, err := ctrl.service.Import(c, reader, opts)
+	if err != nil {
+		err = fmt.Errorf("failed importing catalog with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+			"data": map[string]interface{}{
+				"report": report,
+			},
+		})
+		return
+	}
+	span.AddEvent("imported catalog")
+	logger.Info().Int(constants.KEY_CATALOG_ROWS, report.Rows).Msg("imported catalog")
+
+	message := "successfully imported catalog"
+	if opts.DryRun {
+		message = "successfully checked catalog"
+	}
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    message,
+		"data": map[string]interface{}{
+			"report": report,
+		},
+	})
+}
+
+// Export streams the whole catalog as csv or jsonl, errors after the first
+// rows are written can only be logged.
+func (ctrl CatalogController) Export(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CatalogController Export")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CatalogController Export").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating query params").Logger()
+	logger.Trace().Msg("validating query params")
+	span.AddEvent("validating query params")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = catalog.FormatCSV
+	}
+	writer, err := catalog.NewWriter(format, w)
+	if err != nil {
+		err = fmt.Errorf("failed validating query params with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated query params")
+	logger = logger.With().Str(constants.KEY_CATALOG_FORMAT, format).Logger()
+	logger.Debug().Msg("validated query params")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "exporting catalog").Logger()
+	logger.Trace().Msg("exporting catalog")
+	span.AddEvent("exporting catalog")
+	extendDeadlines(c, w)
+	w.Header().Set("Content-Type", catalog.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="catalog.%s"`, format))
+	c = logger.WithContext(c)
+	rows, err := ctrl.service.Export(c, writer)
+	if err != nil {
+		err = fmt.Errorf("failed exporting catalog with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Int(constants.KEY_CATALOG_ROWS, rows).Msg(err.Error())
+		return
+	}
+	span.AddEvent("exported catalog")
+	logger.Info().Int(constants.KEY_CATALOG_ROWS, rows).Msg("exported catalog")
+}
+
+func importParams(r *http.Request) (string, service.ImportOptions, error) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = catalog.FormatCSV
+	}
+	opts := service.ImportOptions{Match: query.Get("match")}
+	if dryRun := query.Get("dry_run"); dryRun != "" {
+		value, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			return "", service.ImportOptions{}, fmt.Errorf("invalid dry_run with error=%w", err)
+		}
+		opts.DryRun = value
+	}
+	if chunkSize := query.Get("chunk_size"); chunkSize != "" {
+		value, err := strconv.Atoi(chunkSize)
+		if err != nil {
+			return "", service.ImportOptions{}, fmt.Errorf("invalid chunk_size with error=%w", err)
+		}
+		opts.ChunkSize = value
+	}
+	return format, opts, nil
+}
+
+// extendDeadlines lifts the server read and write timeouts for a request, a
+// catalog of tens of thousands of products takes longer than a regular request.
+func extendDeadlines(c context.Context, w http.ResponseWriter) {
+	controller := http.NewResponseController(w)
+	err := errors.Join(
+		controller.SetReadDeadline(time.Time{}),
+		controller.SetWriteDeadline(time.Time{}),
+	)
+	if err != nil {
+		logger := zerolog.Ctx(c)
+		logger.Warn().Err(err).Msg("failed extending request deadlines")
+	}
+}
@@ -0,0 +1,459 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	"github.com/Alturino/ecommerce/internal/middleware"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/service"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+type CategoryController struct {
+	service        *service.CategoryService
+	productService *service.ProductService
+	pagination     config.Pagination
+}
+
+func AttachCategoryController(
+	mux *mux.Router,
+	service *service.CategoryService,
+	productService *service.ProductService,
+	pagination config.Pagination,
+) {
+	controller := CategoryController{
+		service:        service,
+		productService: productService,
+		pagination:     pagination,
+	}
+
+	staff := middleware.RequireRole(constants.ROLE_ADMIN, constants.ROLE_MERCHANT_STAFF)
+
+	router := mux.PathPrefix("/categories").Subrouter()
+	router.Use(
+		otelmux.Middleware(constants.APP_PRODUCT_SERVICE),
+		middleware.Logging,
+		middleware.RecoverPanic,
+		middleware.Auth,
+	)
+	router.HandleFunc("", controller.FindCategories).Methods(http.MethodGet)
+	router.Handle("", staff(http.HandlerFunc(controller.InsertCategory))).Methods(http.MethodPost)
+	router.HandleFunc("/{categoryId}", controller.FindCategoryById).Methods(http.MethodGet)
+	router.Handle("/{categoryId}", staff(http.HandlerFunc(controller.UpdateCategory))).Methods(http.MethodPut)
+	router.Handle("/{categoryId}", staff(http.HandlerFunc(controller.RemoveCategory))).Methods(http.MethodDelete)
+	router.HandleFunc("/{categoryId}/products", controller.FindCategoryProducts).
+		Methods(http.MethodGet)
+}
+
+func (ctrl CategoryController) InsertCategory(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CategoryController InsertCategory")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryController InsertCategory").
+		Logger()
+
+	reqBody, err := decodeCategory(c, r)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting category").Logger()
+	logger.Trace().Msg("inserting category")
+	span.AddEvent("inserting category")
+	c = logger.WithContext(c)
+	category, err := ctrl.service.InsertCategory(c, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed inserting category with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("inserted category")
+	logger.Info().Msg("inserted category")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusCreated,
+		"message":    "successfully inserted category",
+		"data": map[string]interface{}{
+			"category": category,
+		},
+	})
+}
+
+func (ctrl CategoryController) FindCategories(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CategoryController FindCategories")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryController FindCategories").
+		Str(constants.KEY_PROCESS, "finding categories").
+		Logger()
+
+	logger.Trace().Msg("finding categories")
+	span.AddEvent("finding categories")
+	c = logger.WithContext(c)
+	categories, err := ctrl.service.FindCategories(c)
+	if err != nil {
+		err = fmt.Errorf("failed finding categories with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found categories")
+	logger.Info().Msg("found categories")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "found categories",
+		"data": map[string]interface{}{
+			"categories": categories,
+		},
+	})
+}
+
+func (ctrl CategoryController) FindCategoryById(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CategoryController FindCategoryById")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryController FindCategoryById").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating categoryId").Logger()
+	logger.Trace().Msg("validating categoryId")
+	span.AddEvent("validating categoryId")
+	id, err := uuid.Parse(mux.Vars(r)["categoryId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating categoryId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated categoryId")
+	logger = logger.With().Str(constants.KEY_CATEGORY_ID, id.String()).Logger()
+	logger.Debug().Msg("validated categoryId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding category").Logger()
+	logger.Trace().Msg("finding category")
+	span.AddEvent("finding category")
+	c = logger.WithContext(c)
+	category, err := ctrl.service.FindCategoryById(c, id)
+	if err != nil {
+		err = fmt.Errorf("failed finding category with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": categoryStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found category")
+	logger.Info().Msg("found category")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    fmt.Sprintf("category id=%s found", id.String()),
+		"data": map[string]interface{}{
+			"category": category,
+		},
+	})
+}
+
+func (ctrl CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CategoryController UpdateCategory")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryController UpdateCategory").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating categoryId").Logger()
+	logger.Trace().Msg("validating categoryId")
+	span.AddEvent("validating categoryId")
+	id, err := uuid.Parse(mux.Vars(r)["categoryId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating categoryId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated categoryId")
+	logger = logger.With().Str(constants.KEY_CATEGORY_ID, id.String()).Logger()
+	logger.Debug().Msg("validated categoryId")
+
+	reqBody, err := decodeCategory(c, r)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating category").Logger()
+	logger.Trace().Msg("updating category")
+	span.AddEvent("updating category")
+	c = logger.WithContext(c)
+	category, err := ctrl.service.UpdateCategory(c, id, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed updating category with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": categoryStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("updated category")
+	logger.Info().Msg("updated category")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully updated category",
+		"data": map[string]interface{}{
+			"category": category,
+		},
+	})
+}
+
+func (ctrl CategoryController) RemoveCategory(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CategoryController RemoveCategory")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryController RemoveCategory").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating categoryId").Logger()
+	logger.Trace().Msg("validating categoryId")
+	span.AddEvent("validating categoryId")
+	id, err := uuid.Parse(mux.Vars(r)["categoryId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating categoryId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated categoryId")
+	logger = logger.With().Str(constants.KEY_CATEGORY_ID, id.String()).Logger()
+	logger.Debug().Msg("validated categoryId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing category").Logger()
+	logger.Trace().Msg("removing category")
+	span.AddEvent("removing category")
+	c = logger.WithContext(c)
+	category, err := ctrl.service.RemoveCategory(c, id)
+	if err != nil {
+		err = fmt.Errorf("failed removing category with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": categoryStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("removed category")
+	logger.Info().Msg("removed category")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully removed category",
+		"data": map[string]interface{}{
+			"category": category,
+		},
+	})
+}
+
+// FindCategoryProducts lists the products of a category and of all of its
+// descendants, it accepts the same query params as GET /products.
+func (ctrl CategoryController) FindCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "CategoryController FindCategoryProducts")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryController FindCategoryProducts").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating query params").Logger()
+	logger.Trace().Msg("validating query params")
+	span.AddEvent("validating query params")
+	id, err := uuid.Parse(mux.Vars(r)["categoryId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating categoryId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	param, err := findProductParam(r)
+	if err == nil {
+		param.CategoryId = &id
+		err = validator.New(validator.WithRequiredStructEnabled()).StructCtx(c, param)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating query params with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	page, err := inHttp.ParsePage(r, ctrl.pagination)
+	if err != nil {
+		err = fmt.Errorf("failed validating query params with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated query params")
+	logger = logger.With().
+		Str(constants.KEY_CATEGORY_ID, id.String()).
+		Any(constants.KEY_REQUEST, param).
+		Any(constants.KEY_PAGE, page).
+		Logger()
+	logger.Debug().Msg("validated query params")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding products").Logger()
+	logger.Trace().Msg("finding products")
+	span.AddEvent("finding products")
+	c = logger.WithContext(c)
+	products, nextCursor, err := ctrl.productService.FindProducts(c, param, page)
+	if err != nil {
+		err = fmt.Errorf("failed finding products with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, inErrors.ErrInvalidCursor) {
+			statusCode = http.StatusBadRequest
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found products")
+	logger.Info().Int(constants.KEY_PRODUCTS, len(products)).Msg("found products")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":      "success",
+		"statusCode":  http.StatusOK,
+		"message":     "products found",
+		"next_cursor": nextCursor,
+		"data": map[string]interface{}{
+			"products": products,
+		},
+	})
+}
+
+func decodeCategory(c context.Context, r *http.Request) (request.Category, error) {
+	reqBody := request.Category{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		return request.Category{}, fmt.Errorf("failed decoding request body with error=%w", err)
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, reqBody); err != nil {
+		return request.Category{}, fmt.Errorf("failed validating request body with error=%w", err)
+	}
+	if reqBody.SlugOrDefault() == "" {
+		return request.Category{}, errors.New("slug must contain a letter or a digit")
+	}
+	return reqBody, nil
+}
+
+func categoryStatusCode(err error) int {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
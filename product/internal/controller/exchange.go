@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	"github.com/Alturino/ecommerce/internal/money"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+func (p ProductController) FindExchangeRates(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindExchangeRates")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindExchangeRates").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding exchange rates").Logger()
+	logger.Trace().Msg("finding exchange rates")
+	span.AddEvent("finding exchange rates")
+	c = logger.WithContext(c)
+	rates, err := p.service.FindExchangeRates(c)
+	if err != nil {
+		err = fmt.Errorf("failed finding exchange rates with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found exchange rates")
+	logger.Info().Msg("found exchange rates")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "found exchange rates",
+		"data": map[string]interface{}{
+			"exchange_rates": rates,
+		},
+	})
+}
+
+func (p ProductController) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController SetExchangeRate")
+	defer span.End()
+
+	currency := mux.Vars(r)["currency"]
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController SetExchangeRate").
+		Str(constants.KEY_CURRENCY, currency).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.ExchangeRate{}
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err == nil {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.StructCtx(c, reqBody)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "setting exchange rate").Logger()
+	logger.Trace().Msg("setting exchange rate")
+	span.AddEvent("setting exchange rate")
+	c = logger.WithContext(c)
+	rate, err := p.service.SetExchangeRate(c, currency, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed setting exchange rate with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, money.ErrUnsupportedCurrency) ||
+			errors.Is(err, productErrors.ErrBaseCurrencyRate) ||
+			errors.Is(err, productErrors.ErrInvalidExchangeRate) {
+			statusCode = http.StatusBadRequest
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("set exchange rate")
+	logger.Info().Msg("set exchange rate")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully set exchange rate",
+		"data": map[string]interface{}{
+			"exchange_rate": rate,
+		},
+	})
+}
@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+
+	"github.com/Alturino/ecommerce/internal/blob"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	"github.com/Alturino/ecommerce/internal/middleware"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/service"
+)
+
+// multipartOverhead is the room left for the multipart boundaries and headers
+// on top of the largest accepted file.
+const multipartOverhead = 1 << 20
+
+type MediaController struct {
+	service *service.MediaService
+}
+
+func AttachMediaController(mux *mux.Router, service *service.MediaService) {
+	controller := MediaController{service: service}
+
+	router := mux.PathPrefix("/products/{productId}/media").Subrouter()
+	router.Use(
+		otelmux.Middleware(constants.APP_PRODUCT_SERVICE),
+		middleware.Logging,
+		middleware.RecoverPanic,
+		middleware.Auth,
+		middleware.RequireRole(constants.ROLE_ADMIN, constants.ROLE_MERCHANT_STAFF),
+	)
+	router.HandleFunc("", controller.UploadMedia).Methods(http.MethodPost)
+	router.HandleFunc("/{mediaId}", controller.RemoveMedia).Methods(http.MethodDelete)
+
+	blobRouter := mux.PathPrefix("/media").Subrouter()
+	blobRouter.Use(
+		otelmux.Middleware(constants.APP_PRODUCT_SERVICE),
+		middleware.Logging,
+		middleware.RecoverPanic,
+	)
+	blobRouter.HandleFunc("/{key:.+}", controller.FindBlob).Methods(http.MethodGet)
+}
+
+// UploadMedia accepts a multipart form with the image in the file field.
+func (ctrl MediaController) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "MediaController UploadMedia")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "MediaController UploadMedia").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "reading multipart form").Logger()
+	logger.Trace().Msg("reading multipart form")
+	span.AddEvent("reading multipart form")
+	maxSize := ctrl.service.MaxSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+	data, err := readMultipartFile(r, "file", maxSize)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": mediaStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("read multipart form")
+	logger.Debug().Int(constants.KEY_MEDIA_SIZE, len(data)).Msg("read multipart form")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "uploading media").Logger()
+	logger.Trace().Msg("uploading media")
+	span.AddEvent("uploading media")
+	c = logger.WithContext(c)
+	media, err := ctrl.service.UploadMedia(c, productId, data)
+	if err != nil {
+		err = fmt.Errorf("failed uploading media with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": mediaStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("uploaded media")
+	logger.Info().Msg("uploaded media")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusCreated,
+		"message":    "successfully uploaded media",
+		"data": map[string]interface{}{
+			"media": media,
+		},
+	})
+}
+
+func (ctrl MediaController) RemoveMedia(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "MediaController RemoveMedia")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "MediaController RemoveMedia").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating path values").Logger()
+	logger.Trace().Msg("validating path values")
+	span.AddEvent("validating path values")
+	pathValues := mux.Vars(r)
+	productId, err := uuid.Parse(pathValues["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	mediaId, err := uuid.Parse(pathValues["mediaId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating mediaId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated path values")
+	logger = logger.With().
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_MEDIA_ID, mediaId.String()).
+		Logger()
+	logger.Debug().Msg("validated path values")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing media").Logger()
+	logger.Trace().Msg("removing media")
+	span.AddEvent("removing media")
+	c = logger.WithContext(c)
+	media, err := ctrl.service.RemoveMedia(c, productId, mediaId)
+	if err != nil {
+		err = fmt.Errorf("failed removing media with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": mediaStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("removed media")
+	logger.Info().Msg("removed media")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully removed media",
+		"data": map[string]interface{}{
+			"media": media,
+		},
+	})
+}
+
+// FindBlob streams a stored image or thumbnail. Keys are never reused, so the
+// response can be cached for good.
+func (ctrl MediaController) FindBlob(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "MediaController FindBlob")
+	defer span.End()
+
+	key := mux.Vars(r)["key"]
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "MediaController FindBlob").
+		Str(constants.KEY_BLOB_KEY, key).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding blob").Logger()
+	logger.Trace().Msg("finding blob")
+	span.AddEvent("finding blob")
+	c = logger.WithContext(c)
+	body, contentType, err := ctrl.service.FindBlob(c, key)
+	if err != nil {
+		err = fmt.Errorf("failed finding blob with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": mediaStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	defer body.Close()
+	span.AddEvent("found blob")
+	logger.Debug().Msg("found blob")
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, body)
+	if err != nil {
+		err = fmt.Errorf("failed writing blob with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+	}
+}
+
+// readMultipartFile reads the file sent in field, at most maxSize bytes of it
+// are accepted.
+func readMultipartFile(r *http.Request, field string, maxSize int64) ([]byte, error) {
+	err := r.ParseMultipartForm(maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing multipart form with error=%w", err)
+	}
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading form field=%s with error=%w", field, err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed reading form field=%s with error=%w", field, err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf(
+			"failed reading form field=%s with error=%w",
+			field,
+			productErrors.ErrMediaTooLarge,
+		)
+	}
+	return data, nil
+}
+
+func mediaStatusCode(err error) int {
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.Is(err, productErrors.ErrMediaTooLarge), errors.As(err, &maxBytesError):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, productErrors.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, blob.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
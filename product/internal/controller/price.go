@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+func (p ProductController) FindPrices(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindPrices")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindPrices").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding prices").Logger()
+	logger.Trace().Msg("finding prices")
+	span.AddEvent("finding prices")
+	c = logger.WithContext(c)
+	prices, err := p.service.FindPrices(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding prices with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found prices")
+	logger.Info().Int(constants.KEY_PRICES, len(prices)).Msg("found prices")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "prices found",
+		"data": map[string]interface{}{
+			"prices": prices,
+		},
+	})
+}
+
+// FindPriceAt returns the price of a product at the time in the at query param,
+// now by default.
+func (p ProductController) FindPriceAt(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindPriceAt")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindPriceAt").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating at").Logger()
+	logger.Trace().Msg("validating at")
+	span.AddEvent("validating at")
+	at := time.Now()
+	if rawAt := r.URL.Query().Get("at"); rawAt != "" {
+		at, err = time.Parse(time.RFC3339, rawAt)
+		if err != nil {
+			err = fmt.Errorf("failed validating at with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+				"status":     "failed",
+				"statusCode": http.StatusBadRequest,
+				"message":    err.Error(),
+			})
+			return
+		}
+	}
+	span.AddEvent("validated at")
+	logger = logger.With().Time(constants.KEY_PRICE_AT, at).Logger()
+	logger.Debug().Msg("validated at")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding price at").Logger()
+	logger.Trace().Msg("finding price at")
+	span.AddEvent("finding price at")
+	c = logger.WithContext(c)
+	price, err := p.service.FindPriceAt(c, productId, at)
+	if err != nil {
+		err = fmt.Errorf("failed finding price at with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": priceStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found price at")
+	logger.Debug().Msg("found price at")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "price found",
+		"data": map[string]interface{}{
+			"at":    at,
+			"price": price,
+		},
+	})
+}
+
+func (p ProductController) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController SchedulePrice")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController SchedulePrice").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.Price{}
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err == nil {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.StructCtx(c, reqBody)
+	}
+	if err == nil && reqBody.Price.IsNegative() {
+		err = errors.New("price must not be negative")
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "scheduling price").Logger()
+	logger.Trace().Msg("scheduling price")
+	span.AddEvent("scheduling price")
+	c = logger.WithContext(c)
+	price, err := p.service.SchedulePrice(c, productId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed scheduling price with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": priceStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("scheduled price")
+	logger.Info().Msg("scheduled price")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusCreated,
+		"message":    "successfully scheduled price",
+		"data": map[string]interface{}{
+			"price": price,
+		},
+	})
+}
+
+func priceStatusCode(err error) int {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, productErrors.ErrPriceInPast),
+		errors.Is(err, productErrors.ErrInvalidPriceRange):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
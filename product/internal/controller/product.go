@@ -1,47 +1,112 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
 	inHttp "github.com/Alturino/ecommerce/internal/http"
 	"github.com/Alturino/ecommerce/internal/middleware"
 	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
 	"github.com/Alturino/ecommerce/product/internal/otel"
 	"github.com/Alturino/ecommerce/product/internal/service"
 	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
 )
 
 type ProductController struct {
-	service *service.ProductService
+	service    *service.ProductService
+	pagination config.Pagination
 }
 
-func AttachProductController(mux *mux.Router, service *service.ProductService) {
-	controller := ProductController{service}
+func AttachProductController(
+	mux *mux.Router,
+	service *service.ProductService,
+	pagination config.Pagination,
+) {
+	controller := ProductController{service: service, pagination: pagination}
 
-	router := mux.PathPrefix("/products").Subrouter()
-	router.HandleFunc("", controller.GetProducts).Methods(http.MethodGet)
+	staff := middleware.RequireRole(constants.ROLE_ADMIN, constants.ROLE_MERCHANT_STAFF)
+	admin := middleware.RequireRole(constants.ROLE_ADMIN)
 
-	postRouter := mux.PathPrefix("/products").Methods(http.MethodPost).Subrouter()
-	postRouter.HandleFunc("", controller.InsertProduct).Methods(http.MethodPost)
+	router := mux.PathPrefix("/products").Subrouter()
 	router.Use(
 		otelmux.Middleware(constants.APP_PRODUCT_SERVICE),
 		middleware.Logging,
 		middleware.RecoverPanic,
 		middleware.Auth,
 	)
+	router.HandleFunc("", controller.GetProducts).Methods(http.MethodGet)
+	router.Handle("", staff(http.HandlerFunc(controller.InsertProduct))).Methods(http.MethodPost)
 	router.HandleFunc("/{productId}", controller.FindProductById).Methods(http.MethodGet)
-	router.HandleFunc("/{productId}", controller.RemoveProduct).Methods(http.MethodDelete)
-	router.HandleFunc("/{productId}", controller.UpdateProduct).Methods(http.MethodPut)
+	router.Handle("/{productId}", staff(http.HandlerFunc(controller.RemoveProduct))).
+		Methods(http.MethodDelete)
+	router.Handle("/{productId}", staff(http.HandlerFunc(controller.UpdateProduct))).
+		Methods(http.MethodPut)
+	router.Handle("/{productId}/restore", staff(http.HandlerFunc(controller.RestoreProduct))).
+		Methods(http.MethodPost)
+	router.Handle("/{productId}/inventory", staff(http.HandlerFunc(controller.FindInventoryLevels))).
+		Methods(http.MethodGet)
+	router.Handle("/{productId}/inventory/{warehouseId}", staff(http.HandlerFunc(controller.SetInventoryLevel))).
+		Methods(http.MethodPut)
+	router.Handle("/{productId}/movements", staff(http.HandlerFunc(controller.FindInventoryMovements))).
+		Methods(http.MethodGet)
+	router.Handle("/{productId}/stock", staff(http.HandlerFunc(controller.FindStockAsOf))).
+		Methods(http.MethodGet)
+	router.Handle("/{productId}/stock/adjustments", staff(http.HandlerFunc(controller.AdjustStock))).
+		Methods(http.MethodPost)
+	router.Handle("/{productId}/stock-alert", staff(http.HandlerFunc(controller.SetStockAlert))).
+		Methods(http.MethodPut)
+	router.HandleFunc("/{productId}/price", controller.FindPriceAt).Methods(http.MethodGet)
+	router.HandleFunc("/{productId}/prices", controller.FindPrices).Methods(http.MethodGet)
+	router.Handle("/{productId}/prices", staff(http.HandlerFunc(controller.SchedulePrice))).
+		Methods(http.MethodPost)
+	router.Handle("/{productId}/categories", staff(http.HandlerFunc(controller.SetProductCategories))).
+		Methods(http.MethodPut)
+	router.HandleFunc("/{productId}/recommendations", controller.FindRecommendations).
+		Methods(http.MethodGet)
+	router.HandleFunc("/{productId}/reviews", controller.FindReviews).Methods(http.MethodGet)
+	router.HandleFunc("/{productId}/reviews", controller.InsertReview).Methods(http.MethodPost)
+	router.Handle("/{productId}/reviews/{reviewId}/status", staff(http.HandlerFunc(controller.ModerateReview))).
+		Methods(http.MethodPut)
+	router.HandleFunc("/{productId}/reviews/{reviewId}/helpful", controller.VoteReviewHelpful).
+		Methods(http.MethodPost)
+	router.HandleFunc("/{productId}/variants", controller.FindVariants).Methods(http.MethodGet)
+	router.Handle("/{productId}/variants", staff(http.HandlerFunc(controller.InsertVariant))).
+		Methods(http.MethodPost)
+	router.Handle("/{productId}/variants/{variantId}", staff(http.HandlerFunc(controller.UpdateVariant))).
+		Methods(http.MethodPut)
+	router.Handle("/{productId}/variants/{variantId}", staff(http.HandlerFunc(controller.RemoveVariant))).
+		Methods(http.MethodDelete)
+
+	rateRouter := mux.PathPrefix("/exchange-rates").Subrouter()
+	rateRouter.Use(
+		otelmux.Middleware(constants.APP_PRODUCT_SERVICE),
+		middleware.Logging,
+		middleware.RecoverPanic,
+		middleware.Auth,
+	)
+	rateRouter.HandleFunc("", controller.FindExchangeRates).Methods(http.MethodGet)
+	rateRouter.Handle("/{currency}", admin(http.HandlerFunc(controller.SetExchangeRate))).
+		Methods(http.MethodPut)
 }
 
 func (p ProductController) InsertProduct(w http.ResponseWriter, r *http.Request) {
@@ -110,7 +175,7 @@ func (p ProductController) InsertProduct(w http.ResponseWriter, r *http.Request)
 	}
 	logger.Info().Msg("inserted product")
 
-	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+	inHttp.WriteJsonResponse(c, w, p.etagHeader(c, product), map[string]interface{}{
 		"status":     "success",
 		"statusCode": http.StatusOK,
 		"message":    "successfully inserted product",
@@ -130,13 +195,36 @@ func (ctrl ProductController) GetProducts(w http.ResponseWriter, r *http.Request
 		Str(constants.KEY_TAG, "ProductController FindProducts").
 		Logger()
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "get products").Logger()
-	logger.Trace().Msg("get products")
-	span.AddEvent("get products")
-	c = logger.WithContext(c)
-	products, err := ctrl.service.GetProducts(c)
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating query params").Logger()
+	logger.Trace().Msg("validating query params")
+	span.AddEvent("validating query params")
+	param, err := findProductParam(r)
+	if err != nil {
+		err = fmt.Errorf("failed validating query params with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, param); err != nil {
+		err = fmt.Errorf("failed validating query params with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	page, err := inHttp.ParsePage(r, ctrl.pagination)
 	if err != nil {
-		err = fmt.Errorf("failed get products with error=%w", err)
+		err = fmt.Errorf("failed validating query params with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
@@ -146,14 +234,38 @@ func (ctrl ProductController) GetProducts(w http.ResponseWriter, r *http.Request
 		})
 		return
 	}
-	logger = logger.With().Any(constants.KEY_PRODUCTS, products).Logger()
-	span.AddEvent("got products")
-	logger.Info().Msg("got products")
+	span.AddEvent("validated query params")
+	logger = logger.With().Any(constants.KEY_REQUEST, param).Any(constants.KEY_PAGE, page).Logger()
+	logger.Debug().Msg("validated query params")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding products").Logger()
+	logger.Trace().Msg("finding products")
+	span.AddEvent("finding products")
+	c = logger.WithContext(c)
+	products, nextCursor, err := ctrl.service.FindProducts(c, param, page)
+	if err != nil {
+		err = fmt.Errorf("failed finding products with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, inErrors.ErrInvalidCursor) {
+			statusCode = http.StatusBadRequest
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found products")
+	logger.Info().Int(constants.KEY_PRODUCTS, len(products)).Msg("found products")
 
 	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
-		"status":     "success",
-		"statusCode": http.StatusOK,
-		"message":    "products found",
+		"status":      "success",
+		"statusCode":  http.StatusOK,
+		"message":     "products found",
+		"next_cursor": nextCursor,
 		"data": map[string]interface{}{
 			"products": products,
 		},
@@ -193,7 +305,7 @@ func (p ProductController) FindProductById(w http.ResponseWriter, r *http.Reques
 	logger.Trace().Msg("finding product")
 	span.AddEvent("finding product")
 	c = logger.WithContext(c)
-	product, err := p.service.FindProductById(c, id)
+	product, etag, err := p.service.FindProductById(c, id)
 	if err != nil {
 		err = fmt.Errorf("failed finding product with id=%s with error=%w", id.String(), err)
 		inOtel.RecordError(err, span)
@@ -209,7 +321,14 @@ func (p ProductController) FindProductById(w http.ResponseWriter, r *http.Reques
 	logger = logger.With().Any(constants.KEY_PRODUCT, product).Logger()
 	logger.Info().Msg("found product id")
 
-	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+	if inHttp.NotModified(r, etag) {
+		logger.Info().Str(constants.KEY_ETAG, etag).Msg("product not modified")
+		w.Header().Set(inHttp.KEY_HEADER_ETAG, etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{inHttp.KEY_HEADER_ETAG: etag}, map[string]interface{}{
 		"status":     "success",
 		"statusCode": http.StatusOK,
 		"message":    fmt.Sprintf("product id=%s found", id.String()),
@@ -219,6 +338,8 @@ func (p ProductController) FindProductById(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// RemoveProduct archives a product, archiving an already archived product
+// responds with not found.
 func (p ProductController) RemoveProduct(w http.ResponseWriter, r *http.Request) {
 	c, span := otel.Tracer.Start(r.Context(), "ProductController RemoveProduct")
 	defer span.End()
@@ -229,11 +350,12 @@ func (p ProductController) RemoveProduct(w http.ResponseWriter, r *http.Request)
 		Str(constants.KEY_TAG, "ProductController RemoveProduct").
 		Logger()
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "getting pathvalue productId").Logger()
-	logger.Info().Msg("getting pathvalue productId")
-	id, err := uuid.Parse(r.PathValue("productId"))
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	id, err := uuid.Parse(mux.Vars(r)["productId"])
 	if err != nil {
-		err = fmt.Errorf("failed getting pathvalue productId with error=%w", err)
+		err = fmt.Errorf("failed validating productId with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
@@ -243,41 +365,75 @@ func (p ProductController) RemoveProduct(w http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
-	logger = logger.With().Str(constants.KEY_PRODUCT_ID, id.String()).Logger()
+	span.AddEvent("validated productId")
 	span.SetAttributes(attribute.String(constants.KEY_PRODUCT_ID, id.String()))
-	logger.Info().Msgf("got pathvalue productId=%s", id.String())
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, id.String()).Logger()
+	logger.Debug().Msg("validated productId")
 
-	logger.Info().Msg("decoding request body")
-	reqBody := request.Product{}
-	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		err = fmt.Errorf("failed decoding request body with error=%w", err)
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting if-match").Logger()
+	logger.Trace().Msg("getting if-match")
+	span.AddEvent("getting if-match")
+	ifMatch, err := inHttp.IfMatch(r)
+	if err != nil {
+		err = fmt.Errorf("failed getting if-match with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
 			"status":     "failed",
-			"statusCode": http.StatusBadRequest,
+			"statusCode": http.StatusPreconditionRequired,
 			"message":    err.Error(),
 		})
 		return
 	}
+	span.AddEvent("got if-match")
+	logger = logger.With().Strs(constants.KEY_IF_MATCH, ifMatch).Logger()
+	logger.Debug().Msg("got if-match")
 
-	span.SetAttributes(
-		attribute.String(constants.KEY_PRODUCT_NAME, reqBody.Name),
-		attribute.String(constants.KEY_PRODUCT_PRICE, reqBody.Price.String()),
-		attribute.Int(constants.KEY_PRODUCT_QUANTITY, reqBody.Quantity),
-	)
-	logger.Info().Msg("decoded request body")
+	logger = logger.With().Str(constants.KEY_PROCESS, "archiving product").Logger()
+	logger.Trace().Msg("archiving product")
+	span.AddEvent("archiving product")
+	c = logger.WithContext(c)
+	product, err := p.service.RemoveProduct(c, id, ifMatch)
+	if err != nil {
+		err = fmt.Errorf("failed archiving product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": archiveStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("archived product")
+	logger.Info().Msg("archived product")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "validating.request_body").Logger()
+	inHttp.WriteJsonResponse(c, w, p.etagHeader(c, product.Response()), map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully archived product",
+		"data": map[string]interface{}{
+			"product": product.Response(),
+		},
+	})
+}
 
-	logger.Info().Msg("initializing validator")
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	logger.Info().Msg("initialized validator")
+func (p ProductController) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController RestoreProduct")
+	defer span.End()
 
-	logger.Trace().Msg("validating request body")
-	span.AddEvent("validating request body")
-	if err := validate.StructCtx(c, reqBody); err != nil {
-		err = fmt.Errorf("failed validating request body with error=%w", err)
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController RestoreProduct").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	id, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
@@ -287,33 +443,36 @@ func (p ProductController) RemoveProduct(w http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
-	span.AddEvent("validated request body")
-	logger.Info().Msg("validated request body")
+	span.AddEvent("validated productId")
+	span.SetAttributes(attribute.String(constants.KEY_PRODUCT_ID, id.String()))
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, id.String()).Logger()
+	logger.Debug().Msg("validated productId")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "remove product").Logger()
-	logger.Trace().Msg("remove product")
-	span.AddEvent("remove product")
-	product, err := p.service.RemoveProduct(c, id)
+	logger = logger.With().Str(constants.KEY_PROCESS, "restoring product").Logger()
+	logger.Trace().Msg("restoring product")
+	span.AddEvent("restoring product")
+	c = logger.WithContext(c)
+	product, err := p.service.RestoreProduct(c, id)
 	if err != nil {
-		err = fmt.Errorf("failed remove product with error=%w", err)
+		err = fmt.Errorf("failed restoring product with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
 			"status":     "failed",
-			"statusCode": http.StatusBadRequest,
+			"statusCode": archiveStatusCode(err),
 			"message":    err.Error(),
 		})
 		return
 	}
-	span.AddEvent("removed product")
-	logger.Info().Msg("removed product")
+	span.AddEvent("restored product")
+	logger.Info().Msg("restored product")
 
-	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+	inHttp.WriteJsonResponse(c, w, p.etagHeader(c, product.Response()), map[string]interface{}{
 		"status":     "success",
 		"statusCode": http.StatusOK,
-		"message":    "successfully inserted product",
+		"message":    "successfully restored product",
 		"data": map[string]interface{}{
-			"product": product,
+			"product": product.Response(),
 		},
 	})
 }
@@ -331,7 +490,7 @@ func (p ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request)
 	logger = logger.With().Str(constants.KEY_PROCESS, "getting pathValue productId").Logger()
 	logger.Trace().Msg("getting pathValue productId")
 	span.AddEvent("getting pathValue productId")
-	id, err := uuid.Parse(r.PathValue("productId"))
+	id, err := uuid.Parse(mux.Vars(r)["productId"])
 	if err != nil {
 		err = fmt.Errorf("failed getting pathValue productId with error=%w", err)
 		inOtel.RecordError(err, span)
@@ -347,6 +506,25 @@ func (p ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request)
 	logger = logger.With().Str(constants.KEY_PRODUCT_ID, id.String()).Logger()
 	logger.Debug().Msg("got pathValue")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting if-match").Logger()
+	logger.Trace().Msg("getting if-match")
+	span.AddEvent("getting if-match")
+	ifMatch, err := inHttp.IfMatch(r)
+	if err != nil {
+		err = fmt.Errorf("failed getting if-match with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusPreconditionRequired,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got if-match")
+	logger = logger.With().Strs(constants.KEY_IF_MATCH, ifMatch).Logger()
+	logger.Debug().Msg("got if-match")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
 	logger.Trace().Msg("decoding request body")
 	span.AddEvent("decoding request body")
@@ -389,14 +567,22 @@ func (p ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request)
 	logger.Trace().Msg("updating product")
 	span.AddEvent("updating product")
 	c = logger.WithContext(c)
-	product, err := p.service.UpdateProduct(c, id, reqBody)
+	product, err := p.service.UpdateProduct(c, id, ifMatch, reqBody)
 	if err != nil {
 		err = fmt.Errorf("failed updating product with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, inErrors.ErrPreconditionFailed) {
+			statusCode = http.StatusPreconditionFailed
+		} else if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, productErrors.ErrBelowWarehousedStock) {
+			statusCode = http.StatusConflict
+		}
 		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
 			"status":     "failed",
-			"statusCode": http.StatusBadRequest,
+			"statusCode": statusCode,
 			"message":    err.Error(),
 		})
 		return
@@ -404,7 +590,7 @@ func (p ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request)
 	span.AddEvent("updated product")
 	logger.Debug().Msg("updated product")
 
-	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+	inHttp.WriteJsonResponse(c, w, p.etagHeader(c, product.Response()), map[string]interface{}{
 		"status":     "success",
 		"statusCode": http.StatusOK,
 		"message":    "successfully updated product",
@@ -413,3 +599,649 @@ func (p ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request)
 		},
 	})
 }
+
+func (p ProductController) FindInventoryLevels(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindInventoryLevels")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindInventoryLevels").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding inventory levels").Logger()
+	logger.Trace().Msg("finding inventory levels")
+	span.AddEvent("finding inventory levels")
+	c = logger.WithContext(c)
+	levels, err := p.service.FindInventoryLevels(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding inventory levels with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found inventory levels")
+	logger.Debug().Msg("found inventory levels")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "found inventory levels",
+		"data": map[string]interface{}{
+			"inventory_levels": levels,
+		},
+	})
+}
+
+func (p ProductController) SetInventoryLevel(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController SetInventoryLevel")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController SetInventoryLevel").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating path values").Logger()
+	logger.Trace().Msg("validating path values")
+	span.AddEvent("validating path values")
+	pathValues := mux.Vars(r)
+	productId, err := uuid.Parse(pathValues["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	warehouseId, err := uuid.Parse(pathValues["warehouseId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating warehouseId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated path values")
+	logger = logger.With().
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_WAREHOUSE_ID, warehouseId.String()).
+		Logger()
+	logger.Debug().Msg("validated path values")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.InventoryLevel{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		err = fmt.Errorf("failed decoding request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, reqBody); err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "setting inventory level").Logger()
+	logger.Trace().Msg("setting inventory level")
+	span.AddEvent("setting inventory level")
+	c = logger.WithContext(c)
+	product, err := p.service.SetInventoryLevel(c, productId, warehouseId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed setting inventory level with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("set inventory level")
+	logger.Debug().Msg("set inventory level")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully set inventory level",
+		"data": map[string]interface{}{
+			"product": product,
+		},
+	})
+}
+
+func (p ProductController) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController AdjustStock")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController AdjustStock").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating idempotency key").Logger()
+	logger.Trace().Msg("validating idempotency key")
+	span.AddEvent("validating idempotency key")
+	idempotencyKey := strings.TrimSpace(r.Header.Get(inHttp.KEY_HEADER_IDEMPOTENCY_KEY))
+	if idempotencyKey == "" || len(idempotencyKey) > 255 {
+		err = fmt.Errorf(
+			"failed validating idempotency key with error=%w",
+			productErrors.ErrInvalidIdempotencyKey,
+		)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated idempotency key")
+	logger = logger.With().Str(constants.KEY_IDEMPOTENCY_KEY, idempotencyKey).Logger()
+	logger.Debug().Msg("validated idempotency key")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.StockAdjustment{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		err = fmt.Errorf("failed decoding request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, reqBody); err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "adjusting stock").Logger()
+	logger.Trace().Msg("adjusting stock")
+	span.AddEvent("adjusting stock")
+	c = logger.WithContext(c)
+	adjustment, replayed, err := p.service.AdjustStock(c, productId, idempotencyKey, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed adjusting stock with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": adjustStockStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("adjusted stock")
+	logger.Debug().Bool(constants.KEY_REPLAYED, replayed).Msg("adjusted stock")
+
+	statusCode := http.StatusCreated
+	if replayed {
+		statusCode = http.StatusOK
+	}
+	inHttp.WriteJsonResponse(
+		c,
+		w,
+		map[string]string{inHttp.KEY_HEADER_IDEMPOTENT_REPLAYED: strconv.FormatBool(replayed)},
+		map[string]interface{}{
+			"status":     "success",
+			"statusCode": statusCode,
+			"message":    "successfully adjusted stock",
+			"data": map[string]interface{}{
+				"adjustment": adjustment,
+			},
+		},
+	)
+}
+
+func (p ProductController) FindInventoryMovements(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindInventoryMovements")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindInventoryMovements").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating page").Logger()
+	logger.Trace().Msg("validating page")
+	span.AddEvent("validating page")
+	page, err := inHttp.ParsePage(r, p.pagination)
+	if err != nil {
+		err = fmt.Errorf("failed validating page with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated page")
+	logger = logger.With().Any(constants.KEY_PAGE, page).Logger()
+	logger.Debug().Msg("validated page")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding inventory movements").Logger()
+	logger.Trace().Msg("finding inventory movements")
+	span.AddEvent("finding inventory movements")
+	c = logger.WithContext(c)
+	movements, nextCursor, err := p.service.FindInventoryMovements(c, productId, page)
+	if err != nil {
+		err = fmt.Errorf("failed finding inventory movements with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, inErrors.ErrInvalidCursor) {
+			statusCode = http.StatusBadRequest
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found inventory movements")
+	logger.Debug().Msg("found inventory movements")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":      "success",
+		"statusCode":  http.StatusOK,
+		"message":     "found inventory movements",
+		"next_cursor": nextCursor,
+		"data": map[string]interface{}{
+			"inventory_movements": movements,
+		},
+	})
+}
+
+func (p ProductController) FindStockAsOf(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindStockAsOf")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindStockAsOf").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating at").Logger()
+	logger.Trace().Msg("validating at")
+	span.AddEvent("validating at")
+	at := time.Now()
+	if rawAt := r.URL.Query().Get("at"); rawAt != "" {
+		at, err = time.Parse(time.RFC3339, rawAt)
+		if err != nil {
+			err = fmt.Errorf("failed validating at with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+				"status":     "failed",
+				"statusCode": http.StatusBadRequest,
+				"message":    err.Error(),
+			})
+			return
+		}
+	}
+	span.AddEvent("validated at")
+	logger = logger.With().Time(constants.KEY_STOCK_AS_OF, at).Logger()
+	logger.Debug().Msg("validated at")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating variantId").Logger()
+	logger.Trace().Msg("validating variantId")
+	span.AddEvent("validating variantId")
+	var variantId *uuid.UUID
+	if rawVariantId := r.URL.Query().Get("variantId"); rawVariantId != "" {
+		parsed, err := uuid.Parse(rawVariantId)
+		if err != nil {
+			err = fmt.Errorf("failed validating variantId with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+				"status":     "failed",
+				"statusCode": http.StatusBadRequest,
+				"message":    err.Error(),
+			})
+			return
+		}
+		variantId = &parsed
+		logger = logger.With().Str(constants.KEY_VARIANT_ID, parsed.String()).Logger()
+	}
+	span.AddEvent("validated variantId")
+	logger.Debug().Msg("validated variantId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding stock as of").Logger()
+	logger.Trace().Msg("finding stock as of")
+	span.AddEvent("finding stock as of")
+	c = logger.WithContext(c)
+	quantity, err := p.service.FindStockAsOf(c, productId, variantId, at)
+	if err != nil {
+		err = fmt.Errorf("failed finding stock as of with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found stock as of")
+	logger.Debug().Msg("found stock as of")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "found stock as of",
+		"data": map[string]interface{}{
+			"product_id": productId,
+			"variant_id": variantId,
+			"at":         at,
+			"quantity":   quantity,
+		},
+	})
+}
+
+// findProductParam reads the product search from the query string of r.
+func (p ProductController) SetProductCategories(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController SetProductCategories")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController SetProductCategories").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.ProductCategories{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		err = fmt.Errorf("failed decoding request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating request body").Logger()
+	logger.Trace().Msg("validating request body")
+	span.AddEvent("validating request body")
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, reqBody); err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated request body")
+	logger.Debug().Msg("validated request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "setting product categories").Logger()
+	logger.Trace().Msg("setting product categories")
+	span.AddEvent("setting product categories")
+	c = logger.WithContext(c)
+	breadcrumbs, err := p.service.SetProductCategories(c, productId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed setting product categories with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("set product categories")
+	logger.Info().Msg("set product categories")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully set product categories",
+		"data": map[string]interface{}{
+			"breadcrumbs": breadcrumbs,
+		},
+	})
+}
+
+func findProductParam(r *http.Request) (request.FindProduct, error) {
+	query := r.URL.Query()
+	param := request.FindProduct{
+		Name: strings.TrimSpace(query.Get("name")),
+		Sort: query.Get("sort"),
+	}
+	if rawMinPrice := query.Get("min_price"); rawMinPrice != "" {
+		minPrice, err := decimal.NewFromString(rawMinPrice)
+		if err != nil {
+			return request.FindProduct{}, fmt.Errorf("invalid min_price=%s with error=%w", rawMinPrice, err)
+		}
+		param.MinPrice = &minPrice
+	}
+	if rawMaxPrice := query.Get("max_price"); rawMaxPrice != "" {
+		maxPrice, err := decimal.NewFromString(rawMaxPrice)
+		if err != nil {
+			return request.FindProduct{}, fmt.Errorf("invalid max_price=%s with error=%w", rawMaxPrice, err)
+		}
+		param.MaxPrice = &maxPrice
+	}
+	if param.MinPrice != nil && param.MaxPrice != nil && param.MinPrice.GreaterThan(*param.MaxPrice) {
+		return request.FindProduct{}, fmt.Errorf("min_price is greater than max_price")
+	}
+	if rawInStock := query.Get("in_stock"); rawInStock != "" {
+		inStock, err := strconv.ParseBool(rawInStock)
+		if err != nil {
+			return request.FindProduct{}, fmt.Errorf("invalid in_stock=%s with error=%w", rawInStock, err)
+		}
+		param.InStock = inStock
+	}
+	if rawCategoryId := query.Get("category_id"); rawCategoryId != "" {
+		categoryId, err := uuid.Parse(rawCategoryId)
+		if err != nil {
+			return request.FindProduct{}, fmt.Errorf("invalid category_id=%s with error=%w", rawCategoryId, err)
+		}
+		param.CategoryId = &categoryId
+	}
+	return param, nil
+}
+
+// etagHeader returns the ETag header a GET of product responds with. The change
+// is already committed when it is called, so a failure is only logged and the
+// response goes out without an ETag.
+func (p ProductController) etagHeader(c context.Context, product response.Product) map[string]string {
+	etag, err := service.ETag(product)
+	if err != nil {
+		zerolog.Ctx(c).Error().Err(err).Msg(err.Error())
+		return map[string]string{}
+	}
+	return map[string]string{inHttp.KEY_HEADER_ETAG: etag}
+}
+
+// archiveStatusCode maps a product that does not exist, or is already in the
+// requested state, to not found and a product modified since its ETag was read
+// to precondition failed.
+func archiveStatusCode(err error) int {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, inErrors.ErrPreconditionFailed) {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusInternalServerError
+}
+
+// adjustStockStatusCode maps a product or default warehouse that does not
+// exist to not found, a stock that would go below zero to conflict and an
+// idempotency key reused for a different adjustment to unprocessable entity.
+func adjustStockStatusCode(err error) int {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, productErrors.ErrInsufficientStock):
+		return http.StatusConflict
+	case errors.Is(err, productErrors.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, productErrors.ErrInvalidAdjustment):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendationLimit     = 50
+)
+
+// FindRecommendations lists the products frequently bought together with a
+// product, the limit query param defaults to 10 and must not exceed 50.
+func (p ProductController) FindRecommendations(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindRecommendations")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindRecommendations").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating limit").Logger()
+	logger.Trace().Msg("validating limit")
+	span.AddEvent("validating limit")
+	limit := int64(defaultRecommendationLimit)
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		limit, err = strconv.ParseInt(rawLimit, 10, 32)
+		if err == nil && (limit <= 0 || limit > maxRecommendationLimit) {
+			err = fmt.Errorf("limit must be between 1 and %d", maxRecommendationLimit)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating limit with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated limit")
+	logger = logger.With().Int64(constants.KEY_LIMIT, limit).Logger()
+	logger.Debug().Msg("validated limit")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding recommendations").Logger()
+	logger.Trace().Msg("finding recommendations")
+	span.AddEvent("finding recommendations")
+	c = logger.WithContext(c)
+	recommendations, err := p.service.FindRecommendations(c, productId, int32(limit))
+	if err != nil {
+		err = fmt.Errorf("failed finding recommendations with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found recommendations")
+	logger.Info().Int(constants.KEY_RECOMMENDATIONS, len(recommendations)).Msg("found recommendations")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "found recommendations",
+		"data": map[string]interface{}{
+			"recommendations": recommendations,
+		},
+	})
+}
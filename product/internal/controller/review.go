@@ -0,0 +1,423 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+func (p ProductController) InsertReview(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController InsertReview")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController InsertReview").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got userId from jwtToken")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Info().Msg("got userId from jwtToken")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.Review{}
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err == nil {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.StructCtx(c, reqBody)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting review").Logger()
+	logger.Trace().Msg("inserting review")
+	span.AddEvent("inserting review")
+	c = logger.WithContext(c)
+	review, err := p.service.InsertReview(c, productId, userId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed inserting review with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": reviewStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("inserted review")
+	logger.Info().Msg("inserted review")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusCreated,
+		"message":    "successfully reviewed product",
+		"data": map[string]interface{}{
+			"review": review,
+		},
+	})
+}
+
+// FindReviews lists the approved reviews of a product, sorted by the sort query
+// param, newest or helpful.
+func (p ProductController) FindReviews(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindReviews")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindReviews").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating page").Logger()
+	logger.Trace().Msg("validating page")
+	span.AddEvent("validating page")
+	page, err := inHttp.ParsePage(r, p.pagination)
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = request.SortReviewNewest
+	}
+	if err == nil && sortBy != request.SortReviewNewest && sortBy != request.SortReviewHelpful {
+		err = fmt.Errorf("sort must be %s or %s", request.SortReviewNewest, request.SortReviewHelpful)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating page with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated page")
+	logger = logger.With().Any(constants.KEY_PAGE, page).Logger()
+	logger.Debug().Msg("validated page")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding reviews").Logger()
+	logger.Trace().Msg("finding reviews")
+	span.AddEvent("finding reviews")
+	c = logger.WithContext(c)
+	reviews, nextCursor, err := p.service.FindReviews(c, productId, sortBy, page)
+	if err != nil {
+		err = fmt.Errorf("failed finding reviews with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": reviewStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found reviews")
+	logger.Info().Msg("found reviews")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":      "success",
+		"statusCode":  http.StatusOK,
+		"message":     "found reviews",
+		"next_cursor": nextCursor,
+		"data": map[string]interface{}{
+			"reviews": reviews,
+		},
+	})
+}
+
+func (p ProductController) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController ModerateReview")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController ModerateReview").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating reviewId").Logger()
+	logger.Trace().Msg("validating reviewId")
+	span.AddEvent("validating reviewId")
+	reviewId, err := uuid.Parse(mux.Vars(r)["reviewId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating reviewId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated reviewId")
+	logger = logger.With().Str(constants.KEY_REVIEW_ID, reviewId.String()).Logger()
+	logger.Debug().Msg("validated reviewId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.ReviewStatus{}
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err == nil {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.StructCtx(c, reqBody)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "moderating review").Logger()
+	logger.Trace().Msg("moderating review")
+	span.AddEvent("moderating review")
+	c = logger.WithContext(c)
+	review, err := p.service.ModerateReview(c, productId, reviewId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed moderating review with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": reviewStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("moderated review")
+	logger.Info().Msg("moderated review")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully moderated review",
+		"data": map[string]interface{}{
+			"review": review,
+		},
+	})
+}
+
+func (p ProductController) VoteReviewHelpful(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController VoteReviewHelpful")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController VoteReviewHelpful").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got userId from jwtToken")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Info().Msg("got userId from jwtToken")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating reviewId").Logger()
+	logger.Trace().Msg("validating reviewId")
+	span.AddEvent("validating reviewId")
+	reviewId, err := uuid.Parse(mux.Vars(r)["reviewId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating reviewId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated reviewId")
+	logger = logger.With().Str(constants.KEY_REVIEW_ID, reviewId.String()).Logger()
+	logger.Debug().Msg("validated reviewId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "voting review helpful").Logger()
+	logger.Trace().Msg("voting review helpful")
+	span.AddEvent("voting review helpful")
+	c = logger.WithContext(c)
+	review, err := p.service.VoteReviewHelpful(c, productId, reviewId, userId)
+	if err != nil {
+		err = fmt.Errorf("failed voting review helpful with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": reviewStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("voted review helpful")
+	logger.Info().Msg("voted review helpful")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully voted review helpful",
+		"data": map[string]interface{}{
+			"review": review,
+		},
+	})
+}
+
+func reviewStatusCode(err error) int {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, productErrors.ErrNotVerifiedBuyer):
+		return http.StatusForbidden
+	case errors.Is(err, productErrors.ErrReviewAlreadyExist),
+		errors.Is(err, productErrors.ErrReviewNotApproved):
+		return http.StatusConflict
+	case errors.Is(err, inErrors.ErrInvalidCursor):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
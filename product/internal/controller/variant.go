@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+func (p ProductController) FindVariants(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController FindVariants")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController FindVariants").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding variants").Logger()
+	logger.Trace().Msg("finding variants")
+	span.AddEvent("finding variants")
+	c = logger.WithContext(c)
+	variants, err := p.service.FindVariants(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding variants with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found variants")
+	logger.Info().Int(constants.KEY_VARIANTS, len(variants)).Msg("found variants")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "variants found",
+		"data": map[string]interface{}{
+			"variants": variants,
+		},
+	})
+}
+
+func (p ProductController) InsertVariant(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController InsertVariant")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController InsertVariant").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating productId").Logger()
+	logger.Trace().Msg("validating productId")
+	span.AddEvent("validating productId")
+	productId, err := uuid.Parse(mux.Vars(r)["productId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating productId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated productId")
+	logger = logger.With().Str(constants.KEY_PRODUCT_ID, productId.String()).Logger()
+	logger.Debug().Msg("validated productId")
+
+	reqBody, err := decodeVariant(c, r)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting variant").Logger()
+	logger.Trace().Msg("inserting variant")
+	span.AddEvent("inserting variant")
+	c = logger.WithContext(c)
+	variant, err := p.service.InsertVariant(c, productId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed inserting variant with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("inserted variant")
+	logger.Info().Msg("inserted variant")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusCreated,
+		"message":    "successfully inserted variant",
+		"data": map[string]interface{}{
+			"variant": variant,
+		},
+	})
+}
+
+func (p ProductController) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController UpdateVariant")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController UpdateVariant").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating path values").Logger()
+	logger.Trace().Msg("validating path values")
+	span.AddEvent("validating path values")
+	productId, variantId, err := variantPathValues(r)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated path values")
+	logger = logger.With().
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_VARIANT_ID, variantId.String()).
+		Logger()
+	logger.Debug().Msg("validated path values")
+
+	reqBody, err := decodeVariant(c, r)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating variant").Logger()
+	logger.Trace().Msg("updating variant")
+	span.AddEvent("updating variant")
+	c = logger.WithContext(c)
+	variant, err := p.service.UpdateVariant(c, productId, variantId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed updating variant with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": variantStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("updated variant")
+	logger.Info().Msg("updated variant")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully updated variant",
+		"data": map[string]interface{}{
+			"variant": variant,
+		},
+	})
+}
+
+func (p ProductController) RemoveVariant(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "ProductController RemoveVariant")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductController RemoveVariant").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating path values").Logger()
+	logger.Trace().Msg("validating path values")
+	span.AddEvent("validating path values")
+	productId, variantId, err := variantPathValues(r)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated path values")
+	logger = logger.With().
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_VARIANT_ID, variantId.String()).
+		Logger()
+	logger.Debug().Msg("validated path values")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing variant").Logger()
+	logger.Trace().Msg("removing variant")
+	span.AddEvent("removing variant")
+	c = logger.WithContext(c)
+	variant, err := p.service.RemoveVariant(c, productId, variantId)
+	if err != nil {
+		err = fmt.Errorf("failed removing variant with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": variantStatusCode(err),
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("removed variant")
+	logger.Info().Msg("removed variant")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully removed variant",
+		"data": map[string]interface{}{
+			"variant": variant,
+		},
+	})
+}
+
+func variantPathValues(r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	pathValues := mux.Vars(r)
+	productId, err := uuid.Parse(pathValues["productId"])
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("failed validating productId with error=%w", err)
+	}
+	variantId, err := uuid.Parse(pathValues["variantId"])
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("failed validating variantId with error=%w", err)
+	}
+	return productId, variantId, nil
+}
+
+func decodeVariant(c context.Context, r *http.Request) (request.Variant, error) {
+	reqBody := request.Variant{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		return request.Variant{}, fmt.Errorf("failed decoding request body with error=%w", err)
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, reqBody); err != nil {
+		return request.Variant{}, fmt.Errorf("failed validating request body with error=%w", err)
+	}
+	if reqBody.Price != nil && reqBody.Price.IsNegative() {
+		return request.Variant{}, errors.New("price must not be negative")
+	}
+	return reqBody, nil
+}
+
+func variantStatusCode(err error) int {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
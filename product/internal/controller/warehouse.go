@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	"github.com/Alturino/ecommerce/internal/middleware"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/internal/service"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+type WarehouseController struct {
+	service *service.WarehouseService
+}
+
+func AttachWarehouseController(mux *mux.Router, service *service.WarehouseService) {
+	controller := WarehouseController{service}
+
+	staff := middleware.RequireRole(constants.ROLE_ADMIN, constants.ROLE_MERCHANT_STAFF)
+
+	router := mux.PathPrefix("/warehouses").Subrouter()
+	router.Use(
+		otelmux.Middleware(constants.APP_PRODUCT_SERVICE),
+		middleware.Logging,
+		middleware.RecoverPanic,
+		middleware.Auth,
+	)
+	router.HandleFunc("", controller.FindWarehouses).Methods(http.MethodGet)
+	router.Handle("", staff(http.HandlerFunc(controller.InsertWarehouse))).Methods(http.MethodPost)
+}
+
+func (ctrl WarehouseController) InsertWarehouse(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "WarehouseController InsertWarehouse")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "WarehouseController InsertWarehouse").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.Warehouse{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		err = fmt.Errorf("failed decoding request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating request body").Logger()
+	logger.Trace().Msg("validating request body")
+	span.AddEvent("validating request body")
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.StructCtx(c, reqBody); err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated request body")
+	logger.Debug().Msg("validated request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting warehouse").Logger()
+	logger.Trace().Msg("inserting warehouse")
+	span.AddEvent("inserting warehouse")
+	c = logger.WithContext(c)
+	warehouse, err := ctrl.service.InsertWarehouse(c, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed inserting warehouse with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("inserted warehouse")
+	logger.Info().Msg("inserted warehouse")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusCreated,
+		"message":    "successfully inserted warehouse",
+		"data": map[string]interface{}{
+			"warehouse": warehouse,
+		},
+	})
+}
+
+func (ctrl WarehouseController) FindWarehouses(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "WarehouseController FindWarehouses")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "WarehouseController FindWarehouses").
+		Str(constants.KEY_PROCESS, "finding warehouses").
+		Logger()
+
+	logger.Trace().Msg("finding warehouses")
+	span.AddEvent("finding warehouses")
+	c = logger.WithContext(c)
+	warehouses, err := ctrl.service.FindWarehouses(c)
+	if err != nil {
+		err = fmt.Errorf("failed finding warehouses with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusInternalServerError,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("found warehouses")
+	logger.Info().Msg("found warehouses")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "found warehouses",
+		"data": map[string]interface{}{
+			"warehouses": warehouses,
+		},
+	})
+}
@@ -5,4 +5,19 @@ import "errors"
 var (
 	ErrFailedInsertingProduct = errors.New("ErrFailedInsertingProduct")
 	ErrProductAlreadyExist    = errors.New("product already exist")
+	ErrMediaTooLarge          = errors.New("media is too large")
+	ErrUnsupportedMediaType   = errors.New("media type is not supported")
+	ErrSkuBelongsToOther      = errors.New("sku belongs to another product")
+	ErrPriceInPast            = errors.New("price can not take effect in the past")
+	ErrInvalidPriceRange      = errors.New("price must end after it takes effect")
+	ErrNotVerifiedBuyer       = errors.New("only buyers with a completed order of the product can review it")
+	ErrReviewAlreadyExist     = errors.New("product is already reviewed")
+	ErrReviewNotApproved      = errors.New("review is not approved")
+	ErrInvalidExchangeRate    = errors.New("exchange rate must be greater than zero")
+	ErrBaseCurrencyRate       = errors.New("exchange rate of the base currency is always 1")
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was used for a different stock adjustment")
+	ErrInsufficientStock      = errors.New("stock adjustment would take the stock below zero")
+	ErrInvalidAdjustment      = errors.New("restock and return adjustments must add stock")
+	ErrInvalidIdempotencyKey  = errors.New("missing or too long Idempotency-Key header")
+	ErrBelowWarehousedStock   = errors.New("quantity is below the stock held in non-default warehouses")
 )
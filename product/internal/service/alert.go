@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+// SetStockAlert sets the low stock threshold of a product and evaluates its
+// stock level against it right away.
+func (svc ProductService) SetStockAlert(
+	c context.Context,
+	productId uuid.UUID,
+	param request.StockAlert,
+) (repository.ProductStockAlert, error) {
+	c, span := otel.Tracer.Start(c, "ProductService SetStockAlert")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService SetStockAlert").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product").Logger()
+	logger.Trace().Msg("finding product")
+	span.AddEvent("finding product")
+	_, err := svc.queries.FindProductById(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.ProductStockAlert{}, err
+	}
+	span.AddEvent("found product")
+	logger.Info().Msg("found product")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "setting low stock threshold").Logger()
+	logger.Trace().Msg("setting low stock threshold")
+	span.AddEvent("setting low stock threshold")
+	_, err = svc.queries.UpsertLowStockThreshold(c, repository.UpsertLowStockThresholdParams{
+		ProductID:         productId,
+		LowStockThreshold: toInt4(param.LowStockThreshold),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed setting low stock threshold with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.ProductStockAlert{}, err
+	}
+	span.AddEvent("set low stock threshold")
+	logger.Info().Msg("set low stock threshold")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "evaluating stock alerts").Logger()
+	logger.Trace().Msg("evaluating stock alerts")
+	span.AddEvent("evaluating stock alerts")
+	_, err = svc.EvaluateStockAlerts(c, []uuid.UUID{productId})
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.ProductStockAlert{}, err
+	}
+	span.AddEvent("evaluated stock alerts")
+	logger.Info().Msg("evaluated stock alerts")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding stock alert").Logger()
+	logger.Trace().Msg("finding stock alert")
+	span.AddEvent("finding stock alert")
+	alert, err := svc.queries.FindProductStockAlert(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding stock alert with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.ProductStockAlert{}, err
+	}
+	span.AddEvent("found stock alert")
+	logger.Info().Any(constants.KEY_STOCK_ALERT, alert).Msg("found stock alert")
+
+	return alert, nil
+}
+
+// EvaluateStockAlerts compares the stock level of every product with the level
+// it was last seen at and publishes a stock alert when it moved. The products
+// are locked while evaluated, so a level change of a product is alerted once
+// however many services evaluate it at the same time.
+func (svc ProductService) EvaluateStockAlerts(
+	c context.Context,
+	productIds []uuid.UUID,
+) ([]response.StockAlert, error) {
+	c, span := otel.Tracer.Start(c, "ProductService EvaluateStockAlerts")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService EvaluateStockAlerts").
+		Any(constants.KEY_PRODUCT_IDS, productIds).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	span.AddEvent("initialized transaction")
+	logger.Info().Msg("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking stock alert states").Logger()
+	logger.Trace().Msg("locking stock alert states")
+	span.AddEvent("locking stock alert states")
+	states, err := svc.queries.WithTx(tx).FindStockAlertStatesForUpdate(c, productIds)
+	if err != nil {
+		err = fmt.Errorf("failed locking stock alert states with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("locked stock alert states")
+	logger.Info().Msg("locked stock alert states")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating stock levels").Logger()
+	logger.Trace().Msg("updating stock levels")
+	span.AddEvent("updating stock levels")
+	alerts := []response.StockAlert{}
+	now := time.Now()
+	for _, state := range states {
+		threshold := svc.config.StockAlert.LowStockThreshold
+		if state.LowStockThreshold.Valid {
+			threshold = state.LowStockThreshold.Int32
+		}
+		previous := repository.StockLevelINSTOCK
+		if state.Level.Valid {
+			previous = state.Level.StockLevel
+		}
+		level := stockLevel(state.Quantity, threshold)
+		if level == previous {
+			continue
+		}
+
+		err = svc.queries.WithTx(tx).UpdateStockLevel(c, repository.UpdateStockLevelParams{
+			ProductID: state.ProductID,
+			Level:     level,
+		})
+		if err != nil {
+			err = fmt.Errorf("failed updating stock levels with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return nil, err
+		}
+
+		kind, ok := stockAlertKind(previous, level)
+		if !ok {
+			continue
+		}
+		alerts = append(alerts, response.StockAlert{
+			At:        now,
+			Kind:      kind,
+			Name:      state.Name,
+			ProductId: state.ProductID,
+			Quantity:  state.Quantity,
+			Threshold: threshold,
+		})
+	}
+	span.AddEvent("updated stock levels")
+	logger.Info().Int(constants.KEY_STOCK_ALERTS, len(alerts)).Msg("updated stock levels")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	for _, alert := range alerts {
+		lg := logger.With().
+			Str(constants.KEY_PROCESS, "publishing stock alert").
+			Any(constants.KEY_STOCK_ALERT, alert).
+			Logger()
+		lg.Trace().Msg("publishing stock alert")
+		span.AddEvent("publishing stock alert")
+		event, err := json.Marshal(alert)
+		if err == nil {
+			err = svc.cache.Publish(c, constants.STOCK_ALERT, event).Err()
+		}
+		if err != nil {
+			err = fmt.Errorf("failed publishing stock alert with error=%w", err)
+			inOtel.RecordError(err, span)
+			lg.Error().Err(err).Msg(err.Error())
+			continue
+		}
+		span.AddEvent("published stock alert")
+		lg.Info().Msg("published stock alert")
+	}
+
+	return alerts, nil
+}
+
+// stockLevel returns the stock level of a quantity, a product is low on stock
+// once its quantity is at or below threshold.
+func stockLevel(quantity int32, threshold int32) repository.StockLevel {
+	switch {
+	case quantity <= 0:
+		return repository.StockLevelSOLDOUT
+	case quantity <= threshold:
+		return repository.StockLevelLOWSTOCK
+	}
+	return repository.StockLevelINSTOCK
+}
+
+// stockAlertKind returns the alert of a stock level change. Running low is only
+// alerted coming from in stock, a sold out product coming back low on stock is
+// back in stock and a product recovering from low stock is not alerted.
+func stockAlertKind(from repository.StockLevel, to repository.StockLevel) (string, bool) {
+	switch {
+	case from == to:
+		return "", false
+	case to == repository.StockLevelSOLDOUT:
+		return response.StockAlertSoldOut, true
+	case from == repository.StockLevelSOLDOUT:
+		return response.StockAlertBackInStock, true
+	case to == repository.StockLevelLOWSTOCK:
+		return response.StockAlertLowStock, true
+	}
+	return "", false
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+func TestStockLevel(t *testing.T) {
+	assert.Equal(t, repository.StockLevelSOLDOUT, stockLevel(0, 5))
+	assert.Equal(t, repository.StockLevelSOLDOUT, stockLevel(-2, 5))
+	assert.Equal(t, repository.StockLevelLOWSTOCK, stockLevel(5, 5))
+	assert.Equal(t, repository.StockLevelINSTOCK, stockLevel(6, 5))
+	assert.Equal(t, repository.StockLevelINSTOCK, stockLevel(1, 0))
+}
+
+func TestStockAlertKind(t *testing.T) {
+	testCases := []struct {
+		name string
+		from repository.StockLevel
+		to   repository.StockLevel
+		kind string
+		ok   bool
+	}{
+		{"running low", repository.StockLevelINSTOCK, repository.StockLevelLOWSTOCK, response.StockAlertLowStock, true},
+		{"selling out", repository.StockLevelLOWSTOCK, repository.StockLevelSOLDOUT, response.StockAlertSoldOut, true},
+		{"selling out at once", repository.StockLevelINSTOCK, repository.StockLevelSOLDOUT, response.StockAlertSoldOut, true},
+		{"restocked a little", repository.StockLevelSOLDOUT, repository.StockLevelLOWSTOCK, response.StockAlertBackInStock, true},
+		{"restocked", repository.StockLevelSOLDOUT, repository.StockLevelINSTOCK, response.StockAlertBackInStock, true},
+		{"recovered from low stock", repository.StockLevelLOWSTOCK, repository.StockLevelINSTOCK, "", false},
+		{"unchanged", repository.StockLevelLOWSTOCK, repository.StockLevelLOWSTOCK, "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, ok := stockAlertKind(tc.from, tc.to)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.kind, kind)
+		})
+	}
+}
@@ -0,0 +1,736 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	inCache "github.com/Alturino/ecommerce/internal/cache"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/cache"
+	"github.com/Alturino/ecommerce/product/internal/catalog"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+const (
+	MatchName = "name"
+	MatchSku  = "sku"
+
+	DefaultChunkSize = 500
+	exportPageSize   = 500
+)
+
+// ImportOptions controls an import, Match decides whether a row with a sku
+// finds its product through the variant or through the product name.
+type ImportOptions struct {
+	Match     string `validate:"omitempty,oneof=name sku" json:"match"`
+	DryRun    bool   `                                    json:"dry_run"`
+	ChunkSize int    `validate:"gte=0,lte=5000"           json:"chunk_size"`
+}
+
+type CatalogService struct {
+	pool    *pgxpool.Pool
+	queries *repository.Queries
+	cache   *inCache.Cache
+}
+
+func NewCatalogService(
+	pool *pgxpool.Pool,
+	queries *repository.Queries,
+	cache *inCache.Cache,
+) CatalogService {
+	return CatalogService{pool: pool, queries: queries, cache: cache}
+}
+
+// importedRow is what a committed row needs after its chunk is committed.
+type importedRow struct {
+	productId uuid.UUID
+	restocked bool
+}
+
+// Import upserts every row read from reader. Rows are applied in chunks of
+// ChunkSize rows, each in its own transaction so a large import never holds
+// its locks for long, and every row in its own savepoint so a failing row does
+// not fail its chunk. A dry run applies the chunks and rolls them back, the
+// report then shows what the import would change.
+func (svc CatalogService) Import(
+	c context.Context,
+	reader catalog.Reader,
+	opts ImportOptions,
+) (catalog.Report, error) {
+	c, span := otel.Tracer.Start(c, "CatalogService Import")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CatalogService Import").
+		Any(constants.KEY_IMPORT_OPTIONS, opts).
+		Logger()
+
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.Match == "" {
+		opts.Match = MatchName
+	}
+
+	report := catalog.Report{DryRun: opts.DryRun, Changes: []catalog.Change{}}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	chunk := make([]catalog.Row, 0, opts.ChunkSize)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		rowError := &catalog.RowError{}
+		if errors.As(err, &rowError) {
+			report.Add(failedChange(row, rowError.Err))
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("failed reading catalog with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return report, err
+		}
+		if err := validateRow(c, validate, row); err != nil {
+			report.Add(failedChange(row, err))
+			continue
+		}
+
+		chunk = append(chunk, row)
+		if len(chunk) < opts.ChunkSize {
+			continue
+		}
+		if err := svc.importChunk(c, chunk, opts, &report); err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return report, err
+		}
+		chunk = chunk[:0]
+	}
+	if len(chunk) > 0 {
+		if err := svc.importChunk(c, chunk, opts, &report); err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return report, err
+		}
+	}
+	slices.SortStableFunc(report.Changes, func(a, b catalog.Change) int { return a.Line - b.Line })
+
+	span.AddEvent("imported catalog")
+	logger.Info().
+		Int(constants.KEY_CATALOG_ROWS, report.Rows).
+		Int(constants.KEY_CATALOG_FAILED, report.Failed).
+		Msg("imported catalog")
+
+	return report, nil
+}
+
+func (svc CatalogService) importChunk(
+	c context.Context,
+	chunk []catalog.Row,
+	opts ImportOptions,
+	report *catalog.Report,
+) error {
+	c, span := otel.Tracer.Start(c, "CatalogService importChunk")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CatalogService importChunk").
+		Int(constants.KEY_CATALOG_LINE, chunk[0].Line).
+		Int(constants.KEY_CATALOG_ROWS, len(chunk)).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "importing rows").Logger()
+	logger.Trace().Msg("importing rows")
+	span.AddEvent("importing rows")
+	changes := make([]catalog.Change, 0, len(chunk))
+	imported := make([]importedRow, 0, len(chunk))
+	for _, row := range chunk {
+		savepoint, err := tx.Begin(c)
+		if err != nil {
+			err = fmt.Errorf("failed initializing savepoint with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return err
+		}
+		change, result, err := importRow(c, svc.queries.WithTx(savepoint), row, opts.Match)
+		if err != nil {
+			if err := savepoint.Rollback(c); err != nil {
+				err = fmt.Errorf("failed rolling back savepoint with error=%w", err)
+				inOtel.RecordError(err, span)
+				logger.Error().Err(err).Msg(err.Error())
+				return err
+			}
+			logger.Debug().Err(err).Int(constants.KEY_CATALOG_LINE, row.Line).Msg("failed importing row")
+			changes = append(changes, failedChange(row, err))
+			continue
+		}
+		if err := savepoint.Commit(c); err != nil {
+			err = fmt.Errorf("failed releasing savepoint with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return err
+		}
+		changes = append(changes, change)
+		if change.Action != catalog.ActionUnchanged {
+			imported = append(imported, result)
+		}
+	}
+	span.AddEvent("imported rows")
+	logger.Info().Msg("imported rows")
+
+	if opts.DryRun {
+		for _, change := range changes {
+			report.Add(change)
+		}
+		return nil
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+	for _, change := range changes {
+		report.Add(change)
+	}
+
+	if len(imported) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(imported))
+	for _, row := range imported {
+		keys = append(keys, row.productId.String())
+	}
+	logger = logger.With().Str(constants.KEY_PROCESS, "invalidating products in cache").Logger()
+	logger.Trace().Msg("invalidating products in cache")
+	span.AddEvent("invalidating products in cache")
+	err = svc.cache.Delete(c, cache.KEY_PRODUCTS, keys...)
+	if err != nil {
+		err = fmt.Errorf("failed invalidating products in cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+	} else {
+		span.AddEvent("invalidated products in cache")
+		logger.Info().Msg("invalidated products in cache")
+	}
+
+	productIds := make([]uuid.UUID, 0, len(imported))
+	for _, row := range imported {
+		productIds = append(productIds, row.productId)
+	}
+	logger = logger.With().Str(constants.KEY_PROCESS, "publishing product quantity updated").Logger()
+	logger.Trace().Msg("publishing product quantity updated")
+	span.AddEvent("publishing product quantity updated")
+	err = publishQuantityUpdated(c, svc.cache.Client, productIds)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+	} else {
+		span.AddEvent("published product quantity updated")
+		logger.Info().Msg("published product quantity updated")
+	}
+
+	for _, row := range imported {
+		if !row.restocked {
+			continue
+		}
+		err = svc.cache.Publish(c, constants.PRODUCT_RESTOCKED, row.productId.String()).Err()
+		if err != nil {
+			err = fmt.Errorf("failed publishing product restocked with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// importRow upserts the product of a row and, when the row has one, its
+// variant. The product is found by the variant when matching by sku and the
+// sku is known, otherwise by its name.
+func importRow(
+	c context.Context,
+	queries *repository.Queries,
+	row catalog.Row,
+	match string,
+) (catalog.Change, importedRow, error) {
+	change := catalog.Change{
+		Line:   row.Line,
+		Name:   row.Name,
+		Action: catalog.ActionUnchanged,
+		Fields: map[string]catalog.FieldChange{},
+	}
+
+	variant, variantFound := repository.ProductVariant{}, false
+	if row.Variant != nil {
+		change.Sku = row.Variant.Sku
+		found, err := queries.FindProductVariantBySkuForUpdate(c, row.Variant.Sku)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return catalog.Change{}, importedRow{}, fmt.Errorf("failed locking variant with error=%w", err)
+		}
+		variant, variantFound = found, err == nil
+	}
+
+	var product repository.Product
+	var err error
+	if match == MatchSku && variantFound {
+		product, err = queries.FindProductByIdForUpdate(c, variant.ProductID)
+	} else {
+		product, err = queries.FindProductByNameForUpdate(c, row.Name)
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return catalog.Change{}, importedRow{}, fmt.Errorf("failed locking product with error=%w", err)
+	}
+	productFound := err == nil
+
+	result := importedRow{}
+	if !productFound {
+		product, err = queries.InsertProduct(c, repository.InsertProductParams{
+			Name:           row.Name,
+			Price:          toNumeric(&row.Price),
+			Quantity:       int32(row.Quantity),
+			Backorderable:  row.Backorderable,
+			Preorderable:   row.Preorderable,
+			ReleaseDate:    toTimestamptz(row.ReleaseDate),
+			BackorderLimit: int32(row.BackorderLimit),
+			Description:    row.Description,
+		})
+		if err != nil {
+			return catalog.Change{}, importedRow{}, fmt.Errorf("failed inserting product with error=%w", err)
+		}
+		change.Action = catalog.ActionCreate
+		_, err = schedulePrice(c, queries, product, product.Price, product.CreatedAt.Time, nil)
+		if err != nil {
+			return catalog.Change{}, importedRow{}, err
+		}
+		err = syncQuantity(c, queries, product.ID, product.Quantity)
+		if err != nil {
+			return catalog.Change{}, importedRow{}, err
+		}
+		result.restocked = product.Quantity > 0
+	} else if fields := diffProduct(product.Response(), row.Product); len(fields) > 0 {
+		if int32(row.Quantity) != product.Quantity {
+			err = checkWarehousedQuantity(c, queries, product.ID, int32(row.Quantity))
+			if err != nil {
+				return catalog.Change{}, importedRow{}, err
+			}
+		}
+		updated, err := queries.UpdateProduct(c, repository.UpdateProductParams{
+			Name:           row.Name,
+			Price:          toNumeric(&row.Price),
+			Quantity:       int32(row.Quantity),
+			Backorderable:  row.Backorderable,
+			Preorderable:   row.Preorderable,
+			ReleaseDate:    toTimestamptz(row.ReleaseDate),
+			BackorderLimit: int32(row.BackorderLimit),
+			Description:    row.Description,
+			ID:             product.ID,
+		})
+		if err != nil {
+			return catalog.Change{}, importedRow{}, fmt.Errorf("failed updating product with error=%w", err)
+		}
+		if _, ok := fields["price"]; ok {
+			_, err = schedulePrice(c, queries, product, updated.Price, time.Now(), nil)
+			if err != nil {
+				return catalog.Change{}, importedRow{}, err
+			}
+		}
+		delta := updated.Quantity - product.Quantity
+		err = syncQuantity(c, queries, updated.ID, delta)
+		if err != nil {
+			return catalog.Change{}, importedRow{}, err
+		}
+		change.Action = catalog.ActionUpdate
+		maps.Copy(change.Fields, fields)
+		product = updated
+		result.restocked = delta > 0
+	}
+	result.productId = product.ID
+
+	if row.Variant == nil {
+		return change, result, nil
+	}
+
+	options, err := variantOptions(*row.Variant)
+	if err != nil {
+		return catalog.Change{}, importedRow{}, err
+	}
+	if !variantFound {
+		inserted, err := queries.InsertProductVariant(c, repository.InsertProductVariantParams{
+			ProductID: product.ID,
+			Sku:       row.Variant.Sku,
+			Options:   options,
+			Price:     toNumeric(row.Variant.Price),
+			Quantity:  int32(row.Variant.Quantity),
+		})
+		if err != nil {
+			return catalog.Change{}, importedRow{}, fmt.Errorf("failed inserting variant with error=%w", err)
+		}
+		err = recordVariantMovement(c, queries, inserted, inserted.Quantity)
+		if err != nil {
+			return catalog.Change{}, importedRow{}, err
+		}
+		change.Action = catalog.ActionCreate
+		return change, result, nil
+	}
+	if variant.ProductID != product.ID {
+		return catalog.Change{}, importedRow{}, fmt.Errorf(
+			"failed upserting sku=%s of productId=%s with error=%w",
+			row.Variant.Sku,
+			variant.ProductID,
+			productErrors.ErrSkuBelongsToOther,
+		)
+	}
+	current, err := variant.Response()
+	if err != nil {
+		return catalog.Change{}, importedRow{}, fmt.Errorf("failed mapping variant with error=%w", err)
+	}
+	fields := diffVariant(current, *row.Variant)
+	if len(fields) == 0 {
+		return change, result, nil
+	}
+	updated, err := queries.UpdateProductVariant(c, repository.UpdateProductVariantParams{
+		Sku:       row.Variant.Sku,
+		Options:   options,
+		Price:     toNumeric(row.Variant.Price),
+		Quantity:  int32(row.Variant.Quantity),
+		ID:        variant.ID,
+		ProductID: product.ID,
+	})
+	if err != nil {
+		return catalog.Change{}, importedRow{}, fmt.Errorf("failed updating variant with error=%w", err)
+	}
+	err = recordVariantMovement(c, queries, updated, updated.Quantity-variant.Quantity)
+	if err != nil {
+		return catalog.Change{}, importedRow{}, err
+	}
+	if change.Action == catalog.ActionUnchanged {
+		change.Action = catalog.ActionUpdate
+	}
+	maps.Copy(change.Fields, fields)
+	return change, result, nil
+}
+
+// syncQuantity keeps the default inventory level and the movement ledger in
+// line with a product whose quantity changed by delta.
+func syncQuantity(c context.Context, queries *repository.Queries, productId uuid.UUID, delta int32) error {
+	err := queries.SyncDefaultInventoryLevel(c, productId)
+	if err != nil {
+		return fmt.Errorf("failed syncing default inventory level with error=%w", err)
+	}
+	if delta == 0 {
+		return nil
+	}
+	_, err = queries.InsertInventoryMovements(
+		c,
+		[]repository.InsertInventoryMovementsParams{quantityMovement(c, productId, uuid.Nil, delta)},
+	)
+	if err != nil {
+		return fmt.Errorf("failed inserting inventory movement with error=%w", err)
+	}
+	return nil
+}
+
+// Export writes every product ordered by name, a product with variants is
+// written once per variant. The products are read from a single snapshot so
+// the export is consistent while the catalog keeps changing.
+func (svc CatalogService) Export(c context.Context, writer catalog.Writer) (int, error) {
+	c, span := otel.Tracer.Start(c, "CatalogService Export")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CatalogService Export").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(
+		c,
+		pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return 0, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "exporting products").Logger()
+	logger.Trace().Msg("exporting products")
+	span.AddEvent("exporting products")
+	queries := svc.queries.WithTx(tx)
+	rows, cursor := 0, ""
+	for {
+		products, err := queries.FindProductsAfterName(
+			c,
+			repository.FindProductsAfterNameParams{Name: cursor, Limit: exportPageSize},
+		)
+		if err != nil {
+			err = fmt.Errorf("failed finding products with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return rows, err
+		}
+		if len(products) == 0 {
+			break
+		}
+		cursor = products[len(products)-1].Name
+
+		ids := make([]uuid.UUID, 0, len(products))
+		for _, product := range products {
+			ids = append(ids, product.ID)
+		}
+		variants, err := queries.FindProductVariantsByProductIds(c, ids)
+		if err != nil {
+			err = fmt.Errorf("failed finding product variants with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return rows, err
+		}
+		variantsByProduct := map[uuid.UUID][]repository.ProductVariant{}
+		for _, variant := range variants {
+			variantsByProduct[variant.ProductID] = append(variantsByProduct[variant.ProductID], variant)
+		}
+
+		for _, product := range products {
+			exported, err := exportRows(product.Response(), variantsByProduct[product.ID])
+			if err != nil {
+				inOtel.RecordError(err, span)
+				logger.Error().Err(err).Msg(err.Error())
+				return rows, err
+			}
+			for _, row := range exported {
+				if err := writer.Write(row); err != nil {
+					err = fmt.Errorf("failed writing row with error=%w", err)
+					inOtel.RecordError(err, span)
+					logger.Error().Err(err).Msg(err.Error())
+					return rows, err
+				}
+				rows++
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			err = fmt.Errorf("failed flushing rows with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return rows, err
+		}
+		if len(products) < exportPageSize {
+			break
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		err = fmt.Errorf("failed flushing rows with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return rows, err
+	}
+	span.AddEvent("exported products")
+	logger.Info().Int(constants.KEY_CATALOG_ROWS, rows).Msg("exported products")
+
+	return rows, nil
+}
+
+func exportRows(product response.Product, variants []repository.ProductVariant) ([]catalog.Row, error) {
+	row := catalog.Row{
+		Product: request.Product{
+			Name:           product.Name,
+			Description:    product.Description,
+			Price:          product.Price,
+			Quantity:       int(product.Quantity),
+			Backorderable:  product.Backorderable,
+			Preorderable:   product.Preorderable,
+			ReleaseDate:    product.ReleaseDate,
+			BackorderLimit: int(product.BackorderLimit),
+		},
+	}
+	if len(variants) == 0 {
+		return []catalog.Row{row}, nil
+	}
+	rows := make([]catalog.Row, 0, len(variants))
+	for _, variant := range variants {
+		v, err := variant.Response()
+		if err != nil {
+			return nil, fmt.Errorf("failed mapping variantId=%s with error=%w", variant.ID, err)
+		}
+		row.Variant = &request.Variant{
+			Sku:      v.Sku,
+			Options:  v.Options,
+			Price:    v.Price,
+			Quantity: int(v.Quantity),
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func validateRow(c context.Context, validate *validator.Validate, row catalog.Row) error {
+	if err := validate.StructCtx(c, row.Product); err != nil {
+		return err
+	}
+	if len(row.Name) > 128 {
+		return errors.New("name must be at most 128 characters")
+	}
+	if row.Price.IsNegative() {
+		return errors.New("price must not be negative")
+	}
+	if row.Variant == nil {
+		return nil
+	}
+	if err := validate.StructCtx(c, row.Variant); err != nil {
+		return err
+	}
+	if row.Variant.Price != nil && row.Variant.Price.IsNegative() {
+		return errors.New("variant price must not be negative")
+	}
+	return nil
+}
+
+func failedChange(row catalog.Row, err error) catalog.Change {
+	change := catalog.Change{
+		Line:   row.Line,
+		Name:   row.Name,
+		Action: catalog.ActionFailed,
+		Errors: []string{},
+	}
+	if row.Variant != nil {
+		change.Sku = row.Variant.Sku
+	}
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fieldError := range validationErrors {
+			change.Errors = append(change.Errors, fieldError.Error())
+		}
+		return change
+	}
+	change.Errors = append(change.Errors, err.Error())
+	return change
+}
+
+// diffProduct returns the fields of current that importing row changes.
+func diffProduct(current response.Product, row request.Product) map[string]catalog.FieldChange {
+	fields := map[string]catalog.FieldChange{}
+	if current.Name != row.Name {
+		fields["name"] = catalog.FieldChange{From: current.Name, To: row.Name}
+	}
+	if current.Description != row.Description {
+		fields["description"] = catalog.FieldChange{From: current.Description, To: row.Description}
+	}
+	if !current.Price.Equal(row.Price) {
+		fields["price"] = catalog.FieldChange{From: current.Price, To: row.Price}
+	}
+	if int(current.Quantity) != row.Quantity {
+		fields["quantity"] = catalog.FieldChange{From: current.Quantity, To: row.Quantity}
+	}
+	if current.Backorderable != row.Backorderable {
+		fields["backorderable"] = catalog.FieldChange{From: current.Backorderable, To: row.Backorderable}
+	}
+	if current.Preorderable != row.Preorderable {
+		fields["preorderable"] = catalog.FieldChange{From: current.Preorderable, To: row.Preorderable}
+	}
+	if !sameTime(current.ReleaseDate, row.ReleaseDate) {
+		fields["release_date"] = catalog.FieldChange{From: current.ReleaseDate, To: row.ReleaseDate}
+	}
+	if int(current.BackorderLimit) != row.BackorderLimit {
+		fields["backorder_limit"] = catalog.FieldChange{From: current.BackorderLimit, To: row.BackorderLimit}
+	}
+	return fields
+}
+
+// diffVariant returns the fields of current that importing row changes, they
+// are prefixed with variant to tell them apart from the product fields.
+func diffVariant(current response.Variant, row request.Variant) map[string]catalog.FieldChange {
+	fields := map[string]catalog.FieldChange{}
+	options := row.Options
+	if options == nil {
+		options = map[string]string{}
+	}
+	if !maps.Equal(current.Options, options) {
+		fields["variant.options"] = catalog.FieldChange{From: current.Options, To: options}
+	}
+	if !samePrice(current.Price, row.Price) {
+		fields["variant.price"] = catalog.FieldChange{From: current.Price, To: row.Price}
+	}
+	if int(current.Quantity) != row.Quantity {
+		fields["variant.quantity"] = catalog.FieldChange{From: current.Quantity, To: row.Quantity}
+	}
+	return fields
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func samePrice(a *decimal.Decimal, b *decimal.Decimal) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
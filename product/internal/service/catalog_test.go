@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+func TestDiffProduct(t *testing.T) {
+	releaseDate := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	current := response.Product{
+		Name:        "Mug",
+		Price:       decimal.RequireFromString("12.50"),
+		Quantity:    3,
+		ReleaseDate: &releaseDate,
+	}
+	sameDate := releaseDate.In(time.FixedZone("WIB", 7*60*60))
+
+	fields := diffProduct(current, request.Product{
+		Name:        "Mug",
+		Price:       decimal.RequireFromString("12.5"),
+		Quantity:    3,
+		ReleaseDate: &sameDate,
+	})
+	assert.Empty(t, fields)
+
+	fields = diffProduct(current, request.Product{
+		Name:        "Mug",
+		Description: "Stoneware",
+		Price:       decimal.RequireFromString("14"),
+		Quantity:    3,
+	})
+	assert.Len(t, fields, 3)
+	assert.Equal(t, "Stoneware", fields["description"].To)
+	assert.Equal(t, &releaseDate, fields["release_date"].From)
+	assert.Contains(t, fields, "price")
+}
+
+func TestDiffVariant(t *testing.T) {
+	price := decimal.RequireFromString("20")
+	current := response.Variant{Sku: "SHIRT-M", Options: map[string]string{}, Quantity: 4}
+
+	assert.Empty(t, diffVariant(current, request.Variant{Sku: "SHIRT-M", Quantity: 4}))
+
+	fields := diffVariant(current, request.Variant{
+		Sku:      "SHIRT-M",
+		Options:  map[string]string{"size": "M"},
+		Price:    &price,
+		Quantity: 4,
+	})
+	assert.Len(t, fields, 2)
+	assert.Contains(t, fields, "variant.options")
+	assert.Contains(t, fields, "variant.price")
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+type CategoryService struct {
+	pool    *pgxpool.Pool
+	queries *repository.Queries
+}
+
+func NewCategoryService(pool *pgxpool.Pool, queries *repository.Queries) CategoryService {
+	return CategoryService{pool: pool, queries: queries}
+}
+
+func (svc CategoryService) InsertCategory(
+	c context.Context,
+	param request.Category,
+) (response.Category, error) {
+	c, span := otel.Tracer.Start(c, "CategoryService InsertCategory")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryService InsertCategory").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting category to database").Logger()
+	logger.Trace().Msg("inserting category to database")
+	span.AddEvent("inserting category to database")
+	category, err := svc.queries.InsertCategory(c, repository.InsertCategoryParams{
+		ParentID: toUUID(param.ParentId),
+		Name:     param.Name,
+		Slug:     param.SlugOrDefault(),
+		Position: int32(param.Position),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed inserting category with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Category{}, err
+	}
+	span.AddEvent("inserted category to database")
+	logger.Info().Any(constants.KEY_CATEGORY, category).Msg("inserted category to database")
+
+	return category.Response(), nil
+}
+
+// FindCategories returns every category as a tree, root categories and the
+// children of every category are ordered by their position.
+func (svc CategoryService) FindCategories(c context.Context) ([]response.Category, error) {
+	c, span := otel.Tracer.Start(c, "CategoryService FindCategories")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryService FindCategories").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding categories in database").Logger()
+	logger.Trace().Msg("finding categories in database")
+	span.AddEvent("finding categories in database")
+	categories, err := svc.queries.FindCategories(c)
+	if err != nil {
+		err = fmt.Errorf("failed finding categories with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found categories in database")
+	logger.Info().Int(constants.KEY_CATEGORIES, len(categories)).Msg("found categories in database")
+
+	return categoryTree(categories), nil
+}
+
+func (svc CategoryService) FindCategoryById(
+	c context.Context,
+	id uuid.UUID,
+) (response.Category, error) {
+	c, span := otel.Tracer.Start(c, "CategoryService FindCategoryById")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryService FindCategoryById").
+		Str(constants.KEY_CATEGORY_ID, id.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding category in database").Logger()
+	logger.Trace().Msg("finding category in database")
+	span.AddEvent("finding category in database")
+	category, err := svc.queries.FindCategoryById(c, id)
+	if err != nil {
+		err = fmt.Errorf("failed finding category with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Category{}, err
+	}
+	span.AddEvent("found category in database")
+	logger.Info().Any(constants.KEY_CATEGORY, category).Msg("found category in database")
+
+	return category.Response(), nil
+}
+
+// UpdateCategory renames, reorders or moves a category. The transaction is
+// serializable so two concurrent moves can not create a cycle that neither of
+// them sees.
+func (svc CategoryService) UpdateCategory(
+	c context.Context,
+	id uuid.UUID,
+	param request.Category,
+) (response.Category, error) {
+	c, span := otel.Tracer.Start(c, "CategoryService UpdateCategory")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryService UpdateCategory").
+		Str(constants.KEY_CATEGORY_ID, id.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Category{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	if param.ParentId != nil {
+		logger = logger.With().Str(constants.KEY_PROCESS, "finding category descendants").Logger()
+		logger.Trace().Msg("finding category descendants")
+		span.AddEvent("finding category descendants")
+		descendants, err := svc.queries.WithTx(tx).FindCategoryDescendantIds(c, id)
+		if err != nil {
+			err = fmt.Errorf("failed finding category descendants with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Category{}, err
+		}
+		if slices.Contains(descendants, *param.ParentId) {
+			err = fmt.Errorf(
+				"failed moving category under parent_id=%s with error=%w",
+				param.ParentId.String(),
+				inErrors.ErrCategoryCycle,
+			)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Category{}, err
+		}
+		span.AddEvent("found category descendants")
+		logger.Info().Msg("found category descendants")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating category in database").Logger()
+	logger.Trace().Msg("updating category in database")
+	span.AddEvent("updating category in database")
+	category, err := svc.queries.WithTx(tx).UpdateCategory(c, repository.UpdateCategoryParams{
+		ParentID: toUUID(param.ParentId),
+		Name:     param.Name,
+		Slug:     param.SlugOrDefault(),
+		Position: int32(param.Position),
+		ID:       id,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed updating category with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Category{}, err
+	}
+	span.AddEvent("updated category in database")
+	logger.Info().Any(constants.KEY_CATEGORY, category).Msg("updated category in database")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Category{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	return category.Response(), nil
+}
+
+// RemoveCategory deletes a category and its product memberships, a category
+// that still has children can not be removed.
+func (svc CategoryService) RemoveCategory(
+	c context.Context,
+	id uuid.UUID,
+) (response.Category, error) {
+	c, span := otel.Tracer.Start(c, "CategoryService RemoveCategory")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "CategoryService RemoveCategory").
+		Str(constants.KEY_CATEGORY_ID, id.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing category in database").Logger()
+	logger.Trace().Msg("removing category in database")
+	span.AddEvent("removing category in database")
+	category, err := svc.queries.DeleteCategory(c, id)
+	if err != nil {
+		err = fmt.Errorf("failed removing category with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Category{}, err
+	}
+	span.AddEvent("removed category in database")
+	logger.Info().Any(constants.KEY_CATEGORY, category).Msg("removed category in database")
+
+	return category.Response(), nil
+}
+
+// SetProductCategories replaces the categories a product belongs to and returns
+// the breadcrumbs of its new categories.
+func (svc ProductService) SetProductCategories(
+	c context.Context,
+	productId uuid.UUID,
+	param request.ProductCategories,
+) ([][]response.Breadcrumb, error) {
+	c, span := otel.Tracer.Start(c, "ProductService SetProductCategories")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService SetProductCategories").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Any(constants.KEY_CATEGORY_IDS, param.CategoryIds).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking product").Logger()
+	logger.Trace().Msg("locking product")
+	span.AddEvent("locking product")
+	_, err = svc.queries.WithTx(tx).FindProductByIdForUpdate(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed locking product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("locked product")
+	logger.Info().Msg("locked product")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing product categories").Logger()
+	logger.Trace().Msg("removing product categories")
+	span.AddEvent("removing product categories")
+	err = svc.queries.WithTx(tx).DeleteProductCategories(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed removing product categories with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("removed product categories")
+	logger.Info().Msg("removed product categories")
+
+	if len(param.CategoryIds) > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "inserting product categories").Logger()
+		logger.Trace().Msg("inserting product categories")
+		span.AddEvent("inserting product categories")
+		err = svc.queries.WithTx(tx).InsertProductCategories(c, repository.InsertProductCategoriesParams{
+			ProductID:   productId,
+			CategoryIds: param.CategoryIds,
+		})
+		if err != nil {
+			err = fmt.Errorf("failed inserting product categories with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return nil, err
+		}
+		span.AddEvent("inserted product categories")
+		logger.Info().Msg("inserted product categories")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	products := []response.Product{{ID: productId}}
+	err = svc.attachBreadcrumbs(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	return products[0].Breadcrumbs, nil
+}
+
+// attachBreadcrumbs sets the breadcrumbs of every product in products.
+func (svc ProductService) attachBreadcrumbs(c context.Context, products []response.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	rows, err := svc.queries.FindProductBreadcrumbs(c, ids)
+	if err != nil {
+		return fmt.Errorf("failed finding product breadcrumbs with error=%w", err)
+	}
+	trails := breadcrumbs(rows)
+	for i := range products {
+		products[i].Breadcrumbs = trails[products[i].ID]
+		if products[i].Breadcrumbs == nil {
+			products[i].Breadcrumbs = [][]response.Breadcrumb{}
+		}
+	}
+	return nil
+}
+
+// breadcrumbs groups the rows of FindProductBreadcrumbs by product, a product
+// has one trail per category it belongs to and every trail starts at a root
+// category.
+func breadcrumbs(rows []repository.FindProductBreadcrumbsRow) map[uuid.UUID][][]response.Breadcrumb {
+	trails := map[uuid.UUID][][]response.Breadcrumb{}
+	for i, row := range rows {
+		crumb := response.Breadcrumb{ID: row.ID, Name: row.Name, Slug: row.Slug}
+		if i == 0 || rows[i-1].ProductID != row.ProductID || rows[i-1].LeafID != row.LeafID {
+			trails[row.ProductID] = append(trails[row.ProductID], []response.Breadcrumb{crumb})
+			continue
+		}
+		last := len(trails[row.ProductID]) - 1
+		trails[row.ProductID][last] = append(trails[row.ProductID][last], crumb)
+	}
+	return trails
+}
+
+// categoryTree nests categories, which must be ordered by their position, under
+// their parents. Categories whose parent is missing are returned as roots.
+func categoryTree(categories []repository.Category) []response.Category {
+	children := map[uuid.UUID][]repository.Category{}
+	ids := map[uuid.UUID]bool{}
+	for _, category := range categories {
+		ids[category.ID] = true
+	}
+	roots := []repository.Category{}
+	for _, category := range categories {
+		if category.ParentID.Valid && ids[category.ParentID.Bytes] {
+			parentId := uuid.UUID(category.ParentID.Bytes)
+			children[parentId] = append(children[parentId], category)
+			continue
+		}
+		roots = append(roots, category)
+	}
+
+	var build func([]repository.Category) []response.Category
+	build = func(nodes []repository.Category) []response.Category {
+		tree := make([]response.Category, 0, len(nodes))
+		for _, node := range nodes {
+			category := node.Response()
+			category.Children = build(children[node.ID])
+			tree = append(tree, category)
+		}
+		return tree
+	}
+	return build(roots)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+func TestCategoryTree(t *testing.T) {
+	clothing, shoes, boots, toys := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	tree := categoryTree([]repository.Category{
+		{ID: clothing, Name: "Clothing"},
+		{ID: shoes, Name: "Shoes", ParentID: pgtype.UUID{Bytes: clothing, Valid: true}},
+		{ID: toys, Name: "Toys", Position: 1},
+		{ID: boots, Name: "Boots", ParentID: pgtype.UUID{Bytes: shoes, Valid: true}},
+	})
+
+	assert.Len(t, tree, 2)
+	assert.Equal(t, clothing, tree[0].ID)
+	assert.Equal(t, toys, tree[1].ID)
+	assert.Empty(t, tree[1].Children)
+	assert.Equal(t, shoes, tree[0].Children[0].ID)
+	assert.Equal(t, &clothing, tree[0].Children[0].ParentID)
+	assert.Equal(t, boots, tree[0].Children[0].Children[0].ID)
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	shirt, mug := uuid.New(), uuid.New()
+	clothing, tops, kitchen := uuid.New(), uuid.New(), uuid.New()
+	trails := breadcrumbs([]repository.FindProductBreadcrumbsRow{
+		{ProductID: shirt, LeafID: tops, ID: clothing, Name: "Clothing", Slug: "clothing"},
+		{ProductID: shirt, LeafID: tops, ID: tops, Name: "Tops", Slug: "tops"},
+		{ProductID: shirt, LeafID: kitchen, ID: kitchen, Name: "Kitchen", Slug: "kitchen"},
+		{ProductID: mug, LeafID: kitchen, ID: kitchen, Name: "Kitchen", Slug: "kitchen"},
+	})
+
+	assert.Equal(t, [][]response.Breadcrumb{
+		{{ID: clothing, Name: "Clothing", Slug: "clothing"}, {ID: tops, Name: "Tops", Slug: "tops"}},
+		{{ID: kitchen, Name: "Kitchen", Slug: "kitchen"}},
+	}, trails[shirt])
+	assert.Equal(t, [][]response.Breadcrumb{{{ID: kitchen, Name: "Kitchen", Slug: "kitchen"}}}, trails[mug])
+}
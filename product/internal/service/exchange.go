@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	"github.com/Alturino/ecommerce/internal/money"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+// FindExchangeRates returns the rates from the base currency of the catalog to
+// every other currency.
+func (svc ProductService) FindExchangeRates(c context.Context) ([]response.ExchangeRate, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindExchangeRates")
+	defer span.End()
+
+	base := svc.currency.BaseCurrency()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindExchangeRates").
+		Str(constants.KEY_CURRENCY, base).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding exchange rates").Logger()
+	logger.Trace().Msg("finding exchange rates")
+	span.AddEvent("finding exchange rates")
+	rows, err := svc.queries.FindExchangeRates(c, base)
+	if err != nil {
+		err = fmt.Errorf("failed finding exchange rates with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	rates := make([]response.ExchangeRate, 0, len(rows))
+	for _, row := range rows {
+		rates = append(rates, row.Response())
+	}
+	span.AddEvent("found exchange rates")
+	logger.Info().Int(constants.KEY_EXCHANGE_RATES, len(rates)).Msg("found exchange rates")
+
+	return rates, nil
+}
+
+// SetExchangeRate sets the rate from the base currency of the catalog to
+// currency. Orders lock the rate at checkout, so a new rate only applies to
+// orders placed after it is set.
+func (svc ProductService) SetExchangeRate(
+	c context.Context,
+	currency string,
+	param request.ExchangeRate,
+) (response.ExchangeRate, error) {
+	c, span := otel.Tracer.Start(c, "ProductService SetExchangeRate")
+	defer span.End()
+
+	base := svc.currency.BaseCurrency()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService SetExchangeRate").
+		Str(constants.KEY_CURRENCY, currency).
+		Str(constants.KEY_EXCHANGE_RATE, param.Rate.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating exchange rate").Logger()
+	logger.Trace().Msg("validating exchange rate")
+	span.AddEvent("validating exchange rate")
+	quote, err := money.Normalize(currency)
+	if err == nil && quote == base {
+		err = productErrors.ErrBaseCurrencyRate
+	}
+	if err == nil && !param.Rate.IsPositive() {
+		err = productErrors.ErrInvalidExchangeRate
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating exchange rate with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.ExchangeRate{}, err
+	}
+	span.AddEvent("validated exchange rate")
+	logger.Debug().Msg("validated exchange rate")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "setting exchange rate").Logger()
+	logger.Trace().Msg("setting exchange rate")
+	span.AddEvent("setting exchange rate")
+	rate, err := svc.queries.UpsertExchangeRate(c, repository.UpsertExchangeRateParams{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          toNumeric(&param.Rate),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed setting exchange rate with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.ExchangeRate{}, err
+	}
+	span.AddEvent("set exchange rate")
+	logger.Info().Any(constants.KEY_EXCHANGE_RATE, rate).Msg("set exchange rate")
+
+	return rate.Response(), nil
+}
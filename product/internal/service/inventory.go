@@ -0,0 +1,548 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/cache"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+func (svc ProductService) FindInventoryLevels(
+	c context.Context,
+	productId uuid.UUID,
+) ([]repository.InventoryLevel, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindInventoryLevels")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindInventoryLevels").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding inventory levels").Logger()
+	logger.Trace().Msg("finding inventory levels")
+	span.AddEvent("finding inventory levels")
+	levels, err := svc.queries.FindInventoryLevelsByProductId(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding inventory levels with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found inventory levels")
+	logger.Info().Any(constants.KEY_INVENTORY_LEVELS, levels).Msg("found inventory levels")
+
+	return levels, nil
+}
+
+// pgCodeCheckViolation is the SQLSTATE of a violated check constraint.
+const pgCodeCheckViolation = "23514"
+
+// SetInventoryLevel sets the stock of a product in a warehouse, updates the
+// product quantity to the sum of its stock across every warehouse and records
+// the difference in the inventory movements ledger.
+func (svc ProductService) SetInventoryLevel(
+	c context.Context,
+	productId uuid.UUID,
+	warehouseId uuid.UUID,
+	param request.InventoryLevel,
+) (repository.Product, error) {
+	c, span := otel.Tracer.Start(c, "ProductService SetInventoryLevel")
+	defer span.End()
+
+	cacheKey := productId.String()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService SetInventoryLevel").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_WAREHOUSE_ID, warehouseId.String()).
+		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking product").Logger()
+	logger.Trace().Msg("locking product")
+	span.AddEvent("locking product")
+	previous, err := svc.queries.WithTx(tx).FindProductByIdForUpdate(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed locking product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("locked product")
+	logger.Info().Msg("locked product")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "setting inventory level").Logger()
+	logger.Trace().Msg("setting inventory level")
+	span.AddEvent("setting inventory level")
+	level, err := svc.queries.WithTx(tx).UpsertInventoryLevel(c, repository.UpsertInventoryLevelParams{
+		ProductID:   productId,
+		WarehouseID: warehouseId,
+		Quantity:    int32(param.Quantity),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed setting inventory level with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("set inventory level")
+	logger.Info().Any(constants.KEY_INVENTORY_LEVEL, level).Msg("set inventory level")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating product quantity").Logger()
+	logger.Trace().Msg("updating product quantity")
+	span.AddEvent("updating product quantity")
+	product, err := svc.queries.WithTx(tx).UpdateProductQuantityFromInventory(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed updating product quantity with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("updated product quantity")
+	logger = logger.With().Any(constants.KEY_PRODUCT, product).Logger()
+	logger.Info().Msg("updated product quantity")
+
+	if delta := product.Quantity - previous.Quantity; delta != 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "inserting inventory movement").Logger()
+		logger.Trace().Msg("inserting inventory movement")
+		span.AddEvent("inserting inventory movement")
+		_, err = svc.queries.WithTx(tx).InsertInventoryMovements(
+			c,
+			[]repository.InsertInventoryMovementsParams{quantityMovement(c, productId, warehouseId, delta)},
+		)
+		if err != nil {
+			err = fmt.Errorf("failed inserting inventory movement with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return repository.Product{}, err
+		}
+		span.AddEvent("inserted inventory movement")
+		logger.Info().Msg("inserted inventory movement")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "update product to cache").Logger()
+	logger.Trace().Msg("updating product to cache")
+	span.AddEvent("updating product to cache")
+	err = svc.cache.Set(c, cache.KEY_PRODUCTS, cacheKey, product.Response())
+	if err != nil {
+		err = fmt.Errorf("failed to update product to cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return product, nil
+	}
+	span.AddEvent("updated product to cache")
+	logger.Info().Msg("updated product to cache")
+
+	if product.Quantity != previous.Quantity {
+		svc.invalidateSearch(c)
+	}
+
+	if product.Quantity != previous.Quantity {
+		logger = logger.With().Str(constants.KEY_PROCESS, "publishing product quantity updated").Logger()
+		logger.Trace().Msg("publishing product quantity updated")
+		span.AddEvent("publishing product quantity updated")
+		err = publishQuantityUpdated(c, svc.cache.Client, []uuid.UUID{product.ID})
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		} else {
+			span.AddEvent("published product quantity updated")
+			logger.Info().Msg("published product quantity updated")
+		}
+	}
+
+	if product.Quantity > previous.Quantity {
+		logger = logger.With().Str(constants.KEY_PROCESS, "publishing product restocked").Logger()
+		logger.Trace().Msg("publishing product restocked")
+		span.AddEvent("publishing product restocked")
+		err = svc.cache.Publish(c, constants.PRODUCT_RESTOCKED, product.ID.String()).Err()
+		if err != nil {
+			err = fmt.Errorf("failed publishing product restocked with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return product, nil
+		}
+		span.AddEvent("published product restocked")
+		logger.Info().Msg("published product restocked")
+	}
+
+	return product, nil
+}
+
+// AdjustStock changes the stock of a product in a warehouse by a relative
+// delta in SQL, so concurrent checkouts are never overwritten, and records it
+// in the inventory movements ledger. The adjustment is stored under its
+// idempotency key, retrying a request with the same key returns the stored
+// adjustment with replayed set instead of applying it twice.
+func (svc ProductService) AdjustStock(
+	c context.Context,
+	productId uuid.UUID,
+	idempotencyKey string,
+	param request.StockAdjustment,
+) (adjustment repository.StockAdjustment, replayed bool, err error) {
+	c, span := otel.Tracer.Start(c, "ProductService AdjustStock")
+	defer span.End()
+
+	cacheKey := productId.String()
+	reason := repository.InventoryMovementReason(param.Reason)
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService AdjustStock").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_IDEMPOTENCY_KEY, idempotencyKey).
+		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating stock adjustment").Logger()
+	logger.Trace().Msg("validating stock adjustment")
+	span.AddEvent("validating stock adjustment")
+	err = checkAdjustment(reason, param.Delta)
+	if err != nil {
+		err = fmt.Errorf("failed validating stock adjustment with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	span.AddEvent("validated stock adjustment")
+	logger.Debug().Msg("validated stock adjustment")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	// Every adjustment of a product locks it first, so two requests with the
+	// same idempotency key are serialized and the second one sees the first.
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking product").Logger()
+	logger.Trace().Msg("locking product")
+	span.AddEvent("locking product")
+	_, err = svc.queries.WithTx(tx).FindProductByIdForUpdate(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed locking product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	span.AddEvent("locked product")
+	logger.Info().Msg("locked product")
+
+	var warehouseId uuid.UUID
+	if param.WarehouseId != nil {
+		warehouseId = *param.WarehouseId
+	} else {
+		logger = logger.With().Str(constants.KEY_PROCESS, "finding default warehouse").Logger()
+		logger.Trace().Msg("finding default warehouse")
+		span.AddEvent("finding default warehouse")
+		warehouse, err := svc.queries.WithTx(tx).FindDefaultWarehouse(c)
+		if err != nil {
+			err = fmt.Errorf("failed finding default warehouse with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return repository.StockAdjustment{}, false, err
+		}
+		warehouseId = warehouse.ID
+		span.AddEvent("found default warehouse")
+		logger.Info().Msg("found default warehouse")
+	}
+	logger = logger.With().Str(constants.KEY_WAREHOUSE_ID, warehouseId.String()).Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding stock adjustment").Logger()
+	logger.Trace().Msg("finding stock adjustment")
+	span.AddEvent("finding stock adjustment")
+	previous, err := svc.queries.WithTx(tx).FindStockAdjustmentByIdempotencyKey(
+		c,
+		repository.FindStockAdjustmentByIdempotencyKeyParams{ProductID: productId, IdempotencyKey: idempotencyKey},
+	)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		err = fmt.Errorf("failed finding stock adjustment with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	if err == nil {
+		if previous.WarehouseID != warehouseId || previous.Delta != param.Delta || previous.Reason != reason {
+			err = fmt.Errorf("failed replaying stock adjustment with error=%w", productErrors.ErrIdempotencyKeyReused)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Any(constants.KEY_STOCK_ADJUSTMENT, previous).Msg(err.Error())
+			return repository.StockAdjustment{}, false, err
+		}
+		span.AddEvent("replayed stock adjustment")
+		logger.Info().Any(constants.KEY_STOCK_ADJUSTMENT, previous).Msg("replayed stock adjustment")
+		return previous, true, nil
+	}
+	span.AddEvent("found no stock adjustment")
+	logger.Debug().Msg("found no stock adjustment")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "adjusting inventory level").Logger()
+	logger.Trace().Msg("adjusting inventory level")
+	span.AddEvent("adjusting inventory level")
+	level, err := svc.queries.WithTx(tx).AdjustInventoryLevel(c, repository.AdjustInventoryLevelParams{
+		ProductID:   productId,
+		WarehouseID: warehouseId,
+		Delta:       param.Delta,
+	})
+	if isCheckViolation(err) {
+		err = productErrors.ErrInsufficientStock
+	}
+	if err != nil {
+		err = fmt.Errorf("failed adjusting inventory level with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	span.AddEvent("adjusted inventory level")
+	logger.Info().Any(constants.KEY_INVENTORY_LEVEL, level).Msg("adjusted inventory level")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating product quantity").Logger()
+	logger.Trace().Msg("updating product quantity")
+	span.AddEvent("updating product quantity")
+	product, err := svc.queries.WithTx(tx).UpdateProductQuantityFromInventory(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed updating product quantity with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	span.AddEvent("updated product quantity")
+	logger = logger.With().Any(constants.KEY_PRODUCT, product).Logger()
+	logger.Info().Msg("updated product quantity")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting stock adjustment").Logger()
+	logger.Trace().Msg("inserting stock adjustment")
+	span.AddEvent("inserting stock adjustment")
+	adjustment, err = svc.queries.WithTx(tx).InsertStockAdjustment(c, repository.InsertStockAdjustmentParams{
+		ProductID:         productId,
+		WarehouseID:       warehouseId,
+		IdempotencyKey:    idempotencyKey,
+		Delta:             param.Delta,
+		Reason:            reason,
+		Actor:             movementActor(c),
+		WarehouseQuantity: level.Quantity,
+		ProductQuantity:   product.Quantity,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed inserting stock adjustment with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	span.AddEvent("inserted stock adjustment")
+	logger = logger.With().Any(constants.KEY_STOCK_ADJUSTMENT, adjustment).Logger()
+	logger.Info().Msg("inserted stock adjustment")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting inventory movement").Logger()
+	logger.Trace().Msg("inserting inventory movement")
+	span.AddEvent("inserting inventory movement")
+	_, err = svc.queries.WithTx(tx).InsertInventoryMovements(
+		c,
+		[]repository.InsertInventoryMovementsParams{
+			repository.NewInventoryMovement(productId, warehouseId, param.Delta, reason, adjustment.ID, adjustment.Actor),
+		},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed inserting inventory movement with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	span.AddEvent("inserted inventory movement")
+	logger.Info().Msg("inserted inventory movement")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.StockAdjustment{}, false, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "update product to cache").Logger()
+	logger.Trace().Msg("updating product to cache")
+	span.AddEvent("updating product to cache")
+	err = svc.cache.Set(c, cache.KEY_PRODUCTS, cacheKey, product.Response())
+	if err != nil {
+		err = fmt.Errorf("failed to update product to cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+	} else {
+		span.AddEvent("updated product to cache")
+		logger.Info().Msg("updated product to cache")
+	}
+
+	svc.invalidateSearch(c)
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "publishing product quantity updated").Logger()
+	logger.Trace().Msg("publishing product quantity updated")
+	span.AddEvent("publishing product quantity updated")
+	err = publishQuantityUpdated(c, svc.cache.Client, []uuid.UUID{product.ID})
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+	} else {
+		span.AddEvent("published product quantity updated")
+		logger.Info().Msg("published product quantity updated")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "publishing stock adjusted").Logger()
+	logger.Trace().Msg("publishing stock adjusted")
+	span.AddEvent("publishing stock adjusted")
+	err = publishStockAdjusted(c, svc.cache.Client, adjustment)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+	} else {
+		span.AddEvent("published stock adjusted")
+		logger.Info().Msg("published stock adjusted")
+	}
+
+	if param.Delta > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "publishing product restocked").Logger()
+		logger.Trace().Msg("publishing product restocked")
+		span.AddEvent("publishing product restocked")
+		err = svc.cache.Publish(c, constants.PRODUCT_RESTOCKED, product.ID.String()).Err()
+		if err != nil {
+			err = fmt.Errorf("failed publishing product restocked with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return adjustment, false, nil
+		}
+		span.AddEvent("published product restocked")
+		logger.Info().Msg("published product restocked")
+	}
+
+	return adjustment, false, nil
+}
+
+// checkAdjustment rejects restocks and returns that take stock away, only an
+// adjustment may correct the stock either way.
+func checkAdjustment(reason repository.InventoryMovementReason, delta int32) error {
+	switch reason {
+	case repository.InventoryMovementReasonRESTOCK, repository.InventoryMovementReasonRETURN:
+		if delta <= 0 {
+			return productErrors.ErrInvalidAdjustment
+		}
+		return nil
+	case repository.InventoryMovementReasonADJUSTMENT:
+		return nil
+	default:
+		return fmt.Errorf("reason=%s is not a stock adjustment reason", reason)
+	}
+}
+
+// checkWarehousedQuantity rejects an absolute quantity below the stock held in
+// the non-default warehouses, the default warehouse holds the rest and can not
+// go below zero.
+func checkWarehousedQuantity(
+	c context.Context,
+	queries *repository.Queries,
+	productId uuid.UUID,
+	quantity int32,
+) error {
+	warehoused, err := queries.FindWarehousedQuantity(c, productId)
+	if err != nil {
+		return fmt.Errorf("failed finding warehoused quantity with error=%w", err)
+	}
+	if quantity < warehoused {
+		return fmt.Errorf(
+			"quantity=%d of productId=%s is below warehoused quantity=%d with error=%w",
+			quantity,
+			productId,
+			warehoused,
+			productErrors.ErrBelowWarehousedStock,
+		)
+	}
+	return nil
+}
+
+// isCheckViolation reports whether err is a violated check constraint, e.g. an
+// inventory level that would go below zero.
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgCodeCheckViolation
+}
+
+func publishStockAdjusted(c context.Context, cache *redis.Client, adjustment repository.StockAdjustment) error {
+	event, err := json.Marshal(adjustment)
+	if err != nil {
+		return fmt.Errorf("failed marshaling stock adjusted with error=%w", err)
+	}
+	err = cache.Publish(c, constants.STOCK_ADJUSTED, event).Err()
+	if err != nil {
+		return fmt.Errorf("failed publishing stock adjusted with error=%w", err)
+	}
+	return nil
+}
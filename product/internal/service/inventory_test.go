@@ -0,0 +1,41 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+)
+
+func TestCheckAdjustment(t *testing.T) {
+	testCases := []struct {
+		name   string
+		reason repository.InventoryMovementReason
+		delta  int32
+		err    error
+	}{
+		{"restock adds stock", repository.InventoryMovementReasonRESTOCK, 10, nil},
+		{"return adds stock", repository.InventoryMovementReasonRETURN, 1, nil},
+		{"adjustment adds stock", repository.InventoryMovementReasonADJUSTMENT, 3, nil},
+		{"adjustment takes stock", repository.InventoryMovementReasonADJUSTMENT, -3, nil},
+		{"restock takes stock", repository.InventoryMovementReasonRESTOCK, -1, productErrors.ErrInvalidAdjustment},
+		{"return takes stock", repository.InventoryMovementReasonRETURN, -1, productErrors.ErrInvalidAdjustment},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, checkAdjustment(tc.reason, tc.delta), tc.err)
+		})
+	}
+	assert.Error(t, checkAdjustment(repository.InventoryMovementReasonSALE, -1), "sales are recorded by orders")
+}
+
+func TestIsCheckViolation(t *testing.T) {
+	assert.True(t, isCheckViolation(&pgconn.PgError{Code: "23514"}))
+	assert.True(t, isCheckViolation(fmt.Errorf("wrapped error=%w", &pgconn.PgError{Code: "23514"})))
+	assert.False(t, isCheckViolation(&pgconn.PgError{Code: "23503"}))
+	assert.False(t, isCheckViolation(nil))
+}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/blob"
+	"github.com/Alturino/ecommerce/internal/config"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+var (
+	defaultContentTypes   = []string{"image/jpeg", "image/png", "image/gif"}
+	defaultThumbnailSizes = []int{128, 256, 512}
+)
+
+const (
+	defaultMaxMediaSize = 10 << 20
+	// maxMediaPixels stops a small file that decodes to a huge image from
+	// exhausting the memory of the service.
+	maxMediaPixels = 40_000_000
+)
+
+type MediaService struct {
+	queries *repository.Queries
+	store   blob.BlobStore
+	config  config.Media
+}
+
+func NewMediaService(
+	queries *repository.Queries,
+	store blob.BlobStore,
+	config config.Media,
+) MediaService {
+	return MediaService{queries: queries, store: store, config: config}
+}
+
+// MaxSize returns the largest upload accepted, in bytes.
+func (svc MediaService) MaxSize() int64 {
+	if svc.config.MaxSize <= 0 {
+		return defaultMaxMediaSize
+	}
+	return svc.config.MaxSize
+}
+
+// UploadMedia stores an image of a product together with a thumbnail for every
+// configured size. The content type is sniffed from data, the one sent by the
+// client is not trusted.
+func (svc MediaService) UploadMedia(
+	c context.Context,
+	productId uuid.UUID,
+	data []byte,
+) (response.Media, error) {
+	c, span := otel.Tracer.Start(c, "MediaService UploadMedia")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "MediaService UploadMedia").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating media").Logger()
+	logger.Trace().Msg("validating media")
+	span.AddEvent("validating media")
+	contentType, img, err := svc.validateMedia(data)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Media{}, err
+	}
+	span.AddEvent("validated media")
+	logger.Info().Str(constants.KEY_CONTENT_TYPE, contentType).Msg("validated media")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product in database").Logger()
+	logger.Trace().Msg("finding product in database")
+	span.AddEvent("finding product in database")
+	_, err = svc.queries.FindProductById(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Media{}, err
+	}
+	span.AddEvent("found product in database")
+	logger.Info().Msg("found product in database")
+
+	name := fmt.Sprintf("products/%s/%s", productId, uuid.New())
+	key := name + mediaExtension(contentType)
+	stored := []string{}
+	defer func() {
+		if err == nil {
+			return
+		}
+		svc.removeBlobs(c, stored)
+	}()
+
+	logger = logger.With().
+		Str(constants.KEY_PROCESS, "storing media").
+		Str(constants.KEY_BLOB_KEY, key).
+		Logger()
+	logger.Trace().Msg("storing media")
+	span.AddEvent("storing media")
+	err = svc.store.Put(c, key, bytes.NewReader(data), int64(len(data)), contentType)
+	if err != nil {
+		err = fmt.Errorf("failed storing media with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Media{}, err
+	}
+	stored = append(stored, key)
+	span.AddEvent("stored media")
+	logger.Info().Msg("stored media")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "storing thumbnails").Logger()
+	logger.Trace().Msg("storing thumbnails")
+	span.AddEvent("storing thumbnails")
+	thumbnails := map[string]string{}
+	for _, size := range svc.thumbnailSizes() {
+		var buf bytes.Buffer
+		err = encodeImage(&buf, thumbnail(img, size), contentType)
+		if err != nil {
+			err = fmt.Errorf("failed encoding thumbnail of size=%d with error=%w", size, err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Media{}, err
+		}
+		thumbnailKey := fmt.Sprintf("%s_%d%s", name, size, mediaExtension(contentType))
+		err = svc.store.Put(c, thumbnailKey, &buf, int64(buf.Len()), contentType)
+		if err != nil {
+			err = fmt.Errorf("failed storing thumbnail of size=%d with error=%w", size, err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Media{}, err
+		}
+		stored = append(stored, thumbnailKey)
+		thumbnails[strconv.Itoa(size)] = thumbnailKey
+	}
+	span.AddEvent("stored thumbnails")
+	logger.Info().Int(constants.KEY_THUMBNAILS, len(thumbnails)).Msg("stored thumbnails")
+
+	thumbnailKeys, err := json.Marshal(thumbnails)
+	if err != nil {
+		err = fmt.Errorf("failed marshalling thumbnails with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Media{}, err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting media to database").Logger()
+	logger.Trace().Msg("inserting media to database")
+	span.AddEvent("inserting media to database")
+	bounds := img.Bounds()
+	media, err := svc.queries.InsertProductMedia(c, repository.InsertProductMediaParams{
+		ProductID:   productId,
+		Key:         key,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       int32(bounds.Dx()),
+		Height:      int32(bounds.Dy()),
+		Thumbnails:  thumbnailKeys,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed inserting media with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Media{}, err
+	}
+	span.AddEvent("inserted media to database")
+	logger.Info().Any(constants.KEY_MEDIA, media).Msg("inserted media to database")
+
+	return media.Response(svc.config.MediaURL)
+}
+
+// RemoveMedia deletes a media of a product and its blobs. Blobs that can not be
+// deleted are only logged, the media is already gone from the product.
+func (svc MediaService) RemoveMedia(
+	c context.Context,
+	productId uuid.UUID,
+	mediaId uuid.UUID,
+) (response.Media, error) {
+	c, span := otel.Tracer.Start(c, "MediaService RemoveMedia")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "MediaService RemoveMedia").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_MEDIA_ID, mediaId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing media in database").Logger()
+	logger.Trace().Msg("removing media in database")
+	span.AddEvent("removing media in database")
+	media, err := svc.queries.DeleteProductMedia(c, repository.DeleteProductMediaParams{
+		ID:        mediaId,
+		ProductID: productId,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed removing media with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Media{}, err
+	}
+	span.AddEvent("removed media in database")
+	logger.Info().Any(constants.KEY_MEDIA, media).Msg("removed media in database")
+
+	res, err := media.Response(svc.config.MediaURL)
+	if err != nil {
+		err = fmt.Errorf("failed mapping media with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Media{}, err
+	}
+
+	keys := []string{media.Key}
+	thumbnails := map[string]string{}
+	_ = json.Unmarshal(media.Thumbnails, &thumbnails)
+	for _, key := range thumbnails {
+		keys = append(keys, key)
+	}
+	svc.removeBlobs(c, keys)
+
+	return res, nil
+}
+
+// FindBlob returns the blob stored under key and its content type.
+func (svc MediaService) FindBlob(c context.Context, key string) (io.ReadCloser, string, error) {
+	c, span := otel.Tracer.Start(c, "MediaService FindBlob")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "MediaService FindBlob").
+		Str(constants.KEY_BLOB_KEY, key).
+		Str(constants.KEY_PROCESS, "finding blob").
+		Logger()
+
+	logger.Trace().Msg("finding blob")
+	span.AddEvent("finding blob")
+	body, contentType, err := svc.store.Get(c, key)
+	if err != nil {
+		err = fmt.Errorf("failed finding blob with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	span.AddEvent("found blob")
+	logger.Info().Msg("found blob")
+
+	return body, contentType, nil
+}
+
+func (svc MediaService) removeBlobs(c context.Context, keys []string) {
+	logger := zerolog.Ctx(c).With().Str(constants.KEY_PROCESS, "removing blobs").Logger()
+	for _, key := range keys {
+		err := svc.store.Delete(c, key)
+		if err != nil {
+			err = fmt.Errorf("failed removing blob with error=%w", err)
+			logger.Error().Err(err).Str(constants.KEY_BLOB_KEY, key).Msg(err.Error())
+		}
+	}
+}
+
+// validateMedia returns the sniffed content type of data and the decoded image,
+// it fails with ErrMediaTooLarge or ErrUnsupportedMediaType.
+func (svc MediaService) validateMedia(data []byte) (string, image.Image, error) {
+	if int64(len(data)) > svc.MaxSize() {
+		return "", nil, fmt.Errorf(
+			"failed validating media of size=%d with error=%w",
+			len(data),
+			productErrors.ErrMediaTooLarge,
+		)
+	}
+	contentTypes := svc.config.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = defaultContentTypes
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(contentTypes, contentType) {
+		return "", nil, fmt.Errorf(
+			"failed validating media of content_type=%s with error=%w",
+			contentType,
+			productErrors.ErrUnsupportedMediaType,
+		)
+	}
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, fmt.Errorf(
+			"failed decoding media with error=%w",
+			errors.Join(productErrors.ErrUnsupportedMediaType, err),
+		)
+	}
+	if imgConfig.Width*imgConfig.Height > maxMediaPixels {
+		return "", nil, fmt.Errorf(
+			"failed validating media of width=%d height=%d with error=%w",
+			imgConfig.Width,
+			imgConfig.Height,
+			productErrors.ErrMediaTooLarge,
+		)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, fmt.Errorf(
+			"failed decoding media with error=%w",
+			errors.Join(productErrors.ErrUnsupportedMediaType, err),
+		)
+	}
+	return contentType, img, nil
+}
+
+func (svc MediaService) thumbnailSizes() []int {
+	if len(svc.config.ThumbnailSizes) == 0 {
+		return defaultThumbnailSizes
+	}
+	return svc.config.ThumbnailSizes
+}
+
+// attachMedia sets the media of every product in products, oldest first.
+func (svc ProductService) attachMedia(c context.Context, products []response.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	rows, err := svc.queries.FindProductMediaByProductIds(c, ids)
+	if err != nil {
+		return fmt.Errorf("failed finding product media with error=%w", err)
+	}
+	media := map[uuid.UUID][]response.Media{}
+	for _, row := range rows {
+		m, err := row.Response(svc.config.Media.MediaURL)
+		if err != nil {
+			return fmt.Errorf("failed mapping mediaId=%s with error=%w", row.ID, err)
+		}
+		media[row.ProductID] = append(media[row.ProductID], m)
+	}
+	for i := range products {
+		products[i].Media = media[products[i].ID]
+		if products[i].Media == nil {
+			products[i].Media = []response.Media{}
+		}
+	}
+	return nil
+}
+
+// thumbnail scales img down so its longest side is size, keeping its aspect
+// ratio. Every pixel of the thumbnail is the average of the pixels it covers,
+// images that already fit are returned as they are.
+func thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if size <= 0 || (width <= size && height <= size) {
+		return img
+	}
+	dstWidth, dstHeight := size, size
+	if width > height {
+		dstHeight = max(height*size/width, 1)
+	} else {
+		dstWidth = max(width*size/height, 1)
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := max(bounds.Min.Y+(y+1)*height/dstHeight, y0+1)
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := max(bounds.Min.X+(x+1)*width/dstWidth, x0+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sr, sg, sb, sa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(sr), g+uint64(sg), b+uint64(sb), a+uint64(sa)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// encodeImage encodes img in the format of contentType, thumbnails of a gif are
+// encoded as a single frame.
+func encodeImage(w io.Writer, img image.Image, contentType string) error {
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	case "image/gif":
+		return gif.Encode(w, img, nil)
+	default:
+		return png.Encode(w, img)
+	}
+}
+
+func mediaExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".png"
+	}
+}
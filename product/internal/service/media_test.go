@@ -0,0 +1,38 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Alturino/ecommerce/internal/config"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+)
+
+func TestThumbnail(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 400))
+
+	assert.Equal(t, image.Rect(0, 0, 128, 64), thumbnail(img, 128).Bounds(), "aspect ratio should be kept")
+	assert.Equal(t, image.Rect(0, 0, 800, 400), thumbnail(img, 1024).Bounds(), "small images should not be upscaled")
+}
+
+func TestValidateMedia(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 2))))
+	svc := MediaService{config: config.Media{MaxSize: int64(buf.Len())}}
+
+	contentType, img, err := svc.validateMedia(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, 4, img.Bounds().Dx())
+
+	_, _, err = svc.validateMedia([]byte("%PDF-1.4 not an image"))
+	assert.ErrorIs(t, err, productErrors.ErrUnsupportedMediaType)
+
+	_, _, err = svc.validateMedia(append(buf.Bytes(), 0))
+	assert.ErrorIs(t, err, productErrors.ErrMediaTooLarge)
+}
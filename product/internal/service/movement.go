@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+)
+
+func (svc ProductService) FindInventoryMovements(
+	c context.Context,
+	productId uuid.UUID,
+	page inHttp.Page,
+) ([]repository.InventoryMovement, string, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindInventoryMovements")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindInventoryMovements").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Any(constants.KEY_PAGE, page).
+		Logger()
+
+	cursorId, cursorCreatedAt, err := page.TimeCursorParams()
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding inventory movements").Logger()
+	logger.Trace().Msg("finding inventory movements")
+	span.AddEvent("finding inventory movements")
+	movements, err := svc.queries.FindInventoryMovementsByProductId(
+		c,
+		repository.FindInventoryMovementsByProductIdParams{
+			ProductID:       productId,
+			CursorID:        cursorId,
+			CursorCreatedAt: cursorCreatedAt,
+			ResultLimit:     page.Limit + 1,
+		},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed finding inventory movements with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	movements, nextCursor := inHttp.NextPage(
+		movements,
+		page.Limit,
+		func(movement repository.InventoryMovement) inHttp.Cursor {
+			return inHttp.TimeCursor(movement.CreatedAt.Time, movement.ID)
+		},
+	)
+	span.AddEvent("found inventory movements")
+	logger.Info().Int(constants.KEY_INVENTORY_MOVEMENTS, len(movements)).Msg("found inventory movements")
+
+	return movements, nextCursor, nil
+}
+
+// FindStockAsOf replays the inventory movements of a product up to at and
+// returns the quantity the product had at that time, or the quantity of the
+// variant when variantId is set.
+func (svc ProductService) FindStockAsOf(
+	c context.Context,
+	productId uuid.UUID,
+	variantId *uuid.UUID,
+	at time.Time,
+) (int32, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindStockAsOf")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindStockAsOf").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Time(constants.KEY_STOCK_AS_OF, at).
+		Logger()
+
+	variantID := pgtype.UUID{}
+	if variantId != nil {
+		variantID = pgtype.UUID{Bytes: *variantId, Valid: true}
+		logger = logger.With().Str(constants.KEY_VARIANT_ID, variantId.String()).Logger()
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding stock as of").Logger()
+	logger.Trace().Msg("finding stock as of")
+	span.AddEvent("finding stock as of")
+	quantity, err := svc.queries.FindStockAsOf(c, repository.FindStockAsOfParams{
+		ProductID: productId,
+		VariantID: variantID,
+		CreatedAt: pgtype.Timestamptz{Time: at, InfinityModifier: pgtype.Finite, Valid: true},
+	})
+	if err != nil {
+		err = fmt.Errorf("failed finding stock as of with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return 0, err
+	}
+	span.AddEvent("found stock as of")
+	logger.Info().Int32(constants.KEY_PRODUCT_QUANTITY, quantity).Msg("found stock as of")
+
+	return quantity, nil
+}
+
+// quantityMovement returns the inventory movement that changes the quantity of
+// a product by delta, a positive delta is a restock and a negative one is an
+// adjustment.
+func quantityMovement(
+	c context.Context,
+	productId uuid.UUID,
+	warehouseId uuid.UUID,
+	delta int32,
+) repository.InsertInventoryMovementsParams {
+	return repository.NewInventoryMovement(
+		productId,
+		warehouseId,
+		delta,
+		quantityReason(delta),
+		uuid.Nil,
+		movementActor(c),
+	)
+}
+
+// variantMovement returns the inventory movement that changes the quantity of
+// a variant by delta, the same way quantityMovement does for a product.
+func variantMovement(
+	c context.Context,
+	productId uuid.UUID,
+	variantId uuid.UUID,
+	delta int32,
+) repository.InsertInventoryMovementsParams {
+	return repository.NewVariantInventoryMovement(
+		productId,
+		variantId,
+		delta,
+		quantityReason(delta),
+		uuid.Nil,
+		movementActor(c),
+	)
+}
+
+func quantityReason(delta int32) repository.InventoryMovementReason {
+	if delta < 0 {
+		return repository.InventoryMovementReasonADJUSTMENT
+	}
+	return repository.InventoryMovementReasonRESTOCK
+}
+
+// movementActor returns the user that changes the stock, requests without a
+// token are recorded as the product service.
+func movementActor(c context.Context) string {
+	token := internal.JwtTokenFromContext(c)
+	if token == nil {
+		return constants.APP_PRODUCT_SERVICE
+	}
+	subject, err := token.Claims.GetSubject()
+	if err != nil || subject == "" {
+		return constants.APP_PRODUCT_SERVICE
+	}
+	return subject
+}
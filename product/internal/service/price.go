@@ -0,0 +1,498 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/cache"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+// SchedulePrice records a price of a product from param.EffectiveFrom, now by
+// default, until param.EffectiveTo. The prices it overlaps are cut around it,
+// past prices are never changed. A price that takes effect right away updates
+// the product at once, a future one wakes up the price activator.
+func (svc ProductService) SchedulePrice(
+	c context.Context,
+	productId uuid.UUID,
+	param request.Price,
+) (response.Price, error) {
+	c, span := otel.Tracer.Start(c, "ProductService SchedulePrice")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService SchedulePrice").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Logger()
+
+	now := time.Now()
+	from := now
+	if param.EffectiveFrom != nil {
+		from = *param.EffectiveFrom
+	}
+	if from.Before(now.Add(-time.Second)) {
+		err := fmt.Errorf("failed scheduling price with error=%w", productErrors.ErrPriceInPast)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+	if param.EffectiveTo != nil && !param.EffectiveTo.After(from) {
+		err := fmt.Errorf("failed scheduling price with error=%w", productErrors.ErrInvalidPriceRange)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking product").Logger()
+	logger.Trace().Msg("locking product")
+	span.AddEvent("locking product")
+	queries := svc.queries.WithTx(tx)
+	product, err := queries.FindProductByIdForUpdate(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed locking product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+	span.AddEvent("locked product")
+	logger.Info().Msg("locked product")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "scheduling price").Logger()
+	logger.Trace().Msg("scheduling price")
+	span.AddEvent("scheduling price")
+	price, err := schedulePrice(c, queries, product, toNumeric(&param.Price), from, param.EffectiveTo)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+	span.AddEvent("scheduled price")
+	logger.Info().Any(constants.KEY_PRICE, price).Msg("scheduled price")
+
+	activated := []repository.Product{}
+	if !from.After(now) {
+		logger = logger.With().Str(constants.KEY_PROCESS, "activating prices").Logger()
+		logger.Trace().Msg("activating prices")
+		span.AddEvent("activating prices")
+		activated, err = queries.ActivateProductPrices(c)
+		if err != nil {
+			err = fmt.Errorf("failed activating prices with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Price{}, err
+		}
+		span.AddEvent("activated prices")
+		logger.Info().Int(constants.KEY_PRODUCTS, len(activated)).Msg("activated prices")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	if len(activated) > 0 {
+		svc.invalidateProducts(c, activated)
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "publishing price scheduled").Logger()
+	logger.Trace().Msg("publishing price scheduled")
+	span.AddEvent("publishing price scheduled")
+	err = svc.cache.Publish(c, constants.PRODUCT_PRICE_SCHEDULED, productId.String()).Err()
+	if err != nil {
+		err = fmt.Errorf("failed publishing price scheduled with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return svc.priceResponse(price), nil
+	}
+	span.AddEvent("published price scheduled")
+	logger.Info().Msg("published price scheduled")
+
+	return svc.priceResponse(price), nil
+}
+
+func (svc ProductService) FindPrices(
+	c context.Context,
+	productId uuid.UUID,
+) ([]response.Price, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindPrices")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindPrices").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding prices").Logger()
+	logger.Trace().Msg("finding prices")
+	span.AddEvent("finding prices")
+	rows, err := svc.queries.FindProductPricesByProductId(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding prices with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	prices := make([]response.Price, 0, len(rows))
+	for _, row := range rows {
+		prices = append(prices, svc.priceResponse(row))
+	}
+	span.AddEvent("found prices")
+	logger.Info().Int(constants.KEY_PRICES, len(prices)).Msg("found prices")
+
+	return prices, nil
+}
+
+// FindPriceAt returns the price a product had at a point in time, products
+// without any recorded price return their current price.
+func (svc ProductService) FindPriceAt(
+	c context.Context,
+	productId uuid.UUID,
+	at time.Time,
+) (response.Price, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindPriceAt")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindPriceAt").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Time(constants.KEY_PRICE_AT, at).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding price at").Logger()
+	logger.Trace().Msg("finding price at")
+	span.AddEvent("finding price at")
+	price, err := svc.queries.FindProductPriceAt(c, repository.FindProductPriceAtParams{
+		ProductID: productId,
+		At:        pgtype.Timestamptz{Time: at, InfinityModifier: pgtype.Finite, Valid: true},
+	})
+	if err == nil {
+		span.AddEvent("found price at")
+		logger.Info().Any(constants.KEY_PRICE, price).Msg("found price at")
+		return svc.priceResponse(price), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		err = fmt.Errorf("failed finding price at with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+	logger.Debug().Msg("price history is empty")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product").Logger()
+	logger.Trace().Msg("finding product")
+	span.AddEvent("finding product")
+	product, err := svc.queries.FindProductById(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+	if at.Before(product.CreatedAt.Time) {
+		err = fmt.Errorf("failed finding price at with error=%w", pgx.ErrNoRows)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Price{}, err
+	}
+	span.AddEvent("found product")
+	logger.Info().Msg("found product")
+
+	return response.Price{
+		ProductID:     product.ID,
+		Price:         product.Response().Price,
+		Currency:      svc.currency.BaseCurrency(),
+		EffectiveFrom: product.CreatedAt.Time,
+		CreatedAt:     product.CreatedAt.Time,
+	}, nil
+}
+
+// ActivatePrices copies the prices that took effect to their products and
+// drops the products from the cache, it returns the products whose price
+// changed.
+func (svc ProductService) ActivatePrices(c context.Context) ([]repository.Product, error) {
+	c, span := otel.Tracer.Start(c, "ProductService ActivatePrices")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService ActivatePrices").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "activating prices").Logger()
+	logger.Trace().Msg("activating prices")
+	span.AddEvent("activating prices")
+	products, err := svc.queries.ActivateProductPrices(c)
+	if err != nil {
+		err = fmt.Errorf("failed activating prices with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("activated prices")
+	logger.Info().Int(constants.KEY_PRODUCTS, len(products)).Msg("activated prices")
+
+	if len(products) > 0 {
+		svc.invalidateProducts(c, products)
+	}
+	return products, nil
+}
+
+// NextPriceChange returns when the next scheduled price takes effect, ok is
+// false when there is none.
+func (svc ProductService) NextPriceChange(c context.Context) (time.Time, bool, error) {
+	c, span := otel.Tracer.Start(c, "ProductService NextPriceChange")
+	defer span.End()
+
+	next, err := svc.queries.FindNextProductPriceChange(c)
+	if err != nil {
+		err = fmt.Errorf("failed finding next price change with error=%w", err)
+		inOtel.RecordError(err, span)
+		return time.Time{}, false, err
+	}
+	return next.Time, next.Valid, nil
+}
+
+// attachPrices sets the price of every product in products to the price that
+// is in effect now and its currency, the product price is only updated by the
+// price activator and may lag behind for a moment.
+func (svc ProductService) attachPrices(c context.Context, products []response.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	rows, err := svc.queries.FindCurrentProductPrices(c, ids)
+	if err != nil {
+		return fmt.Errorf("failed finding current prices with error=%w", err)
+	}
+	prices := make(map[uuid.UUID]response.Price, len(rows))
+	for _, row := range rows {
+		prices[row.ProductID] = row.Response()
+	}
+	for i := range products {
+		if price, ok := prices[products[i].ID]; ok {
+			products[i].Price = price.Price
+		}
+		products[i].Currency = svc.currency.BaseCurrency()
+	}
+	return nil
+}
+
+// priceResponse returns price in the base currency of the catalog.
+func (svc ProductService) priceResponse(price repository.ProductPrice) response.Price {
+	res := price.Response()
+	res.Currency = svc.currency.BaseCurrency()
+	return res
+}
+
+func (svc ProductService) invalidateProducts(c context.Context, products []repository.Product) {
+	c, span := otel.Tracer.Start(c, "ProductService invalidateProducts")
+	defer span.End()
+
+	keys := make([]string, 0, len(products))
+	for _, product := range products {
+		keys = append(keys, product.ID.String())
+	}
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService invalidateProducts").
+		Strs(constants.KEY_CACHE_KEY, keys).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "invalidating products in cache").Logger()
+	logger.Trace().Msg("invalidating products in cache")
+	span.AddEvent("invalidating products in cache")
+	err := svc.cache.Delete(c, cache.KEY_PRODUCTS, keys...)
+	if err != nil {
+		err = fmt.Errorf("failed invalidating products in cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return
+	}
+	span.AddEvent("invalidated products in cache")
+	logger.Info().Msg("invalidated products in cache")
+}
+
+// schedulePrice records price for product from from until to and cuts the open
+// prices of the product around it. A product without any recorded price gets
+// its current price recorded first, so the price before from is kept.
+func schedulePrice(
+	c context.Context,
+	queries *repository.Queries,
+	product repository.Product,
+	price pgtype.Numeric,
+	from time.Time,
+	to *time.Time,
+) (repository.ProductPrice, error) {
+	rows, err := queries.FindOpenProductPricesForUpdate(c, repository.FindOpenProductPricesForUpdateParams{
+		ProductID: product.ID,
+		At:        toTimestamptz(&from),
+	})
+	if err != nil {
+		return repository.ProductPrice{}, fmt.Errorf("failed locking prices with error=%w", err)
+	}
+	if len(rows) == 0 && product.CreatedAt.Time.Before(from) {
+		base, err := queries.InsertProductPrice(c, repository.InsertProductPriceParams{
+			ProductID:     product.ID,
+			Price:         product.Price,
+			EffectiveFrom: product.CreatedAt,
+		})
+		if err != nil {
+			return repository.ProductPrice{}, fmt.Errorf("failed inserting base price with error=%w", err)
+		}
+		rows = append(rows, base)
+	}
+
+	plan := planPrice(rows, product.ID, price, from, to)
+	for _, id := range plan.deletes {
+		err = queries.DeleteProductPrice(c, id)
+		if err != nil {
+			return repository.ProductPrice{}, fmt.Errorf("failed deleting priceId=%s with error=%w", id, err)
+		}
+	}
+	for _, update := range plan.updates {
+		_, err = queries.UpdateProductPriceRange(c, update)
+		if err != nil {
+			return repository.ProductPrice{}, fmt.Errorf("failed updating priceId=%s with error=%w", update.ID, err)
+		}
+	}
+	for _, insert := range plan.inserts {
+		_, err = queries.InsertProductPrice(c, insert)
+		if err != nil {
+			return repository.ProductPrice{}, fmt.Errorf("failed inserting price with error=%w", err)
+		}
+	}
+	scheduled, err := queries.InsertProductPrice(c, plan.price)
+	if err != nil {
+		return repository.ProductPrice{}, fmt.Errorf("failed inserting price with error=%w", err)
+	}
+	return scheduled, nil
+}
+
+// pricePlan is how the open prices of a product change to make room for price.
+type pricePlan struct {
+	price   repository.InsertProductPriceParams
+	deletes []uuid.UUID
+	updates []repository.UpdateProductPriceRangeParams
+	inserts []repository.InsertProductPriceParams
+}
+
+// planPrice fits price from from until to between the open prices in rows,
+// ordered by their start. Without to the price lasts until the next price
+// that starts after from. A price that starts before from ends at from and
+// continues after to when it outlasts to, a price within the new range is
+// deleted.
+func planPrice(
+	rows []repository.ProductPrice,
+	productId uuid.UUID,
+	price pgtype.Numeric,
+	from time.Time,
+	to *time.Time,
+) pricePlan {
+	if to == nil {
+		for _, row := range rows {
+			if row.EffectiveFrom.Time.After(from) {
+				to = &row.EffectiveFrom.Time
+				break
+			}
+		}
+	}
+
+	plan := pricePlan{
+		price: repository.InsertProductPriceParams{
+			ProductID:     productId,
+			Price:         price,
+			EffectiveFrom: toTimestamptz(&from),
+			EffectiveTo:   toTimestamptz(to),
+		},
+	}
+	for _, row := range rows {
+		start, end := row.EffectiveFrom.Time, row.EffectiveTo
+		if to != nil && !start.Before(*to) {
+			continue
+		}
+		if end.Valid && !end.Time.After(from) {
+			continue
+		}
+		outlasts := to != nil && (!end.Valid || end.Time.After(*to))
+		if start.Before(from) {
+			plan.updates = append(plan.updates, repository.UpdateProductPriceRangeParams{
+				EffectiveFrom: row.EffectiveFrom,
+				EffectiveTo:   toTimestamptz(&from),
+				ID:            row.ID,
+			})
+			if outlasts {
+				plan.inserts = append(plan.inserts, repository.InsertProductPriceParams{
+					ProductID:     productId,
+					Price:         row.Price,
+					EffectiveFrom: toTimestamptz(to),
+					EffectiveTo:   end,
+				})
+			}
+			continue
+		}
+		if outlasts {
+			plan.updates = append(plan.updates, repository.UpdateProductPriceRangeParams{
+				EffectiveFrom: toTimestamptz(to),
+				EffectiveTo:   end,
+				ID:            row.ID,
+			})
+			continue
+		}
+		plan.deletes = append(plan.deletes, row.ID)
+	}
+	return plan
+}
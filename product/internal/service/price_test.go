@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+)
+
+func TestPlanPrice(t *testing.T) {
+	productId := uuid.New()
+	day := func(d int) time.Time { return time.Date(2025, 2, d, 0, 0, 0, 0, time.UTC) }
+	row := func(price string, from int, to int) repository.ProductPrice {
+		value := decimal.RequireFromString(price)
+		r := repository.ProductPrice{
+			ID:            uuid.New(),
+			ProductID:     productId,
+			Price:         toNumeric(&value),
+			EffectiveFrom: pgtype.Timestamptz{Time: day(from), InfinityModifier: pgtype.Finite, Valid: true},
+		}
+		if to != 0 {
+			r.EffectiveTo = pgtype.Timestamptz{Time: day(to), InfinityModifier: pgtype.Finite, Valid: true}
+		}
+		return r
+	}
+	sale := decimal.RequireFromString("8")
+	price := toNumeric(&sale)
+
+	t.Run("open price ends at the new price", func(t *testing.T) {
+		base := row("10", 1, 0)
+		plan := planPrice([]repository.ProductPrice{base}, productId, price, day(10), nil)
+
+		require.Len(t, plan.updates, 1)
+		assert.Equal(t, base.ID, plan.updates[0].ID)
+		assert.Equal(t, day(10), plan.updates[0].EffectiveTo.Time)
+		assert.Empty(t, plan.inserts)
+		assert.Empty(t, plan.deletes)
+		assert.Equal(t, day(10), plan.price.EffectiveFrom.Time)
+		assert.False(t, plan.price.EffectiveTo.Valid)
+	})
+
+	t.Run("bounded price splits the open price", func(t *testing.T) {
+		base := row("10", 1, 0)
+		to := day(15)
+		plan := planPrice([]repository.ProductPrice{base}, productId, price, day(10), &to)
+
+		require.Len(t, plan.updates, 1)
+		assert.Equal(t, day(10), plan.updates[0].EffectiveTo.Time)
+		require.Len(t, plan.inserts, 1)
+		assert.Equal(t, base.Price, plan.inserts[0].Price)
+		assert.Equal(t, day(15), plan.inserts[0].EffectiveFrom.Time)
+		assert.False(t, plan.inserts[0].EffectiveTo.Valid)
+		assert.Equal(t, day(15), plan.price.EffectiveTo.Time)
+	})
+
+	t.Run("open price lasts until the next scheduled price", func(t *testing.T) {
+		base := row("10", 1, 20)
+		next := row("12", 20, 0)
+		plan := planPrice([]repository.ProductPrice{base, next}, productId, price, day(10), nil)
+
+		require.Len(t, plan.updates, 1)
+		assert.Equal(t, base.ID, plan.updates[0].ID)
+		assert.Empty(t, plan.inserts)
+		assert.Empty(t, plan.deletes)
+		assert.Equal(t, day(20), plan.price.EffectiveTo.Time)
+	})
+
+	t.Run("covered prices are deleted and overlapping prices shifted", func(t *testing.T) {
+		base := row("10", 1, 12)
+		covered := row("9", 12, 14)
+		overlapping := row("11", 14, 0)
+		to := day(16)
+		plan := planPrice(
+			[]repository.ProductPrice{base, covered, overlapping},
+			productId,
+			price,
+			day(10),
+			&to,
+		)
+
+		assert.Equal(t, []uuid.UUID{covered.ID}, plan.deletes)
+		require.Len(t, plan.updates, 2)
+		assert.Equal(t, base.ID, plan.updates[0].ID)
+		assert.Equal(t, day(10), plan.updates[0].EffectiveTo.Time)
+		assert.Equal(t, overlapping.ID, plan.updates[1].ID)
+		assert.Equal(t, day(16), plan.updates[1].EffectiveFrom.Time)
+		assert.False(t, plan.updates[1].EffectiveTo.Valid)
+		assert.Empty(t, plan.inserts)
+	})
+}
@@ -2,16 +2,24 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
 
+	inCache "github.com/Alturino/ecommerce/internal/cache"
+	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
 	inOtel "github.com/Alturino/ecommerce/internal/otel"
 	"github.com/Alturino/ecommerce/internal/repository"
 	"github.com/Alturino/ecommerce/product/internal/cache"
@@ -21,17 +29,27 @@ import (
 )
 
 type ProductService struct {
-	pool    *pgxpool.Pool
-	queries *repository.Queries
-	cache   *redis.Client
+	pool     *pgxpool.Pool
+	queries  *repository.Queries
+	cache    *inCache.Cache
+	config   config.Product
+	currency config.Currency
 }
 
 func NewProductService(
 	pool *pgxpool.Pool,
 	queries *repository.Queries,
-	cache *redis.Client,
+	cache *inCache.Cache,
+	config config.Product,
+	currency config.Currency,
 ) ProductService {
-	return ProductService{pool: pool, queries: queries, cache: cache}
+	return ProductService{
+		pool:     pool,
+		queries:  queries,
+		cache:    cache,
+		config:   config,
+		currency: currency,
+	}
 }
 
 func (svc ProductService) InsertProduct(
@@ -60,10 +78,31 @@ func (svc ProductService) InsertProduct(
 	span.AddEvent("product is not exist in database")
 	logger.Info().Msg("product is not exist in database")
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "inserting product to database").Logger()
 	logger.Trace().Msg("inserting product to database")
 	span.AddEvent("inserting product to database")
-	product, err = svc.queries.InsertProduct(
+	product, err = svc.queries.WithTx(tx).InsertProduct(
 		c,
 		repository.InsertProductParams{
 			Name: param.Name,
@@ -74,7 +113,12 @@ func (svc ProductService) InsertProduct(
 				NaN:              false,
 				Valid:            true,
 			},
-			Quantity: int32(param.Quantity),
+			Quantity:       int32(param.Quantity),
+			Backorderable:  param.Backorderable,
+			Preorderable:   param.Preorderable,
+			ReleaseDate:    toTimestamptz(param.ReleaseDate),
+			BackorderLimit: int32(param.BackorderLimit),
+			Description:    param.Description,
 		},
 	)
 	if err != nil {
@@ -87,64 +131,258 @@ func (svc ProductService) InsertProduct(
 	logger = logger.With().Any(constants.KEY_PRODUCT, product).Logger()
 	logger.Info().Msg("inserted product to database")
 
-	cacheKey := cache.KEY_PRODUCTS + product.ID.String()
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting product price").Logger()
+	logger.Trace().Msg("inserting product price")
+	span.AddEvent("inserting product price")
+	_, err = schedulePrice(c, svc.queries.WithTx(tx), product, product.Price, product.CreatedAt.Time, nil)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, err
+	}
+	span.AddEvent("inserted product price")
+	logger.Info().Msg("inserted product price")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "syncing default inventory level").Logger()
+	logger.Trace().Msg("syncing default inventory level")
+	span.AddEvent("syncing default inventory level")
+	err = svc.queries.WithTx(tx).SyncDefaultInventoryLevel(c, product.ID)
+	if err != nil {
+		err = fmt.Errorf("failed syncing default inventory level with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, err
+	}
+	span.AddEvent("synced default inventory level")
+	logger.Info().Msg("synced default inventory level")
+
+	if product.Quantity != 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "inserting inventory movement").Logger()
+		logger.Trace().Msg("inserting inventory movement")
+		span.AddEvent("inserting inventory movement")
+		_, err = svc.queries.WithTx(tx).InsertInventoryMovements(
+			c,
+			[]repository.InsertInventoryMovementsParams{quantityMovement(c, product.ID, uuid.Nil, product.Quantity)},
+		)
+		if err != nil {
+			err = fmt.Errorf("failed inserting inventory movement with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Product{}, err
+		}
+		span.AddEvent("inserted inventory movement")
+		logger.Info().Msg("inserted inventory movement")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	cacheKey := product.ID.String()
 	logger = logger.With().
 		Str(constants.KEY_PROCESS, "inserting product to cache").
 		Str(constants.KEY_CACHE_KEY, cacheKey).
 		Logger()
 	logger.Trace().Msg("inserting product to cache")
 	span.AddEvent("inserting product to cache")
-	err = svc.cache.JSONSet(c, cacheKey, "$", product).Err()
+	err = svc.cache.Set(c, cache.KEY_PRODUCTS, cacheKey, product.Response())
 	if err != nil {
 		err = fmt.Errorf("failed to inserting product to cache with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		return response.Product{}, nil
+		return product.Response(), nil
 	}
 	span.AddEvent("inserted product to cache")
 	logger.Info().Msg("inserted product to cache")
 
+	svc.invalidateSearch(c)
+
 	logger.Info().Msg("inserted product to database and cache")
 	return product.Response(), nil
 }
 
-func (svc ProductService) GetProducts(
+type productsPage struct {
+	Products   []response.Product `json:"products"`
+	NextCursor string             `json:"next_cursor"`
+}
+
+// FindProducts searches products by their name and description, filters them
+// by price and stock and caches every page of the result for a short time.
+func (svc ProductService) FindProducts(
 	c context.Context,
-) ([]repository.Product, error) {
+	param request.FindProduct,
+	page inHttp.Page,
+) ([]response.Product, string, error) {
 	c, span := otel.Tracer.Start(c, "ProductService FindProducts")
 	defer span.End()
 
+	cacheKey := productsQueryKey(param, page)
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
 		Str(constants.KEY_TAG, "ProductService FindProducts").
+		Str(constants.KEY_CACHE_KEY, cacheKey).
 		Logger()
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "find.product.database").Logger()
-	logger.Trace().Msg("finding products in database")
-	span.AddEvent("finding products in database")
-	products, err := svc.queries.FindProducts(c)
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding products").Logger()
+	logger.Trace().Msg("finding products")
+	span.AddEvent("finding products")
+	c = logger.WithContext(c)
+	found, err := inCache.GetOrLoad(
+		c,
+		svc.cache,
+		cache.KEY_PRODUCTS_QUERY,
+		cacheKey,
+		func(c context.Context) (productsPage, error) {
+			products, nextCursor, err := svc.searchProducts(c, param, page)
+			return productsPage{Products: products, NextCursor: nextCursor}, err
+		},
+	)
 	if err != nil {
-		err = fmt.Errorf("failed to get products from database with error=%w", err)
 		inOtel.RecordError(err, span)
-		logger.Info().Err(err).Msg(err.Error())
-		return nil, err
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
 	}
-	logger = logger.With().Any(constants.KEY_PRODUCTS, products).Logger()
-	span.AddEvent("found products in database")
-	logger.Info().Msg("found products in database")
+	span.AddEvent("found products")
+	logger.Info().Int(constants.KEY_PRODUCTS, len(found.Products)).Msg("found products")
 
-	return products, err
+	return found.Products, found.NextCursor, nil
 }
 
+// searchProducts searches a page of products in the database with their
+// prices, breadcrumbs, variants, media and ratings.
+func (svc ProductService) searchProducts(
+	c context.Context,
+	param request.FindProduct,
+	page inHttp.Page,
+) ([]response.Product, string, error) {
+	c, span := otel.Tracer.Start(c, "ProductService searchProducts")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService searchProducts").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "searching products in database").Logger()
+	logger.Trace().Msg("searching products in database")
+	span.AddEvent("searching products in database")
+	var err error
+	arg := repository.SearchProductsParams{
+		CategoryID:  toUUID(param.CategoryId),
+		Query:       pgtype.Text{String: param.Name, Valid: param.Name != ""},
+		MinPrice:    toNumeric(param.MinPrice),
+		MaxPrice:    toNumeric(param.MaxPrice),
+		InStock:     param.InStock,
+		SortBy:      param.SortBy(),
+		ResultLimit: page.Limit + 1,
+	}
+	if page.Cursor != nil {
+		arg, err = withProductsCursor(arg, *page.Cursor)
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return nil, "", err
+		}
+	}
+	rows, err := svc.queries.SearchProducts(c, arg)
+	if err != nil {
+		err = fmt.Errorf("failed searching products in database with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	rows, nextCursor := inHttp.NextPage(rows, page.Limit, productsCursor(arg.SortBy))
+	products := make([]response.Product, 0, len(rows))
+	for _, row := range rows {
+		products = append(products, row.Response())
+	}
+	span.AddEvent("searched products in database")
+	logger.Info().Int(constants.KEY_PRODUCTS, len(products)).Msg("searched products in database")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product prices").Logger()
+	logger.Trace().Msg("finding product prices")
+	span.AddEvent("finding product prices")
+	err = svc.attachPrices(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	span.AddEvent("found product prices")
+	logger.Info().Msg("found product prices")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product breadcrumbs").Logger()
+	logger.Trace().Msg("finding product breadcrumbs")
+	span.AddEvent("finding product breadcrumbs")
+	err = svc.attachBreadcrumbs(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	span.AddEvent("found product breadcrumbs")
+	logger.Info().Msg("found product breadcrumbs")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product variants").Logger()
+	logger.Trace().Msg("finding product variants")
+	span.AddEvent("finding product variants")
+	err = svc.attachVariants(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	span.AddEvent("found product variants")
+	logger.Info().Msg("found product variants")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product media").Logger()
+	logger.Trace().Msg("finding product media")
+	span.AddEvent("finding product media")
+	err = svc.attachMedia(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	span.AddEvent("found product media")
+	logger.Info().Msg("found product media")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product ratings").Logger()
+	logger.Trace().Msg("finding product ratings")
+	span.AddEvent("finding product ratings")
+	err = svc.attachRatings(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	span.AddEvent("found product ratings")
+	logger.Info().Msg("found product ratings")
+
+	return products, nextCursor, nil
+}
+
+// FindProductById returns the product with its details and the ETag of the
+// product it was read from, taken before the details are attached.
 func (svc ProductService) FindProductById(
 	c context.Context,
 	id uuid.UUID,
-) (product response.Product, err error) {
+) (product response.Product, etag string, err error) {
 	c, span := otel.Tracer.Start(c, "ProductService FindProductById")
 	defer span.End()
 
-	cacheKey := cache.KEY_PRODUCTS + id.String()
+	cacheKey := id.String()
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
@@ -152,57 +390,156 @@ func (svc ProductService) FindProductById(
 		Str(constants.KEY_CACHE_KEY, cacheKey).
 		Logger()
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "find product in cache").Logger()
-	logger.Trace().Msg("finding product in cache")
-	jsonCache, err := svc.cache.JSONGet(c, cacheKey).Result()
-	if err != nil || err == redis.Nil || jsonCache == "" {
-		err = fmt.Errorf("failed to get product from cache with error=%w", err)
-		logger.Info().Err(err).Msg(err.Error())
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product").Logger()
+	logger.Trace().Msg("finding product")
+	span.AddEvent("finding product")
+	c = logger.WithContext(c)
+	product, err = inCache.GetOrLoad(
+		c,
+		svc.cache,
+		cache.KEY_PRODUCTS,
+		cacheKey,
+		func(c context.Context) (response.Product, error) {
+			product, err := svc.queries.FindProductById(c, id)
+			if err != nil {
+				return response.Product{}, err
+			}
+			return product.Response(), nil
+		},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed to find product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, "", err
+	}
+	span.AddEvent("found product")
+	logger = logger.With().Any(constants.KEY_PRODUCT, product).Logger()
+	logger.Info().Msg("found product")
 
-		logger = logger.With().Str(constants.KEY_PROCESS, "finding product in database").Logger()
-		logger.Trace().Msg("finding product in database")
-		span.AddEvent("finding product in database")
-		product, err := svc.queries.FindProductById(c, id)
-		if err != nil {
-			err = fmt.Errorf("failed to find product in database with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return response.Product{}, err
-		}
-		span.AddEvent("found product in database")
-		logger = logger.With().Any(constants.KEY_PRODUCT, product).Logger()
+	etag, err = ETag(product)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, "", err
+	}
 
-		logger.Info().Msg("found product in database")
-		return product.Response(), nil
+	product, err = svc.withDetails(c, product)
+	if err != nil {
+		return response.Product{}, "", err
+	}
+	return product, etag, nil
+}
+
+// ETag returns the ETag of product. It only covers the fields of the product
+// row, which PUT and DELETE write and check If-Match against, so a new rating,
+// an uploaded image or a variant sold on checkout does not fail a precondition.
+func ETag(product response.Product) (string, error) {
+	product.Currency = ""
+	product.Rating, product.RatingCount = decimal.Decimal{}, 0
+	product.Breadcrumbs, product.Variants, product.Media = nil, nil, nil
+	etag, err := inHttp.ContentETag(product)
+	if err != nil {
+		return "", fmt.Errorf("failed computing etag of productId=%s with error=%w", product.ID, err)
 	}
-	span.AddEvent("found product in cache")
-	logger = logger.With().Str(constants.KEY_JSON_CACHE, jsonCache).Logger()
-	logger.Debug().Msg("found product in cache")
+	return etag, nil
+}
+
+// withDetails returns product with its current price, breadcrumbs, variants,
+// media and rating. They are not cached with the product, so a scheduled price,
+// moving a category, restocking a variant, uploading an image or approving a
+// review is visible right away.
+func (svc ProductService) withDetails(
+	c context.Context,
+	product response.Product,
+) (response.Product, error) {
+	c, span := otel.Tracer.Start(c, "ProductService withDetails")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService withDetails").
+		Str(constants.KEY_PRODUCT_ID, product.ID.String()).
+		Logger()
 
-	logger.Trace().Msg("unmarshalling product from cache")
-	err = json.Unmarshal([]byte(jsonCache), &product)
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product prices").Logger()
+	logger.Trace().Msg("finding product prices")
+	span.AddEvent("finding product prices")
+	products := []response.Product{product}
+	err := svc.attachPrices(c, products)
 	if err != nil {
-		err = fmt.Errorf("failed to unmarshal jsonCache with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		return response.Product{}, err
 	}
-	logger = logger.With().Any(constants.KEY_PRODUCT, product).Logger()
-	logger.Trace().Msg("unmarshalling product from cache")
+	span.AddEvent("found product prices")
+	logger.Info().Msg("found product prices")
 
-	logger.Info().Msg("found product in cache")
-	return product, nil
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product breadcrumbs").Logger()
+	logger.Trace().Msg("finding product breadcrumbs")
+	span.AddEvent("finding product breadcrumbs")
+	err = svc.attachBreadcrumbs(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, err
+	}
+	span.AddEvent("found product breadcrumbs")
+	logger.Info().Msg("found product breadcrumbs")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product variants").Logger()
+	logger.Trace().Msg("finding product variants")
+	span.AddEvent("finding product variants")
+	err = svc.attachVariants(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, err
+	}
+	span.AddEvent("found product variants")
+	logger.Info().Msg("found product variants")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product media").Logger()
+	logger.Trace().Msg("finding product media")
+	span.AddEvent("finding product media")
+	err = svc.attachMedia(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, err
+	}
+	span.AddEvent("found product media")
+	logger.Info().Msg("found product media")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product ratings").Logger()
+	logger.Trace().Msg("finding product ratings")
+	span.AddEvent("finding product ratings")
+	err = svc.attachRatings(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Product{}, err
+	}
+	span.AddEvent("found product ratings")
+	logger.Info().Msg("found product ratings")
+
+	return products[0], nil
 }
 
+// UpdateProduct updates a product when one of ifMatch is its current ETag, the
+// ETag is checked while the product is locked so concurrent updates can not
+// overwrite each other.
 func (svc ProductService) UpdateProduct(
 	c context.Context,
 	id uuid.UUID,
+	ifMatch []string,
 	param request.Product,
 ) (repository.Product, error) {
 	c, span := otel.Tracer.Start(c, "ProductService UpdateProduct")
 	defer span.End()
 
-	cacheKey := cache.KEY_PRODUCTS + id.String()
+	cacheKey := id.String()
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
@@ -210,10 +547,80 @@ func (svc ProductService) UpdateProduct(
 		Str(constants.KEY_CACHE_KEY, cacheKey).
 		Logger()
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking product").Logger()
+	logger.Trace().Msg("locking product")
+	span.AddEvent("locking product")
+	previous, err := svc.queries.WithTx(tx).FindProductByIdForUpdate(c, id)
+	if err != nil {
+		err = fmt.Errorf("failed locking product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("locked product")
+	logger.Info().Msg("locked product")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "checking etag").Logger()
+	logger.Trace().Msg("checking etag")
+	span.AddEvent("checking etag")
+	etag, err := ETag(previous.Response())
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	err = inHttp.CheckIfMatch(ifMatch, etag)
+	if err != nil {
+		err = fmt.Errorf("failed checking etag with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		// the tag may come from a stale cached product, drop it so the next GET
+		// serves the tag checked here instead of failing the retry again
+		svc.invalidateProducts(c, []repository.Product{previous})
+		return repository.Product{}, err
+	}
+	span.AddEvent("checked etag")
+	logger.Debug().Msg("checked etag")
+
+	if int32(param.Quantity) != previous.Quantity {
+		logger = logger.With().Str(constants.KEY_PROCESS, "checking warehoused quantity").Logger()
+		logger.Trace().Msg("checking warehoused quantity")
+		span.AddEvent("checking warehoused quantity")
+		err = checkWarehousedQuantity(c, svc.queries.WithTx(tx), id, int32(param.Quantity))
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return repository.Product{}, err
+		}
+		span.AddEvent("checked warehoused quantity")
+		logger.Debug().Msg("checked warehoused quantity")
+	}
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "updating product to database").Logger()
 	logger.Trace().Msg("updating product to database")
 	span.AddEvent("updating product to database")
-	product, err := svc.queries.UpdateProduct(c, repository.UpdateProductParams{
+	product, err := svc.queries.WithTx(tx).UpdateProduct(c, repository.UpdateProductParams{
 		Name: param.Name,
 		Price: pgtype.Numeric{
 			Exp:              param.Price.Exponent(),
@@ -222,8 +629,13 @@ func (svc ProductService) UpdateProduct(
 			NaN:              false,
 			Valid:            true,
 		},
-		Quantity: int32(param.Quantity),
-		ID:       id,
+		Quantity:       int32(param.Quantity),
+		Backorderable:  param.Backorderable,
+		Preorderable:   param.Preorderable,
+		ReleaseDate:    toTimestamptz(param.ReleaseDate),
+		BackorderLimit: int32(param.BackorderLimit),
+		Description:    param.Description,
+		ID:             id,
 	})
 	if err != nil {
 		err = fmt.Errorf("failed to update product with error=%w", err)
@@ -235,10 +647,68 @@ func (svc ProductService) UpdateProduct(
 	logger = logger.With().Any("product", product).Logger()
 	logger.Info().Msg("updated product to database")
 
+	if !previous.Response().Price.Equal(product.Response().Price) {
+		logger = logger.With().Str(constants.KEY_PROCESS, "inserting product price").Logger()
+		logger.Trace().Msg("inserting product price")
+		span.AddEvent("inserting product price")
+		_, err = schedulePrice(c, svc.queries.WithTx(tx), previous, product.Price, time.Now(), nil)
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return repository.Product{}, err
+		}
+		span.AddEvent("inserted product price")
+		logger.Info().Msg("inserted product price")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "syncing default inventory level").Logger()
+	logger.Trace().Msg("syncing default inventory level")
+	span.AddEvent("syncing default inventory level")
+	err = svc.queries.WithTx(tx).SyncDefaultInventoryLevel(c, product.ID)
+	if err != nil {
+		err = fmt.Errorf("failed syncing default inventory level with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("synced default inventory level")
+	logger.Info().Msg("synced default inventory level")
+
+	if delta := product.Quantity - previous.Quantity; delta != 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "inserting inventory movement").Logger()
+		logger.Trace().Msg("inserting inventory movement")
+		span.AddEvent("inserting inventory movement")
+		_, err = svc.queries.WithTx(tx).InsertInventoryMovements(
+			c,
+			[]repository.InsertInventoryMovementsParams{quantityMovement(c, product.ID, uuid.Nil, delta)},
+		)
+		if err != nil {
+			err = fmt.Errorf("failed inserting inventory movement with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return repository.Product{}, err
+		}
+		span.AddEvent("inserted inventory movement")
+		logger.Info().Msg("inserted inventory movement")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "update product to cache").Logger()
 	logger.Trace().Msg("updating product to cache")
 	span.AddEvent("updating product to cache")
-	err = svc.cache.JSONSet(c, cacheKey, "$", product).Err()
+	err = svc.cache.Set(c, cache.KEY_PRODUCTS, cacheKey, product.Response())
 	if err != nil {
 		err = fmt.Errorf("failed to update product to cache with error=%w", err)
 		inOtel.RecordError(err, span)
@@ -248,17 +718,53 @@ func (svc ProductService) UpdateProduct(
 	span.AddEvent("updated product to cache")
 	logger.Info().Msg("updated product to cache")
 
+	svc.invalidateSearch(c)
+
+	if product.Quantity != previous.Quantity {
+		logger = logger.With().Str(constants.KEY_PROCESS, "publishing product quantity updated").Logger()
+		logger.Trace().Msg("publishing product quantity updated")
+		span.AddEvent("publishing product quantity updated")
+		err = publishQuantityUpdated(c, svc.cache.Client, []uuid.UUID{product.ID})
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		} else {
+			span.AddEvent("published product quantity updated")
+			logger.Info().Msg("published product quantity updated")
+		}
+	}
+
+	if product.Quantity > previous.Quantity {
+		logger = logger.With().Str(constants.KEY_PROCESS, "publishing product restocked").Logger()
+		logger.Trace().Msg("publishing product restocked")
+		span.AddEvent("publishing product restocked")
+		err = svc.cache.Publish(c, constants.PRODUCT_RESTOCKED, product.ID.String()).Err()
+		if err != nil {
+			err = fmt.Errorf("failed publishing product restocked with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return product, nil
+		}
+		span.AddEvent("published product restocked")
+		logger.Info().Msg("published product restocked")
+	}
+
 	return product, nil
 }
 
+// RemoveProduct archives a product when one of ifMatch is its current ETag. An
+// archived product is hidden from listing and search and can not be ordered
+// anymore, it is kept so past orders still resolve it and can be brought back
+// with RestoreProduct.
 func (svc ProductService) RemoveProduct(
 	c context.Context,
 	id uuid.UUID,
+	ifMatch []string,
 ) (repository.Product, error) {
 	c, span := otel.Tracer.Start(c, "ProductService RemoveProduct")
 	defer span.End()
 
-	cacheKey := cache.KEY_PRODUCTS + id.String()
+	cacheKey := id.String()
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
@@ -266,31 +772,297 @@ func (svc ProductService) RemoveProduct(
 		Str(constants.KEY_CACHE_KEY, cacheKey).
 		Logger()
 
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking product").Logger()
+	logger.Trace().Msg("locking product")
+	span.AddEvent("locking product")
+	previous, err := svc.queries.WithTx(tx).FindProductByIdForUpdate(c, id)
+	if err != nil {
+		err = fmt.Errorf("failed locking product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("locked product")
+	logger.Info().Msg("locked product")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "checking etag").Logger()
+	logger.Trace().Msg("checking etag")
+	span.AddEvent("checking etag")
+	etag, err := ETag(previous.Response())
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	err = inHttp.CheckIfMatch(ifMatch, etag)
+	if err != nil {
+		err = fmt.Errorf("failed checking etag with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		// the tag may come from a stale cached product, drop it so the next GET
+		// serves the tag checked here instead of failing the retry again
+		svc.invalidateProducts(c, []repository.Product{previous})
+		return repository.Product{}, err
+	}
+	span.AddEvent("checked etag")
+	logger.Debug().Msg("checked etag")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "archiving product in database").Logger()
+	logger.Trace().Msg("archiving product in database")
+	span.AddEvent("archiving product in database")
+	product, err := svc.queries.WithTx(tx).ArchiveProduct(c, id)
+	if err != nil {
+		err = fmt.Errorf("failed archiving product in database with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("archived product in database")
+	logger.Info().Msg("archived product in database")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Product{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
 	logger = logger.With().Str(constants.KEY_PROCESS, "removing product in cache").Logger()
 	logger.Trace().Msg("removing product in cache")
 	span.AddEvent("removing product in cache")
-	err := svc.cache.JSONDel(c, cacheKey, "$").Err()
+	err = svc.cache.Delete(c, cache.KEY_PRODUCTS, cacheKey)
 	if err != nil {
 		err = fmt.Errorf("failed to remove product in cache with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
-		return repository.Product{}, err
+		return product, nil
 	}
 	span.AddEvent("removed product in cache")
 	logger.Info().Msg("removed product in cache")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "removing product in database").Logger()
-	logger.Trace().Msg("removing product in database")
-	span.AddEvent("removing product in database")
-	product, err := svc.queries.DeleteProduct(c, id)
+	svc.invalidateSearch(c)
+
+	return product, nil
+}
+
+// RestoreProduct brings an archived product back to listing, search and
+// checkout.
+func (svc ProductService) RestoreProduct(
+	c context.Context,
+	id uuid.UUID,
+) (repository.Product, error) {
+	c, span := otel.Tracer.Start(c, "ProductService RestoreProduct")
+	defer span.End()
+
+	cacheKey := id.String()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService RestoreProduct").
+		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "restoring product in database").Logger()
+	logger.Trace().Msg("restoring product in database")
+	span.AddEvent("restoring product in database")
+	product, err := svc.queries.RestoreProduct(c, id)
 	if err != nil {
-		err = fmt.Errorf("failed to remove product in database with error=%w", err)
+		err = fmt.Errorf("failed restoring product in database with error=%w", err)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		return repository.Product{}, err
 	}
-	span.AddEvent("removed product in database")
-	logger.Info().Msg("removed product in database")
+	span.AddEvent("restored product in database")
+	logger.Info().Msg("restored product in database")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing product in cache").Logger()
+	logger.Trace().Msg("removing product in cache")
+	span.AddEvent("removing product in cache")
+	err = svc.cache.Delete(c, cache.KEY_PRODUCTS, cacheKey)
+	if err != nil {
+		err = fmt.Errorf("failed to remove product in cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return product, nil
+	}
+	span.AddEvent("removed product in cache")
+	logger.Info().Msg("removed product in cache")
+
+	svc.invalidateSearch(c)
 
 	return product, nil
 }
+
+// invalidateSearch drops every cached search page after a product changed, the
+// pages expire with the TTL of their family so a failure is only logged.
+func (svc ProductService) invalidateSearch(c context.Context) {
+	c, span := otel.Tracer.Start(c, "ProductService invalidateSearch")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService invalidateSearch").
+		Str(constants.KEY_CACHE_FAMILY, cache.KEY_PRODUCTS_QUERY).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "invalidating searches in cache").Logger()
+	logger.Trace().Msg("invalidating searches in cache")
+	span.AddEvent("invalidating searches in cache")
+	err := svc.cache.Invalidate(c, cache.KEY_PRODUCTS_QUERY)
+	if err != nil {
+		err = fmt.Errorf("failed invalidating searches in cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return
+	}
+	span.AddEvent("invalidated searches in cache")
+	logger.Info().Msg("invalidated searches in cache")
+}
+
+func toTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, InfinityModifier: pgtype.Finite, Valid: true}
+}
+
+func toUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *id, Valid: true}
+}
+
+func toInt4(i *int32) pgtype.Int4 {
+	if i == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *i, Valid: true}
+}
+
+func toNumeric(d *decimal.Decimal) pgtype.Numeric {
+	if d == nil {
+		return pgtype.Numeric{}
+	}
+	return pgtype.Numeric{
+		Exp:              d.Exponent(),
+		InfinityModifier: pgtype.Finite,
+		Int:              d.Coefficient(),
+		NaN:              false,
+		Valid:            true,
+	}
+}
+
+// productsQueryKey returns the cache key of a page of a product search,
+// searches that only differ in the case or surrounding spaces of the name share
+// a key.
+func productsQueryKey(param request.FindProduct, page inHttp.Page) string {
+	minPrice, maxPrice, categoryId, cursor := "", "", "", ""
+	if param.MinPrice != nil {
+		minPrice = param.MinPrice.String()
+	}
+	if param.MaxPrice != nil {
+		maxPrice = param.MaxPrice.String()
+	}
+	if param.CategoryId != nil {
+		categoryId = param.CategoryId.String()
+	}
+	if page.Cursor != nil {
+		cursor = page.Cursor.Encode()
+	}
+	return fmt.Sprintf(
+		cache.FORMAT_PRODUCTS_QUERY,
+		strings.ToLower(strings.TrimSpace(param.Name)),
+		minPrice,
+		maxPrice,
+		param.InStock,
+		categoryId,
+		param.SortBy(),
+		cursor,
+		page.Limit,
+	)
+}
+
+// productsCursor returns the cursor of a search result, its key is the value
+// the results are sorted by.
+func productsCursor(sortBy string) func(repository.SearchProductsRow) inHttp.Cursor {
+	return func(row repository.SearchProductsRow) inHttp.Cursor {
+		cursor := inHttp.Cursor{ID: row.ID}
+		switch sortBy {
+		case request.SortPriceAsc, request.SortPriceDesc:
+			cursor.Key = decimal.NewFromBigInt(row.Price.Int, row.Price.Exp).String()
+		case request.SortNewest:
+			cursor = inHttp.TimeCursor(row.CreatedAt.Time, row.ID)
+		case request.SortRelevance:
+			cursor.Key = strconv.FormatFloat(float64(row.Rank), 'g', -1, 32)
+		}
+		return cursor
+	}
+}
+
+// withProductsCursor sets the search to continue after cursor, which must have
+// been created by a search with the same sort.
+func withProductsCursor(
+	arg repository.SearchProductsParams,
+	cursor inHttp.Cursor,
+) (repository.SearchProductsParams, error) {
+	arg.CursorID = pgtype.UUID{Bytes: cursor.ID, Valid: true}
+	switch arg.SortBy {
+	case request.SortPriceAsc, request.SortPriceDesc:
+		price, err := decimal.NewFromString(cursor.Key)
+		if err != nil {
+			return arg, fmt.Errorf(
+				"failed parsing cursor price with error=%w",
+				errors.Join(inErrors.ErrInvalidCursor, err),
+			)
+		}
+		arg.CursorPrice = toNumeric(&price)
+	case request.SortNewest:
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return arg, fmt.Errorf(
+				"failed parsing cursor created_at with error=%w",
+				errors.Join(inErrors.ErrInvalidCursor, err),
+			)
+		}
+		arg.CursorCreatedAt = pgtype.Timestamptz{Time: createdAt, InfinityModifier: pgtype.Finite, Valid: true}
+	case request.SortRelevance:
+		rank, err := strconv.ParseFloat(cursor.Key, 32)
+		if err != nil {
+			return arg, fmt.Errorf(
+				"failed parsing cursor rank with error=%w",
+				errors.Join(inErrors.ErrInvalidCursor, err),
+			)
+		}
+		arg.CursorRank = pgtype.Float4{Float32: float32(rank), Valid: true}
+	}
+	return arg, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+func TestProductETag(t *testing.T) {
+	price := decimal.RequireFromString("10.50")
+	updatedAt := pgtype.Timestamptz{
+		Time:             time.Date(2025, 2, 1, 9, 0, 0, 123456000, time.UTC),
+		InfinityModifier: pgtype.Finite,
+		Valid:            true,
+	}
+	locked := repository.Product{
+		ID:        uuid.New(),
+		Name:      "T-Shirt",
+		Price:     toNumeric(&price),
+		Quantity:  5,
+		CreatedAt: updatedAt,
+		UpdatedAt: updatedAt,
+	}.Response()
+
+	etag, err := ETag(locked)
+	require.NoError(t, err)
+
+	// the product read from the cache must match the one read while locked, or
+	// a tag from a GET would never pass If-Match
+	b, err := json.Marshal(locked)
+	require.NoError(t, err)
+	cached := response.Product{}
+	require.NoError(t, json.Unmarshal(b, &cached))
+	cachedETag, err := ETag(cached)
+	require.NoError(t, err)
+	assert.Equal(t, etag, cachedETag)
+
+	detailed := cached
+	detailed.Currency = "EUR"
+	detailed.Rating, detailed.RatingCount = decimal.RequireFromString("4.5"), 2
+	detailed.Breadcrumbs = [][]response.Breadcrumb{{{ID: uuid.New(), Name: "Clothing"}}}
+	detailed.Variants = []response.Variant{{ID: uuid.New(), ProductID: locked.ID, Quantity: 3}}
+	detailed.Media = []response.Media{{ID: uuid.New(), URL: "https://cdn.example.com/t-shirt.png"}}
+	detailedETag, err := ETag(detailed)
+	require.NoError(t, err)
+	assert.Equal(t, etag, detailedETag, "details are not written by PUT and should not fail If-Match")
+
+	sold := locked
+	sold.Quantity = 4
+	soldETag, err := ETag(sold)
+	require.NoError(t, err)
+	assert.NotEqual(t, etag, soldETag, "a product sold on checkout should change the etag")
+}
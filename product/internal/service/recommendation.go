@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	inCache "github.com/Alturino/ecommerce/internal/cache"
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/cache"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+const defaultRecommendationWindow = 180 * 24 * time.Hour
+
+// ComputeRecommendations recomputes the co-purchase score of every pair of
+// products, the number of completed orders in the recommendation window that
+// contain both of them. The scores are replaced in a single transaction so
+// readers keep seeing the previous scores until the new ones are committed,
+// then every cached recommendation is invalidated.
+func (svc ProductService) ComputeRecommendations(c context.Context) (int64, error) {
+	c, span := otel.Tracer.Start(c, "ProductService ComputeRecommendations")
+	defer span.End()
+
+	window := svc.config.Recommendation.Window
+	if window <= 0 {
+		window = defaultRecommendationWindow
+	}
+	since := time.Now().Add(-window)
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService ComputeRecommendations").
+		Time(constants.KEY_UPDATED_SINCE, since).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return 0, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "deleting co-purchases").Logger()
+	logger.Trace().Msg("deleting co-purchases")
+	span.AddEvent("deleting co-purchases")
+	err = svc.queries.WithTx(tx).DeleteProductCoPurchases(c)
+	if err != nil {
+		err = fmt.Errorf("failed deleting co-purchases with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return 0, err
+	}
+	span.AddEvent("deleted co-purchases")
+	logger.Info().Msg("deleted co-purchases")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting co-purchases").Logger()
+	logger.Trace().Msg("inserting co-purchases")
+	span.AddEvent("inserting co-purchases")
+	pairs, err := svc.queries.WithTx(tx).InsertProductCoPurchases(
+		c,
+		pgtype.Timestamptz{Time: since, InfinityModifier: pgtype.Finite, Valid: true},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed inserting co-purchases with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return 0, err
+	}
+	span.AddEvent("inserted co-purchases")
+	logger = logger.With().Int64(constants.KEY_CO_PURCHASES, pairs).Logger()
+	logger.Info().Msg("inserted co-purchases")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return 0, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "invalidating recommendations in cache").Logger()
+	logger.Trace().Msg("invalidating recommendations in cache")
+	span.AddEvent("invalidating recommendations in cache")
+	err = svc.cache.Invalidate(c, cache.KEY_PRODUCTS_RECOMMENDATIONS)
+	if err != nil {
+		err = fmt.Errorf("failed invalidating recommendations in cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return pairs, nil
+	}
+	span.AddEvent("invalidated recommendations in cache")
+	logger.Info().Msg("invalidated recommendations in cache")
+
+	return pairs, nil
+}
+
+// FindRecommendations returns up to limit products frequently bought together
+// with productId. When there are not enough co-purchases the rest are the best
+// selling products of its categories. Archived and out of stock products are
+// never recommended.
+func (svc ProductService) FindRecommendations(
+	c context.Context,
+	productId uuid.UUID,
+	limit int32,
+) ([]response.Recommendation, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindRecommendations")
+	defer span.End()
+
+	cacheKey := fmt.Sprintf(cache.FORMAT_PRODUCTS_RECOMMENDATIONS, productId.String(), limit)
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindRecommendations").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Int32(constants.KEY_LIMIT, limit).
+		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding recommendations").Logger()
+	logger.Trace().Msg("finding recommendations")
+	span.AddEvent("finding recommendations")
+	c = logger.WithContext(c)
+	recommendations, err := inCache.GetOrLoad(
+		c,
+		svc.cache,
+		cache.KEY_PRODUCTS_RECOMMENDATIONS,
+		cacheKey,
+		func(c context.Context) ([]response.Recommendation, error) {
+			return svc.recommendProducts(c, productId, limit)
+		},
+	)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found recommendations")
+	logger.Info().Int(constants.KEY_RECOMMENDATIONS, len(recommendations)).Msg("found recommendations")
+
+	return recommendations, nil
+}
+
+// recommendProducts finds the recommendations of productId in the database
+// with their prices, media and ratings.
+func (svc ProductService) recommendProducts(
+	c context.Context,
+	productId uuid.UUID,
+	limit int32,
+) ([]response.Recommendation, error) {
+	c, span := otel.Tracer.Start(c, "ProductService recommendProducts")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService recommendProducts").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product").Logger()
+	logger.Trace().Msg("finding product")
+	span.AddEvent("finding product")
+	_, err := svc.queries.FindProductById(c, productId)
+	if err != nil {
+		err = fmt.Errorf("failed finding product with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found product")
+	logger.Info().Msg("found product")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding products bought together").Logger()
+	logger.Trace().Msg("finding products bought together")
+	span.AddEvent("finding products bought together")
+	together, err := svc.queries.FindFrequentlyBoughtTogether(c, repository.FindFrequentlyBoughtTogetherParams{
+		ProductID:   productId,
+		ResultLimit: limit,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed finding products bought together with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found products bought together")
+	logger.Info().Int(constants.KEY_PRODUCTS, len(together)).Msg("found products bought together")
+
+	var bestsellers []repository.Product
+	if missing := limit - int32(len(together)); missing > 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "finding category bestsellers").Logger()
+		logger.Trace().Msg("finding category bestsellers")
+		span.AddEvent("finding category bestsellers")
+		excludedIds := make([]uuid.UUID, 0, len(together))
+		for _, product := range together {
+			excludedIds = append(excludedIds, product.ID)
+		}
+		bestsellers, err = svc.queries.FindCategoryBestsellers(c, repository.FindCategoryBestsellersParams{
+			ProductID:   productId,
+			ExcludedIds: excludedIds,
+			ResultLimit: missing,
+		})
+		if err != nil {
+			err = fmt.Errorf("failed finding category bestsellers with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return nil, err
+		}
+		span.AddEvent("found category bestsellers")
+		logger.Info().Int(constants.KEY_PRODUCTS, len(bestsellers)).Msg("found category bestsellers")
+	}
+
+	recommendations := recommend(together, bestsellers)
+	products := make([]response.Product, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		products = append(products, recommendation.Product)
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product prices").Logger()
+	logger.Trace().Msg("finding product prices")
+	span.AddEvent("finding product prices")
+	err = svc.attachPrices(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found product prices")
+	logger.Info().Msg("found product prices")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product media").Logger()
+	logger.Trace().Msg("finding product media")
+	span.AddEvent("finding product media")
+	err = svc.attachMedia(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found product media")
+	logger.Info().Msg("found product media")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding product ratings").Logger()
+	logger.Trace().Msg("finding product ratings")
+	span.AddEvent("finding product ratings")
+	err = svc.attachRatings(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found product ratings")
+	logger.Info().Msg("found product ratings")
+
+	for i := range recommendations {
+		recommendations[i].Product = products[i]
+	}
+
+	return recommendations, nil
+}
+
+// recommend lists the products bought together first, in the order of their
+// score, followed by the category bestsellers.
+func recommend(together []repository.Product, bestsellers []repository.Product) []response.Recommendation {
+	recommendations := make([]response.Recommendation, 0, len(together)+len(bestsellers))
+	for _, product := range together {
+		recommendations = append(recommendations, response.Recommendation{
+			Reason:  response.RecommendationBoughtTogether,
+			Product: product.Response(),
+		})
+	}
+	for _, product := range bestsellers {
+		recommendations = append(recommendations, response.Recommendation{
+			Reason:  response.RecommendationCategoryBestseller,
+			Product: product.Response(),
+		})
+	}
+	return recommendations
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+func TestRecommend(t *testing.T) {
+	price := pgtype.Numeric{Int: big.NewInt(1000), Exp: -2, Valid: true}
+	together := []repository.Product{{ID: uuid.New(), Price: price}, {ID: uuid.New(), Price: price}}
+	bestsellers := []repository.Product{{ID: uuid.New(), Price: price}}
+
+	recommendations := recommend(together, bestsellers)
+	assert.Len(t, recommendations, 3)
+	assert.Equal(t, together[0].ID, recommendations[0].Product.ID)
+	assert.Equal(t, response.RecommendationBoughtTogether, recommendations[0].Reason)
+	assert.Equal(t, together[1].ID, recommendations[1].Product.ID)
+	assert.Equal(t, response.RecommendationBoughtTogether, recommendations[1].Reason)
+	assert.Equal(t, bestsellers[0].ID, recommendations[2].Product.ID)
+	assert.Equal(t, response.RecommendationCategoryBestseller, recommendations[2].Reason)
+
+	assert.Empty(t, recommend(nil, nil))
+}
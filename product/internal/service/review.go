@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	inHttp "github.com/Alturino/ecommerce/internal/http"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	productErrors "github.com/Alturino/ecommerce/product/internal/errors"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+// InsertReview reviews a product as userId, who must have a completed order of
+// it. A user reviews a product once and the review waits for moderation before
+// it is listed or counted in the rating of the product.
+func (svc ProductService) InsertReview(
+	c context.Context,
+	productId uuid.UUID,
+	userId uuid.UUID,
+	param request.Review,
+) (response.Review, error) {
+	c, span := otel.Tracer.Start(c, "ProductService InsertReview")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService InsertReview").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_USER_ID, userId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding completed order").Logger()
+	logger.Trace().Msg("finding completed order")
+	span.AddEvent("finding completed order")
+	orderId, err := svc.queries.FindCompletedOrderIdByProduct(
+		c,
+		repository.FindCompletedOrderIdByProductParams{UserID: userId, ProductID: productId},
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = productErrors.ErrNotVerifiedBuyer
+	}
+	if err != nil {
+		err = fmt.Errorf("failed finding completed order with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("found completed order")
+	logger = logger.With().Str(constants.KEY_ORDER_ID, orderId.String()).Logger()
+	logger.Info().Msg("found completed order")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting review").Logger()
+	logger.Trace().Msg("inserting review")
+	span.AddEvent("inserting review")
+	mediaUrls := param.MediaUrls
+	if mediaUrls == nil {
+		mediaUrls = []string{}
+	}
+	review, err := svc.queries.InsertProductReview(c, repository.InsertProductReviewParams{
+		ProductID: productId,
+		UserID:    userId,
+		OrderID:   orderId,
+		Rating:    param.Rating,
+		Body:      param.Body,
+		MediaUrls: mediaUrls,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = productErrors.ErrReviewAlreadyExist
+	}
+	if err != nil {
+		err = fmt.Errorf("failed inserting review with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("inserted review")
+	logger.Info().Str(constants.KEY_REVIEW_ID, review.ID.String()).Msg("inserted review")
+
+	return review.Response(), nil
+}
+
+// FindReviews returns a page of the approved reviews of a product, the newest
+// or the most helpful first.
+func (svc ProductService) FindReviews(
+	c context.Context,
+	productId uuid.UUID,
+	sortBy string,
+	page inHttp.Page,
+) ([]response.Review, string, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindReviews")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindReviews").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Any(constants.KEY_PAGE, page).
+		Logger()
+
+	arg := repository.FindApprovedProductReviewsParams{
+		ProductID:   productId,
+		SortBy:      sortBy,
+		ResultLimit: page.Limit + 1,
+	}
+	if page.Cursor != nil {
+		var err error
+		arg, err = withReviewsCursor(arg, page)
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return nil, "", err
+		}
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding reviews").Logger()
+	logger.Trace().Msg("finding reviews")
+	span.AddEvent("finding reviews")
+	rows, err := svc.queries.FindApprovedProductReviews(c, arg)
+	if err != nil {
+		err = fmt.Errorf("failed finding reviews with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, "", err
+	}
+	rows, nextCursor := inHttp.NextPage(rows, page.Limit, reviewsCursor(sortBy))
+	reviews := make([]response.Review, 0, len(rows))
+	for _, row := range rows {
+		reviews = append(reviews, row.Response())
+	}
+	span.AddEvent("found reviews")
+	logger.Info().Int(constants.KEY_REVIEWS, len(reviews)).Msg("found reviews")
+
+	return reviews, nextCursor, nil
+}
+
+// ModerateReview moves a review to the status of param and adds or removes its
+// rating from the rating of the product when it is approved or no longer is.
+func (svc ProductService) ModerateReview(
+	c context.Context,
+	productId uuid.UUID,
+	reviewId uuid.UUID,
+	param request.ReviewStatus,
+) (response.Review, error) {
+	c, span := otel.Tracer.Start(c, "ProductService ModerateReview")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService ModerateReview").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_REVIEW_ID, reviewId.String()).
+		Str(constants.KEY_STATUS, param.Status).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	span.AddEvent("initialized transaction")
+	logger.Info().Msg("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking review").Logger()
+	logger.Trace().Msg("locking review")
+	span.AddEvent("locking review")
+	previous, err := svc.queries.WithTx(tx).FindProductReviewByIdForUpdate(
+		c,
+		repository.FindProductReviewByIdForUpdateParams{ID: reviewId, ProductID: productId},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed locking review with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("locked review")
+	logger.Info().Msg("locked review")
+
+	status := repository.ReviewStatus(param.Status)
+	if previous.Status == status {
+		return previous.Response(), nil
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating review status").Logger()
+	logger.Trace().Msg("updating review status")
+	span.AddEvent("updating review status")
+	review, err := svc.queries.WithTx(tx).UpdateProductReviewStatus(
+		c,
+		repository.UpdateProductReviewStatusParams{ID: reviewId, Status: status},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed updating review status with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("updated review status")
+	logger.Info().Msg("updated review status")
+
+	if count, sum := ratingDelta(previous.Rating, previous.Status, status); count != 0 {
+		logger = logger.With().Str(constants.KEY_PROCESS, "updating product rating").Logger()
+		logger.Trace().Msg("updating product rating")
+		span.AddEvent("updating product rating")
+		err = svc.queries.WithTx(tx).AddProductRating(c, repository.AddProductRatingParams{
+			ProductID:   productId,
+			RatingCount: count,
+			RatingSum:   sum,
+		})
+		if err != nil {
+			err = fmt.Errorf("failed updating product rating with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return response.Review{}, err
+		}
+		span.AddEvent("updated product rating")
+		logger.Info().Msg("updated product rating")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	return review.Response(), nil
+}
+
+// VoteReviewHelpful counts userId as finding an approved review helpful, voting
+// a review again changes nothing.
+func (svc ProductService) VoteReviewHelpful(
+	c context.Context,
+	productId uuid.UUID,
+	reviewId uuid.UUID,
+	userId uuid.UUID,
+) (response.Review, error) {
+	c, span := otel.Tracer.Start(c, "ProductService VoteReviewHelpful")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService VoteReviewHelpful").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_REVIEW_ID, reviewId.String()).
+		Str(constants.KEY_USER_ID, userId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	span.AddEvent("initialized transaction")
+	logger.Info().Msg("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking review").Logger()
+	logger.Trace().Msg("locking review")
+	span.AddEvent("locking review")
+	review, err := svc.queries.WithTx(tx).FindProductReviewByIdForUpdate(
+		c,
+		repository.FindProductReviewByIdForUpdateParams{ID: reviewId, ProductID: productId},
+	)
+	if err == nil && review.Status != repository.ReviewStatusAPPROVED {
+		err = productErrors.ErrReviewNotApproved
+	}
+	if err != nil {
+		err = fmt.Errorf("failed locking review with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("locked review")
+	logger.Info().Msg("locked review")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting review vote").Logger()
+	logger.Trace().Msg("inserting review vote")
+	span.AddEvent("inserting review vote")
+	voted, err := svc.queries.WithTx(tx).InsertProductReviewVote(
+		c,
+		repository.InsertProductReviewVoteParams{ReviewID: reviewId, UserID: userId},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed inserting review vote with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("inserted review vote")
+	logger.Info().Msg("inserted review vote")
+	if voted == 0 {
+		return review.Response(), nil
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "incrementing helpful count").Logger()
+	logger.Trace().Msg("incrementing helpful count")
+	span.AddEvent("incrementing helpful count")
+	review, err = svc.queries.WithTx(tx).IncrementProductReviewHelpfulCount(c, reviewId)
+	if err != nil {
+		err = fmt.Errorf("failed incrementing helpful count with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("incremented helpful count")
+	logger.Info().Msg("incremented helpful count")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Review{}, err
+	}
+	span.AddEvent("committed transaction")
+	logger.Info().Msg("committed transaction")
+
+	return review.Response(), nil
+}
+
+// attachRatings sets the average rating and the number of approved reviews of
+// products.
+func (svc ProductService) attachRatings(c context.Context, products []response.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	rows, err := svc.queries.FindProductRatingsByProductIds(c, ids)
+	if err != nil {
+		return fmt.Errorf("failed finding product ratings with error=%w", err)
+	}
+	ratings := make(map[uuid.UUID]repository.ProductRating, len(rows))
+	for _, row := range rows {
+		ratings[row.ProductID] = row
+	}
+	for i := range products {
+		rating := ratings[products[i].ID]
+		products[i].Rating = averageRating(rating.RatingSum, rating.RatingCount)
+		products[i].RatingCount = rating.RatingCount
+	}
+	return nil
+}
+
+// ratingDelta returns what a review moving from one status to another adds to
+// the rating count and sum of its product, only approved reviews are counted.
+func ratingDelta(rating int32, from repository.ReviewStatus, to repository.ReviewStatus) (int32, int32) {
+	switch {
+	case from != repository.ReviewStatusAPPROVED && to == repository.ReviewStatusAPPROVED:
+		return 1, rating
+	case from == repository.ReviewStatusAPPROVED && to != repository.ReviewStatusAPPROVED:
+		return -1, -rating
+	}
+	return 0, 0
+}
+
+func averageRating(sum int32, count int32) decimal.Decimal {
+	if count == 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt32(sum).DivRound(decimal.NewFromInt32(count), 2)
+}
+
+func reviewsCursor(sortBy string) func(repository.ProductReview) inHttp.Cursor {
+	return func(review repository.ProductReview) inHttp.Cursor {
+		if sortBy == request.SortReviewHelpful {
+			return inHttp.Cursor{Key: strconv.Itoa(int(review.HelpfulCount)), ID: review.ID}
+		}
+		return inHttp.TimeCursor(review.CreatedAt.Time, review.ID)
+	}
+}
+
+// withReviewsCursor sets the listing to continue after the cursor of page,
+// which must have been created by a listing with the same sort.
+func withReviewsCursor(
+	arg repository.FindApprovedProductReviewsParams,
+	page inHttp.Page,
+) (repository.FindApprovedProductReviewsParams, error) {
+	if arg.SortBy == request.SortReviewHelpful {
+		count, err := strconv.ParseInt(page.Cursor.Key, 10, 32)
+		if err != nil {
+			return arg, fmt.Errorf(
+				"failed parsing cursor helpful count with error=%w",
+				errors.Join(inErrors.ErrInvalidCursor, err),
+			)
+		}
+		arg.CursorID = pgtype.UUID{Bytes: page.Cursor.ID, Valid: true}
+		arg.CursorHelpfulCount = pgtype.Int4{Int32: int32(count), Valid: true}
+		return arg, nil
+	}
+	cursorId, cursorCreatedAt, err := page.TimeCursorParams()
+	if err != nil {
+		return arg, err
+	}
+	arg.CursorID, arg.CursorCreatedAt = cursorId, cursorCreatedAt
+	return arg, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Alturino/ecommerce/internal/repository"
+)
+
+func TestRatingDelta(t *testing.T) {
+	testCases := []struct {
+		name  string
+		from  repository.ReviewStatus
+		to    repository.ReviewStatus
+		count int32
+		sum   int32
+	}{
+		{"approving a pending review", repository.ReviewStatusPENDING, repository.ReviewStatusAPPROVED, 1, 4},
+		{"approving a rejected review", repository.ReviewStatusREJECTED, repository.ReviewStatusAPPROVED, 1, 4},
+		{"rejecting an approved review", repository.ReviewStatusAPPROVED, repository.ReviewStatusREJECTED, -1, -4},
+		{"rejecting a pending review", repository.ReviewStatusPENDING, repository.ReviewStatusREJECTED, 0, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			count, sum := ratingDelta(4, tc.from, tc.to)
+			assert.Equal(t, tc.count, count)
+			assert.Equal(t, tc.sum, sum)
+		})
+	}
+}
+
+func TestAverageRating(t *testing.T) {
+	assert.True(t, decimal.Zero.Equal(averageRating(0, 0)))
+	assert.Equal(t, "4.33", averageRating(13, 3).String())
+	assert.Equal(t, "5", averageRating(10, 2).String())
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	orderResponse "github.com/Alturino/ecommerce/order/pkg/response"
+	"github.com/Alturino/ecommerce/product/internal/cache"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+)
+
+// reconcilePageSize is how many updated products are refreshed at a time.
+const reconcilePageSize = 500
+
+// RefreshCachedProducts drops the cached products of productIds so their next
+// read loads their row from the database, and the cached searches they may be
+// part of.
+func (svc ProductService) RefreshCachedProducts(c context.Context, productIds []uuid.UUID) error {
+	c, span := otel.Tracer.Start(c, "ProductService RefreshCachedProducts")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService RefreshCachedProducts").
+		Any(constants.KEY_PRODUCT_IDS, productIds).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding products").Logger()
+	logger.Trace().Msg("finding products")
+	span.AddEvent("finding products")
+	products, err := svc.queries.FindProductsByIds(c, productIds)
+	if err != nil {
+		err = fmt.Errorf("failed finding products with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	span.AddEvent("found products")
+	logger.Info().Int(constants.KEY_PRODUCTS, len(products)).Msg("found products")
+
+	found := make(map[uuid.UUID]bool, len(products))
+	for _, product := range products {
+		found[product.ID] = true
+	}
+	deleted := []uuid.UUID{}
+	for _, id := range productIds {
+		if !found[id] {
+			deleted = append(deleted, id)
+		}
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "refreshing products in cache").Logger()
+	logger.Trace().Msg("refreshing products in cache")
+	span.AddEvent("refreshing products in cache")
+	err = svc.refreshCache(c, products, deleted)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return err
+	}
+	span.AddEvent("refreshed products in cache")
+	logger.Info().Msg("refreshed products in cache")
+
+	return nil
+}
+
+// ReconcileCachedProducts refreshes the cached products and evaluates the stock
+// alerts of every product updated after since and returns the latest update it
+// saw, since when no product was updated. It catches the updates whose message
+// was lost.
+func (svc ProductService) ReconcileCachedProducts(c context.Context, since time.Time) (time.Time, error) {
+	c, span := otel.Tracer.Start(c, "ProductService ReconcileCachedProducts")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService ReconcileCachedProducts").
+		Time(constants.KEY_UPDATED_SINCE, since).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "reconciling products").Logger()
+	logger.Trace().Msg("reconciling products")
+	span.AddEvent("reconciling products")
+	latest := since
+	param := repository.FindProductsUpdatedAfterParams{
+		UpdatedAt:   toTimestamptz(&since),
+		ID:          uuid.Nil,
+		ResultLimit: reconcilePageSize,
+	}
+	reconciled := 0
+	for {
+		products, err := svc.queries.FindProductsUpdatedAfter(c, param)
+		if err != nil {
+			err = fmt.Errorf("failed finding updated products with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return latest, err
+		}
+		if len(products) == 0 {
+			break
+		}
+		err = svc.refreshCache(c, products, nil)
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return latest, err
+		}
+		productIds := make([]uuid.UUID, 0, len(products))
+		for _, product := range products {
+			productIds = append(productIds, product.ID)
+		}
+		_, err = svc.EvaluateStockAlerts(c, productIds)
+		if err != nil {
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+			return latest, err
+		}
+		reconciled += len(products)
+		last := products[len(products)-1]
+		latest = last.UpdatedAt.Time
+		param.UpdatedAt, param.ID = last.UpdatedAt, last.ID
+		if len(products) < reconcilePageSize {
+			break
+		}
+	}
+	span.AddEvent("reconciled products")
+	logger.Info().Int(constants.KEY_PRODUCTS, reconciled).Msg("reconciled products")
+
+	return latest, nil
+}
+
+// refreshCache drops the cached products and the deleted ids, and invalidates
+// the cached searches.
+func (svc ProductService) refreshCache(
+	c context.Context,
+	products []repository.Product,
+	deleted []uuid.UUID,
+) error {
+	keys := make([]string, 0, len(products)+len(deleted))
+	for _, product := range products {
+		keys = append(keys, product.ID.String())
+	}
+	for _, id := range deleted {
+		keys = append(keys, id.String())
+	}
+	err := svc.cache.Delete(c, cache.KEY_PRODUCTS, keys...)
+	if err != nil {
+		return fmt.Errorf("failed refreshing products in cache with error=%w", err)
+	}
+	err = svc.cache.Invalidate(c, cache.KEY_PRODUCTS_QUERY)
+	if err != nil {
+		return fmt.Errorf("failed refreshing products in cache with error=%w", err)
+	}
+	return nil
+}
+
+// publishQuantityUpdated tells the product update listener that the quantity of
+// productIds changed, the same event the order service publishes.
+func publishQuantityUpdated(c context.Context, cache *redis.Client, productIds []uuid.UUID) error {
+	event, err := json.Marshal(orderResponse.ProductQuantityUpdated{
+		UpdatedAt:  time.Now(),
+		ProductIds: productIds,
+	})
+	if err != nil {
+		return fmt.Errorf("failed marshaling product quantity updated with error=%w", err)
+	}
+	err = cache.Publish(c, constants.UPDATE_PRODUCT_QUANTITY, event).Err()
+	if err != nil {
+		return fmt.Errorf("failed publishing product quantity updated with error=%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+	"github.com/Alturino/ecommerce/product/pkg/response"
+)
+
+func (svc ProductService) InsertVariant(
+	c context.Context,
+	productId uuid.UUID,
+	param request.Variant,
+) (response.Variant, error) {
+	c, span := otel.Tracer.Start(c, "ProductService InsertVariant")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService InsertVariant").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Logger()
+
+	options, err := variantOptions(param)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting variant to database").Logger()
+	logger.Trace().Msg("inserting variant to database")
+	span.AddEvent("inserting variant to database")
+	variant, err := svc.queries.WithTx(tx).InsertProductVariant(c, repository.InsertProductVariantParams{
+		ProductID: productId,
+		Sku:       param.Sku,
+		Options:   options,
+		Price:     toNumeric(param.Price),
+		Quantity:  int32(param.Quantity),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed inserting variant with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	span.AddEvent("inserted variant to database")
+	logger.Info().Any(constants.KEY_VARIANT, variant).Msg("inserted variant to database")
+
+	err = recordVariantMovement(c, svc.queries.WithTx(tx), variant, variant.Quantity)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	logger.Info().Msg("committed transaction")
+	span.AddEvent("committed transaction")
+
+	return variant.Response()
+}
+
+func (svc ProductService) FindVariants(
+	c context.Context,
+	productId uuid.UUID,
+) ([]response.Variant, error) {
+	c, span := otel.Tracer.Start(c, "ProductService FindVariants")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService FindVariants").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding variants in database").Logger()
+	logger.Trace().Msg("finding variants in database")
+	span.AddEvent("finding variants in database")
+	products := []response.Product{{ID: productId}}
+	err := svc.attachVariants(c, products)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found variants in database")
+	logger.Info().Int(constants.KEY_VARIANTS, len(products[0].Variants)).Msg("found variants in database")
+
+	return products[0].Variants, nil
+}
+
+func (svc ProductService) UpdateVariant(
+	c context.Context,
+	productId uuid.UUID,
+	variantId uuid.UUID,
+	param request.Variant,
+) (response.Variant, error) {
+	c, span := otel.Tracer.Start(c, "ProductService UpdateVariant")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService UpdateVariant").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_VARIANT_ID, variantId.String()).
+		Logger()
+
+	options, err := variantOptions(param)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "locking variant").Logger()
+	logger.Trace().Msg("locking variant")
+	span.AddEvent("locking variant")
+	previous, err := svc.lockVariant(c, tx, productId, variantId)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	span.AddEvent("locked variant")
+	logger.Info().Msg("locked variant")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating variant in database").Logger()
+	logger.Trace().Msg("updating variant in database")
+	span.AddEvent("updating variant in database")
+	variant, err := svc.queries.WithTx(tx).UpdateProductVariant(c, repository.UpdateProductVariantParams{
+		Sku:       param.Sku,
+		Options:   options,
+		Price:     toNumeric(param.Price),
+		Quantity:  int32(param.Quantity),
+		ID:        variantId,
+		ProductID: productId,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed updating variant with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	span.AddEvent("updated variant in database")
+	logger.Info().Any(constants.KEY_VARIANT, variant).Msg("updated variant in database")
+
+	err = recordVariantMovement(c, svc.queries.WithTx(tx), variant, variant.Quantity-previous.Quantity)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	logger.Info().Msg("committed transaction")
+	span.AddEvent("committed transaction")
+
+	return variant.Response()
+}
+
+func (svc ProductService) RemoveVariant(
+	c context.Context,
+	productId uuid.UUID,
+	variantId uuid.UUID,
+) (response.Variant, error) {
+	c, span := otel.Tracer.Start(c, "ProductService RemoveVariant")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "ProductService RemoveVariant").
+		Str(constants.KEY_PRODUCT_ID, productId.String()).
+		Str(constants.KEY_VARIANT_ID, variantId.String()).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "initializing transaction").Logger()
+	logger.Trace().Msg("initializing transaction")
+	span.AddEvent("initializing transaction")
+	tx, err := svc.pool.BeginTx(c, pgx.TxOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed initializing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	defer func() {
+		err := tx.Rollback(c)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			err = fmt.Errorf("failed rolling back transaction with error=%w", err)
+			inOtel.RecordError(err, span)
+			logger.Error().Err(err).Msg(err.Error())
+		}
+	}()
+	logger.Info().Msg("initialized transaction")
+	span.AddEvent("initialized transaction")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "removing variant in database").Logger()
+	logger.Trace().Msg("removing variant in database")
+	span.AddEvent("removing variant in database")
+	variant, err := svc.queries.WithTx(tx).DeleteProductVariant(c, repository.DeleteProductVariantParams{
+		ID:        variantId,
+		ProductID: productId,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed removing variant with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	span.AddEvent("removed variant in database")
+	logger.Info().Any(constants.KEY_VARIANT, variant).Msg("removed variant in database")
+
+	err = recordVariantMovement(c, svc.queries.WithTx(tx), variant, -variant.Quantity)
+	if err != nil {
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "committing transaction").Logger()
+	logger.Trace().Msg("committing transaction")
+	span.AddEvent("committing transaction")
+	err = tx.Commit(c)
+	if err != nil {
+		err = fmt.Errorf("failed committing transaction with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return response.Variant{}, err
+	}
+	logger.Info().Msg("committed transaction")
+	span.AddEvent("committed transaction")
+
+	return variant.Response()
+}
+
+// lockVariant locks variantId of productId for the rest of tx, a variant of
+// another product does not exist.
+func (svc ProductService) lockVariant(
+	c context.Context,
+	tx pgx.Tx,
+	productId uuid.UUID,
+	variantId uuid.UUID,
+) (repository.ProductVariant, error) {
+	variants, err := svc.queries.WithTx(tx).FindProductVariantsByIdsForUpdate(c, []uuid.UUID{variantId})
+	if err != nil {
+		return repository.ProductVariant{}, fmt.Errorf("failed locking variant with error=%w", err)
+	}
+	if len(variants) == 0 || variants[0].ProductID != productId {
+		return repository.ProductVariant{}, fmt.Errorf("failed locking variant with error=%w", pgx.ErrNoRows)
+	}
+	return variants[0], nil
+}
+
+// recordVariantMovement appends the change of variant quantity by delta to the
+// inventory movements ledger, a change of zero is not recorded.
+func recordVariantMovement(
+	c context.Context,
+	queries *repository.Queries,
+	variant repository.ProductVariant,
+	delta int32,
+) error {
+	if delta == 0 {
+		return nil
+	}
+	_, err := queries.InsertInventoryMovements(
+		c,
+		[]repository.InsertInventoryMovementsParams{variantMovement(c, variant.ProductID, variant.ID, delta)},
+	)
+	if err != nil {
+		return fmt.Errorf("failed inserting variant inventory movement with error=%w", err)
+	}
+	return nil
+}
+
+// attachVariants sets the variants of every product in products, ordered by
+// their sku.
+func (svc ProductService) attachVariants(c context.Context, products []response.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	rows, err := svc.queries.FindProductVariantsByProductIds(c, ids)
+	if err != nil {
+		return fmt.Errorf("failed finding product variants with error=%w", err)
+	}
+	variants := map[uuid.UUID][]response.Variant{}
+	for _, row := range rows {
+		variant, err := row.Response()
+		if err != nil {
+			return fmt.Errorf("failed mapping variantId=%s with error=%w", row.ID, err)
+		}
+		variants[row.ProductID] = append(variants[row.ProductID], variant)
+	}
+	for i := range products {
+		products[i].Variants = variants[products[i].ID]
+		if products[i].Variants == nil {
+			products[i].Variants = []response.Variant{}
+		}
+	}
+	return nil
+}
+
+func variantOptions(param request.Variant) ([]byte, error) {
+	if param.Options == nil {
+		return []byte("{}"), nil
+	}
+	options, err := json.Marshal(param.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed marshalling variant options with error=%w", err)
+	}
+	return options, nil
+}
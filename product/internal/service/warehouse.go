@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/Alturino/ecommerce/internal/constants"
+	inOtel "github.com/Alturino/ecommerce/internal/otel"
+	"github.com/Alturino/ecommerce/internal/repository"
+	"github.com/Alturino/ecommerce/product/internal/otel"
+	"github.com/Alturino/ecommerce/product/pkg/request"
+)
+
+type WarehouseService struct {
+	queries *repository.Queries
+}
+
+func NewWarehouseService(queries *repository.Queries) WarehouseService {
+	return WarehouseService{queries: queries}
+}
+
+func (svc WarehouseService) InsertWarehouse(
+	c context.Context,
+	param request.Warehouse,
+) (repository.Warehouse, error) {
+	c, span := otel.Tracer.Start(c, "WarehouseService InsertWarehouse")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "WarehouseService InsertWarehouse").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting warehouse to database").Logger()
+	logger.Trace().Msg("inserting warehouse to database")
+	span.AddEvent("inserting warehouse to database")
+	warehouse, err := svc.queries.InsertWarehouse(c, repository.InsertWarehouseParams{
+		Name:      param.Name,
+		Latitude:  param.Latitude,
+		Longitude: param.Longitude,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed inserting warehouse with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.Warehouse{}, err
+	}
+	span.AddEvent("inserted warehouse to database")
+	logger.Info().Any(constants.KEY_WAREHOUSE, warehouse).Msg("inserted warehouse to database")
+
+	return warehouse, nil
+}
+
+func (svc WarehouseService) FindWarehouses(c context.Context) ([]repository.Warehouse, error) {
+	c, span := otel.Tracer.Start(c, "WarehouseService FindWarehouses")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "WarehouseService FindWarehouses").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding warehouses in database").Logger()
+	logger.Trace().Msg("finding warehouses in database")
+	span.AddEvent("finding warehouses in database")
+	warehouses, err := svc.queries.FindWarehouses(c)
+	if err != nil {
+		err = fmt.Errorf("failed finding warehouses with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return nil, err
+	}
+	span.AddEvent("found warehouses in database")
+	logger.Info().Int(constants.KEY_WAREHOUSES, len(warehouses)).Msg("found warehouses in database")
+
+	return warehouses, nil
+}
@@ -0,0 +1,37 @@
+package request
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+type Category struct {
+	ParentId *uuid.UUID `                            json:"parent_id"`
+	Name     string     `validate:"required,max=128" json:"name"`
+	Slug     string     `validate:"max=128"          json:"slug"`
+	Position int        `validate:"gte=0"            json:"position"`
+}
+
+// SlugOrDefault returns the slug of the category, it is derived from the name
+// when the client does not send one.
+func (c Category) SlugOrDefault() string {
+	if c.Slug != "" {
+		return Slugify(c.Slug)
+	}
+	return Slugify(c.Name)
+}
+
+// Slugify lowercases s and joins its words with dashes, e.g. "Men's Shoes" is
+// "men-s-shoes".
+func Slugify(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+type ProductCategories struct {
+	CategoryIds []uuid.UUID `validate:"dive,required" json:"category_ids"`
+}
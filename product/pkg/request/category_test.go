@@ -0,0 +1,13 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategorySlugOrDefault(t *testing.T) {
+	assert.Equal(t, "men-s-shoes", Category{Name: "Men's Shoes"}.SlugOrDefault())
+	assert.Equal(t, "boots", Category{Name: "Winter Boots", Slug: " Boots "}.SlugOrDefault())
+	assert.Equal(t, "", Category{Name: "!!"}.SlugOrDefault())
+}
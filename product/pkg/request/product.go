@@ -1,17 +1,117 @@
 package request
 
 import (
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
 type Product struct {
-	Name     string          `validate:"required" json:"name"`
-	Price    decimal.Decimal `validate:"required" json:"price"`
-	Quantity int             `validate:"required" json:"quantity"`
+	Name           string          `validate:"required"                      json:"name"`
+	Description    string          `                                         json:"description"`
+	Price          decimal.Decimal `validate:"required"                      json:"price"`
+	Quantity       int             `validate:"gte=0"                         json:"quantity"`
+	Backorderable  bool            `                                         json:"backorderable"`
+	Preorderable   bool            `                                         json:"preorderable"`
+	ReleaseDate    *time.Time      `validate:"required_if=Preorderable true" json:"release_date"`
+	BackorderLimit int             `validate:"gte=0"                         json:"backorder_limit"`
 }
 
+const (
+	SortRelevance = "relevance"
+	SortNewest    = "newest"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+)
+
 type FindProduct struct {
-	Name     string
-	MinPrice *decimal.Decimal `validate:"numeric"`
-	MaxPrice *decimal.Decimal `validate:"numeric"`
+	Name       string
+	MinPrice   *decimal.Decimal
+	MaxPrice   *decimal.Decimal
+	InStock    bool
+	CategoryId *uuid.UUID
+	Sort       string `validate:"omitempty,oneof=relevance newest price_asc price_desc"`
+}
+
+// SortBy returns the order of the search results, it defaults to relevance when
+// there is a search term and to newest otherwise.
+func (f FindProduct) SortBy() string {
+	if f.Sort == SortRelevance && f.Name == "" {
+		return SortNewest
+	}
+	if f.Sort != "" {
+		return f.Sort
+	}
+	if f.Name != "" {
+		return SortRelevance
+	}
+	return SortNewest
+}
+
+// Variant is one purchasable option of a product, e.g. a size and a colour. A
+// variant without a price is sold at the price of its product.
+type Variant struct {
+	Sku      string            `validate:"required,max=64" json:"sku"`
+	Options  map[string]string `                           json:"options"`
+	Price    *decimal.Decimal  `                           json:"price"`
+	Quantity int               `validate:"gte=0"           json:"quantity"`
+}
+
+// Price schedules a price of a product. A price without EffectiveFrom takes
+// effect right away and a price without EffectiveTo lasts until the next
+// scheduled price.
+type Price struct {
+	Price         decimal.Decimal `validate:"required" json:"price"`
+	EffectiveFrom *time.Time      `                    json:"effective_from"`
+	EffectiveTo   *time.Time      `                    json:"effective_to"`
+}
+
+// ExchangeRate is how many units of a currency one unit of the base currency
+// buys.
+type ExchangeRate struct {
+	Rate decimal.Decimal `validate:"required" json:"rate"`
+}
+
+type InventoryLevel struct {
+	Quantity int `validate:"gte=0" json:"quantity"`
+}
+
+// StockAdjustment changes the stock of a product by Delta, in the default
+// warehouse when WarehouseId is missing.
+type StockAdjustment struct {
+	Delta       int32      `validate:"required"                                 json:"delta"`
+	Reason      string     `validate:"required,oneof=RESTOCK RETURN ADJUSTMENT" json:"reason"`
+	WarehouseId *uuid.UUID `                                                    json:"warehouse_id"`
+}
+
+const (
+	SortReviewNewest  = "newest"
+	SortReviewHelpful = "helpful"
+)
+
+// Review is a review of a product, MediaUrls link images or videos the reviewer
+// uploaded elsewhere.
+type Review struct {
+	Rating    int32    `validate:"required,gte=1,lte=5"    json:"rating"`
+	Body      string   `validate:"max=5000"                json:"body"`
+	MediaUrls []string `validate:"max=5,dive,required,url" json:"media_urls"`
+}
+
+// ReviewStatus moderates a review, an approved review can still be rejected and
+// a rejected one approved.
+type ReviewStatus struct {
+	Status string `validate:"required,oneof=APPROVED REJECTED" json:"status"`
+}
+
+// StockAlert sets the quantity at or below which a product is low on stock, a
+// nil LowStockThreshold falls back to the configured default.
+type StockAlert struct {
+	LowStockThreshold *int32 `validate:"omitempty,gte=0" json:"low_stock_threshold"`
+}
+
+type Warehouse struct {
+	Name      string  `validate:"required"         json:"name"`
+	Latitude  float64 `validate:"gte=-90,lte=90"   json:"latitude"`
+	Longitude float64 `validate:"gte=-180,lte=180" json:"longitude"`
 }
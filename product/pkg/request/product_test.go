@@ -0,0 +1,14 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindProductSortBy(t *testing.T) {
+	assert.Equal(t, SortNewest, FindProduct{}.SortBy())
+	assert.Equal(t, SortRelevance, FindProduct{Name: "shoe"}.SortBy())
+	assert.Equal(t, SortPriceAsc, FindProduct{Name: "shoe", Sort: SortPriceAsc}.SortBy())
+	assert.Equal(t, SortNewest, FindProduct{Sort: SortRelevance}.SortBy(), "relevance needs a search term")
+}
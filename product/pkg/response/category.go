@@ -0,0 +1,26 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Category struct {
+	ID        uuid.UUID  `json:"id"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	Position  int32      `json:"position"`
+	Children  []Category `json:"children,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Breadcrumb is one category of the path from a root category to the category
+// a product belongs to.
+type Breadcrumb struct {
+	ID   uuid.UUID `json:"id"   redis:"id"`
+	Name string    `json:"name" redis:"name"`
+	Slug string    `json:"slug" redis:"slug"`
+}
@@ -0,0 +1,15 @@
+package response
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate converts an amount in BaseCurrency to QuoteCurrency.
+type ExchangeRate struct {
+	BaseCurrency  string          `json:"base_currency"`
+	QuoteCurrency string          `json:"quote_currency"`
+	Rate          decimal.Decimal `json:"rate"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
@@ -8,10 +8,61 @@ import (
 )
 
 type Product struct {
-	ID        uuid.UUID       `json:"id"         redis:"id"`
-	Name      string          `json:"name"       redis:"name"`
-	Price     decimal.Decimal `json:"price"      redis:"price"`
-	Quantity  int32           `json:"quantity"   redis:"quantity"`
-	CreatedAt time.Time       `json:"created_at" redis:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at" redis:"updated_at"`
+	ID             uuid.UUID       `json:"id"              redis:"id"`
+	Name           string          `json:"name"            redis:"name"`
+	Description    string          `json:"description"     redis:"description"`
+	Price          decimal.Decimal `json:"price"           redis:"price"`
+	Currency       string          `json:"currency"        redis:"currency"`
+	Quantity       int32           `json:"quantity"        redis:"quantity"`
+	Backorderable  bool            `json:"backorderable"   redis:"backorderable"`
+	Preorderable   bool            `json:"preorderable"    redis:"preorderable"`
+	ReleaseDate    *time.Time      `json:"release_date"    redis:"release_date"`
+	BackorderLimit int32           `json:"backorder_limit" redis:"backorder_limit"`
+	CreatedAt      time.Time       `json:"created_at"      redis:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"      redis:"updated_at"`
+	ArchivedAt     *time.Time      `json:"archived_at"     redis:"archived_at"`
+	Rating         decimal.Decimal `json:"rating"          redis:"rating"`
+	RatingCount    int32           `json:"rating_count"    redis:"rating_count"`
+	Breadcrumbs    [][]Breadcrumb  `json:"breadcrumbs"     redis:"breadcrumbs"`
+	Variants       []Variant       `json:"variants"        redis:"variants"`
+	Media          []Media         `json:"media"           redis:"media"`
+}
+
+// Media is an image of a product, Thumbnails maps the size of the longest side
+// of a thumbnail to its URL.
+type Media struct {
+	ID          uuid.UUID         `json:"id"           redis:"id"`
+	URL         string            `json:"url"          redis:"url"`
+	ContentType string            `json:"content_type" redis:"content_type"`
+	Size        int64             `json:"size"         redis:"size"`
+	Width       int32             `json:"width"        redis:"width"`
+	Height      int32             `json:"height"       redis:"height"`
+	Thumbnails  map[string]string `json:"thumbnails"   redis:"thumbnails"`
+	CreatedAt   time.Time         `json:"created_at"   redis:"created_at"`
+}
+
+// Price is the price of a product from EffectiveFrom until EffectiveTo, a
+// price without EffectiveTo lasts until a new price is scheduled. Prices are
+// in Currency, the base currency of the catalog.
+type Price struct {
+	ID            uuid.UUID       `json:"id"             redis:"id"`
+	ProductID     uuid.UUID       `json:"product_id"     redis:"product_id"`
+	Price         decimal.Decimal `json:"price"          redis:"price"`
+	Currency      string          `json:"currency"       redis:"currency"`
+	EffectiveFrom time.Time       `json:"effective_from" redis:"effective_from"`
+	EffectiveTo   *time.Time      `json:"effective_to"   redis:"effective_to"`
+	CreatedAt     time.Time       `json:"created_at"     redis:"created_at"`
+}
+
+// Variant is a purchasable option of a product, Price overrides the price of
+// the product and is in the same currency.
+type Variant struct {
+	ID        uuid.UUID         `json:"id"         redis:"id"`
+	ProductID uuid.UUID         `json:"product_id" redis:"product_id"`
+	Sku       string            `json:"sku"        redis:"sku"`
+	Options   map[string]string `json:"options"    redis:"options"`
+	Price     *decimal.Decimal  `json:"price"      redis:"price"`
+	Quantity  int32             `json:"quantity"   redis:"quantity"`
+	CreatedAt time.Time         `json:"created_at" redis:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" redis:"updated_at"`
 }
@@ -0,0 +1,14 @@
+package response
+
+const (
+	RecommendationBoughtTogether     = "BoughtTogether"
+	RecommendationCategoryBestseller = "CategoryBestseller"
+)
+
+// Recommendation is a product recommended alongside another one, Reason is
+// BoughtTogether when customers ordered both products or CategoryBestseller
+// when there are too few co-purchases and it sells best in the same category.
+type Recommendation struct {
+	Reason  string  `json:"reason"`
+	Product Product `json:"product"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Review struct {
+	ID           uuid.UUID  `json:"id"`
+	ProductId    uuid.UUID  `json:"product_id"`
+	UserId       uuid.UUID  `json:"user_id"`
+	Rating       int32      `json:"rating"`
+	Body         string     `json:"body"`
+	MediaUrls    []string   `json:"media_urls"`
+	Status       string     `json:"status"`
+	HelpfulCount int32      `json:"helpful_count"`
+	ModeratedAt  *time.Time `json:"moderated_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
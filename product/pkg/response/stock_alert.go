@@ -0,0 +1,24 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StockAlertLowStock    = "LowStock"
+	StockAlertSoldOut     = "SoldOut"
+	StockAlertBackInStock = "BackInStock"
+)
+
+// StockAlert is published when the stock level of a product changes, Kind is
+// one of LowStock, SoldOut or BackInStock.
+type StockAlert struct {
+	At        time.Time `json:"at"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	ProductId uuid.UUID `json:"product_id"`
+	Quantity  int32     `json:"quantity"`
+	Threshold int32     `json:"threshold"`
+}
@@ -0,0 +1,31 @@
+-- name: FindAllocatableBackorders :many
+select b.*
+from backorders as b
+inner join products as p on b.product_id = p.id
+where
+    b.allocated_at is null
+    and (p.release_date is null or p.release_date <= now())
+order by b.created_at, b.id
+limit $1
+for update of b skip locked;
+
+-- name: FindPendingBackorderQuantities :many
+select
+    product_id,
+    coalesce(sum(quantity), 0)::integer as quantity
+from backorders
+where product_id = any($1::uuid []) and allocated_at is null
+group by product_id;
+
+-- name: InsertBackorders :copyfrom
+insert into backorders (order_id, order_item_id, product_id, quantity, created_at) values (
+    $1, $2, $3, $4, $5
+);
+
+-- name: UpdateBackordersAllocated :exec
+update backorders set allocated_at = now()
+where id = any($1::uuid []);
+
+-- name: DeletePendingBackordersByOrderIds :exec
+delete from backorders
+where order_id = any($1::uuid []) and allocated_at is null;
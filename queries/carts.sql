@@ -4,11 +4,12 @@ insert into carts (user_id) values ($1) returning *;
 -- name: FindCartById :one
 select
     c.*,
-    json_agg(to_json(ci.*)) as cart_items
-from users as u
-inner join carts as c on u.id = c.user_id
-inner join cart_items as ci on c.id = ci.cart_id
-where u.id = $1 and c.id = $2
+    coalesce(json_agg(to_json(ci.*)) filter (where ci.id is not null), '[]')::json as cart_items
+from carts as c
+left join cart_items as ci on c.id = ci.cart_id
+where
+    c.id = sqlc.arg(id)
+    and (sqlc.narg(user_id)::uuid is null or c.user_id = sqlc.narg(user_id))
 group by c.id, c.user_id, c.created_at, c.updated_at;
 
 -- name: FindCartByUserId :many
@@ -20,6 +21,22 @@ inner join carts as c on u.id = c.user_id
 inner join cart_items as ci on c.id = ci.cart_id
 where u.id = $1;
 
+-- name: FindCartsByUserId :many
+select
+    c.*,
+    coalesce(json_agg(to_json(ci.*)) filter (where ci.id is not null), '[]')::json as cart_items
+from carts as c
+left join cart_items as ci on c.id = ci.cart_id
+where
+    c.user_id = sqlc.arg(user_id)
+    and (
+        sqlc.narg(cursor_id)::uuid is null
+        or (c.created_at, c.id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+    )
+group by c.id, c.user_id, c.created_at, c.updated_at
+order by c.created_at desc, c.id desc
+limit sqlc.arg(result_limit);
+
 -- name: FindCartItemById :one
 select * from cart_items
 where id = $1;
@@ -37,11 +54,46 @@ delete from cart_items
 where id = $1 and cart_id = $2 returning *;
 
 -- name: InsertCartItem :one
-insert into cart_items (id, cart_id, product_id, quantity, price) values (
-    $1, $2, $3, $4, $5
+insert into cart_items (id, cart_id, product_id, quantity, price, variant_id, currency) values (
+    $1, $2, $3, $4, $5, $6, $7
 ) returning *;
 
 -- name: InsertCartItems :copyfrom
-insert into cart_items (id, cart_id, product_id, quantity, price) values (
-    $1, $2, $3, $4, $5
+insert into cart_items (id, cart_id, product_id, quantity, price, variant_id, currency) values (
+    $1, $2, $3, $4, $5, $6, $7
 );
+
+-- name: FindActiveCartByUserId :one
+select
+    c.*,
+    coalesce(json_agg(to_json(ci.*)) filter (where ci.id is not null), '[]')::json as cart_items
+from active_carts as ac
+inner join carts as c on ac.cart_id = c.id
+left join cart_items as ci on c.id = ci.cart_id
+where ac.user_id = $1
+group by c.id, c.user_id, c.created_at, c.updated_at;
+
+-- name: FindActiveCartIdByUserIdForUpdate :one
+select cart_id from active_carts
+where user_id = $1
+for update;
+
+-- name: InsertActiveCart :one
+insert into active_carts (user_id, cart_id) values ($1, $2)
+on conflict (user_id) do nothing
+returning cart_id;
+
+-- name: UpdateCartItemQuantity :one
+update cart_items set
+    quantity = $1,
+    updated_at = now()
+where id = $2 and cart_id = $3
+returning *;
+
+-- name: DeleteCartItemsByCartId :exec
+delete from cart_items
+where cart_id = $1;
+
+-- name: TouchCart :exec
+update carts set updated_at = now()
+where id = $1;
@@ -0,0 +1,72 @@
+-- name: InsertCategory :one
+insert into categories (parent_id, name, slug, position) values ($1, $2, $3, $4) returning *;
+
+-- name: FindCategories :many
+select * from categories
+order by position, name;
+
+-- name: FindCategoryById :one
+select * from categories
+where id = $1;
+
+-- name: UpdateCategory :one
+update categories set
+    parent_id = $1,
+    name = $2,
+    slug = $3,
+    position = $4,
+    updated_at = now()
+where id = $5 returning *;
+
+-- name: DeleteCategory :one
+delete from categories
+where id = $1 returning *;
+
+-- name: FindCategoryDescendantIds :many
+with recursive tree as (
+    select categories.id from categories
+    where categories.id = $1
+    union all
+    select c.id from categories as c
+    inner join tree as t on c.parent_id = t.id
+)
+
+select id from tree;
+
+-- name: FindProductBreadcrumbs :many
+with recursive trail as (
+    select
+        pc.product_id,
+        pc.category_id as leaf_id,
+        c.id,
+        c.parent_id,
+        c.name,
+        c.slug,
+        0 as depth
+    from product_categories as pc
+    inner join categories as c on pc.category_id = c.id
+    where pc.product_id = any(sqlc.arg(product_ids)::uuid [])
+    union all
+    select
+        t.product_id,
+        t.leaf_id,
+        c.id,
+        c.parent_id,
+        c.name,
+        c.slug,
+        t.depth + 1
+    from trail as t
+    inner join categories as c on t.parent_id = c.id
+)
+
+select product_id, leaf_id, id, name, slug from trail
+order by product_id, leaf_id, depth desc;
+
+-- name: InsertProductCategories :exec
+insert into product_categories (product_id, category_id)
+select sqlc.arg(product_id)::uuid, unnest(sqlc.arg(category_ids)::uuid [])
+on conflict do nothing;
+
+-- name: DeleteProductCategories :exec
+delete from product_categories
+where product_id = $1;
@@ -0,0 +1,11 @@
+-- name: FindExchangeRates :many
+select * from exchange_rates
+where base_currency = $1
+order by quote_currency;
+
+-- name: UpsertExchangeRate :one
+insert into exchange_rates (base_currency, quote_currency, rate) values ($1, $2, $3)
+on conflict (base_currency, quote_currency) do update set
+    rate = excluded.rate,
+    updated_at = now()
+returning *;
@@ -0,0 +1,72 @@
+-- name: FindInventoryLevelsByProductId :many
+select * from inventory_levels
+where product_id = $1
+order by warehouse_id;
+
+-- name: FindInventoryLevelsByProductIdsForUpdate :many
+select * from inventory_levels
+where product_id = any($1::uuid [])
+order by product_id, warehouse_id
+for update;
+
+-- name: FindWarehousedQuantity :one
+select coalesce(sum(il.quantity), 0)::integer as quantity
+from inventory_levels as il
+inner join warehouses as w on il.warehouse_id = w.id
+where il.product_id = $1 and not w.is_default;
+
+-- name: UpsertInventoryLevel :one
+insert into inventory_levels (product_id, warehouse_id, quantity) values ($1, $2, $3)
+on conflict (product_id, warehouse_id) do update set
+    quantity = excluded.quantity,
+    updated_at = now()
+returning *;
+
+-- name: SyncDefaultInventoryLevel :exec
+insert into inventory_levels (product_id, warehouse_id, quantity)
+select
+    p.id,
+    w.id,
+    p.quantity - coalesce((
+        select sum(il.quantity)
+        from inventory_levels as il
+        where il.product_id = p.id and il.warehouse_id <> w.id
+    ), 0)::integer
+from products as p
+cross join warehouses as w
+where p.id = $1 and w.is_default
+on conflict (product_id, warehouse_id) do update set
+    quantity = excluded.quantity,
+    updated_at = now();
+
+-- name: DecreaseInventoryLevels :exec
+update inventory_levels as il set
+    quantity = il.quantity - d.quantity,
+    updated_at = now()
+from (
+    select
+        unnest(sqlc.arg(product_ids)::uuid []) as product_id,
+        unnest(sqlc.arg(warehouse_ids)::uuid []) as warehouse_id,
+        unnest(sqlc.arg(quantities)::integer []) as quantity
+) as d
+where il.product_id = d.product_id and il.warehouse_id = d.warehouse_id;
+
+-- name: AdjustInventoryLevel :one
+insert into inventory_levels (product_id, warehouse_id, quantity)
+values (sqlc.arg(product_id), sqlc.arg(warehouse_id), sqlc.arg(delta)::integer)
+on conflict (product_id, warehouse_id) do update set
+    quantity = inventory_levels.quantity + excluded.quantity,
+    updated_at = now()
+returning *;
+
+-- name: IncreaseInventoryLevels :exec
+update inventory_levels as il set
+    quantity = il.quantity + d.quantity,
+    updated_at = now()
+from (
+    select
+        unnest(sqlc.arg(product_ids)::uuid []) as product_id,
+        unnest(sqlc.arg(warehouse_ids)::uuid []) as warehouse_id,
+        unnest(sqlc.arg(quantities)::integer []) as quantity
+) as d
+where il.product_id = d.product_id and il.warehouse_id = d.warehouse_id;
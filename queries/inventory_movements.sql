@@ -0,0 +1,23 @@
+-- name: InsertInventoryMovements :copyfrom
+insert into inventory_movements (
+    product_id, variant_id, warehouse_id, quantity, reason, reference_id, actor, created_at
+) values ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: FindInventoryMovementsByProductId :many
+select * from inventory_movements
+where
+    product_id = sqlc.arg(product_id)
+    and (
+        sqlc.narg(cursor_id)::uuid is null
+        or (created_at, id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+    )
+order by created_at desc, id desc
+limit sqlc.arg(result_limit);
+
+-- name: FindStockAsOf :one
+select coalesce(sum(quantity), 0)::integer as quantity
+from inventory_movements
+where
+    product_id = sqlc.arg(product_id)
+    and variant_id is not distinct from sqlc.narg(variant_id)::uuid
+    and created_at <= sqlc.arg(created_at);
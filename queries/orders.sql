@@ -5,10 +5,11 @@ insert into orders (id, user_id) values ($1, $2) returning *;
 select
     o.*,
     json_agg(to_json(oi.*)) as order_items
-from users as u
-inner join orders as o on u.id = o.user_id
+from orders as o
 inner join order_items as oi on o.id = oi.order_id
-where u.id = $1 and o.id = $2
+where
+    o.id = sqlc.arg(id)
+    and (sqlc.narg(user_id)::uuid is null or o.user_id = sqlc.narg(user_id))
 group by o.id, o.user_id, o.created_at, o.updated_at;
 
 -- name: FindOrderItemById :many
@@ -25,6 +26,17 @@ from users as u
 inner join orders as o on u.id = o.user_id
 where u.id = $1;
 
+-- name: FindOrdersByUserId :many
+select * from orders
+where
+    user_id = sqlc.arg(user_id)
+    and (
+        sqlc.narg(cursor_id)::uuid is null
+        or (created_at, id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+    )
+order by created_at desc, id desc
+limit sqlc.arg(result_limit);
+
 -- name: FindOrderItemByIdAndUserId :many
 select oi.*
 from orders as o
@@ -37,14 +49,14 @@ delete from order_items
 where id = $1 returning *;
 
 -- name: InsertOrders :copyfrom
-insert into orders (id, user_id, created_at, updated_at) values (
-    $1, $2, $3, $4
-);
+insert into orders (
+    id, user_id, status, created_at, updated_at, currency, presentment_currency, exchange_rate
+) values ($1, $2, $3, $4, $5, $6, $7, $8);
 
 -- name: InsertOrderItem :copyfrom
 insert into order_items (
-    id, order_id, product_id, quantity, price, created_at, updated_at
-) values ($1, $2, $3, $4, $5, $6, $7);
+    id, order_id, product_id, quantity, price, created_at, updated_at, variant_id, currency
+) values ($1, $2, $3, $4, $5, $6, $7, $8, $9);
 
 -- name: GetOrders :many
 select
@@ -54,3 +66,42 @@ from orders as o
 inner join order_items as oi on o.id = oi.order_id
 where o.id = any($1::uuid [])
 group by o.id, o.user_id, o.created_at, o.updated_at;
+
+-- name: InsertDeadLetterOrder :one
+insert into dead_letter_orders (order_id, user_id, payload, error, attempts) values (
+    $1, $2, $3, $4, $5
+) returning *;
+
+-- name: UpdateAllocatedOrders :many
+update orders set status = 'WAITING_PAYMENT', updated_at = now()
+where
+    id = any($1::uuid [])
+    and status in ('BACKORDERED', 'PREORDER')
+    and not exists (
+        select 1 from backorders as b
+        where b.order_id = orders.id and b.allocated_at is null
+    )
+returning *;
+
+-- name: CancelOrder :one
+update orders set status = 'CANCELLED', updated_at = now()
+where
+    id = sqlc.arg(id)
+    and (sqlc.narg(user_id)::uuid is null or user_id = sqlc.narg(user_id))
+    and status in ('WAITING_PAYMENT', 'BACKORDERED', 'PREORDER')
+returning *;
+
+-- name: ExpireOrders :many
+update orders set status = 'EXPIRED', updated_at = now()
+where id in (
+    select o.id from orders as o
+    where o.status = 'WAITING_PAYMENT' and o.updated_at <= sqlc.arg(updated_before)
+    order by o.updated_at, o.id
+    limit sqlc.arg(result_limit)
+    for update skip locked
+)
+returning *;
+
+-- name: FindOrderItemsByOrderIds :many
+select * from order_items
+where order_id = any($1::uuid []);
@@ -0,0 +1,13 @@
+-- name: InsertProductMedia :one
+insert into product_media (product_id, key, content_type, size, width, height, thumbnails) values (
+    $1, $2, $3, $4, $5, $6, $7
+) returning *;
+
+-- name: FindProductMediaByProductIds :many
+select * from product_media
+where product_id = any($1::uuid [])
+order by product_id, created_at, id;
+
+-- name: DeleteProductMedia :one
+delete from product_media
+where id = $1 and product_id = $2 returning *;
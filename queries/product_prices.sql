@@ -0,0 +1,57 @@
+-- name: InsertProductPrice :one
+insert into product_prices (product_id, price, effective_from, effective_to) values (
+    $1, $2, $3, $4
+) returning *;
+
+-- name: FindProductPricesByProductId :many
+select * from product_prices
+where product_id = $1
+order by effective_from desc;
+
+-- name: FindOpenProductPricesForUpdate :many
+select * from product_prices
+where
+    product_id = sqlc.arg(product_id)
+    and (effective_to is null or effective_to > sqlc.arg(at)::timestamptz)
+order by effective_from
+for update;
+
+-- name: FindProductPriceAt :one
+select * from product_prices
+where
+    product_id = sqlc.arg(product_id)
+    and effective_from <= sqlc.arg(at)::timestamptz
+    and (effective_to is null or effective_to > sqlc.arg(at)::timestamptz);
+
+-- name: FindCurrentProductPrices :many
+select * from product_prices
+where
+    product_id = any(sqlc.arg(product_ids)::uuid [])
+    and effective_from <= now()
+    and (effective_to is null or effective_to > now());
+
+-- name: FindNextProductPriceChange :one
+select min(effective_from)::timestamptz as effective_from from product_prices
+where effective_from > now();
+
+-- name: UpdateProductPriceRange :one
+update product_prices set
+    effective_from = $1,
+    effective_to = $2
+where id = $3 returning *;
+
+-- name: DeleteProductPrice :exec
+delete from product_prices
+where id = $1;
+
+-- name: ActivateProductPrices :many
+update products set
+    price = pp.price,
+    updated_at = now()
+from product_prices as pp
+where
+    pp.product_id = products.id
+    and pp.effective_from <= now()
+    and (pp.effective_to is null or pp.effective_to > now())
+    and products.price <> pp.price
+returning products.*;
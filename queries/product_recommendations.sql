@@ -0,0 +1,78 @@
+-- name: DeleteProductCoPurchases :exec
+delete from product_co_purchases;
+
+-- name: InsertProductCoPurchases :execrows
+insert into product_co_purchases (product_id, related_product_id, score)
+select
+    a.product_id,
+    b.product_id as related_product_id,
+    count(distinct a.order_id)::integer as score
+from order_items as a
+inner join order_items as b on a.order_id = b.order_id and a.product_id <> b.product_id
+inner join orders as o on a.order_id = o.id
+where o.status = 'COMPLETED' and o.created_at >= sqlc.arg(since)::timestamptz
+group by a.product_id, b.product_id
+on conflict (product_id, related_product_id) do update set
+    score = excluded.score,
+    updated_at = now();
+
+-- name: FindFrequentlyBoughtTogether :many
+select
+    p.id,
+    p.name,
+    p.price,
+    p.quantity,
+    p.created_at,
+    p.updated_at,
+    p.backorderable,
+    p.preorderable,
+    p.release_date,
+    p.backorder_limit,
+    p.description,
+    p.archived_at
+from product_co_purchases as cp
+inner join products as p on cp.related_product_id = p.id
+where cp.product_id = sqlc.arg(product_id) and p.archived_at is null and p.quantity > 0
+order by cp.score desc, p.id
+limit sqlc.arg(result_limit);
+
+-- name: FindCategoryBestsellers :many
+with sold as (
+    select
+        oi.product_id,
+        sum(oi.quantity)::integer as quantity
+    from order_items as oi
+    inner join orders as o on oi.order_id = o.id
+    where o.status = 'COMPLETED'
+    group by oi.product_id
+)
+
+select
+    p.id,
+    p.name,
+    p.price,
+    p.quantity,
+    p.created_at,
+    p.updated_at,
+    p.backorderable,
+    p.preorderable,
+    p.release_date,
+    p.backorder_limit,
+    p.description,
+    p.archived_at
+from products as p
+left join sold as s on p.id = s.product_id
+where
+    p.id in (
+        select pc.product_id from product_categories as pc
+        where pc.category_id in (
+            select category_id from product_categories
+            where product_id = sqlc.arg(product_id)
+        )
+    )
+    and p.id <> sqlc.arg(product_id)
+    and p.id <> all(sqlc.arg(excluded_ids)::uuid [])
+    and p.archived_at is null
+    and p.quantity > 0
+order by coalesce(s.quantity, 0) desc, p.id
+limit sqlc.arg(result_limit);
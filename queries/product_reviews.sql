@@ -0,0 +1,68 @@
+-- name: FindCompletedOrderIdByProduct :one
+select o.id from orders as o
+inner join order_items as oi on o.id = oi.order_id
+where o.user_id = $1 and oi.product_id = $2 and o.status = 'COMPLETED'
+order by o.created_at desc
+limit 1;
+
+-- name: InsertProductReview :one
+insert into product_reviews (
+    product_id, user_id, order_id, rating, body, media_urls
+) values ($1, $2, $3, $4, $5, $6)
+on conflict (product_id, user_id) do nothing
+returning *;
+
+-- name: FindProductReviewByIdForUpdate :one
+select * from product_reviews
+where id = $1 and product_id = $2
+for update;
+
+-- name: UpdateProductReviewStatus :one
+update product_reviews set
+    status = $2,
+    moderated_at = now(),
+    updated_at = now()
+where id = $1
+returning *;
+
+-- name: FindApprovedProductReviews :many
+select * from product_reviews
+where
+    product_id = sqlc.arg(product_id)
+    and status = 'APPROVED'
+    and (
+        sqlc.narg(cursor_id)::uuid is null
+        or (
+            sqlc.arg(sort_by)::text = 'newest'
+            and (created_at, id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid)
+        )
+        or (
+            sqlc.arg(sort_by)::text = 'helpful'
+            and (helpful_count, id) < (sqlc.narg(cursor_helpful_count)::integer, sqlc.narg(cursor_id)::uuid)
+        )
+    )
+order by
+    case when sqlc.arg(sort_by)::text = 'helpful' then helpful_count end desc,
+    case when sqlc.arg(sort_by)::text = 'newest' then created_at end desc,
+    id desc
+limit sqlc.arg(result_limit);
+
+-- name: InsertProductReviewVote :execrows
+insert into product_review_votes (review_id, user_id) values ($1, $2)
+on conflict (review_id, user_id) do nothing;
+
+-- name: IncrementProductReviewHelpfulCount :one
+update product_reviews set helpful_count = helpful_count + 1
+where id = $1
+returning *;
+
+-- name: AddProductRating :exec
+insert into product_ratings (product_id, rating_count, rating_sum) values ($1, $2, $3)
+on conflict (product_id) do update set
+    rating_count = product_ratings.rating_count + excluded.rating_count,
+    rating_sum = product_ratings.rating_sum + excluded.rating_sum,
+    updated_at = now();
+
+-- name: FindProductRatingsByProductIds :many
+select * from product_ratings
+where product_id = any($1::uuid []);
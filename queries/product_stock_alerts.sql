@@ -0,0 +1,28 @@
+-- name: FindStockAlertStatesForUpdate :many
+select
+    p.id as product_id,
+    p.name,
+    p.quantity,
+    a.low_stock_threshold,
+    a.level
+from products as p
+left join product_stock_alerts as a on p.id = a.product_id
+where p.id = any(sqlc.arg(product_ids)::uuid []) and p.archived_at is null
+order by p.id
+for update of p;
+
+-- name: UpdateStockLevel :exec
+insert into product_stock_alerts (product_id, level) values ($1, $2)
+on conflict (product_id) do update set
+    level = excluded.level,
+    updated_at = now();
+
+-- name: UpsertLowStockThreshold :one
+insert into product_stock_alerts (product_id, low_stock_threshold) values ($1, $2)
+on conflict (product_id) do update set
+    low_stock_threshold = excluded.low_stock_threshold,
+    updated_at = now()
+returning *;
+
+-- name: FindProductStockAlert :one
+select * from product_stock_alerts where product_id = $1;
@@ -0,0 +1,45 @@
+-- name: InsertProductVariant :one
+insert into product_variants (product_id, sku, options, price, quantity) values (
+    $1, $2, $3, $4, $5
+) returning *;
+
+-- name: FindProductVariantsByProductIds :many
+select * from product_variants
+where product_id = any($1::uuid [])
+order by product_id, sku;
+
+-- name: FindProductVariantsByIds :many
+select * from product_variants
+where id = any($1::uuid []);
+
+-- name: FindProductVariantsByIdsForUpdate :many
+select * from product_variants
+where id = any($1::uuid []) for update;
+
+-- name: FindProductVariantBySkuForUpdate :one
+select * from product_variants
+where sku = $1 for update;
+
+-- name: UpdateProductVariant :one
+update product_variants set
+    sku = $1,
+    options = $2,
+    price = $3,
+    quantity = $4,
+    updated_at = now()
+where id = $5 and product_id = $6 returning *;
+
+-- name: DeleteProductVariant :one
+delete from product_variants
+where id = $1 and product_id = $2 returning *;
+
+-- name: IncreaseProductVariantQuantities :exec
+update product_variants as pv set
+    quantity = pv.quantity + d.quantity,
+    updated_at = now()
+from (
+    select
+        unnest(sqlc.arg(variant_ids)::uuid []) as id,
+        unnest(sqlc.arg(quantities)::integer []) as quantity
+) as d
+where pv.id = d.id;
@@ -2,31 +2,174 @@
 select * from products;
 
 -- name: InsertProduct :one
-insert into products (name, price, quantity) values ($1, $2, $3) returning *;
+insert into products (
+    name, price, quantity, backorderable, preorderable, release_date, backorder_limit, description
+) values ($1, $2, $3, $4, $5, $6, $7, $8) returning *;
 
 -- name: FindProductById :one
 select * from products
 where id = $1;
 
+-- name: FindProductByIdForUpdate :one
+select * from products
+where id = $1 for update;
+
 -- name: FindProducts :many
 select * from products;
 
+-- name: FindProductsAfterName :many
+select * from products
+where name > $1
+order by name
+limit $2;
+
+-- name: FindProductsUpdatedAfter :many
+select * from products
+where (updated_at, id) > (sqlc.arg(updated_at)::timestamptz, sqlc.arg(id)::uuid)
+order by updated_at, id
+limit sqlc.arg(result_limit);
+
 -- name: FindProductByName :one
 select * from products
 where name = $1;
 
+-- name: FindProductByNameForUpdate :one
+select * from products
+where name = $1 for update;
+
 -- name: UpdateProduct :one
-update products set name = $1, price = $2, quantity = $3, updated_at = now()
-where id = $4 returning *;
+update products set
+    name = $1,
+    price = $2,
+    quantity = $3,
+    backorderable = $4,
+    preorderable = $5,
+    release_date = $6,
+    backorder_limit = $7,
+    description = $8,
+    updated_at = now()
+where id = $9 returning *;
 
--- name: UpdateProductQuantity :one
-update products set quantity = $2
-where id = $1 returning *;
+-- name: DecreaseProductQuantity :one
+update products set quantity = quantity - sqlc.arg(quantity)::integer, updated_at = now()
+where id = sqlc.arg(id) returning *;
 
--- name: DeleteProduct :one
-delete from products
-where id = $1 returning *;
+-- name: ArchiveProduct :one
+update products set archived_at = now(), updated_at = now()
+where id = $1 and archived_at is null returning *;
+
+-- name: RestoreProduct :one
+update products set archived_at = null, updated_at = now()
+where id = $1 and archived_at is not null returning *;
+
+-- name: FindArchivedProductIds :many
+select id from products
+where id = any($1::uuid []) and archived_at is not null;
 
 -- name: FindProductsByIds :many
 select * from products
 where id = any($1::uuid []);
+
+-- name: FindProductsByIdsForUpdate :many
+select * from products
+where id = any($1::uuid []) for update;
+
+-- name: UpdateProductQuantityFromInventory :one
+update products set
+    quantity = (
+        select coalesce(sum(il.quantity), 0)::integer
+        from inventory_levels as il
+        where il.product_id = $1
+    ),
+    updated_at = now()
+where id = $1 returning *;
+
+-- name: SearchProducts :many
+with recursive category_tree as (
+    select categories.id from categories
+    where categories.id = sqlc.narg(category_id)::uuid
+    union all
+    select c.id from categories as c
+    inner join category_tree as ct on c.parent_id = ct.id
+),
+
+ranked as (
+    select
+        *,
+        ts_rank(
+            to_tsvector('english', name || ' ' || description),
+            websearch_to_tsquery('english', coalesce(sqlc.narg(query)::text, ''))
+        )::real as rank
+    from products
+    where
+        (
+            sqlc.narg(query)::text is null
+            or to_tsvector('english', name || ' ' || description)
+            @@ websearch_to_tsquery('english', sqlc.narg(query)::text)
+        )
+        and (sqlc.narg(min_price)::numeric is null or price >= sqlc.narg(min_price)::numeric)
+        and (sqlc.narg(max_price)::numeric is null or price <= sqlc.narg(max_price)::numeric)
+        and (not sqlc.arg(in_stock)::boolean or quantity > 0)
+        and archived_at is null
+        and (
+            sqlc.narg(category_id)::uuid is null
+            or exists (
+                select 1 from product_categories as pc
+                inner join category_tree as ct on pc.category_id = ct.id
+                where pc.product_id = products.id
+            )
+        )
+)
+
+select * from ranked
+where
+    sqlc.narg(cursor_id)::uuid is null
+    or (
+        sqlc.arg(sort_by)::text = 'price_asc'
+        and (
+            price > sqlc.narg(cursor_price)::numeric
+            or (price = sqlc.narg(cursor_price)::numeric and id > sqlc.narg(cursor_id)::uuid)
+        )
+    )
+    or (
+        sqlc.arg(sort_by)::text = 'price_desc'
+        and (
+            price < sqlc.narg(cursor_price)::numeric
+            or (price = sqlc.narg(cursor_price)::numeric and id > sqlc.narg(cursor_id)::uuid)
+        )
+    )
+    or (
+        sqlc.arg(sort_by)::text = 'newest'
+        and (
+            created_at < sqlc.narg(cursor_created_at)::timestamptz
+            or (
+                created_at = sqlc.narg(cursor_created_at)::timestamptz
+                and id > sqlc.narg(cursor_id)::uuid
+            )
+        )
+    )
+    or (
+        sqlc.arg(sort_by)::text = 'relevance'
+        and (
+            rank < sqlc.narg(cursor_rank)::real
+            or (rank = sqlc.narg(cursor_rank)::real and id > sqlc.narg(cursor_id)::uuid)
+        )
+    )
+order by
+    case when sqlc.arg(sort_by)::text = 'price_asc' then price end asc,
+    case when sqlc.arg(sort_by)::text = 'price_desc' then price end desc,
+    case when sqlc.arg(sort_by)::text = 'newest' then created_at end desc,
+    case when sqlc.arg(sort_by)::text = 'relevance' then rank end desc,
+    id asc
+limit sqlc.arg(result_limit);
+
+-- name: IncreaseProductQuantities :exec
+update products as p set
+    quantity = p.quantity + d.quantity,
+    updated_at = now()
+from (
+    select
+        unnest(sqlc.arg(product_ids)::uuid []) as id,
+        unnest(sqlc.arg(quantities)::integer []) as quantity
+) as d
+where p.id = d.id;
@@ -0,0 +1,51 @@
+-- name: InsertShipments :copyfrom
+insert into shipments (id, order_id, warehouse_id, created_at, updated_at) values (
+    $1, $2, $3, $4, $5
+);
+
+-- name: InsertOrderItemAllocations :copyfrom
+insert into order_item_allocations (
+    order_item_id, shipment_id, warehouse_id, quantity, created_at
+) values ($1, $2, $3, $4, $5);
+
+-- name: FindShipmentsByOrderId :many
+select s.*
+from shipments as s
+inner join orders as o on s.order_id = o.id
+where
+    s.order_id = sqlc.arg(order_id)
+    and (sqlc.narg(user_id)::uuid is null or o.user_id = sqlc.narg(user_id))
+order by s.created_at, s.id;
+
+-- name: FindOrderItemAllocationsByOrderId :many
+select
+    a.id,
+    a.order_item_id,
+    a.shipment_id,
+    a.warehouse_id,
+    a.quantity,
+    a.created_at,
+    oi.product_id
+from order_item_allocations as a
+inner join order_items as oi on a.order_item_id = oi.id
+where oi.order_id = $1
+order by a.created_at, a.id;
+
+-- name: FindOrderItemAllocationsByOrderIds :many
+select
+    a.id,
+    a.order_item_id,
+    a.shipment_id,
+    a.warehouse_id,
+    a.quantity,
+    a.created_at,
+    oi.product_id,
+    oi.order_id
+from order_item_allocations as a
+inner join order_items as oi on a.order_item_id = oi.id
+where oi.order_id = any($1::uuid [])
+order by a.created_at, a.id;
+
+-- name: DeletePendingShipmentsByOrderIds :exec
+delete from shipments
+where order_id = any($1::uuid []) and status = 'PENDING';
@@ -0,0 +1,9 @@
+-- name: FindStockAdjustmentByIdempotencyKey :one
+select * from stock_adjustments
+where product_id = $1 and idempotency_key = $2;
+
+-- name: InsertStockAdjustment :one
+insert into stock_adjustments (
+    product_id, warehouse_id, idempotency_key, delta, reason, actor, warehouse_quantity, product_quantity
+) values ($1, $2, $3, $4, $5, $6, $7, $8)
+returning *;
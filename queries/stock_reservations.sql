@@ -0,0 +1,35 @@
+-- name: InsertStockReservations :copyfrom
+insert into stock_reservations (
+    cart_id, cart_item_id, product_id, quantity, expires_at, variant_id
+) values ($1, $2, $3, $4, $5, $6);
+
+-- name: FindReservedQuantities :many
+select
+    product_id,
+    coalesce(sum(quantity), 0)::integer as quantity
+from stock_reservations
+where
+    product_id = any(sqlc.arg(product_ids)::uuid [])
+    and variant_id is null
+    and cart_id <> all(sqlc.arg(excluded_cart_ids)::uuid [])
+    and expires_at > now()
+group by product_id;
+
+-- name: FindReservedVariantQuantities :many
+select
+    variant_id::uuid as variant_id,
+    coalesce(sum(quantity), 0)::integer as quantity
+from stock_reservations
+where
+    variant_id = any(sqlc.arg(variant_ids)::uuid [])
+    and cart_id <> all(sqlc.arg(excluded_cart_ids)::uuid [])
+    and expires_at > now()
+group by variant_id;
+
+-- name: DeleteStockReservationsByCartIds :exec
+delete from stock_reservations
+where cart_id = any($1::uuid []);
+
+-- name: DeleteExpiredStockReservations :execrows
+delete from stock_reservations
+where expires_at <= now();
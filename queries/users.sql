@@ -10,3 +10,23 @@ where email = $1;
 -- name: FindById :one
 select * from users
 where id = $1;
+
+-- name: UpdatePreferredCurrency :one
+update users set preferred_currency = $2, updated_at = now()
+where id = $1
+returning *;
+
+-- name: FindPresentmentRates :many
+select
+    u.id as user_id,
+    u.preferred_currency::varchar as currency,
+    r.rate
+from users as u
+inner join exchange_rates as r
+    on r.base_currency = sqlc.arg(base_currency) and u.preferred_currency = r.quote_currency
+where u.id = any(sqlc.arg(user_ids)::uuid []);
+
+-- name: UpdateUserRole :one
+update users set role = $2, updated_at = now()
+where id = $1
+returning *;
@@ -0,0 +1,10 @@
+-- name: InsertWarehouse :one
+insert into warehouses (name, latitude, longitude) values ($1, $2, $3) returning *;
+
+-- name: FindWarehouses :many
+select * from warehouses
+order by name;
+
+-- name: FindDefaultWarehouse :one
+select * from warehouses
+where is_default;
@@ -39,3 +39,22 @@ insert into products (id, name, price, quantity) values
 ('6dd42e75-a11c-4a2c-8335-786d6098fba3', 'product 29', '100', 1000),
 ('27b05fb3-294f-459e-b63d-443a3223e785', 'product 30', '100', 1000),
 ('37ab4567-1ea9-404d-b682-5fe069c6deb2', 'product 31', '100', 1000) returning *;
+
+insert into inventory_levels (product_id, warehouse_id, quantity)
+select
+    p.id,
+    w.id,
+    p.quantity
+from products as p
+cross join warehouses as w
+where w.is_default
+on conflict (product_id, warehouse_id) do update set quantity = excluded.quantity;
+
+insert into inventory_movements (product_id, quantity, reason, actor)
+select
+    id,
+    quantity,
+    'ADJUSTMENT',
+    'seed'
+from products
+where quantity <> 0;
@@ -12,6 +12,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	inCache "github.com/Alturino/ecommerce/internal/cache"
 	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
 	"github.com/Alturino/ecommerce/internal/infra"
@@ -97,7 +98,7 @@ func RunUserService(c context.Context) {
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing userService").Logger()
 	logger.Info().Msg("initializing userService")
 	queries := repository.New(db)
-	userService := service.NewUserService(queries, cfg.Application, cache)
+	userService := service.NewUserService(queries, cfg.Application, inCache.New(cache, cfg.Cache))
 	logger.Info().Msg("initialized userService")
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "initializing userController").Logger()
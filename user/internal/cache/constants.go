@@ -1,6 +1,7 @@
 package cache
 
+// families of the cached entries of the user service, see internal/cache.
 const (
-	KEY_USER   = "user:%s"
-	LOGIN_USER = "login_user:%s"
+	KEY_USERS          = "users"
+	KEY_USERS_BY_EMAIL = "users_by_email"
 )
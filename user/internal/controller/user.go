@@ -10,12 +10,15 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 
+	"github.com/Alturino/ecommerce/internal"
 	"github.com/Alturino/ecommerce/internal/constants"
 	inHttp "github.com/Alturino/ecommerce/internal/http"
 	"github.com/Alturino/ecommerce/internal/middleware"
+	"github.com/Alturino/ecommerce/internal/money"
 	inOtel "github.com/Alturino/ecommerce/internal/otel"
 	userErrors "github.com/Alturino/ecommerce/user/internal/errors"
 	"github.com/Alturino/ecommerce/user/internal/otel"
@@ -39,6 +42,25 @@ func AttachUserController(c context.Context, mux *mux.Router, service *service.U
 	router.HandleFunc("/login", controller.Login).Methods(http.MethodPost)
 	router.HandleFunc("/register", controller.Register).Methods(http.MethodPost)
 	router.HandleFunc("/{userId}", controller.FindUserById).Methods(http.MethodGet)
+
+	meRouter := mux.PathPrefix("/users/me").Subrouter()
+	meRouter.Use(
+		otelmux.Middleware(constants.APP_USER_SERVICE),
+		middleware.Logging,
+		middleware.RecoverPanic,
+		middleware.Auth,
+	)
+	meRouter.HandleFunc("/currency", controller.UpdatePreferredCurrency).Methods(http.MethodPut)
+
+	adminRouter := mux.PathPrefix("/users/{userId}").Subrouter()
+	adminRouter.Use(
+		otelmux.Middleware(constants.APP_USER_SERVICE),
+		middleware.Logging,
+		middleware.RecoverPanic,
+		middleware.Auth,
+		middleware.RequireRole(constants.ROLE_ADMIN),
+	)
+	adminRouter.HandleFunc("/role", controller.UpdateUserRole).Methods(http.MethodPut)
 }
 
 func (u UserController) Login(w http.ResponseWriter, r *http.Request) {
@@ -296,3 +318,198 @@ func (u UserController) FindUserById(w http.ResponseWriter, r *http.Request) {
 		"message":    fmt.Sprintf("user with id=%s is found", user.ID.String()),
 	})
 }
+
+func (u UserController) UpdatePreferredCurrency(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "UserController UpdatePreferredCurrency")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "UserController UpdatePreferredCurrency").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	userId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got userId from jwtToken")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Info().Msg("got userId from jwtToken")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.PreferredCurrency{}
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err == nil {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.StructCtx(c, reqBody)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating preferred currency").Logger()
+	logger.Trace().Msg("updating preferred currency")
+	span.AddEvent("updating preferred currency")
+	c = logger.WithContext(c)
+	user, err := u.service.UpdatePreferredCurrency(c, userId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed updating preferred currency with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, money.ErrUnsupportedCurrency) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("updated preferred currency")
+	logger.Info().Msg("updated preferred currency")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully updated preferred currency",
+		"data": map[string]interface{}{
+			"preferred_currency": user.PreferredCurrency.String,
+		},
+	})
+}
+
+func (u UserController) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	c, span := otel.Tracer.Start(r.Context(), "UserController UpdateUserRole")
+	defer span.End()
+
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "UserController UpdateUserRole").
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "getting userId from jwtToken").Logger()
+	logger.Trace().Msg("getting userId from jwtToken")
+	span.AddEvent("getting userId from jwtToken")
+	adminId, err := internal.UserIdFromJwtToken(c)
+	if err != nil {
+		err = fmt.Errorf("failed getting userId from jwtToken with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("got userId from jwtToken")
+	logger.Info().Msg("got userId from jwtToken")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating userId").Logger()
+	logger.Trace().Msg("validating userId")
+	span.AddEvent("validating userId")
+	userId, err := uuid.Parse(mux.Vars(r)["userId"])
+	if err != nil {
+		err = fmt.Errorf("failed validating userId with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("validated userId")
+	logger = logger.With().Str(constants.KEY_USER_ID, userId.String()).Logger()
+	logger.Debug().Msg("validated userId")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "decoding request body").Logger()
+	logger.Trace().Msg("decoding request body")
+	span.AddEvent("decoding request body")
+	reqBody := request.Role{}
+	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	if err == nil {
+		validate := validator.New(validator.WithRequiredStructEnabled())
+		err = validate.StructCtx(c, reqBody)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed validating request body with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": http.StatusBadRequest,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("decoded request body")
+	logger = logger.With().Any(constants.KEY_REQUEST, reqBody).Logger()
+	logger.Debug().Msg("decoded request body")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating user role").Logger()
+	logger.Trace().Msg("updating user role")
+	span.AddEvent("updating user role")
+	c = logger.WithContext(c)
+	user, err := u.service.UpdateUserRole(c, adminId, userId, reqBody)
+	if err != nil {
+		err = fmt.Errorf("failed updating user role with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, userErrors.ErrOwnRole) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, pgx.ErrNoRows) {
+			statusCode = http.StatusNotFound
+		}
+		inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+			"status":     "failed",
+			"statusCode": statusCode,
+			"message":    err.Error(),
+		})
+		return
+	}
+	span.AddEvent("updated user role")
+	logger.Info().Msg("updated user role")
+
+	inHttp.WriteJsonResponse(c, w, map[string]string{}, map[string]interface{}{
+		"status":     "success",
+		"statusCode": http.StatusOK,
+		"message":    "successfully updated user role",
+		"data": map[string]interface{}{
+			"id":   user.ID,
+			"role": user.Role,
+		},
+	})
+}
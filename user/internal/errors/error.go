@@ -6,4 +6,5 @@ var (
 	ErrPasswordMismatch = errors.New("password mismatch")
 	ErrUserNotFound     = errors.New("user not found")
 	ErrEmailExist       = errors.New("email already exist")
+	ErrOwnRole          = errors.New("admins can not change their own role")
 )
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -10,15 +9,15 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/Alturino/ecommerce/internal"
+	inCache "github.com/Alturino/ecommerce/internal/cache"
 	"github.com/Alturino/ecommerce/internal/config"
 	"github.com/Alturino/ecommerce/internal/constants"
 	inErrors "github.com/Alturino/ecommerce/internal/errors"
+	"github.com/Alturino/ecommerce/internal/money"
 	inOtel "github.com/Alturino/ecommerce/internal/otel"
 	"github.com/Alturino/ecommerce/internal/repository"
 	"github.com/Alturino/ecommerce/user/internal/cache"
@@ -29,14 +28,14 @@ import (
 
 type UserService struct {
 	config  config.Application
-	cache   *redis.Client
+	cache   *inCache.Cache
 	queries *repository.Queries
 }
 
 func NewUserService(
 	queries *repository.Queries,
 	config config.Application,
-	cache *redis.Client,
+	cache *inCache.Cache,
 ) *UserService {
 	return &UserService{queries: queries, config: config, cache: cache}
 }
@@ -48,61 +47,61 @@ func (u UserService) Login(
 	c, span := otel.Tracer.Start(c, "UserService Login")
 	defer span.End()
 
-	cacheKey := fmt.Sprintf(cache.LOGIN_USER, param.Email)
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
 		Str(constants.KEY_TAG, "UserService Login").
 		Str(constants.KEY_EMAIL, param.Email).
-		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Str(constants.KEY_CACHE_KEY, param.Email).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "finding user by email").Logger()
+	logger.Trace().Msg("finding user by email")
+	span.AddEvent("finding user by email")
+	c = logger.WithContext(c)
+	user, err := inCache.GetOrLoad(
+		c,
+		u.cache,
+		cache.KEY_USERS_BY_EMAIL,
+		param.Email,
+		func(c context.Context) (repository.User, error) {
+			return u.queries.FindByEmail(c, param.Email)
+		},
+	)
+	if err != nil {
+		err = errors.Join(err, userErrors.ErrUserNotFound)
+		err = fmt.Errorf("failed finding user by email=%s with error=%w", param.Email, err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return "", err
+	}
+	span.AddEvent("found user by email")
+	logger.Info().Msg("found user by email")
+
+	logger = logger.With().
+		Str(constants.KEY_PROCESS, "verifying hashed password with password").
 		Logger()
+	logger.Trace().Msg("verifying hashed password with password")
+	span.AddEvent("verifying hashed password with password")
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(param.Password))
+	if err != nil {
+		err = errors.Join(err, userErrors.ErrPasswordMismatch)
+		err = fmt.Errorf("failed verifying hashed password and password with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return "", err
+	}
+	logger.Info().Msg("verified hashed password with password")
+	span.AddEvent("verified hashed password with password")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "getting token from cache").Logger()
-	logger.Trace().Msg("getting token from cache")
-	span.AddEvent("getting token from cache")
-	signedToken, err := u.cache.JSONGet(c, cacheKey).Result()
-	if (err != nil || errors.Is(err, redis.Nil)) || signedToken == "" {
-		err = fmt.Errorf("failed getting token from cache with error=%w", err)
-		logger.Info().Err(err).Msg(err.Error())
-		span.AddEvent("failed getting token from cache")
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "finding user by email").Logger()
-		logger.Trace().Msg("finding user by email")
-		span.AddEvent("finding user by email")
-		user, err := u.queries.FindByEmail(c, param.Email)
-		if err != nil {
-			err = errors.Join(err, userErrors.ErrUserNotFound)
-			err = fmt.Errorf("failed finding user by email=%s with error=%w", param.Email, err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return "", err
-		}
-		span.AddEvent("found user by email")
-		logger.Info().Msg("found user by email")
-
-		logger = logger.With().
-			Str(constants.KEY_PROCESS, "verifying hashed password with password").
-			Logger()
-		logger.Trace().Msg("verifying hashed password with password")
-		span.AddEvent("verifying hashed password with password")
-		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(param.Password))
-		if err != nil {
-			err = errors.Join(err, userErrors.ErrPasswordMismatch)
-			err = fmt.Errorf("failed verifying hashed password and password with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return "", err
-		}
-		logger.Info().Msg("verified hashed password with password")
-		span.AddEvent("verified hashed password with password")
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "creating login token").Logger()
-		logger.Trace().Msg("creating login token")
-		tokenCreationTime := time.Now()
-		span.AddEvent("creating login token")
-		token := jwt.NewWithClaims(
-			jwt.SigningMethodHS256,
-			jwt.RegisteredClaims{
+	logger = logger.With().Str(constants.KEY_PROCESS, "creating login token").Logger()
+	logger.Trace().Msg("creating login token")
+	tokenCreationTime := time.Now()
+	span.AddEvent("creating login token")
+	token := jwt.NewWithClaims(
+		jwt.SigningMethodHS256,
+		internal.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
 				Audience:  jwt.ClaimStrings{constants.AUDIENCE_USER},
 				Issuer:    constants.APP_USER_SERVICE,
 				Subject:   user.ID.String(),
@@ -110,42 +109,24 @@ func (u UserService) Login(
 				IssuedAt:  jwt.NewNumericDate(tokenCreationTime),
 				ID:        uuid.NewString(),
 			},
-		)
-		logger.Info().Msg("created login token")
-		span.AddEvent("created login token")
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "signing token").Logger()
-		logger.Trace().Msg("signing token")
-		span.AddEvent("signing token")
-		signedToken, err = token.SignedString([]byte(u.config.SecretKey))
-		if err != nil {
-			err = fmt.Errorf("failed signing token with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return "", err
-		}
-		logger = logger.With().Str(constants.KEY_TOKEN, signedToken).Logger()
-		span.AddEvent("signed token")
-		logger.Info().Msg("signed token")
-
-		logger = logger.With().Str(constants.KEY_PROCESS, "inserting token to cache").Logger()
-		logger.Trace().Msg("inserting token to cache")
-		span.AddEvent("inserting token to cache")
-		err = u.cache.SetEx(c, cacheKey, signedToken, 25*time.Minute).Err()
-		if err != nil {
-			err = fmt.Errorf("failed inserting token to cache with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return "", err
-		}
-		span.AddEvent("inserted token to cache")
-		logger.Debug().Msg("inserted token to cache")
-	}
-	logger.Info().RawJSON(constants.KEY_JSON_CACHE, []byte(signedToken)).Msg("got token from cache")
-	span.AddEvent(
-		"got token from cache",
-		trace.WithAttributes(attribute.String(constants.KEY_JSON_CACHE, signedToken)),
+			Role: string(user.Role),
+		},
 	)
+	logger.Info().Msg("created login token")
+	span.AddEvent("created login token")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "signing token").Logger()
+	logger.Trace().Msg("signing token")
+	span.AddEvent("signing token")
+	signedToken, err := token.SignedString([]byte(u.config.SecretKey))
+	if err != nil {
+		err = fmt.Errorf("failed signing token with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return "", err
+	}
+	span.AddEvent("signed token")
+	logger.Info().Msg("signed token")
 
 	return signedToken, nil
 }
@@ -221,7 +202,7 @@ func (svc UserService) Register(
 	logger = logger.With().Str(constants.KEY_PROCESS, "inserting user to cache").Logger()
 	logger.Trace().Msg("inserting user to cache")
 	span.AddEvent("inserting user to cache")
-	err = svc.cache.JSONSet(c, fmt.Sprintf(cache.KEY_USER, user.ID.String()), "$", user).Err()
+	err = svc.cache.Set(c, cache.KEY_USERS, user.ID.String(), user)
 	if err != nil {
 		err = fmt.Errorf("failed inserting user to cache with error=%w", err)
 		inOtel.RecordError(err, span)
@@ -231,6 +212,20 @@ func (svc UserService) Register(
 	span.AddEvent("inserted user to cache")
 	logger.Debug().Msg("inserted user to cache")
 
+	// a login attempt before registering caches the email as missing
+	logger = logger.With().Str(constants.KEY_PROCESS, "deleting missing email from cache").Logger()
+	logger.Trace().Msg("deleting missing email from cache")
+	span.AddEvent("deleting missing email from cache")
+	err = svc.cache.Delete(c, cache.KEY_USERS_BY_EMAIL, user.Email)
+	if err != nil {
+		err = fmt.Errorf("failed deleting missing email from cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return user, nil
+	}
+	span.AddEvent("deleted missing email from cache")
+	logger.Debug().Msg("deleted missing email from cache")
+
 	logger.Info().Msg("registered user")
 	return user, nil
 }
@@ -242,7 +237,7 @@ func (svc UserService) FindUserById(
 	c, span := otel.Tracer.Start(c, "UserService FindUserById")
 	defer span.End()
 
-	cacheKey := fmt.Sprintf(cache.KEY_USER, param.ID.String())
+	cacheKey := param.ID.String()
 	logger := zerolog.Ctx(c).
 		With().
 		Ctx(c).
@@ -251,58 +246,171 @@ func (svc UserService) FindUserById(
 		Logger()
 
 	logger = logger.With().Str(constants.KEY_PROCESS, "find user").Logger()
-	logger.Trace().Msg("finding user by id in cache")
-	jsonCache, err := svc.cache.JSONGet(c, cacheKey).Result()
-	if err != nil || err == redis.Nil || errors.Is(err, redis.Nil) || jsonCache == "" {
-		err = fmt.Errorf("failed finding user by id from cache with error=%w", err)
+	logger.Trace().Msg("finding user by id")
+	span.AddEvent("finding user by id")
+	c = logger.WithContext(c)
+	user, err := inCache.GetOrLoad(
+		c,
+		svc.cache,
+		cache.KEY_USERS,
+		cacheKey,
+		func(c context.Context) (repository.User, error) {
+			return svc.queries.FindById(c, param.ID)
+		},
+	)
+	if err != nil {
+		err = fmt.Errorf("failed finding user by id with error=%w", err)
 		inOtel.RecordError(err, span)
-		logger.Info().Err(err).Msg(err.Error())
-
-		logger.Trace().Msg("finding user by id in database")
-		span.AddEvent("finding user by id in database")
-		user, err := svc.queries.FindById(c, param.ID)
-		if err != nil {
-			err = fmt.Errorf("failed finding user from database with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return repository.User{}, err
-		}
-		span.AddEvent("found user in database")
-		logger = logger.With().Any(constants.KEY_USER, user).Logger()
-		logger.Debug().Msg("found user in database")
-
-		logger.With().Str(constants.KEY_PROCESS, "cache user").Logger()
-		logger.Trace().Msg("inserting user to cache")
-		span.AddEvent("inserting user to cache")
-		err = svc.cache.JSONSet(c, cacheKey, "$", user).Err()
-		if err != nil {
-			err = fmt.Errorf("failed inserting user to cache with error=%w", err)
-			inOtel.RecordError(err, span)
-			logger.Error().Err(err).Msg(err.Error())
-			return repository.User{}, err
-		}
-		span.AddEvent("inserted user to cache")
-		logger.Debug().Msg("inserted user to cache")
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.User{}, err
+	}
+	span.AddEvent("found user by id")
+	logger = logger.With().Any(constants.KEY_USER, user).Logger()
+
+	logger.Info().Msg("found user by id")
+	return user, nil
+}
+
+// UpdatePreferredCurrency sets the currency orders of userId are presented in,
+// orders placed before keep the currency they were placed in.
+func (svc UserService) UpdatePreferredCurrency(
+	c context.Context,
+	userId uuid.UUID,
+	param request.PreferredCurrency,
+) (repository.User, error) {
+	c, span := otel.Tracer.Start(c, "UserService UpdatePreferredCurrency")
+	defer span.End()
+
+	cacheKey := userId.String()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "UserService UpdatePreferredCurrency").
+		Str(constants.KEY_USER_ID, userId.String()).
+		Str(constants.KEY_CURRENCY, param.Currency).
+		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating currency").Logger()
+	logger.Trace().Msg("validating currency")
+	span.AddEvent("validating currency")
+	currency, err := money.Normalize(param.Currency)
+	if err != nil {
+		err = fmt.Errorf("failed validating currency with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.User{}, err
+	}
+	span.AddEvent("validated currency")
+	logger.Debug().Msg("validated currency")
 
-		logger.Info().Msg("found user in database and inserted to cache")
-		return user, err
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating preferred currency").Logger()
+	logger.Trace().Msg("updating preferred currency")
+	span.AddEvent("updating preferred currency")
+	user, err := svc.queries.UpdatePreferredCurrency(c, repository.UpdatePreferredCurrencyParams{
+		ID:                userId,
+		PreferredCurrency: pgtype.Text{String: currency, Valid: true},
+	})
+	if err != nil {
+		err = fmt.Errorf("failed updating preferred currency with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.User{}, err
 	}
-	logger = logger.With().RawJSON(constants.KEY_JSON_CACHE, []byte(jsonCache)).Logger()
-	logger.Debug().Msg("found user by id in cache")
+	span.AddEvent("updated preferred currency")
+	logger.Info().Msg("updated preferred currency")
 
-	logger = logger.With().Str(constants.KEY_PROCESS, "unmarshaling user from cache").Logger()
-	logger.Trace().Msg("unmarshaling user from cache")
-	user := repository.User{}
-	err = json.Unmarshal([]byte(jsonCache), &user)
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting user to cache").Logger()
+	logger.Trace().Msg("inserting user to cache")
+	span.AddEvent("inserting user to cache")
+	err = svc.cache.Set(c, cache.KEY_USERS, cacheKey, user)
 	if err != nil {
-		err = fmt.Errorf("failed unmarshaling user from cache with error=%w", err)
+		err = fmt.Errorf("failed inserting user to cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return user, nil
+	}
+	span.AddEvent("inserted user to cache")
+	logger.Info().Msg("inserted user to cache")
+
+	return user, nil
+}
+
+// UpdateUserRole assigns param.Role to userId on behalf of adminId. The user
+// cached for login is dropped so the next login claims the new role, tokens
+// issued before keep their role until they expire.
+func (svc UserService) UpdateUserRole(
+	c context.Context,
+	adminId uuid.UUID,
+	userId uuid.UUID,
+	param request.Role,
+) (repository.User, error) {
+	c, span := otel.Tracer.Start(c, "UserService UpdateUserRole")
+	defer span.End()
+
+	cacheKey := userId.String()
+	logger := zerolog.Ctx(c).
+		With().
+		Ctx(c).
+		Str(constants.KEY_TAG, "UserService UpdateUserRole").
+		Str(constants.KEY_USER_ID, userId.String()).
+		Str(constants.KEY_ROLE, param.Role).
+		Str(constants.KEY_CACHE_KEY, cacheKey).
+		Logger()
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "validating role").Logger()
+	logger.Trace().Msg("validating role")
+	span.AddEvent("validating role")
+	if adminId == userId {
+		err := fmt.Errorf("failed validating role with error=%w", userErrors.ErrOwnRole)
 		inOtel.RecordError(err, span)
 		logger.Error().Err(err).Msg(err.Error())
 		return repository.User{}, err
 	}
-	logger = logger.With().Any(constants.KEY_USER, user).Logger()
-	logger.Debug().Msg("unmarshaled user from cache")
+	span.AddEvent("validated role")
+	logger.Debug().Msg("validated role")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "updating user role").Logger()
+	logger.Trace().Msg("updating user role")
+	span.AddEvent("updating user role")
+	user, err := svc.queries.UpdateUserRole(c, repository.UpdateUserRoleParams{
+		ID:   userId,
+		Role: repository.UserRole(param.Role),
+	})
+	if err != nil {
+		err = fmt.Errorf("failed updating user role with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return repository.User{}, err
+	}
+	span.AddEvent("updated user role")
+	logger.Info().Msg("updated user role")
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "inserting user to cache").Logger()
+	logger.Trace().Msg("inserting user to cache")
+	span.AddEvent("inserting user to cache")
+	err = svc.cache.Set(c, cache.KEY_USERS, cacheKey, user)
+	if err != nil {
+		err = fmt.Errorf("failed inserting user to cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+	} else {
+		span.AddEvent("inserted user to cache")
+		logger.Info().Msg("inserted user to cache")
+	}
+
+	logger = logger.With().Str(constants.KEY_PROCESS, "deleting login user from cache").Logger()
+	logger.Trace().Msg("deleting login user from cache")
+	span.AddEvent("deleting login user from cache")
+	err = svc.cache.Delete(c, cache.KEY_USERS_BY_EMAIL, user.Email)
+	if err != nil {
+		err = fmt.Errorf("failed deleting login user from cache with error=%w", err)
+		inOtel.RecordError(err, span)
+		logger.Error().Err(err).Msg(err.Error())
+		return user, nil
+	}
+	span.AddEvent("deleted login user from cache")
+	logger.Info().Msg("deleted login user from cache")
 
-	logger.Info().Msg("found user by id in cache")
 	return user, nil
 }
@@ -0,0 +1,7 @@
+package request
+
+// PreferredCurrency is the ISO 4217 code of the currency orders of a user are
+// presented in.
+type PreferredCurrency struct {
+	Currency string `validate:"required,len=3" json:"currency"`
+}
@@ -0,0 +1,6 @@
+package request
+
+// Role is the role an admin assigns to a user.
+type Role struct {
+	Role string `validate:"required,oneof=CUSTOMER MERCHANT_STAFF ADMIN" json:"role"`
+}